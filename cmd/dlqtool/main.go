@@ -0,0 +1,105 @@
+// Command dlqtool inspects and drains the dead-letter queues without the service running.
+//
+// Usage:
+//
+//	dlqtool list                            DLQ depth per event type and the stored replay backlog
+//	dlqtool replay -queue <dlq> [-limit n]  move up to n messages from a DLQ back to its source queue
+//
+// It reads the same configuration as the service, from the environment or a .env file.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go-order-eda/src/config"
+	"go-order-eda/src/infrastructure/mongo"
+	"go-order-eda/src/infrastructure/rabbitmq"
+	"go-order-eda/src/services/dlq"
+	"go-order-eda/src/services/order/domain/persistence"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	configs, err := config.LoadConfig()
+	if err != nil {
+		fail("failed to load configuration", err)
+	}
+	dialConfig, err := rabbitmq.DialConfig(configs)
+	if err != nil {
+		fail("invalid RabbitMQ configuration", err)
+	}
+	// The topology is redeclared, so the queues must be declared with the service's arguments
+	broker, err := rabbitmq.NewRabbitMQService(configs.RabbitMQHostName, configs.RabbitMQExchange, configs.RabbitMQQueueName, dialConfig, rabbitmq.NewConsumerOptions(configs))
+	if err != nil {
+		fail("failed to connect to RabbitMQ", err)
+	}
+	defer broker.Close()
+
+	switch os.Args[1] {
+	case "list":
+		err = list(ctx, configs, broker)
+	case "replay":
+		flags := flag.NewFlagSet("replay", flag.ExitOnError)
+		queue := flags.String("queue", "", "DLQ to replay, e.g. order.created.dlq")
+		limit := flags.Int("limit", 100, "maximum number of messages to replay")
+		flags.Parse(os.Args[2:])
+		if *queue == "" || *limit < 1 {
+			usage()
+		}
+		var replayed int
+		replayed, err = dlq.Replay(ctx, broker, *queue, *limit)
+		fmt.Printf("Replayed %d messages from %s\n", replayed, *queue)
+	default:
+		usage()
+	}
+	if err != nil {
+		fail(os.Args[1]+" failed", err)
+	}
+}
+
+// list prints the DLQ depth of every event type and the number of failed events stored for replay
+func list(ctx context.Context, configs *config.Config, broker *rabbitmq.RabbitMQServiceImpl) error {
+	depths, err := dlq.Depths(broker)
+	if err != nil {
+		return err
+	}
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "EVENT TYPE\tDLQ\tMESSAGES")
+	for _, depth := range depths {
+		fmt.Fprintf(out, "%s\t%s\t%d\n", depth.EventType, depth.DLQ, depth.Messages)
+	}
+	out.Flush()
+
+	client, err := mongo.GetMongoClient(configs)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	defer client.Disconnect(context.Background())
+	backlog, err := persistence.NewOrderRepository(configs, client).CountUnreplayedEvents(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count the replay backlog: %w", err)
+	}
+	fmt.Printf("\nStored events awaiting replay: %d\n", backlog)
+	return nil
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: dlqtool list | dlqtool replay -queue <dlq> [-limit n]")
+	os.Exit(2)
+}
+
+func fail(msg string, err error) {
+	fmt.Fprintf(os.Stderr, "dlqtool: %s: %v\n", msg, err)
+	os.Exit(1)
+}
@@ -2,12 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go-order-eda/src/config"
 	"go-order-eda/src/controllers"
+	"go-order-eda/src/controllers/middleware"
+	"go-order-eda/src/controllers/models"
 	"go-order-eda/src/infrastructure"
+	"go-order-eda/src/infrastructure/audit"
 	"go-order-eda/src/infrastructure/log"
 	"go-order-eda/src/infrastructure/mongo"
+	"go-order-eda/src/infrastructure/outbox"
 	"go-order-eda/src/infrastructure/rabbitmq"
+	"go-order-eda/src/infrastructure/shutdown"
+	"go-order-eda/src/infrastructure/startup"
+	"go-order-eda/src/infrastructure/status"
+	"go-order-eda/src/infrastructure/tracing"
+	"go-order-eda/src/infrastructure/worker"
 	"go-order-eda/src/services/dlq"
 	"go-order-eda/src/services/events"
 	"go-order-eda/src/services/inventory"
@@ -17,6 +28,7 @@ import (
 	"go-order-eda/src/services/order/domain"
 	"go-order-eda/src/services/order/domain/persistence"
 	orderHandlers "go-order-eda/src/services/order/handlers"
+	"go-order-eda/src/services/order/projection"
 	"os"
 	"os/signal"
 	"syscall"
@@ -44,6 +56,15 @@ func main() {
 	}
 	logger.Info(ctx, "Configuration loaded successfully")
 
+	// Initialize tracing; spans are only exported when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Setup(ctx, configs.OTLPEndpoint)
+	if err != nil {
+		logger.Fatal(ctx, "Failed to initialize tracing", err)
+	}
+
+	// Wait for MongoDB and RabbitMQ, which may still be starting up alongside the service
+	waiter := startup.NewWaiter(logger, configs.StartupTimeout)
+
 	// Initialize MongoDB connection with health check
 	client, err := mongo.GetMongoClient(configs)
 	if err != nil {
@@ -51,44 +72,124 @@ func main() {
 	}
 
 	// Verify MongoDB connection
-	if err := client.Ping(ctx, nil); err != nil {
+	if err := waiter.Wait(ctx, "MongoDB", func(ctx context.Context) error { return client.Ping(ctx, nil) }); err != nil {
 		logger.Fatal(ctx, "MongoDB ping failed", err)
 	}
 	logger.Info(ctx, "MongoDB connection successful")
 
 	// Initialize repositories
 	orderRepository := persistence.NewOrderRepository(configs, client)
-	productRepository := inventory.NewProductRepository(client.Database(configs.MongoDBDatabaseName))
+	if err := orderRepository.EnsureIndexes(ctx); err != nil {
+		logger.Fatal(ctx, "Failed to create order repository indexes", err)
+	}
+	productFeed := inventory.NewProductFeed()
+	productRepository := inventory.NewProductRepository(client.Database(configs.MongoDBDatabaseName), configs.MongoOperationTimeout, logger, productFeed)
+	if err := productRepository.EnsureIndexes(ctx); err != nil {
+		logger.Fatal(ctx, "Failed to create product history indexes", err)
+	}
+	reservationRepository := inventory.NewReservationRepository(client.Database(configs.MongoDBDatabaseName), configs.MongoOperationTimeout)
+	if err := reservationRepository.EnsureIndexes(ctx); err != nil {
+		logger.Fatal(ctx, "Failed to create reservation indexes", err)
+	}
+	outboxRepository := outbox.NewRepository(client.Database(configs.MongoDBDatabaseName), configs.MongoOperationTimeout)
+	if err := outboxRepository.EnsureIndexes(ctx, configs.OutboxRetention); err != nil {
+		logger.Fatal(ctx, "Failed to create outbox indexes", err)
+	}
+	notificationRepository := notification.NewNotificationRepository(client.Database(configs.MongoDBDatabaseName), configs.MongoOperationTimeout)
+	if err := notificationRepository.EnsureIndexes(ctx); err != nil {
+		logger.Fatal(ctx, "Failed to create notification indexes", err)
+	}
+	auditRepository := audit.NewRepository(client.Database(configs.MongoDBDatabaseName), configs.MongoOperationTimeout)
+	if err := auditRepository.EnsureIndexes(ctx); err != nil {
+		logger.Fatal(ctx, "Failed to create event audit indexes", err)
+	}
+	timelineRepository := projection.NewRepository(client.Database(configs.MongoDBDatabaseName), configs.MongoOperationTimeout)
+	if err := timelineRepository.EnsureIndexes(ctx); err != nil {
+		logger.Fatal(ctx, "Failed to create order projection indexes", err)
+	}
+
+	// Convert float amounts stored before orders carried a currency
+	if migrated, err := orderRepository.MigrateLegacyAmounts(ctx); err != nil {
+		logger.Fatal(ctx, "Failed to migrate legacy order amounts", err)
+	} else if migrated > 0 {
+		logger.Info(ctx, fmt.Sprintf("Migrated %d orders to minor-unit amounts", migrated))
+	}
+	if migrated, err := timelineRepository.MigrateLegacyAmounts(ctx); err != nil {
+		logger.Fatal(ctx, "Failed to migrate legacy order projection amounts", err)
+	} else if migrated > 0 {
+		logger.Info(ctx, fmt.Sprintf("Migrated %d order projections to minor-unit amounts", migrated))
+	}
 
 	// Seed products with error handling
-	if err := seedProducts(ctx, productRepository, logger); err != nil {
-		logger.Fatal(ctx, "Failed to seed products", err)
+	if configs.Enabled(config.FeatureSeeding) {
+		if err := seedProducts(ctx, productRepository, logger); err != nil {
+			logger.Fatal(ctx, "Failed to seed products", err)
+		}
 	}
 
 	// Initialize RabbitMQ service with health check
-	rabbitmqService, err := rabbitmq.NewRabbitMQService(configs.RabbitMQHostName, configs.RabbitMQExchange, configs.RabbitMQQueueName)
+	dialConfig, err := rabbitmq.DialConfig(configs)
+	if err != nil {
+		logger.Fatal(ctx, "Invalid RabbitMQ configuration", err)
+	}
+	consumerOptions := rabbitmq.NewConsumerOptions(configs)
+	var rabbitmqService *rabbitmq.RabbitMQServiceImpl
+	err = waiter.Wait(ctx, "RabbitMQ", func(ctx context.Context) error {
+		service, err := rabbitmq.NewRabbitMQService(configs.RabbitMQHostName, configs.RabbitMQExchange, configs.RabbitMQQueueName, dialConfig, consumerOptions)
+		if err != nil {
+			return err
+		}
+		// Verify RabbitMQ connection health
+		if !service.IsHealthy() {
+			service.Close()
+			return errors.New("connection is not healthy")
+		}
+		rabbitmqService = service
+		return nil
+	})
 	if err != nil {
 		logger.Fatal(ctx, "Failed to create RabbitMQ service", err)
 	}
-	defer rabbitmqService.Close()
+	logger.Info(ctx, "RabbitMQ connection successful")
 
-	// Verify RabbitMQ connection health
-	if !rabbitmqService.IsHealthy() {
-		logger.Fatal(ctx, "RabbitMQ connection is not healthy", nil)
+	// Record what this instance is connected to; a missing MongoDB version does not stop startup
+	mongoVersion, err := mongo.ServerVersion(ctx, client)
+	if err != nil {
+		logger.Warn(ctx, "Failed to get the MongoDB server version: "+err.Error())
 	}
-	logger.Info(ctx, "RabbitMQ connection successful")
+	startup.LogDependencies(ctx, logger, startup.Dependencies{
+		MongoVersion:   mongoVersion,
+		RabbitMQServer: rabbitmqService.ServerInfo(),
+		Topology:       rabbitmqService.Topology(),
+	})
 
 	// Create business services
-	orderService := domain.NewOrderService(logger, *rabbitmqService, orderRepository)
-	inventoryService := inventory.NewInventoryService(logger, productRepository)
-	notificationService := notification.NewNotificationService(logger)
+	reservationMetrics := inventory.NewReservationMetrics()
+	inventoryService := inventory.NewInventoryService(logger, productRepository, reservationRepository, rabbitmqService, configs.LowStockThreshold, reservationMetrics)
+	var stockChecker domain.StockChecker
+	if configs.Enabled(config.FeaturePrecheck) {
+		stockChecker = inventoryService
+	}
+	orderCompletions := domain.NewCompletions()
+	orderProgress := domain.NewProgress()
+	publishMetrics := domain.NewPublishMetrics()
+	orderService := domain.NewOrderService(logger, rabbitmqService, orderRepository, orderCompletions, stockChecker, configs.ReplayConcurrency, publishMetrics)
+	notificationService := notification.NewNotificationService(logger, notificationRepository)
+
+	// Validate the configured notification channels before any events are consumed
+	channelPolicy := notification.NewChannelPolicy(configs)
+	if err := channelPolicy.Validate(notificationService); err != nil {
+		logger.Fatal(ctx, "Invalid notification channel configuration", err)
+	}
 
 	// Create event handlers with proper error handling
-	orderRequestedHandler := orderHandlers.NewOrderRequestedEventHandler(logger, rabbitmqService, orderRepository)
+	orderRequestedHandler := orderHandlers.NewOrderRequestedEventHandler(logger, orderRepository)
 	orderCreatedHandler := inventoryHandlers.NewOrderCreatedEventHandler(rabbitmqService, orderRepository, inventoryService, logger)
-	orderCancelledHandler := inventoryHandlers.NewOrderCancelledEventHandler(rabbitmqService, orderRepository, inventoryService, logger)
-	inventoryStatusHandler := notificationHandlers.NewInventoryStatusUpdatedEventHandler(rabbitmqService, notificationService, logger)
+	orderCancelledHandler := inventoryHandlers.NewOrderCancelledEventHandler(orderRepository, inventoryService, logger)
+	inventoryStatusHandler := notificationHandlers.NewInventoryStatusUpdatedEventHandler(rabbitmqService, notificationService, channelPolicy, logger)
 	notificationSentHandler := orderHandlers.NewNotificationSentEventHandler(orderRepository, logger)
+	notificationRetryHandler := notificationHandlers.NewNotificationRetryEventHandler(inventoryStatusHandler, logger)
+	lowStockHandler := notificationHandlers.NewLowStockEventHandler(notificationService, logger)
 
 	// Create DLQ handlers for storing failed events
 	dlqHandler := dlq.NewDLQHandler(orderRepository, logger)
@@ -97,22 +198,77 @@ func main() {
 	inventoryStatusUpdatedDLQHandler := dlqHandler.NewInventoryStatusUpdatedDLQHandler()
 
 	// Create and configure event listener
-	eventListener := infrastructure.NewEventListener(rabbitmqService, logger)
-
-	// Register event handlers
-	eventListener.RegisterHandler(events.OrderRequested, orderRequestedHandler)
-	eventListener.RegisterHandler(events.OrderCreated, orderCreatedHandler)
-	eventListener.RegisterHandler(events.OrderCancelled, orderCancelledHandler)
-	eventListener.RegisterHandler(events.InventoryStatusUpdated, inventoryStatusHandler)
-	eventListener.RegisterHandler(events.NotificationSent, notificationSentHandler)
+	eventListener := infrastructure.NewEventListener(rabbitmqService, logger, configs.EventListenerWorkers, configs.MaxRedeliveries)
+	auditRecorder := audit.NewRecorder(auditRepository, logger, configs.EventAuditBufferSize, configs.EventAuditFlushInterval, 500)
+	eventListener.SetAuditor(auditRecorder)
+	eventListener.SetHandlerTimeout(configs.EventHandlerTimeout)
+	eventListener.SetDrainTimeout(configs.EventDrainTimeout)
+	eventListener.SetOrderPartitions(configs.EventOrderPartitions)
+	handlerMetrics := infrastructure.NewHandlerMetrics()
+	eventListener.Use(infrastructure.Recovery(logger), infrastructure.Logging(logger), handlerMetrics.Middleware())
+	go auditRecorder.Run(ctx)
+
+	// Register event and DLQ handlers on the queues events.Registry declares for each event type
+	eventHandlers := map[string]infrastructure.EventHandler{
+		events.OrderRequested:         orderRequestedHandler,
+		events.OrderCreated:           orderCreatedHandler,
+		events.OrderCancelled:         orderCancelledHandler,
+		events.InventoryStatusUpdated: inventoryStatusHandler,
+		events.NotificationSent:       notificationSentHandler,
+		events.LowStock:               lowStockHandler,
+		events.NotificationRetry:      notificationRetryHandler,
+	}
+	dlqHandlers := map[string]infrastructure.EventHandler{
+		events.OrderCreated:           orderCreatedDLQHandler,
+		events.OrderCancelled:         orderCancelledDLQHandler,
+		events.InventoryStatusUpdated: inventoryStatusUpdatedDLQHandler,
+	}
+	// Subscribers run after the event's own handler: the order timeline projection, the
+	// completion signals for requests waiting on their order, and the status streams of clients following it
+	timelineProjector := projection.NewProjector(timelineRepository, logger)
+	projectionHandlers := make(map[string]infrastructure.EventHandler, len(projection.EventTypes))
+	for _, name := range projection.EventTypes {
+		projectionHandlers[name] = timelineProjector.Handler(name)
+	}
+	completionHandlers := make(map[string]infrastructure.EventHandler, len(orderHandlers.CompletionEventTypes))
+	for _, name := range orderHandlers.CompletionEventTypes {
+		completionHandlers[name] = orderHandlers.NewOrderCompletionEventHandler(name, orderCompletions)
+	}
+	progressHandlers := make(map[string]infrastructure.EventHandler, len(orderHandlers.ProgressEventTypes))
+	for _, name := range orderHandlers.ProgressEventTypes {
+		progressHandlers[name] = orderHandlers.NewOrderProgressEventHandler(name, orderProgress)
+	}
+	subscribers := []map[string]infrastructure.EventHandler{projectionHandlers, completionHandlers, progressHandlers}
 
-	// Register DLQ handlers
-	eventListener.RegisterHandler("order.created.dlq", orderCreatedDLQHandler)
-	eventListener.RegisterHandler("order.cancelled.dlq", orderCancelledDLQHandler)
-	eventListener.RegisterHandler("inventory.status.updated.dlq", inventoryStatusUpdatedDLQHandler)
+	for _, handlers := range append([]map[string]infrastructure.EventHandler{eventHandlers, dlqHandlers}, subscribers...) {
+		for name := range handlers {
+			if _, ok := events.LookupEventType(name); !ok {
+				logger.Fatal(ctx, "Handler registered for unknown event type: "+name, errors.New("not in events.Registry"))
+			}
+		}
+	}
+	for _, eventType := range events.Registry {
+		if handler, ok := eventHandlers[eventType.Name]; ok {
+			eventListener.RegisterHandler(eventType.Queue, handler)
+		} else {
+			logger.Warn(ctx, "No handler registered for event type: "+eventType.Name)
+		}
+		for _, handlers := range subscribers {
+			if handler, ok := handlers[eventType.Name]; ok {
+				eventListener.RegisterHandler(eventType.Queue, handler)
+			}
+		}
+		if handler, ok := dlqHandlers[eventType.Name]; ok {
+			eventListener.RegisterHandler(eventType.DLQ, handler)
+		}
+	}
+	// The main queue dead-letters through the fanout DLX to a catch-all DLQ of its own
+	eventListener.RegisterHandlerOnQueue(dlq.CatchAll, rabbitmq.DeadLetterQueue(configs.RabbitMQQueueName), dlqHandler.NewCatchAllDLQHandler())
 
 	// Start event listeners in background with error handling
+	listenerDone := make(chan struct{})
 	go func() {
+		defer close(listenerDone)
 		if err := eventListener.StartListening(ctx); err != nil {
 			logger.Fatal(ctx, "Failed to start event listeners", err)
 		}
@@ -120,26 +276,105 @@ func main() {
 
 	logger.Info(ctx, "Event listeners started successfully")
 
+	// Start the outbox relay that publishes events written alongside business data
+	outboxRelay := outbox.NewRelay(outboxRepository, rabbitmqService, logger, configs.OutboxPollInterval, 100)
+	workers := worker.NewManager(logger)
+	workers.Start(ctx, "outbox relay", outboxRelay.Run)
+
+	// Start the sweeper that releases reservations of orders stalled past the TTL
+	reservationSweeper := inventory.NewReservationSweeper(reservationRepository, inventoryService, sweeperOrderStore{orderRepository}, logger, configs.ReservationTTL, configs.ReservationSweepInterval, 100)
+	workers.Start(ctx, "reservation sweeper", reservationSweeper.Run)
+
+	// Start the cleaner that keeps order_events within the retention window
+	eventCleaner := domain.NewEventCleaner(orderRepository, logger, configs.OrderEventRetention, configs.OrderEventCleanupInterval, 100)
+	workers.Start(ctx, "event cleaner", eventCleaner.Run)
+
+	// Start the detector warning about orders that did not reach a terminal status in time
+	stuckOrderDetector := domain.NewStuckOrderDetector(orderRepository, logger, configs.StuckOrderAge, configs.StuckOrderCheckInterval, 100)
+	workers.Start(ctx, "stuck order detector", stuckOrderDetector.Run)
+
+	// Start the sampler estimating how far behind the consumers of each event queue are
+	lagQueues := make([]string, 0, len(events.Registry))
+	for _, eventType := range events.Registry {
+		lagQueues = append(lagQueues, eventType.Queue)
+	}
+	queueLagSampler := infrastructure.NewQueueLagSampler(rabbitmqService, eventListener, logger, lagQueues, configs.QueueLagSampleInterval)
+	workers.Start(ctx, "queue lag sampler", queueLagSampler.Run)
+
+	// Probes behind GET /api/v1/status; each runs with its own timeout
+	statusReporter := status.NewReporter(configs.StatusProbeTimeout)
+	statusReporter.Register("mongodb", func(ctx context.Context) (any, error) {
+		return nil, client.Ping(ctx, nil)
+	})
+	statusReporter.Register("rabbitmq", func(ctx context.Context) (any, error) {
+		if !rabbitmqService.IsHealthy() {
+			return nil, errors.New("connection is closed")
+		}
+		return nil, nil
+	})
+	for _, eventType := range events.Registry {
+		statusReporter.Register("queue:"+eventType.Queue, func(ctx context.Context) (any, error) {
+			ready, err := rabbitmqService.QueueDepth(eventType.Queue)
+			if err != nil {
+				return nil, err
+			}
+			deadLettered, err := rabbitmqService.QueueDepth(eventType.DLQ)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]int{"messages": ready, "dlq": deadLettered}, nil
+		})
+	}
+	statusReporter.Register("replayBacklog", func(ctx context.Context) (any, error) {
+		pending, err := orderRepository.CountUnreplayedEvents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]int64{"pending": pending}, nil
+	})
+	statusReporter.Register("outboxRelay", status.WorkerProbe(outboxRelay.LastRun, 3*configs.OutboxPollInterval))
+	statusReporter.Register("eventHandlers", func(ctx context.Context) (any, error) {
+		return handlerMetrics.Snapshot(), nil
+	})
+	statusReporter.Register("queueLag", func(ctx context.Context) (any, error) {
+		return queueLagSampler.Snapshot(), nil
+	})
+	statusReporter.Register("queueLagSampler", status.WorkerProbe(queueLagSampler.LastRun, 3*configs.QueueLagSampleInterval))
+	statusReporter.Register("reservations", func(ctx context.Context) (any, error) {
+		return reservationMetrics.Snapshot(), nil
+	})
+	statusReporter.Register("publishRetriesExhausted", func(ctx context.Context) (any, error) {
+		return publishMetrics.Snapshot(), nil
+	})
+	statusReporter.Register("eventAudit", status.WorkerProbe(auditRecorder.LastRun, 3*configs.EventAuditFlushInterval))
+	statusReporter.Register("eventCleaner", status.WorkerProbe(eventCleaner.LastRun, 3*configs.OrderEventCleanupInterval))
+	statusReporter.Register("reservationSweeper", status.WorkerProbe(reservationSweeper.LastRun, 3*configs.ReservationSweepInterval))
+	statusReporter.Register("stuckOrders", stuckOrderDetector.Probe)
+	statusReporter.Register("stuckOrderDetector", status.WorkerProbe(stuckOrderDetector.LastRun, 3*configs.StuckOrderCheckInterval))
+
 	// Create controllers
-	orderController := controllers.NewOrderController(orderService)
-	inventoryController := controllers.NewInventoryController(inventoryService)
+	orderController := controllers.NewOrderController(orderService, configs.Enabled(config.FeatureSyncCreate))
+	inventoryController := controllers.NewInventoryController(inventoryService, configs.Enabled(config.FeatureInventoryAdmin))
+	// Cancelled first on shutdown, ending the order event streams and inventory feeds that would
+	// otherwise keep the HTTP server from shutting down
+	serverCtx, stopStreams := context.WithCancel(context.Background())
+	defer stopStreams()
+	inventoryFeedController := controllers.NewInventoryFeedController(serverCtx, inventoryService, productFeed)
+	notificationController := controllers.NewNotificationController(notificationService)
+	statusController := controllers.NewStatusController(statusReporter)
+	orderTimelineController := controllers.NewOrderTimelineController(timelineRepository)
+	orderEventsController := controllers.NewOrderEventsController(serverCtx, orderService, orderProgress)
+	orderStoredEventsController := controllers.NewOrderStoredEventsController(orderRepository)
+	orderStatsController := controllers.NewOrderStatsController(orderRepository)
+	eventAuditController := controllers.NewEventAuditController(auditRepository)
+	eventSchemaController := controllers.NewEventSchemaController()
 
 	// Configure Fiber app with optimized settings
 	app := fiber.New(fiber.Config{
 		ReadBufferSize:  81920,
 		WriteBufferSize: 81920,
 		ServerHeader:    "Order-EDA-Service",
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			logger.Exception(c.Context(), "HTTP request error", err)
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-			return c.Status(code).JSON(fiber.Map{
-				"error":   true,
-				"message": err.Error(),
-			})
-		},
+		ErrorHandler:    controllers.ErrorHandler(logger),
 	})
 
 	// Add middleware
@@ -148,6 +383,12 @@ func main() {
 		AllowOriginsFunc: func(_ string) bool { return true },
 	}))
 	app.Use(recover.New())
+	app.Use(middleware.RequestLogging(logger, log.NewRedactor(configs.LogRedactFields), configs.LogHTTPBodies, "/api/healthCheck"))
+	if len(configs.APIKeys) == 0 {
+		logger.Warn(ctx, "No API_KEYS configured, all mutating requests will be rejected")
+	}
+	app.Use(middleware.APIKeyAuth(configs.APIKeys))
+	app.Use(middleware.RequireJSON())
 
 	// Add routes
 	app.Get("/api/swagger/*", fiberSwagger.WrapHandler)
@@ -155,29 +396,36 @@ func main() {
 		// Check MongoDB health
 		if err := client.Ping(c.Context(), nil); err != nil {
 			logger.Exception(c.Context(), "Health check: MongoDB ping failed", err)
-			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-				"status": "unhealthy",
-				"error":  "database connection failed",
-			})
+			response := models.NewErrorResponse(fiber.StatusServiceUnavailable, "database connection failed")
+			response.Data = fiber.Map{"status": "unhealthy"}
+			return c.Status(fiber.StatusServiceUnavailable).JSON(response)
 		}
 
 		// Check RabbitMQ health
 		if !rabbitmqService.IsHealthy() {
 			logger.Warn(c.Context(), "Health check: RabbitMQ connection is unhealthy")
-			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-				"status": "unhealthy",
-				"error":  "message queue connection failed",
-			})
+			response := models.NewErrorResponse(fiber.StatusServiceUnavailable, "message queue connection failed")
+			response.Data = fiber.Map{"status": "unhealthy"}
+			return c.Status(fiber.StatusServiceUnavailable).JSON(response)
 		}
 
-		return c.JSON(fiber.Map{
+		return c.JSON(models.Response{Data: fiber.Map{
 			"status":    "healthy",
 			"timestamp": time.Now().UTC(),
-		})
+		}})
 	})
 
 	orderController.Route(app)
+	orderTimelineController.Route(app)
+	orderEventsController.Route(app)
+	orderStoredEventsController.Route(app)
+	orderStatsController.Route(app)
 	inventoryController.Route(app)
+	inventoryFeedController.Route(app)
+	notificationController.Route(app)
+	statusController.Route(app)
+	eventAuditController.Route(app)
+	eventSchemaController.Route(app)
 
 	// Set up graceful shutdown
 	c := make(chan os.Signal, 1)
@@ -200,57 +448,55 @@ func main() {
 		logger.Exception(ctx, "Server error occurred", err)
 	}
 
-	// Cancel context to stop background processes
-	cancel()
-
-	// Shutdown server with timeout
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
+	// Shut down in dependency order: drain HTTP requests while the workers and connections they
+	// use are still up, then stop the event listeners and background workers, then close the connections
+	shutdowner := shutdown.NewShutdowner(logger)
+	shutdowner.Add("http server", 30*time.Second, func(stopCtx context.Context) error {
+		stopStreams()
+		return app.ShutdownWithContext(stopCtx)
+	})
+	shutdowner.Add("event listeners", 30*time.Second, func(stopCtx context.Context) error {
+		cancel()
+		select {
+		case <-listenerDone:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err()
+		}
+	})
+	shutdowner.Add("background workers", 10*time.Second, workers.StopAll)
+	shutdowner.Add("event audit", 10*time.Second, auditRecorder.Flush)
+	shutdowner.Add("rabbitmq", 5*time.Second, func(context.Context) error {
+		rabbitmqService.Close()
+		return nil
+	})
+	shutdowner.Add("mongodb", 10*time.Second, client.Disconnect)
+	shutdowner.Add("tracing", 5*time.Second, shutdownTracing)
 
-	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
-		logger.Exception(ctx, "Server shutdown error", err)
+	if err := shutdowner.Shutdown(context.Background()); err != nil {
+		logger.Warn(ctx, "Shutdown completed with errors: "+err.Error())
 	}
 
 	logger.Info(ctx, "Server shutdown complete")
 }
 
+// sweeperOrderStore adapts the order repository to the reservation sweeper, which does not depend on order persistence
+type sweeperOrderStore struct {
+	*persistence.OrderRepository
+}
+
+func (s sweeperOrderStore) GetOrderStatus(ctx context.Context, id string) (string, error) {
+	status, err := s.OrderRepository.GetOrderStatus(ctx, id)
+	if errors.Is(err, persistence.ErrOrderNotFound) {
+		return "", inventory.ErrOrderNotFound
+	}
+	return status, err
+}
+
 // seedProducts adds sample products to the products collection
 func seedProducts(ctx context.Context, productRepo inventory.ProductRepository, logger log.Logger) error {
-	// Check if products already exist
-	products := []inventory.Product{
-		{
-			ID:       uuid.NewString(),
-			Name:     "Gaming Laptop",
-			Quantity: 50,
-			Reserved: 0,
-		},
-		{
-			ID:       uuid.NewString(),
-			Name:     "Wireless Mouse",
-			Quantity: 100,
-			Reserved: 0,
-		},
-		{
-			ID:       uuid.NewString(),
-			Name:     "Mechanical Keyboard",
-			Quantity: 75,
-			Reserved: 0,
-		},
-		{
-			ID:       uuid.NewString(),
-			Name:     "4K Monitor",
-			Quantity: 30,
-			Reserved: 0,
-		},
-		{
-			ID:       uuid.NewString(),
-			Name:     "USB-C Hub",
-			Quantity: 80,
-			Reserved: 0,
-		},
-	}
-
-	for _, product := range products {
+	for _, product := range inventory.SeedProducts {
+		product.ID = uuid.NewString()
 		err := productRepo.SeedProduct(ctx, product)
 		if err != nil {
 			logger.Exception(ctx, "Failed to seed product: "+product.Name, err)
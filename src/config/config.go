@@ -3,16 +3,144 @@ package config
 import (
 	"log"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Feature flags toggling optional behaviors, enabled by listing them in FEATURES
+const (
+	FeatureSyncCreate = "sync_create" // create-order?wait=true waits for the order to settle
+	FeaturePrecheck   = "precheck"    // Order creation rejects orders exceeding the available stock
+	FeatureSeeding    = "seeding"     // Sample products are seeded on startup
+	FeatureSMS        = "sms"         // Notifications are sent through the SMS channel where configured
+	// The inventory admin endpoints resetting stock are served; for development only
+	FeatureInventoryAdmin = "inventory_admin"
+)
+
+// KnownFeatures are the feature flags FEATURES accepts
+var KnownFeatures = []string{FeatureSyncCreate, FeaturePrecheck, FeatureSeeding, FeatureSMS, FeatureInventoryAdmin}
+
+// DefaultFeatures are the feature flags enabled when FEATURES is unset. Development-only flags
+// such as inventory_admin are left out, so they are never on unless listed explicitly.
+var DefaultFeatures = []string{FeatureSyncCreate, FeaturePrecheck, FeatureSeeding, FeatureSMS}
+
 type Config struct {
 	MongoDBConnectionString string
 	MongoDBDatabaseName     string
 	RabbitMQHostName        string
 	RabbitMQExchange        string
 	RabbitMQQueueName       string
+	LowStockThreshold       int
+	// AMQP heartbeat interval, which bounds how long a dead broker goes unnoticed, the timeout for
+	// opening the connection, and the locale requested during the handshake
+	RabbitMQHeartbeat         time.Duration
+	RabbitMQConnectionTimeout time.Duration
+	RabbitMQLocale            string
+	// Prefix of the consumer tags, and the queues consumed by one replica at a time
+	RabbitMQConsumerTag        string
+	RabbitMQSingleActiveQueues []string
+	// Time a message waits unconsumed in an event queue before it is dead-lettered, keyed by queue name
+	RabbitMQQueueTTL map[string]time.Duration
+	// TLS settings of the RabbitMQ and MongoDB connections
+	RabbitMQTLS TLS
+	MongoTLS    TLS
+	// Notification channels used per message type, e.g. "email,push"
+	ConfirmationChannels []string
+	CancellationChannels []string
+	// How often the outbox relay polls for pending messages
+	OutboxPollInterval time.Duration
+	// How long published outbox messages are kept before MongoDB expires them
+	OutboxRetention time.Duration
+	// Upper bound for a single MongoDB operation issued by a repository
+	MongoOperationTimeout time.Duration
+	// MongoDB driver pool and connection settings; the pool defaults to one connection per listener worker
+	MongoMaxPoolSize            uint64
+	MongoServerSelectionTimeout time.Duration
+	MongoConnectTimeout         time.Duration
+	MongoSocketTimeout          time.Duration
+	// Write concern ("majority" or a number of members) and journaling, and the read concern level, of every
+	// MongoDB operation; empty keeps the server's defaults
+	MongoWriteConcern string
+	MongoWriteJournal bool
+	MongoReadConcern  string
+	// Maximum number of event handlers running at once across all queues
+	EventListenerWorkers int
+	// Redeliveries after which a message failing with a transient error is dead-lettered as poison
+	MaxRedeliveries int
+	// Upper bound for one event handler call; a handler still running is abandoned and its message requeued
+	EventHandlerTimeout time.Duration
+	// How long event handlers running at shutdown may take to finish before their messages are requeued
+	EventDrainTimeout time.Duration
+	// Keys accepted by the API key middleware; more than one allows rotation
+	APIKeys []string
+	// How long a reservation may be held by an order that has not completed, and how often that is checked
+	ReservationTTL           time.Duration
+	ReservationSweepInterval time.Duration
+	// Upper bound for each subsystem probe of the status endpoint
+	StatusProbeTimeout time.Duration
+	// How long completed and failed order events are kept, and how often old ones are cleaned up
+	OrderEventRetention       time.Duration
+	OrderEventCleanupInterval time.Duration
+	// Number of orders whose failed events are replayed at once
+	ReplayConcurrency int
+	// Audit entries buffered before new ones are dropped, and how often the buffer is written to MongoDB
+	EventAuditBufferSize    int
+	EventAuditFlushInterval time.Duration
+	// OTLP/HTTP endpoint spans are exported to; tracing is a no-op when empty
+	OTLPEndpoint string
+	// Feature flags that are enabled; nil enables DefaultFeatures. Use Enabled to check one.
+	Features map[string]bool
+	// Whether request and response bodies are logged, and the JSON fields masked in them
+	LogHTTPBodies   bool
+	LogRedactFields []string
+	// How long startup waits for MongoDB and RabbitMQ each to become reachable; 0 gives up at the first failure
+	StartupTimeout time.Duration
+	// How often the depth and consumption rate of each event queue are sampled for the queue lag
+	QueueLagSampleInterval time.Duration
+	// Partitions per queue serializing the events of one order; 0 handles every message concurrently
+	EventOrderPartitions int
+	// Age after which an order that has not reached a terminal status is reported as stuck, and how often that is checked
+	StuckOrderAge           time.Duration
+	StuckOrderCheckInterval time.Duration
+}
+
+// Enabled reports whether a feature flag is enabled, ignoring case and surrounding spaces
+func (c *Config) Enabled(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if c.Features == nil {
+		return slices.Contains(DefaultFeatures, name)
+	}
+	return c.Features[name]
+}
+
+// disable turns a feature flag off, keeping the others as they are
+func (c *Config) disable(name string) {
+	if c.Features == nil {
+		c.Features = make(map[string]bool, len(DefaultFeatures))
+		for _, feature := range DefaultFeatures {
+			c.Features[feature] = true
+		}
+	}
+	delete(c.Features, name)
+}
+
+// TLS holds the certificates of a TLS connection. The zero value adds nothing to what the
+// connection URI asks for, e.g. amqps:// or tls=true verifies the server against the system roots.
+type TLS struct {
+	CAFile   string // PEM CA certificates verifying the server, instead of the system roots
+	CertFile string // PEM client certificate presented to the server, together with KeyFile
+	KeyFile  string
+	// Accept any server certificate; for development against self-signed servers only
+	InsecureSkipVerify bool
+}
+
+// Enabled reports whether any TLS setting is configured
+func (t TLS) Enabled() bool {
+	return t != TLS{}
 }
 
 func LoadConfig() (*Config, error) {
@@ -24,13 +152,67 @@ func LoadConfig() (*Config, error) {
 	}
 
 	config := &Config{
-		MongoDBConnectionString: os.Getenv("MONGODB_CONNECTION_STRING"),
-		MongoDBDatabaseName:     os.Getenv("MONGODB_DATABASE_NAME"),
-		RabbitMQHostName:        os.Getenv("RABBITMQ_HOSTNAME"),
-		RabbitMQExchange:        os.Getenv("RABBITMQ_EXCHANGE"),
-		RabbitMQQueueName:       os.Getenv("RABBITMQ_QUEUENAME"),
+		MongoDBConnectionString:     os.Getenv("MONGODB_CONNECTION_STRING"),
+		MongoDBDatabaseName:         os.Getenv("MONGODB_DATABASE_NAME"),
+		RabbitMQHostName:            os.Getenv("RABBITMQ_HOSTNAME"),
+		RabbitMQExchange:            os.Getenv("RABBITMQ_EXCHANGE"),
+		RabbitMQQueueName:           os.Getenv("RABBITMQ_QUEUENAME"),
+		RabbitMQHeartbeat:           getEnvAsDuration("RABBITMQ_HEARTBEAT", 10*time.Second),
+		RabbitMQConnectionTimeout:   getEnvAsDuration("RABBITMQ_CONNECTION_TIMEOUT", 30*time.Second),
+		RabbitMQLocale:              os.Getenv("RABBITMQ_LOCALE"),
+		RabbitMQConsumerTag:         os.Getenv("RABBITMQ_CONSUMER_TAG"),
+		RabbitMQSingleActiveQueues:  getEnvAsList("RABBITMQ_SINGLE_ACTIVE_QUEUES", nil),
+		RabbitMQQueueTTL:            getEnvAsDurations("RABBITMQ_QUEUE_TTL"),
+		RabbitMQTLS:                 getEnvAsTLS("RABBITMQ_TLS"),
+		MongoTLS:                    getEnvAsTLS("MONGO_TLS"),
+		LowStockThreshold:           getEnvAsInt("LOW_STOCK_THRESHOLD", 10),
+		ConfirmationChannels:        getEnvAsList("NOTIFICATION_CONFIRMATION_CHANNELS", []string{"email", "push"}),
+		CancellationChannels:        getEnvAsList("NOTIFICATION_CANCELLATION_CHANNELS", []string{"email", "sms"}),
+		OutboxPollInterval:          getEnvAsDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxRetention:             getEnvAsDuration("OUTBOX_RETENTION", 24*time.Hour),
+		EventListenerWorkers:        getEnvAsInt("EVENT_LISTENER_WORKERS", 50),
+		MaxRedeliveries:             getEnvAsInt("MAX_REDELIVERIES", 5),
+		EventHandlerTimeout:         getEnvAsDuration("EVENT_HANDLER_TIMEOUT", 30*time.Second),
+		EventDrainTimeout:           getEnvAsDuration("EVENT_DRAIN_TIMEOUT", 25*time.Second),
+		MongoServerSelectionTimeout: getEnvAsDuration("MONGO_SERVER_SELECTION_TIMEOUT", 5*time.Second),
+		MongoConnectTimeout:         getEnvAsDuration("MONGO_CONNECT_TIMEOUT", 10*time.Second),
+		MongoSocketTimeout:          getEnvAsDuration("MONGO_SOCKET_TIMEOUT", 30*time.Second),
+		MongoOperationTimeout:       getEnvAsDuration("MONGO_OPERATION_TIMEOUT", 5*time.Second),
+		MongoWriteConcern:           os.Getenv("MONGO_WRITE_CONCERN"),
+		MongoWriteJournal:           getEnvAsBool("MONGO_WRITE_JOURNAL", false),
+		MongoReadConcern:            os.Getenv("MONGO_READ_CONCERN"),
+		APIKeys:                     getEnvAsList("API_KEYS", nil),
+		ReservationTTL:              getEnvAsDuration("RESERVATION_TTL", 15*time.Minute),
+		ReservationSweepInterval:    getEnvAsDuration("RESERVATION_SWEEP_INTERVAL", time.Minute),
+		StatusProbeTimeout:          getEnvAsDuration("STATUS_PROBE_TIMEOUT", 2*time.Second),
+		OrderEventRetention:         getEnvAsDuration("ORDER_EVENT_RETENTION", 7*24*time.Hour),
+		OrderEventCleanupInterval:   getEnvAsDuration("ORDER_EVENT_CLEANUP_INTERVAL", time.Hour),
+		ReplayConcurrency:           getEnvAsInt("REPLAY_CONCURRENCY", 4),
+		EventAuditBufferSize:        getEnvAsInt("EVENT_AUDIT_BUFFER_SIZE", 10000),
+		EventAuditFlushInterval:     getEnvAsDuration("EVENT_AUDIT_FLUSH_INTERVAL", time.Second),
+		OTLPEndpoint:                os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		Features:                    getEnvAsFeatures("FEATURES"),
+		LogHTTPBodies:               getEnvAsBool("LOG_HTTP_BODIES", false),
+		LogRedactFields:             getEnvAsList("LOG_REDACT_FIELDS", []string{"email", "phone", "recipient"}),
+		StartupTimeout:              getEnvAsNonNegativeDuration("STARTUP_TIMEOUT", time.Minute),
+		QueueLagSampleInterval:      getEnvAsDuration("QUEUE_LAG_SAMPLE_INTERVAL", 15*time.Second),
+		EventOrderPartitions:        getEnvAsInt("EVENT_ORDER_PARTITIONS", 16),
+		StuckOrderAge:               getEnvAsDuration("STUCK_ORDER_AGE", 30*time.Minute),
+		StuckOrderCheckInterval:     getEnvAsDuration("STUCK_ORDER_CHECK_INTERVAL", 5*time.Minute),
+	}
+	// ORDER_STOCK_PRECHECK predates FEATURES and still turns the precheck off
+	if !getEnvAsBool("ORDER_STOCK_PRECHECK", true) {
+		config.disable(FeaturePrecheck)
 	}
 
+	if config.EventListenerWorkers < 1 {
+		config.EventListenerWorkers = 1
+	}
+	if config.ReplayConcurrency < 1 {
+		config.ReplayConcurrency = 1
+	}
+	config.MongoMaxPoolSize = uint64(getEnvAsInt("MONGO_MAX_POOL_SIZE", config.EventListenerWorkers))
+
 	// Set default values if environment variables are not set
 	if config.MongoDBDatabaseName == "" {
 		config.MongoDBDatabaseName = "order-db"
@@ -41,6 +223,136 @@ func LoadConfig() (*Config, error) {
 	if config.RabbitMQQueueName == "" {
 		config.RabbitMQQueueName = "order_events_queue"
 	}
+	if config.RabbitMQLocale == "" {
+		config.RabbitMQLocale = "en_US"
+	}
 
 	return config, nil
 }
+
+// getEnvAsInt reads an integer environment variable, falling back to defaultValue when unset or invalid
+func getEnvAsInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: invalid value for %s, using default %d", key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvAsBool reads a boolean environment variable such as "true" or "0",
+// falling back to defaultValue when unset or invalid
+func getEnvAsBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid value for %s, using default %t", key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvAsList reads a comma-separated environment variable, falling back to defaultValue when unset
+func getEnvAsList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvAsDurations reads a comma-separated list of durations by name such as
+// "notification.sent=30s,order.cancelled=0s", returning nil when it is unset.
+// Entries without a name or with an invalid duration are logged and ignored.
+func getEnvAsDurations(key string) map[string]time.Duration {
+	items := getEnvAsList(key, nil)
+	if items == nil {
+		return nil
+	}
+	durations := make(map[string]time.Duration, len(items))
+	for _, item := range items {
+		name, value, _ := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		parsed, err := time.ParseDuration(strings.TrimSpace(value))
+		if name == "" || err != nil || parsed < 0 {
+			log.Printf("Warning: invalid entry %q in %s, ignoring it", item, key)
+			continue
+		}
+		durations[name] = parsed
+	}
+	return durations
+}
+
+// getEnvAsFeatures reads a comma-separated list of feature flags such as "sync_create,precheck",
+// returning nil when it is unset or blank so the default features are enabled. "none" enables none.
+// Unknown flags are logged and ignored rather than failing startup.
+func getEnvAsFeatures(key string) map[string]bool {
+	if strings.TrimSpace(os.Getenv(key)) == "" {
+		return nil
+	}
+	features := make(map[string]bool)
+	for _, name := range getEnvAsList(key, nil) {
+		name = strings.ToLower(name)
+		switch {
+		case name == "none":
+		case slices.Contains(KnownFeatures, name):
+			features[name] = true
+		default:
+			log.Printf("Warning: unknown feature %q in %s, ignoring it", name, key)
+		}
+	}
+	return features
+}
+
+// getEnvAsTLS reads the TLS settings from the environment variables starting with prefix,
+// e.g. MONGO_TLS_CA_FILE, MONGO_TLS_CERT_FILE, MONGO_TLS_KEY_FILE and MONGO_TLS_INSECURE_SKIP_VERIFY
+func getEnvAsTLS(prefix string) TLS {
+	return TLS{
+		CAFile:             os.Getenv(prefix + "_CA_FILE"),
+		CertFile:           os.Getenv(prefix + "_CERT_FILE"),
+		KeyFile:            os.Getenv(prefix + "_KEY_FILE"),
+		InsecureSkipVerify: getEnvAsBool(prefix+"_INSECURE_SKIP_VERIFY", false),
+	}
+}
+
+// getEnvAsDuration reads a duration environment variable such as "500ms" or "5s",
+// falling back to defaultValue when unset or invalid
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("Warning: invalid value for %s, using default %s", key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvAsNonNegativeDuration is getEnvAsDuration for settings that 0 turns off, accepting "0"
+func getEnvAsNonNegativeDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		log.Printf("Warning: invalid value for %s, using default %s", key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
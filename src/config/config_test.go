@@ -0,0 +1,129 @@
+package config
+
+import (
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestGetEnvAsFeatures(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  map[string]bool
+	}{
+		{name: "unset", value: "", want: nil},
+		{name: "blank", value: "  ", want: nil},
+		{name: "listed flags", value: "sync_create,precheck", want: map[string]bool{FeatureSyncCreate: true, FeaturePrecheck: true}},
+		{name: "spaces, case and empty entries", value: " SMS , ,seeding,", want: map[string]bool{FeatureSMS: true, FeatureSeeding: true}},
+		{name: "unknown flag ignored", value: "precheck,teleport", want: map[string]bool{FeaturePrecheck: true}},
+		{name: "none", value: "none", want: map[string]bool{}},
+		{name: "development flag", value: "inventory_admin", want: map[string]bool{FeatureInventoryAdmin: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FEATURES", tt.value)
+			got := getEnvAsFeatures("FEATURES")
+			if (got == nil) != (tt.want == nil) || !maps.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	t.Log("✅ Feature flags parsed from FEATURES")
+}
+
+func TestGetEnvAsDurations(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  map[string]time.Duration
+	}{
+		{name: "unset", value: "", want: nil},
+		{name: "listed queues", value: "notification.sent=30s, order.cancelled = 2m", want: map[string]time.Duration{"notification.sent": 30 * time.Second, "order.cancelled": 2 * time.Minute}},
+		{name: "zero turns a TTL off", value: "notification.sent=0s", want: map[string]time.Duration{"notification.sent": 0}},
+		{name: "invalid entries ignored", value: "notification.sent=soon,=1s,order.created,low.stock=-1s,order.created=1s", want: map[string]time.Duration{"order.created": time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RABBITMQ_QUEUE_TTL", tt.value)
+			got := getEnvAsDurations("RABBITMQ_QUEUE_TTL")
+			if (got == nil) != (tt.want == nil) || !maps.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	t.Log("✅ Durations by name parsed from the environment")
+}
+
+func TestGetEnvAsNonNegativeDuration(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "unset", value: "", want: time.Minute},
+		{name: "duration", value: "90s", want: 90 * time.Second},
+		{name: "zero", value: "0", want: 0},
+		{name: "negative", value: "-1s", want: time.Minute},
+		{name: "invalid", value: "soon", want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("STARTUP_TIMEOUT", tt.value)
+			if got := getEnvAsNonNegativeDuration("STARTUP_TIMEOUT", time.Minute); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	t.Log("✅ Zero accepted where it turns a duration off")
+}
+
+func TestConfig_Enabled(t *testing.T) {
+	t.Run("the default features when none are configured", func(t *testing.T) {
+		cfg := &Config{}
+		for _, feature := range DefaultFeatures {
+			if !cfg.Enabled(feature) {
+				t.Errorf("Expected %s to be enabled", feature)
+			}
+		}
+		if cfg.Enabled(FeatureInventoryAdmin) {
+			t.Error("Expected inventory_admin to be disabled by default")
+		}
+		if cfg.Enabled("teleport") || cfg.Enabled("") {
+			t.Error("Expected unknown features to be disabled")
+		}
+	})
+
+	t.Run("only the configured features", func(t *testing.T) {
+		cfg := &Config{Features: map[string]bool{FeaturePrecheck: true}}
+		if !cfg.Enabled(FeaturePrecheck) || !cfg.Enabled(" PRECHECK ") {
+			t.Error("Expected precheck to be enabled")
+		}
+		if cfg.Enabled(FeatureSyncCreate) {
+			t.Error("Expected sync_create to be disabled")
+		}
+		if (&Config{Features: map[string]bool{}}).Enabled(FeaturePrecheck) {
+			t.Error("Expected no feature enabled by an empty set")
+		}
+	})
+
+	t.Run("ORDER_STOCK_PRECHECK turns the precheck off", func(t *testing.T) {
+		t.Setenv("FEATURES", "")
+		t.Setenv("ORDER_STOCK_PRECHECK", "false")
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		if cfg.Enabled(FeaturePrecheck) || !cfg.Enabled(FeatureSeeding) || cfg.Enabled(FeatureInventoryAdmin) {
+			t.Errorf("Expected only the precheck disabled, got %v", cfg.Features)
+		}
+	})
+
+	t.Log("✅ Enabled reports the configured features")
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"go-order-eda/src/infrastructure/audit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxAuditEntries bounds the entries returned for one correlation ID
+const maxAuditEntries = 500
+
+type EventAuditController struct {
+	repository audit.Repository
+}
+
+func NewEventAuditController(repository audit.Repository) *EventAuditController {
+	return &EventAuditController{
+		repository: repository,
+	}
+}
+
+func (c *EventAuditController) Route(app *fiber.App) {
+	app.Get("/api/v1/events/audit", c.GetEventAudit)
+}
+
+// GetEventAudit godoc
+// @Summary      Trace consumed events
+// @Description  Lists the consumed messages of one correlation ID, oldest first, with how each was settled (ack, nack or dlq) and how long it took
+// @Tags         events
+// @Produce      json
+// @Param        correlationId  query     string  true  "Correlation ID"
+// @Success      200  {object}  models.Response
+// @Failure      400  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/events/audit [get]
+func (c *EventAuditController) GetEventAudit(ctx *fiber.Ctx) error {
+	correlationID := ctx.Query("correlationId")
+	if correlationID == "" {
+		return respondError(ctx, fiber.StatusBadRequest, "correlationId is required")
+	}
+	entries, err := c.repository.FindByCorrelationID(ctx.Context(), correlationID, maxAuditEntries)
+	if err != nil {
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+	return respond(ctx, fiber.StatusOK, fiber.Map{"correlationId": correlationID, "entries": entries})
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"go-order-eda/src/infrastructure/audit"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeAuditRepository serves stored entries by correlation ID
+type fakeAuditRepository struct {
+	audit.Repository
+	entries map[string][]audit.Entry
+}
+
+func (f *fakeAuditRepository) FindByCorrelationID(ctx context.Context, correlationID string, limit int64) ([]audit.Entry, error) {
+	return f.entries[correlationID], nil
+}
+
+func TestEventAuditController_GetEventAudit(t *testing.T) {
+	repository := &fakeAuditRepository{entries: map[string][]audit.Entry{
+		"order-1": {
+			{EventType: "order.requested", CorrelationID: "order-1", Outcome: audit.OutcomeAck},
+			{EventType: "order.created", CorrelationID: "order-1", Outcome: audit.OutcomeNack},
+		},
+	}}
+	app := fiber.New()
+	NewEventAuditController(repository).Route(app)
+
+	t.Run("entries of a correlation ID", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/events/audit?correlationId=order-1", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		var body struct {
+			Entries []audit.Entry `json:"entries"`
+		}
+		decodeResponse(t, resp, &body)
+		if len(body.Entries) != 2 || body.Entries[1].Outcome != audit.OutcomeNack {
+			t.Errorf("Expected both entries, got %+v", body.Entries)
+		}
+	})
+
+	t.Run("missing correlation ID", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/events/audit", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"go-order-eda/src/services/events"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// EventSchemaController serves the JSON Schemas of the event payloads, for consumers of the broker
+type EventSchemaController struct{}
+
+func NewEventSchemaController() *EventSchemaController {
+	return &EventSchemaController{}
+}
+
+func (c *EventSchemaController) Route(app *fiber.App) {
+	app.Get("/api/v1/events/schema", c.GetEventSchemas)
+	app.Get("/api/v1/events/schema/:type", c.GetEventSchema)
+}
+
+// GetEventSchemas godoc
+// @Summary      List event schemas
+// @Description  Returns the JSON Schema of the payload of every event type, keyed by event type. The schemas
+// @Description  are derived from the event structs, so they always match what is published.
+// @Tags         events
+// @Produce      json
+// @Success      200  {object}  models.Response{data=map[string]events.Schema}
+// @Router       /api/v1/events/schema [get]
+func (c *EventSchemaController) GetEventSchemas(ctx *fiber.Ctx) error {
+	schemas := make(map[string]*events.Schema, len(events.Registry))
+	for _, eventType := range events.Registry {
+		schemas[eventType.Name] = eventType.Schema()
+	}
+	return respond(ctx, fiber.StatusOK, schemas)
+}
+
+// GetEventSchema godoc
+// @Summary      Get an event schema
+// @Description  Returns the JSON Schema of the payload of one event type, e.g. order.created
+// @Tags         events
+// @Produce      json
+// @Param        type  path      string  true  "Event type"
+// @Success      200  {object}  models.Response{data=events.Schema}
+// @Failure      404  {object}  models.Response
+// @Router       /api/v1/events/schema/{type} [get]
+func (c *EventSchemaController) GetEventSchema(ctx *fiber.Ctx) error {
+	eventType, ok := events.LookupEventType(ctx.Params("type"))
+	if !ok {
+		return respondError(ctx, fiber.StatusNotFound, "Unknown event type: "+ctx.Params("type"))
+	}
+	return respond(ctx, fiber.StatusOK, eventType.Schema())
+}
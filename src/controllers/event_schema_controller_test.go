@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"go-order-eda/src/services/events"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestEventSchemaController(t *testing.T) {
+	app := fiber.New()
+	NewEventSchemaController().Route(app)
+
+	t.Run("every event type", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/events/schema", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		var schemas map[string]events.Schema
+		decodeResponse(t, resp, &schemas)
+		for _, eventType := range events.Registry {
+			if _, ok := schemas[eventType.Name]; !ok {
+				t.Errorf("Expected a schema for %s", eventType.Name)
+			}
+		}
+	})
+
+	t.Run("order created", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/events/schema/"+events.OrderCreated, nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		var schema events.Schema
+		decodeResponse(t, resp, &schema)
+		for _, name := range []string{"id", "product", "amount", "currency", "status", "version", "timestamp"} {
+			if schema.Properties[name] == nil {
+				t.Errorf("Expected OrderCreatedEvent to have %s, got %v", name, schema.Properties)
+			}
+		}
+	})
+
+	t.Run("unknown event type", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/events/schema/order.unknown", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Log("✅ Event schemas served per event type")
+}
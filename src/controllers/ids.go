@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// uuidParams rejects a request whose named path parameters are not UUIDs with 400. Order and
+// product IDs are generated as UUIDs, so a malformed ID is reported as such rather than looked up
+// and answered with a 404 as if the order or product did not exist.
+func uuidParams(names ...string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		for _, name := range names {
+			if !isUUID(ctx.Params(name)) {
+				return respondError(ctx, fiber.StatusBadRequest, "Invalid "+name+": must be a UUID")
+			}
+		}
+		return ctx.Next()
+	}
+}
+
+// isUUID reports whether id is a UUID in the canonical 8-4-4-4-12 form IDs are generated in
+func isUUID(id string) bool {
+	return len(id) == 36 && uuid.Validate(id) == nil
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"go-order-eda/src/services/inventory"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Path IDs are validated as UUIDs, so the fixtures use UUIDs as well
+const (
+	testProductID = "5f0c6a3e-8b1d-4c27-9e4a-2d7b91c3f0a5"
+	testOrderID   = "0d2a7c4e-6f13-4b8a-a5e9-3c1f8b2d7e60"
+	otherOrderID  = "8e4b1f6a-2c9d-4e73-b0a8-5d6c3e9f1a27"
+	thirdOrderID  = "c3a95e1d-7b24-4f86-9d0e-1a8b6c4f2e93"
+	absentID      = "9b7e2d4c-1a35-4c68-8f9b-6e0d3a2c5b14" // A valid ID no fixture uses
+)
+
+func TestUUIDParams(t *testing.T) {
+	service := newFakeInventoryService(inventory.Product{ID: testProductID, Quantity: 10})
+	app := fiber.New()
+	NewInventoryController(service, false).Route(app)
+	NewOrderController(&fakeOrderService{}, false).Route(app)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "known product", path: "/api/v1/inventory/products/" + testProductID, wantStatus: fiber.StatusOK},
+		{name: "absent product", path: "/api/v1/inventory/products/" + absentID, wantStatus: fiber.StatusNotFound},
+		{name: "malformed product ID", path: "/api/v1/inventory/products/product-1", wantStatus: fiber.StatusBadRequest},
+		{name: "product ID with braces", path: "/api/v1/inventory/products/{" + testProductID + "}", wantStatus: fiber.StatusBadRequest},
+		{name: "known order", path: "/api/v1/orders/" + testOrderID + "/status", wantStatus: fiber.StatusOK},
+		{name: "malformed order ID", path: "/api/v1/orders/order-1/status", wantStatus: fiber.StatusBadRequest},
+		{name: "truncated order ID", path: "/api/v1/orders/" + testOrderID[:35] + "/status", wantStatus: fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus == fiber.StatusBadRequest {
+				if apiErr := decodeResponse(t, resp, nil); apiErr == nil || apiErr.Code != "bad_request" {
+					t.Errorf("Expected a bad_request error, got %+v", apiErr)
+				}
+			}
+		})
+	}
+
+	t.Log("✅ Malformed IDs rejected with 400, absent ones answered with 404")
+}
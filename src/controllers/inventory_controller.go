@@ -1,47 +1,67 @@
 package controllers
 
 import (
+	"errors"
 	"strconv"
 
+	"go-order-eda/src/controllers/models"
 	"go-order-eda/src/services/inventory"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 500
+)
+
 type InventoryController struct {
 	inventoryService inventory.InventoryService
+	admin            bool // Whether the development-only admin routes resetting stock are served
 }
 
-func NewInventoryController(inventoryService inventory.InventoryService) *InventoryController {
+func NewInventoryController(inventoryService inventory.InventoryService, admin bool) *InventoryController {
 	return &InventoryController{
 		inventoryService: inventoryService,
+		admin:            admin,
 	}
 }
 
 func (c *InventoryController) Route(app *fiber.App) {
 	api := app.Group("/api/v1/inventory")
 	api.Get("/products", c.GetAllProducts)
-	api.Get("/products/:id", c.GetProduct)
+	api.Get("/products/:id", uuidParams("id"), c.GetProduct)
+	api.Get("/products/:id/availability", uuidParams("id"), c.GetProductAvailability)
+	api.Get("/products/:id/history", uuidParams("id"), c.GetProductHistory)
 	api.Get("/products/low-stock/:threshold", c.GetLowStockProducts)
-	api.Post("/products/:id/reserve/:quantity", c.ReserveProduct)
-	api.Post("/products/:id/release/:quantity", c.ReleaseProduct)
-	api.Put("/products/:id/quantity/:quantity", c.UpdateQuantity)
+	api.Post("/products/:id/reserve", uuidParams("id"), c.ReserveProductWithBody)
+	api.Post("/products/:id/release", uuidParams("id"), c.ReleaseProductWithBody)
+	api.Post("/products/:id/reserve/:quantity", uuidParams("id"), c.ReserveProduct)
+	api.Post("/products/:id/release/:quantity", uuidParams("id"), c.ReleaseProduct)
+	api.Post("/orders/:orderId/release", uuidParams("orderId"), c.ReleaseOrderReservations)
+	api.Put("/products/:id/quantity/:quantity", uuidParams("id"), c.UpdateQuantity)
+	api.Post("/products/:id/restock/:quantity", uuidParams("id"), c.RestockProduct)
+	if c.admin {
+		api.Post("/products/:id/set/:quantity", uuidParams("id"), c.SetProductStock)
+		api.Post("/admin/reset", c.ResetInventory)
+	}
 }
 
 // GetAllProducts godoc
 // @Summary      Get all products
-// @Description  Retrieves all products in inventory
+// @Description  Retrieves all products in inventory, or only those of a category
 // @Tags         inventory
 // @Produce      json
-// @Success      200  {array}  inventory.Product
-// @Failure      500  {object}  map[string]interface{}
+// @Param        category  query     string  false  "Only products of this category"
+// @Success      200  {object}  models.Response{data=[]inventory.Product}
+// @Failure      500  {object}  models.Response
 // @Router       /api/v1/inventory/products [get]
 func (c *InventoryController) GetAllProducts(ctx *fiber.Ctx) error {
-	products, err := c.inventoryService.GetAllProducts(ctx.Context())
+	products, err := c.inventoryService.GetAllProducts(ctx.Context(), ctx.Query("category"))
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
 	}
-	return ctx.JSON(products)
+	return respond(ctx, fiber.StatusOK, products)
 }
 
 // GetProduct godoc
@@ -50,20 +70,72 @@ func (c *InventoryController) GetAllProducts(ctx *fiber.Ctx) error {
 // @Tags         inventory
 // @Produce      json
 // @Param        id   path      string  true  "Product ID"
-// @Success      200  {object}  inventory.Product
-// @Failure      404  {object}  map[string]interface{}
-// @Failure      500  {object}  map[string]interface{}
+// @Success      200  {object}  models.Response{data=inventory.Product}
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
 // @Router       /api/v1/inventory/products/{id} [get]
 func (c *InventoryController) GetProduct(ctx *fiber.Ctx) error {
 	productID := ctx.Params("id")
 	product, err := c.inventoryService.GetProductStock(ctx.Context(), productID)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
 	}
 	if product == nil {
-		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Product not found"})
+		return respondError(ctx, fiber.StatusNotFound, "Product not found")
+	}
+	return respond(ctx, fiber.StatusOK, product)
+}
+
+// GetProductAvailability godoc
+// @Summary      Get product availability
+// @Description  Returns the available, reserved and total stock of a product
+// @Tags         inventory
+// @Produce      json
+// @Param        id   path      string  true  "Product ID"
+// @Success      200  {object}  models.Response{data=inventory.ProductAvailability}
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/inventory/products/{id}/availability [get]
+func (c *InventoryController) GetProductAvailability(ctx *fiber.Ctx) error {
+	productID := ctx.Params("id")
+	availability, err := c.inventoryService.GetProductAvailability(ctx.Context(), productID)
+	if err != nil {
+		if errors.Is(err, inventory.ErrProductNotFound) {
+			return respondError(ctx, fiber.StatusNotFound, "Product not found")
+		}
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+	return respond(ctx, fiber.StatusOK, availability)
+}
+
+// GetProductHistory godoc
+// @Summary      Get product stock history
+// @Description  Returns the latest stock changes of a product, oldest first, with the quantity and reserved stock before and after each change
+// @Tags         inventory
+// @Produce      json
+// @Param        id     path      string  true   "Product ID"
+// @Param        limit  query     int     false  "Maximum number of changes (default 50, max 500)"
+// @Success      200  {object}  models.Response{data=[]inventory.ProductChange}
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/inventory/products/{id}/history [get]
+func (c *InventoryController) GetProductHistory(ctx *fiber.Ctx) error {
+	limit := ctx.QueryInt("limit", defaultHistoryLimit)
+	if limit <= 0 || limit > maxHistoryLimit {
+		return respondError(ctx, fiber.StatusBadRequest, "limit must be between 1 and 500")
+	}
+
+	history, err := c.inventoryService.GetProductHistory(ctx.Context(), ctx.Params("id"), int64(limit))
+	if err != nil {
+		if errors.Is(err, inventory.ErrProductNotFound) {
+			return respondError(ctx, fiber.StatusNotFound, "Product not found")
+		}
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
 	}
-	return ctx.JSON(product)
+	return respond(ctx, fiber.StatusOK, history)
 }
 
 // GetLowStockProducts godoc
@@ -72,80 +144,213 @@ func (c *InventoryController) GetProduct(ctx *fiber.Ctx) error {
 // @Tags         inventory
 // @Produce      json
 // @Param        threshold   path      int  true  "Stock threshold"
-// @Success      200  {array}  inventory.Product
-// @Failure      400  {object}  map[string]interface{}
-// @Failure      500  {object}  map[string]interface{}
+// @Success      200  {object}  models.Response{data=[]inventory.Product}
+// @Failure      400  {object}  models.Response
+// @Failure      500  {object}  models.Response
 // @Router       /api/v1/inventory/products/low-stock/{threshold} [get]
 func (c *InventoryController) GetLowStockProducts(ctx *fiber.Ctx) error {
 	thresholdStr := ctx.Params("threshold")
 	threshold, err := strconv.Atoi(thresholdStr)
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid threshold"})
+		return respondError(ctx, fiber.StatusBadRequest, "Invalid threshold")
 	}
 
 	products, err := c.inventoryService.GetLowStockProducts(ctx.Context(), threshold)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
 	}
-	return ctx.JSON(products)
+	return respond(ctx, fiber.StatusOK, products)
 }
 
 // ReserveProduct godoc
 // @Summary      Reserve product quantity
-// @Description  Reserves a quantity of a product
+// @Description  Reserves a quantity of a product and returns the updated stock
 // @Tags         inventory
 // @Produce      json
 // @Param        id        path      string  true  "Product ID"
 // @Param        quantity  path      int     true  "Quantity to reserve"
-// @Success      200  {object}  map[string]interface{}
-// @Failure      400  {object}  map[string]interface{}
-// @Failure      500  {object}  map[string]interface{}
+// @Success      200  {object}  models.Response
+// @Failure      400  {object}  models.Response
+// @Failure      500  {object}  models.Response
 // @Router       /api/v1/inventory/products/{id}/reserve/{quantity} [post]
 func (c *InventoryController) ReserveProduct(ctx *fiber.Ctx) error {
 	productID := ctx.Params("id")
 	quantityStr := ctx.Params("quantity")
 	quantity, err := strconv.Atoi(quantityStr)
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid quantity"})
+		return respondError(ctx, fiber.StatusBadRequest, "Invalid quantity")
 	}
 
-	success, err := c.inventoryService.ReserveProduct(ctx.Context(), productID, quantity)
+	product, err := c.inventoryService.ReserveProduct(ctx.Context(), productID, quantity)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
 	}
 
-	if !success {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Insufficient stock or product not found"})
+	if product == nil {
+		return respondError(ctx, fiber.StatusBadRequest, "Insufficient stock or product not found")
 	}
 
-	return ctx.JSON(fiber.Map{"message": "Product reserved successfully"})
+	return stockResponse(ctx, "Product reserved successfully", product, "")
 }
 
 // ReleaseProduct godoc
 // @Summary      Release reserved product quantity
-// @Description  Releases reserved quantity back to available stock
+// @Description  Releases reserved quantity back to available stock and returns the updated stock
 // @Tags         inventory
 // @Produce      json
 // @Param        id        path      string  true  "Product ID"
 // @Param        quantity  path      int     true  "Quantity to release"
-// @Success      200  {object}  map[string]interface{}
-// @Failure      400  {object}  map[string]interface{}
-// @Failure      500  {object}  map[string]interface{}
+// @Success      200  {object}  models.Response
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
 // @Router       /api/v1/inventory/products/{id}/release/{quantity} [post]
 func (c *InventoryController) ReleaseProduct(ctx *fiber.Ctx) error {
 	productID := ctx.Params("id")
 	quantityStr := ctx.Params("quantity")
 	quantity, err := strconv.Atoi(quantityStr)
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid quantity"})
+		return respondError(ctx, fiber.StatusBadRequest, "Invalid quantity")
+	}
+
+	product, err := c.inventoryService.ReleaseReservedProduct(ctx.Context(), productID, quantity)
+	if err != nil {
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+	if product == nil {
+		return respondError(ctx, fiber.StatusNotFound, "Product not found")
+	}
+
+	return stockResponse(ctx, "Reserved product released successfully", product, "")
+}
+
+// ReserveProductWithBody godoc
+// @Summary      Reserve product quantity
+// @Description  Reserves a quantity of a product. When orderId is set the reservation is recorded
+// @Description  in the reservations ledger and expires with the order.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string               true  "Product ID"
+// @Param        request  body  models.StockRequest  true  "Quantity and optional order ID"
+// @Success      200  {object}  models.Response
+// @Failure      400  {object}  models.Response
+// @Failure      409  {object}  models.Response
+// @Failure      415  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/inventory/products/{id}/reserve [post]
+func (c *InventoryController) ReserveProductWithBody(ctx *fiber.Ctx) error {
+	productID := ctx.Params("id")
+	var request models.StockRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return respondError(ctx, fiber.StatusBadRequest, "Invalid request")
+	}
+	if err := request.Validate(); err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	var product *inventory.Product
+	var err error
+	if request.OrderID != "" {
+		product, err = c.inventoryService.ReserveProductForOrder(ctx.Context(), request.OrderID, productID, request.Quantity)
+	} else {
+		product, err = c.inventoryService.ReserveProduct(ctx.Context(), productID, request.Quantity)
+	}
+	if err != nil {
+		if errors.Is(err, inventory.ErrReservationExists) {
+			return respondError(ctx, fiber.StatusConflict, err.Error())
+		}
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+	if product == nil {
+		return respondError(ctx, fiber.StatusBadRequest, "Insufficient stock or product not found")
+	}
+
+	return stockResponse(ctx, "Product reserved successfully", product, request.OrderID)
+}
+
+// ReleaseProductWithBody godoc
+// @Summary      Release reserved product quantity
+// @Description  Releases reserved quantity back to available stock. When orderId is set the order's
+// @Description  ledger entry is released instead, so the stock is not returned twice.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string               true  "Product ID"
+// @Param        request  body  models.StockRequest  true  "Quantity and optional order ID"
+// @Success      200  {object}  models.Response
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      415  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/inventory/products/{id}/release [post]
+func (c *InventoryController) ReleaseProductWithBody(ctx *fiber.Ctx) error {
+	productID := ctx.Params("id")
+	var request models.StockRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return respondError(ctx, fiber.StatusBadRequest, "Invalid request")
+	}
+	if err := request.Validate(); err != nil {
+		return errorResponse(ctx, err)
+	}
+
+	var product *inventory.Product
+	var err error
+	if request.OrderID != "" {
+		product, err = c.inventoryService.ReleaseOrderReservation(ctx.Context(), request.OrderID, productID, request.Quantity)
+	} else {
+		product, err = c.inventoryService.ReleaseReservedProduct(ctx.Context(), productID, request.Quantity)
+	}
+	if err != nil {
+		if errors.Is(err, inventory.ErrReservationMismatch) {
+			return respondError(ctx, fiber.StatusBadRequest, err.Error())
+		}
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if product == nil {
+		return respondError(ctx, fiber.StatusNotFound, "Product not found")
 	}
 
-	err = c.inventoryService.ReleaseReservedProduct(ctx.Context(), productID, quantity)
+	return stockResponse(ctx, "Reserved product released successfully", product, request.OrderID)
+}
+
+// ReleaseOrderReservations godoc
+// @Summary      Release an order's reservations
+// @Description  Returns the stock of every active ledger reservation of an order in one call and lists
+// @Description  the released reservations. Releasing an order again releases nothing.
+// @Tags         inventory
+// @Produce      json
+// @Param        orderId  path  string  true  "Order ID"
+// @Success      200  {object}  models.Response{data=[]inventory.Reservation}
+// @Failure      400  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/inventory/orders/{orderId}/release [post]
+func (c *InventoryController) ReleaseOrderReservations(ctx *fiber.Ctx) error {
+	released, err := c.inventoryService.ReleaseOrderReservations(ctx.Context(), ctx.Params("orderId"), nil)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
 	}
+	if released == nil {
+		released = []inventory.Reservation{}
+	}
+	return respond(ctx, fiber.StatusOK, released)
+}
 
-	return ctx.JSON(fiber.Map{"message": "Reserved product released successfully"})
+// stockResponse reports the stock of a product as returned by a reserve or release
+func stockResponse(ctx *fiber.Ctx, message string, product *inventory.Product, orderID string) error {
+	availability := product.Availability()
+	response := fiber.Map{
+		"message":   message,
+		"productId": availability.ProductID,
+		"available": availability.Available,
+		"reserved":  availability.Reserved,
+		"total":     availability.Total,
+	}
+	if orderID != "" {
+		response["orderId"] = orderID
+	}
+	return respond(ctx, fiber.StatusOK, response)
 }
 
 // UpdateQuantity godoc
@@ -155,22 +360,113 @@ func (c *InventoryController) ReleaseProduct(ctx *fiber.Ctx) error {
 // @Produce      json
 // @Param        id        path      string  true  "Product ID"
 // @Param        quantity  path      int     true  "New quantity"
-// @Success      200  {object}  map[string]interface{}
-// @Failure      400  {object}  map[string]interface{}
-// @Failure      500  {object}  map[string]interface{}
+// @Success      200  {object}  models.Response
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
 // @Router       /api/v1/inventory/products/{id}/quantity/{quantity} [put]
 func (c *InventoryController) UpdateQuantity(ctx *fiber.Ctx) error {
 	productID := ctx.Params("id")
 	quantityStr := ctx.Params("quantity")
 	quantity, err := strconv.Atoi(quantityStr)
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid quantity"})
+		return respondError(ctx, fiber.StatusBadRequest, "Invalid quantity")
 	}
 
 	err = c.inventoryService.UpdateProductQuantity(ctx.Context(), productID, quantity)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		switch {
+		case errors.Is(err, inventory.ErrNegativeQuantity), errors.Is(err, inventory.ErrQuantityBelowReserved):
+			return respondError(ctx, fiber.StatusBadRequest, err.Error())
+		case errors.Is(err, inventory.ErrProductNotFound):
+			return respondError(ctx, fiber.StatusNotFound, "Product not found")
+		}
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return respond(ctx, fiber.StatusOK, fiber.Map{"message": "Product quantity updated successfully"})
+}
+
+// RestockProduct godoc
+// @Summary      Restock product
+// @Description  Atomically adds quantity to the available stock of a product
+// @Tags         inventory
+// @Produce      json
+// @Param        id        path      string  true  "Product ID"
+// @Param        quantity  path      int     true  "Quantity to add"
+// @Success      200  {object}  models.Response
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/inventory/products/{id}/restock/{quantity} [post]
+func (c *InventoryController) RestockProduct(ctx *fiber.Ctx) error {
+	productID := ctx.Params("id")
+	quantityStr := ctx.Params("quantity")
+	quantity, err := strconv.Atoi(quantityStr)
+	if err != nil {
+		return respondError(ctx, fiber.StatusBadRequest, "Invalid quantity")
+	}
+
+	err = c.inventoryService.RestockProduct(ctx.Context(), productID, quantity)
+	if err != nil {
+		switch {
+		case errors.Is(err, inventory.ErrNonPositiveRestock):
+			return respondError(ctx, fiber.StatusBadRequest, err.Error())
+		case errors.Is(err, inventory.ErrProductNotFound):
+			return respondError(ctx, fiber.StatusNotFound, "Product not found")
+		}
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return respond(ctx, fiber.StatusOK, fiber.Map{"message": "Product restocked successfully"})
+}
+
+// SetProductStock godoc
+// @Summary      Set product stock
+// @Description  Sets the available quantity of a product and clears its reserved stock, releasing its active
+// @Description  reservations without returning their stock. Served only with the inventory_admin feature, for development.
+// @Tags         inventory
+// @Produce      json
+// @Param        id        path      string  true  "Product ID"
+// @Param        quantity  path      int     true  "New quantity"
+// @Success      200  {object}  models.Response{data=inventory.Product}
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/inventory/products/{id}/set/{quantity} [post]
+func (c *InventoryController) SetProductStock(ctx *fiber.Ctx) error {
+	productID := ctx.Params("id")
+	quantity, err := strconv.Atoi(ctx.Params("quantity"))
+	if err != nil {
+		return respondError(ctx, fiber.StatusBadRequest, "Invalid quantity")
+	}
+
+	product, err := c.inventoryService.SetProductStock(ctx.Context(), productID, quantity)
+	if err != nil {
+		switch {
+		case errors.Is(err, inventory.ErrNegativeQuantity):
+			return respondError(ctx, fiber.StatusBadRequest, err.Error())
+		case errors.Is(err, inventory.ErrProductNotFound):
+			return respondError(ctx, fiber.StatusNotFound, "Product not found")
+		}
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
 	}
+	return respond(ctx, fiber.StatusOK, product)
+}
 
-	return ctx.JSON(fiber.Map{"message": "Product quantity updated successfully"})
+// ResetInventory godoc
+// @Summary      Reset inventory
+// @Description  Restores the sample products to their seed stock, clearing their reserved stock, and seeds those
+// @Description  missing. Other products are left as they are. Served only with the inventory_admin feature, for development.
+// @Tags         inventory
+// @Produce      json
+// @Success      200  {object}  models.Response{data=[]inventory.Product}
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/inventory/admin/reset [post]
+func (c *InventoryController) ResetInventory(ctx *fiber.Ctx) error {
+	products, err := c.inventoryService.ResetInventory(ctx.Context())
+	if err != nil {
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+	return respond(ctx, fiber.StatusOK, products)
 }
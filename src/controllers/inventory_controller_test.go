@@ -0,0 +1,478 @@
+package controllers
+
+import (
+	"context"
+	"go-order-eda/src/services/inventory"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeInventoryService keeps the stock of one product in memory and records ledger reservations.
+// Like the product repository, it publishes stock changes to feed when set.
+// Methods the tests do not use fall through to the nil embedded interface.
+type fakeInventoryService struct {
+	inventory.InventoryService
+	mu           sync.Mutex
+	feed         *inventory.ProductFeed
+	product      inventory.Product
+	reservations map[string]int
+	history      []inventory.ProductChange
+}
+
+func newFakeInventoryService(product inventory.Product) *fakeInventoryService {
+	return &fakeInventoryService{product: product, reservations: make(map[string]int)}
+}
+
+func (f *fakeInventoryService) ReserveProduct(ctx context.Context, productID string, quantity int) (*inventory.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if productID != f.product.ID || f.product.Quantity < quantity {
+		return nil, nil
+	}
+	before := f.product
+	f.product.Quantity -= quantity
+	f.product.Reserved += quantity
+	f.publish(inventory.ChangeReserve, before)
+	product := f.product
+	return &product, nil
+}
+
+func (f *fakeInventoryService) ReserveProductForOrder(ctx context.Context, orderID, productID string, quantity int) (*inventory.Product, error) {
+	if _, ok := f.reservations[orderID]; ok {
+		return nil, inventory.ErrReservationExists
+	}
+	product, err := f.ReserveProduct(ctx, productID, quantity)
+	if product != nil {
+		f.reservations[orderID] = quantity
+	}
+	return product, err
+}
+
+func (f *fakeInventoryService) ReleaseReservedProduct(ctx context.Context, productID string, quantity int) (*inventory.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if productID != f.product.ID {
+		return nil, nil
+	}
+	before := f.product
+	f.product.Quantity += quantity
+	f.product.Reserved -= quantity
+	f.publish(inventory.ChangeRelease, before)
+	product := f.product
+	return &product, nil
+}
+
+func (f *fakeInventoryService) ReleaseOrderReservations(ctx context.Context, orderID string, unrecorded *inventory.Reservation) ([]inventory.Reservation, error) {
+	quantity, ok := f.reservations[orderID]
+	if !ok {
+		return nil, nil
+	}
+	delete(f.reservations, orderID)
+	if _, err := f.ReleaseReservedProduct(ctx, f.product.ID, quantity); err != nil {
+		return nil, err
+	}
+	return []inventory.Reservation{{
+		OrderID:   orderID,
+		ProductID: f.product.ID,
+		Quantity:  quantity,
+		Status:    inventory.ReservationReleased,
+	}}, nil
+}
+
+func (f *fakeInventoryService) GetProductStock(ctx context.Context, productID string) (*inventory.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if productID != f.product.ID {
+		return nil, nil
+	}
+	product := f.product
+	return &product, nil
+}
+
+func (f *fakeInventoryService) GetAllProducts(ctx context.Context, category string) ([]inventory.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if category != "" && category != f.product.Category {
+		return []inventory.Product{}, nil
+	}
+	return []inventory.Product{f.product}, nil
+}
+
+func (f *fakeInventoryService) SetProductStock(ctx context.Context, productID string, quantity int) (*inventory.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if productID != f.product.ID {
+		return nil, inventory.ErrProductNotFound
+	}
+	f.product.Quantity = quantity
+	f.product.Reserved = 0
+	product := f.product
+	return &product, nil
+}
+
+// ResetInventory treats the product as the first sample product
+func (f *fakeInventoryService) ResetInventory(ctx context.Context) ([]inventory.Product, error) {
+	product, err := f.SetProductStock(ctx, f.product.ID, inventory.SeedProducts[0].Quantity)
+	if err != nil {
+		return nil, err
+	}
+	return []inventory.Product{*product}, nil
+}
+
+// publish sends the change of the product from before to its current stock to the feed; f.mu must be held
+func (f *fakeInventoryService) publish(kind string, before inventory.Product) {
+	f.feed.Publish(inventory.ProductChange{
+		ProductID:      f.product.ID,
+		Kind:           kind,
+		QuantityBefore: before.Quantity,
+		QuantityAfter:  f.product.Quantity,
+		QuantityDelta:  f.product.Quantity - before.Quantity,
+		ReservedBefore: before.Reserved,
+		ReservedAfter:  f.product.Reserved,
+		ReservedDelta:  f.product.Reserved - before.Reserved,
+		At:             time.Now().UTC(),
+	})
+}
+
+func (f *fakeInventoryService) GetProductHistory(ctx context.Context, productID string, limit int64) ([]inventory.ProductChange, error) {
+	if productID != f.product.ID {
+		return nil, inventory.ErrProductNotFound
+	}
+	if int64(len(f.history)) > limit {
+		return f.history[int64(len(f.history))-limit:], nil
+	}
+	return f.history, nil
+}
+
+func TestInventoryController_ReserveProductWithBody(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		wantStatus      int
+		wantAvailable   int
+		wantReservation string
+	}{
+		{
+			name:          "without orderId",
+			body:          `{"quantity":3}`,
+			wantStatus:    fiber.StatusOK,
+			wantAvailable: 7,
+		},
+		{
+			name:            "with orderId",
+			body:            `{"quantity":4,"orderId":"` + testOrderID + `"}`,
+			wantStatus:      fiber.StatusOK,
+			wantAvailable:   6,
+			wantReservation: testOrderID,
+		},
+		{
+			name:       "non-positive quantity",
+			body:       `{"quantity":0,"orderId":"` + testOrderID + `"}`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:       "insufficient stock",
+			body:       `{"quantity":11}`,
+			wantStatus: fiber.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeInventoryService(inventory.Product{ID: testProductID, Quantity: 10})
+			app := fiber.New()
+			NewInventoryController(service, false).Route(app)
+
+			req := httptest.NewRequest("POST", "/api/v1/inventory/products/"+testProductID+"/reserve", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+
+			var body struct {
+				Available int    `json:"available"`
+				OrderID   string `json:"orderId"`
+			}
+			decodeResponse(t, resp, &body)
+			if body.Available != tt.wantAvailable {
+				t.Errorf("Expected available %d, got %d", tt.wantAvailable, body.Available)
+			}
+			if body.OrderID != tt.wantReservation {
+				t.Errorf("Expected orderId %q, got %q", tt.wantReservation, body.OrderID)
+			}
+			if _, recorded := service.reservations[tt.wantReservation]; recorded != (tt.wantReservation != "") {
+				t.Errorf("Expected ledger entry for %q to be recorded=%v", tt.wantReservation, tt.wantReservation != "")
+			}
+		})
+	}
+
+	t.Run("second reservation for the same order conflicts", func(t *testing.T) {
+		service := newFakeInventoryService(inventory.Product{ID: testProductID, Quantity: 10})
+		app := fiber.New()
+		NewInventoryController(service, false).Route(app)
+
+		var statuses []int
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest("POST", "/api/v1/inventory/products/"+testProductID+"/reserve",
+				strings.NewReader(`{"quantity":2,"orderId":"`+testOrderID+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			statuses = append(statuses, resp.StatusCode)
+		}
+		if statuses[0] != fiber.StatusOK || statuses[1] != fiber.StatusConflict {
+			t.Errorf("Expected statuses [200 409], got %v", statuses)
+		}
+	})
+}
+
+func TestInventoryController_ReleaseOrderReservations(t *testing.T) {
+	service := newFakeInventoryService(inventory.Product{ID: testProductID, Quantity: 10})
+	app := fiber.New()
+	NewInventoryController(service, false).Route(app)
+
+	req := httptest.NewRequest("POST", "/api/v1/inventory/products/"+testProductID+"/reserve", strings.NewReader(`{"quantity":4,"orderId":"`+testOrderID+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if resp, err := app.Test(req); err != nil || resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Reservation for the order failed: %v, %v", resp, err)
+	}
+
+	// release posts the release of the order's reservations and returns them
+	release := func(t *testing.T, orderID string) []inventory.Reservation {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/inventory/orders/"+orderID+"/release", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status %d, got %d", fiber.StatusOK, resp.StatusCode)
+		}
+		var released []inventory.Reservation
+		decodeResponse(t, resp, &released)
+		return released
+	}
+
+	t.Run("the order's reservations are released in one call", func(t *testing.T) {
+		released := release(t, testOrderID)
+		if len(released) != 1 || released[0].OrderID != testOrderID || released[0].Quantity != 4 || released[0].Status != inventory.ReservationReleased {
+			t.Errorf("Expected the order's reservation of 4 released, got %+v", released)
+		}
+		if service.product.Quantity != 10 || service.product.Reserved != 0 {
+			t.Errorf("Expected quantity 10 and reserved 0, got %d and %d", service.product.Quantity, service.product.Reserved)
+		}
+	})
+
+	t.Run("releasing again releases nothing", func(t *testing.T) {
+		if released := release(t, testOrderID); len(released) != 0 {
+			t.Errorf("Expected nothing released, got %+v", released)
+		}
+		if service.product.Quantity != 10 {
+			t.Errorf("Expected quantity 10, got %d", service.product.Quantity)
+		}
+	})
+
+	t.Log("✅ Order reservations released by order ID")
+}
+
+func TestInventoryController_StockResponses(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		body          string
+		wantStatus    int
+		wantAvailable int
+		wantReserved  int
+	}{
+		{name: "reserve by path", path: "/api/v1/inventory/products/" + testProductID + "/reserve/2", wantStatus: fiber.StatusOK, wantAvailable: 6, wantReserved: 4},
+		{name: "release by path", path: "/api/v1/inventory/products/" + testProductID + "/release/2", wantStatus: fiber.StatusOK, wantAvailable: 10, wantReserved: 0},
+		{name: "release by body", path: "/api/v1/inventory/products/" + testProductID + "/release", body: `{"quantity":1}`, wantStatus: fiber.StatusOK, wantAvailable: 9, wantReserved: 1},
+		{name: "release of a missing product", path: "/api/v1/inventory/products/" + absentID + "/release/2", wantStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			NewInventoryController(newFakeInventoryService(inventory.Product{ID: testProductID, Quantity: 8, Reserved: 2}), false).Route(app)
+
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+
+			var body struct {
+				Available int `json:"available"`
+				Reserved  int `json:"reserved"`
+				Total     int `json:"total"`
+			}
+			decodeResponse(t, resp, &body)
+			if body.Available != tt.wantAvailable || body.Reserved != tt.wantReserved || body.Total != 10 {
+				t.Errorf("Expected available %d, reserved %d and total 10, got %+v", tt.wantAvailable, tt.wantReserved, body)
+			}
+		})
+	}
+}
+
+func TestInventoryController_GetAllProducts(t *testing.T) {
+	laptop := inventory.Product{
+		ID: testProductID, Name: "Laptop", Quantity: 5, SKU: "LAP-001", Category: "computers",
+		Attributes: map[string]any{"color": "silver"},
+	}
+	app := fiber.New()
+	NewInventoryController(newFakeInventoryService(laptop), false).Route(app)
+
+	tests := []struct {
+		name      string
+		path      string
+		wantCount int
+	}{
+		{name: "every product", path: "/api/v1/inventory/products", wantCount: 1},
+		{name: "products of the category", path: "/api/v1/inventory/products?category=computers", wantCount: 1},
+		{name: "products of another category", path: "/api/v1/inventory/products?category=accessories", wantCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+			}
+			var products []inventory.Product
+			decodeResponse(t, resp, &products)
+			if len(products) != tt.wantCount {
+				t.Fatalf("Expected %d products, got %+v", tt.wantCount, products)
+			}
+			if len(products) == 1 && (products[0].SKU != "LAP-001" || products[0].Category != "computers" || products[0].Attributes["color"] != "silver") {
+				t.Errorf("Expected the catalog data returned, got %+v", products[0])
+			}
+		})
+	}
+}
+
+func TestInventoryController_GetProductHistory(t *testing.T) {
+	service := newFakeInventoryService(inventory.Product{ID: testProductID, Quantity: 10})
+	service.history = []inventory.ProductChange{
+		{ProductID: testProductID, Kind: inventory.ChangeReserve, QuantityBefore: 10, QuantityAfter: 7, QuantityDelta: -3, ReservedAfter: 3, ReservedDelta: 3},
+		{ProductID: testProductID, Kind: inventory.ChangeRelease, QuantityBefore: 7, QuantityAfter: 10, QuantityDelta: 3, ReservedBefore: 3, ReservedDelta: -3},
+	}
+	app := fiber.New()
+	NewInventoryController(service, false).Route(app)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantKinds  []string
+	}{
+		{name: "full history", path: "/api/v1/inventory/products/" + testProductID + "/history", wantStatus: fiber.StatusOK, wantKinds: []string{inventory.ChangeReserve, inventory.ChangeRelease}},
+		{name: "limited to the latest change", path: "/api/v1/inventory/products/" + testProductID + "/history?limit=1", wantStatus: fiber.StatusOK, wantKinds: []string{inventory.ChangeRelease}},
+		{name: "invalid limit", path: "/api/v1/inventory/products/" + testProductID + "/history?limit=0", wantStatus: fiber.StatusBadRequest},
+		{name: "missing product", path: "/api/v1/inventory/products/" + absentID + "/history", wantStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+
+			var history []inventory.ProductChange
+			decodeResponse(t, resp, &history)
+			if len(history) != len(tt.wantKinds) {
+				t.Fatalf("Expected %d changes, got %+v", len(tt.wantKinds), history)
+			}
+			for i, kind := range tt.wantKinds {
+				if history[i].Kind != kind {
+					t.Errorf("Expected change %d to be %s, got %s", i, kind, history[i].Kind)
+				}
+			}
+		})
+	}
+
+	t.Log("✅ Product history served oldest first")
+}
+
+func TestInventoryController_AdminRoutes(t *testing.T) {
+	paths := []string{"/api/v1/inventory/products/" + testProductID + "/set/20", "/api/v1/inventory/admin/reset"}
+
+	t.Run("not served without the feature", func(t *testing.T) {
+		app := fiber.New()
+		NewInventoryController(newFakeInventoryService(inventory.Product{ID: testProductID, Quantity: 4, Reserved: 6}), false).Route(app)
+		for _, path := range paths {
+			resp, err := app.Test(httptest.NewRequest("POST", path, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusNotFound {
+				t.Errorf("Expected %s not served, got %d", path, resp.StatusCode)
+			}
+		}
+	})
+
+	tests := []struct {
+		name         string
+		path         string
+		wantStatus   int
+		wantQuantity int
+	}{
+		{name: "set zeroes reserved", path: "/api/v1/inventory/products/" + testProductID + "/set/20", wantStatus: fiber.StatusOK, wantQuantity: 20},
+		{name: "set of a missing product", path: "/api/v1/inventory/products/" + absentID + "/set/20", wantStatus: fiber.StatusNotFound},
+		{name: "set with an invalid quantity", path: "/api/v1/inventory/products/" + testProductID + "/set/many", wantStatus: fiber.StatusBadRequest},
+		{name: "reset restores the seed stock", path: "/api/v1/inventory/admin/reset", wantStatus: fiber.StatusOK, wantQuantity: inventory.SeedProducts[0].Quantity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFakeInventoryService(inventory.Product{ID: testProductID, Quantity: 4, Reserved: 6})
+			app := fiber.New()
+			NewInventoryController(service, true).Route(app)
+
+			resp, err := app.Test(httptest.NewRequest("POST", tt.path, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+			if service.product.Quantity != tt.wantQuantity || service.product.Reserved != 0 {
+				t.Errorf("Expected %d available and none reserved, got %+v", tt.wantQuantity, service.product)
+			}
+		})
+	}
+
+	t.Log("✅ Admin routes reset stock only with the inventory_admin feature")
+}
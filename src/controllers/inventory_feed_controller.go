@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"go-order-eda/src/services/inventory"
+	"strings"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// How often an idle inventory feed pings its client, which notices clients that went away
+	inventoryFeedPing = 30 * time.Second
+	// How long a write to a feed client may take. Changes made meanwhile are coalesced by the
+	// subscription, so a slow client delays only itself.
+	inventoryFeedWriteTimeout = 10 * time.Second
+)
+
+// InventoryFeedSubscription is a message a feed client sends to change the products it follows
+type InventoryFeedSubscription struct {
+	Products []string `json:"products"` // Empty follows every product
+}
+
+type InventoryFeedController struct {
+	serverCtx        context.Context // Done when the server shuts down, which closes every feed
+	inventoryService inventory.InventoryService
+	feed             *inventory.ProductFeed
+	ping             time.Duration
+}
+
+func NewInventoryFeedController(serverCtx context.Context, inventoryService inventory.InventoryService, feed *inventory.ProductFeed) *InventoryFeedController {
+	return &InventoryFeedController{
+		serverCtx:        serverCtx,
+		inventoryService: inventoryService,
+		feed:             feed,
+		ping:             inventoryFeedPing,
+	}
+}
+
+func (c *InventoryFeedController) Route(app *fiber.App) {
+	app.Get("/api/v1/inventory/ws", c.RequireUpgrade, websocket.New(c.StreamInventory))
+}
+
+// RequireUpgrade rejects requests to the feed that are not WebSocket upgrades
+func (c *InventoryFeedController) RequireUpgrade(ctx *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(ctx) {
+		return respondError(ctx, fiber.StatusUpgradeRequired, "expected a WebSocket upgrade")
+	}
+	return ctx.Next()
+}
+
+// StreamInventory godoc
+// @Summary      Stream inventory changes
+// @Description  WebSocket feed of product stock. On connect it sends the current stock of the followed products, then one message per change. A client that reads slower than stock changes receives only the latest stock of each product. Send {"products":[...]} to change the followed products; an empty list follows every product. Only changes made by this instance are streamed.
+// @Tags         inventory
+// @Produce      json
+// @Param        products  query     string  false  "Comma-separated product IDs to follow, every product when omitted"
+// @Success      101       {object}  inventory.ProductSnapshot
+// @Failure      426       {object}  models.Response
+// @Router       /api/v1/inventory/ws [get]
+func (c *InventoryFeedController) StreamInventory(conn *websocket.Conn) {
+	products := splitProductIDs(conn.Query("products"))
+	// Subscribe before reading the current stock, so no change falls in between
+	subscription := c.feed.Subscribe(products...)
+	defer subscription.Close()
+
+	// The client only sends subscription changes; reading also notices when it goes away
+	follow := make(chan []string)
+	gone := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		defer close(gone)
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var message InventoryFeedSubscription
+			if err := json.Unmarshal(data, &message); err != nil {
+				continue
+			}
+			select {
+			case follow <- message.Products:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	if err := c.sendCurrentStock(conn, products); err != nil {
+		return
+	}
+
+	ping := time.NewTicker(c.ping)
+	defer ping.Stop()
+	for {
+		select {
+		case <-subscription.Ready():
+			for _, snapshot := range subscription.Next() {
+				if err := writeSnapshot(conn, snapshot); err != nil {
+					return
+				}
+			}
+		case products := <-follow:
+			subscription.Follow(products...)
+			if err := c.sendCurrentStock(conn, products); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(inventoryFeedWriteTimeout)); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		case <-c.serverCtx.Done():
+			// Lets the server shut down without waiting for the client, telling it why
+			closing := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+			conn.WriteControl(websocket.CloseMessage, closing, time.Now().Add(inventoryFeedWriteTimeout))
+			return
+		}
+	}
+}
+
+// sendCurrentStock sends the stock of the given products, or of every product when none are given.
+// Unknown products are skipped, as they may still be added.
+func (c *InventoryFeedController) sendCurrentStock(conn *websocket.Conn, productIDs []string) error {
+	ctx := context.Background()
+	var products []inventory.Product
+	if len(productIDs) == 0 {
+		all, err := c.inventoryService.GetAllProducts(ctx, "")
+		if err != nil {
+			return err
+		}
+		products = all
+	}
+	for _, id := range productIDs {
+		product, err := c.inventoryService.GetProductStock(ctx, id)
+		if err != nil {
+			return err
+		}
+		if product != nil {
+			products = append(products, *product)
+		}
+	}
+
+	for _, product := range products {
+		if err := writeSnapshot(conn, inventory.CurrentSnapshot(product)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSnapshot sends one snapshot, giving up on clients that do not read it in time
+func writeSnapshot(conn *websocket.Conn, snapshot inventory.ProductSnapshot) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(inventoryFeedWriteTimeout)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(snapshot)
+}
+
+// splitProductIDs parses a comma-separated list of product IDs, ignoring empty entries
+func splitProductIDs(list string) []string {
+	var ids []string
+	for _, id := range strings.Split(list, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
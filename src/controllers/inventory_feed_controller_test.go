@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"context"
+	"go-order-eda/src/services/inventory"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+// startInventoryFeedServer serves the inventory API and its feed on a local port, as WebSocket needs a real connection
+func startInventoryFeedServer(t *testing.T, serverCtx context.Context, service *fakeInventoryService) string {
+	t.Helper()
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	NewInventoryController(service, false).Route(app)
+	NewInventoryFeedController(serverCtx, service, service.feed).Route(app)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go app.Listener(listener)
+	t.Cleanup(func() { app.ShutdownWithTimeout(time.Second) })
+	return listener.Addr().String()
+}
+
+// dialInventoryFeed connects to the feed with the given query
+func dialInventoryFeed(t *testing.T, addr, query string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/api/v1/inventory/ws"+query, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readSnapshot reads the next snapshot from the feed
+func readSnapshot(t *testing.T, conn *websocket.Conn) inventory.ProductSnapshot {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var snapshot inventory.ProductSnapshot
+	if err := conn.ReadJSON(&snapshot); err != nil {
+		t.Fatalf("Failed to read a snapshot: %v", err)
+	}
+	return snapshot
+}
+
+func TestInventoryFeedController_StreamInventory(t *testing.T) {
+	service := newFakeInventoryService(inventory.Product{ID: testProductID, Quantity: 10})
+	service.feed = inventory.NewProductFeed()
+	addr := startInventoryFeedServer(t, context.Background(), service)
+
+	conn := dialInventoryFeed(t, addr, "?products="+testProductID)
+
+	t.Run("current stock on connect", func(t *testing.T) {
+		snapshot := readSnapshot(t, conn)
+		if snapshot.ProductID != testProductID || snapshot.Quantity != 10 || snapshot.Reserved != 0 || snapshot.Kind != "" {
+			t.Errorf("Expected the current stock of the product, got %+v", snapshot)
+		}
+	})
+
+	t.Run("reservation is pushed", func(t *testing.T) {
+		resp, err := http.Post("http://"+addr+"/api/v1/inventory/products/"+testProductID+"/reserve/3", "", nil)
+		if err != nil {
+			t.Fatalf("Reserve request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		snapshot := readSnapshot(t, conn)
+		if snapshot.ProductID != testProductID || snapshot.Kind != inventory.ChangeReserve || snapshot.Quantity != 7 || snapshot.Reserved != 3 {
+			t.Errorf("Expected the reservation of the product, got %+v", snapshot)
+		}
+	})
+
+	t.Run("following every product", func(t *testing.T) {
+		if err := conn.WriteJSON(InventoryFeedSubscription{}); err != nil {
+			t.Fatalf("Failed to send the subscription: %v", err)
+		}
+		snapshot := readSnapshot(t, conn)
+		if snapshot.ProductID != testProductID || snapshot.Kind != "" || snapshot.Quantity != 7 {
+			t.Errorf("Expected the current stock of every product, got %+v", snapshot)
+		}
+	})
+
+	t.Log("✅ Connected client received the reservation")
+}
+
+func TestInventoryFeedController_ClientDisconnect(t *testing.T) {
+	service := newFakeInventoryService(inventory.Product{ID: testProductID, Quantity: 10})
+	service.feed = inventory.NewProductFeed()
+	addr := startInventoryFeedServer(t, context.Background(), service)
+
+	conn := dialInventoryFeed(t, addr, "")
+	readSnapshot(t, conn) // Current stock of every product
+	if service.feed.Subscribers() != 1 {
+		t.Fatalf("Expected 1 subscriber, got %d", service.feed.Subscribers())
+	}
+	conn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for service.feed.Subscribers() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the subscription to be closed after the client went away")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Log("✅ Subscription closed after the client went away")
+}
+
+func TestInventoryFeedController_ServerShutdown(t *testing.T) {
+	service := newFakeInventoryService(inventory.Product{ID: testProductID, Quantity: 10})
+	service.feed = inventory.NewProductFeed()
+	serverCtx, stopStreams := context.WithCancel(context.Background())
+	addr := startInventoryFeedServer(t, serverCtx, service)
+
+	conn := dialInventoryFeed(t, addr, "")
+	readSnapshot(t, conn) // Current stock of every product
+	stopStreams()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected the feed closed as going away, got %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for service.feed.Subscribers() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the subscription to be closed after shutdown")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Log("✅ Open feeds are closed when the server shuts down")
+}
+
+func TestInventoryFeedController_RequiresUpgrade(t *testing.T) {
+	app := fiber.New()
+	NewInventoryFeedController(context.Background(), newFakeInventoryService(inventory.Product{ID: testProductID}), inventory.NewProductFeed()).Route(app)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/api/v1/inventory/ws", nil))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusUpgradeRequired {
+		t.Errorf("Expected status 426, got %d", resp.StatusCode)
+	}
+}
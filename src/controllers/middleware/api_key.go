@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"go-order-eda/src/controllers/models"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHeader is the header clients send their API key in.
+// "Authorization: Bearer <key>" is accepted as well.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuth rejects mutating requests that do not carry one of the configured keys.
+// Several keys can be active at once so a key can be rotated without downtime.
+// Read-only requests (GET, HEAD, OPTIONS) such as the health check pass through.
+func APIKeyAuth(keys []string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+
+		key := requestAPIKey(c)
+		if key == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.NewErrorResponse(fiber.StatusUnauthorized, "missing API key"))
+		}
+		if !validKey(keys, key) {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.NewErrorResponse(fiber.StatusUnauthorized, "invalid API key"))
+		}
+		return c.Next()
+	}
+}
+
+// requestAPIKey reads the key from X-API-Key, falling back to the Authorization header
+func requestAPIKey(c *fiber.Ctx) string {
+	if key := strings.TrimSpace(c.Get(APIKeyHeader)); key != "" {
+		return key
+	}
+	auth := strings.TrimSpace(c.Get(fiber.HeaderAuthorization))
+	if len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(auth[len("Bearer "):])
+	}
+	return auth
+}
+
+// validKey compares in constant time so response timing does not reveal key prefixes
+func validKey(keys []string, key string) bool {
+	valid := false
+	for _, candidate := range keys {
+		if candidate != "" && subtle.ConstantTimeCompare([]byte(candidate), []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
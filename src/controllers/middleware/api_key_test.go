@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newTestApp(keys []string) *fiber.App {
+	app := fiber.New()
+	app.Use(APIKeyAuth(keys))
+	app.Get("/api/healthCheck", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Post("/api/v1/orders/create-order", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) })
+	return app
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	app := newTestApp([]string{"current-key", "previous-key"})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{name: "valid key", method: "POST", path: "/api/v1/orders/create-order", headers: map[string]string{APIKeyHeader: "current-key"}, wantStatus: fiber.StatusCreated},
+		{name: "rotated key still valid", method: "POST", path: "/api/v1/orders/create-order", headers: map[string]string{APIKeyHeader: "previous-key"}, wantStatus: fiber.StatusCreated},
+		{name: "bearer token", method: "POST", path: "/api/v1/orders/create-order", headers: map[string]string{"Authorization": "Bearer current-key"}, wantStatus: fiber.StatusCreated},
+		{name: "invalid key", method: "POST", path: "/api/v1/orders/create-order", headers: map[string]string{APIKeyHeader: "wrong-key"}, wantStatus: fiber.StatusUnauthorized},
+		{name: "missing key", method: "POST", path: "/api/v1/orders/create-order", wantStatus: fiber.StatusUnauthorized},
+		{name: "health check stays open", method: "GET", path: "/api/healthCheck", wantStatus: fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuth_NoKeysConfigured(t *testing.T) {
+	app := newTestApp(nil)
+
+	req := httptest.NewRequest("POST", "/api/v1/orders/create-order", nil)
+	req.Header.Set(APIKeyHeader, "")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", fiber.StatusUnauthorized, resp.StatusCode)
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"go-order-eda/src/controllers/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireJSON rejects mutating requests whose body is not declared as application/json with
+// 415 Unsupported Media Type, so a form-encoded or text body is not parsed into an empty request.
+// Requests without a body, such as a cancel or a restock taking its quantity from the path,
+// and read-only requests pass through.
+func RequireJSON() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+		if len(c.Body()) == 0 || c.Is("json") {
+			return c.Next()
+		}
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(models.NewErrorResponse(fiber.StatusUnsupportedMediaType, "content type must be application/json"))
+	}
+}
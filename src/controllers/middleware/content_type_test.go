@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequireJSON(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequireJSON())
+	app.Post("/api/v1/orders/create-order", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusAccepted) })
+	app.Post("/api/v1/orders/:id/cancel", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/api/healthCheck", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{name: "json body", method: "POST", path: "/api/v1/orders/create-order", contentType: "application/json", body: `{"amount":100}`, wantStatus: fiber.StatusAccepted},
+		{name: "json with charset", method: "POST", path: "/api/v1/orders/create-order", contentType: "application/json; charset=utf-8", body: `{"amount":100}`, wantStatus: fiber.StatusAccepted},
+		{name: "form body", method: "POST", path: "/api/v1/orders/create-order", contentType: "application/x-www-form-urlencoded", body: "amount=100", wantStatus: fiber.StatusUnsupportedMediaType},
+		{name: "text body", method: "POST", path: "/api/v1/orders/create-order", contentType: "text/plain", body: `{"amount":100}`, wantStatus: fiber.StatusUnsupportedMediaType},
+		{name: "body without content type", method: "POST", path: "/api/v1/orders/create-order", body: `{"amount":100}`, wantStatus: fiber.StatusUnsupportedMediaType},
+		{name: "no body", method: "POST", path: "/api/v1/orders/order-1/cancel", wantStatus: fiber.StatusOK},
+		{name: "read-only request", method: "GET", path: "/api/healthCheck", contentType: "text/plain", wantStatus: fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	t.Log("✅ Bodies other than JSON rejected with 415")
+}
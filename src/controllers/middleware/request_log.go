@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"go-order-eda/src/infrastructure/log"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestLogging logs every request through logger.Response with its status and duration.
+// With logBodies the request and response bodies are logged too, after redactor masked their
+// sensitive fields; streamed responses such as SSE are logged without a body. Requests to
+// skipPaths, such as the health check polled by the orchestrator, are not logged.
+func RequestLogging(logger log.Logger, redactor *log.Redactor, logBodies bool, skipPaths ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if slices.Contains(skipPaths, c.Path()) {
+			return c.Next()
+		}
+
+		start := time.Now()
+		if err := c.Next(); err != nil {
+			// Answer the error here, so the status and body logged are the ones the client receives
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		field := &log.Field{
+			URL:            c.OriginalURL(),
+			HostName:       c.Hostname(),
+			HTTPStatusCode: c.Response().StatusCode(),
+			Duration:       time.Since(start).Milliseconds(),
+			HTTPMethod:     c.Method(),
+			Message:        "HTTP request handled",
+		}
+		if logBodies {
+			field.RequestBody = redactor.Redact(string(c.Body()))
+			if !c.Response().IsBodyStream() {
+				field.ResponseBody = redactor.Redact(string(c.Response().Body()))
+			}
+		}
+		logger.Response(c.UserContext(), field)
+		return nil
+	}
+}
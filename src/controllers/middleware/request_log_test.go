@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"go-order-eda/src/infrastructure/log"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// recordingLogger records the fields passed to Response
+type recordingLogger struct {
+	log.Logger
+	mu     sync.Mutex
+	fields []log.Field
+}
+
+func (l *recordingLogger) Response(ctx context.Context, withFields *log.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fields = append(l.fields, *withFields)
+}
+
+func (l *recordingLogger) logged() []log.Field {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]log.Field(nil), l.fields...)
+}
+
+func newLoggingTestApp(logger log.Logger, logBodies bool) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		},
+	})
+	app.Use(RequestLogging(logger, log.NewRedactor([]string{"email", "phone"}), logBodies, "/api/healthCheck"))
+	app.Get("/api/healthCheck", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Post("/api/v1/orders/create-order", func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusCreated).SendString(`{"data":{"id":"order-1","customer":{"email":"jane@example.com"}}}`)
+	})
+	app.Get("/api/v1/orders/failing", func(c *fiber.Ctx) error { return errors.New("boom") })
+	app.Get("/api/v1/orders/stream", func(c *fiber.Ctx) error {
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) { _, _ = w.WriteString("data: {}\n\n") })
+		return nil
+	})
+	return app
+}
+
+func TestRequestLogging(t *testing.T) {
+	send := func(t *testing.T, app *fiber.App, method, path, body string) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	t.Run("configured fields masked in logged bodies", func(t *testing.T) {
+		logger := &recordingLogger{Logger: log.NewLogger()}
+		app := newLoggingTestApp(logger, true)
+
+		send(t, app, "POST", "/api/v1/orders/create-order?source=web",
+			`{"productId":"product-1","quantity":2,"customer":{"email":"jane@example.com","phone":"+31 6 1234"}}`)
+
+		logged := logger.logged()
+		if len(logged) != 1 {
+			t.Fatalf("Expected one request logged, got %d", len(logged))
+		}
+		field := logged[0]
+		if field.HTTPMethod != "POST" || field.URL != "/api/v1/orders/create-order?source=web" || field.HTTPStatusCode != fiber.StatusCreated {
+			t.Errorf("Unexpected request fields: %+v", field)
+		}
+		if want := `{"customer":{"email":"***","phone":"***"},"productId":"product-1","quantity":2}`; field.RequestBody != want {
+			t.Errorf("Expected request body %s, got %s", want, field.RequestBody)
+		}
+		if want := `{"data":{"customer":{"email":"***"},"id":"order-1"}}`; field.ResponseBody != want {
+			t.Errorf("Expected response body %s, got %s", want, field.ResponseBody)
+		}
+	})
+
+	t.Run("bodies left out when disabled", func(t *testing.T) {
+		logger := &recordingLogger{Logger: log.NewLogger()}
+		send(t, newLoggingTestApp(logger, false), "POST", "/api/v1/orders/create-order", `{"quantity":2}`)
+
+		if logged := logger.logged(); len(logged) != 1 || logged[0].RequestBody != "" || logged[0].ResponseBody != "" {
+			t.Errorf("Expected the request logged without bodies, got %+v", logged)
+		}
+	})
+
+	t.Run("error status logged as answered", func(t *testing.T) {
+		logger := &recordingLogger{Logger: log.NewLogger()}
+		send(t, newLoggingTestApp(logger, true), "GET", "/api/v1/orders/failing", "")
+
+		if logged := logger.logged(); len(logged) != 1 || logged[0].HTTPStatusCode != fiber.StatusInternalServerError || logged[0].ResponseBody != "boom" {
+			t.Errorf("Expected the error response logged, got %+v", logged)
+		}
+	})
+
+	t.Run("streamed response logged without body", func(t *testing.T) {
+		logger := &recordingLogger{Logger: log.NewLogger()}
+		send(t, newLoggingTestApp(logger, true), "GET", "/api/v1/orders/stream", "")
+
+		if logged := logger.logged(); len(logged) != 1 || logged[0].ResponseBody != "" {
+			t.Errorf("Expected the stream logged without body, got %+v", logged)
+		}
+	})
+
+	t.Run("skipped path not logged", func(t *testing.T) {
+		logger := &recordingLogger{Logger: log.NewLogger()}
+		send(t, newLoggingTestApp(logger, true), "GET", "/api/healthCheck", "")
+
+		if logged := logger.logged(); len(logged) != 0 {
+			t.Errorf("Expected the health check not logged, got %+v", logged)
+		}
+	})
+
+	t.Log("✅ Requests logged with sensitive fields masked")
+}
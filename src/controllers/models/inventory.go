@@ -0,0 +1,20 @@
+package models
+
+import "go-order-eda/src/services/events"
+
+// StockRequest is the body of the reserve and release endpoints.
+// OrderID is optional; when set the reservation is tracked in the reservations ledger.
+type StockRequest struct {
+	Quantity int    `json:"quantity"`
+	OrderID  string `json:"orderId,omitempty"`
+}
+
+// Validate checks the request before it reaches the inventory service.
+// It returns an *events.ValidationError listing every invalid field by its JSON path.
+func (r StockRequest) Validate() error {
+	v := events.NewValidationError("StockRequest")
+	if r.Quantity <= 0 {
+		v.Add("quantity", "must be greater than 0")
+	}
+	return v.Err()
+}
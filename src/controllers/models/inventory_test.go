@@ -0,0 +1,21 @@
+package models
+
+import (
+	"errors"
+	"go-order-eda/src/services/events"
+	"testing"
+)
+
+func TestStockRequest_Validate(t *testing.T) {
+	for _, quantity := range []int{0, -3} {
+		err := StockRequest{Quantity: quantity, OrderID: "order-1"}.Validate()
+		var validationErr *events.ValidationError
+		if !errors.As(err, &validationErr) || len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "quantity" {
+			t.Errorf("Expected a quantity error for %d, got %v", quantity, err)
+		}
+	}
+
+	if err := (StockRequest{Quantity: 2}).Validate(); err != nil {
+		t.Errorf("Expected orderId to be optional, got %v", err)
+	}
+}
@@ -1,10 +1,84 @@
 package models
 
+import (
+	"encoding/json"
+	"errors"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/money"
+	"strings"
+)
+
 type OrderRequest struct {
-	Amount  float64 `json:"amount"`
-	Product struct {
-		ID       string `json:"id"`
-		Name     string `json:"name"`
-		Quantity int    `json:"quantity"`
+	Amount   json.Number `json:"amount" swaggertype:"number" example:"19.99"` // Decimal amount in the currency, converted exactly to minor units
+	Currency string      `json:"currency" example:"USD"`                      // ISO 4217 code, USD when omitted
+	Product  struct {
+		ID       string   `json:"id"`
+		Name     string   `json:"name"`
+		Quantity Quantity `json:"quantity" swaggertype:"integer"` // Whole number; 2.0 is read as 2, 2.5 and "2" are rejected
 	} `json:"product"`
 }
+
+// CurrencyCode returns the upper-cased currency of the request, or money.DefaultCurrency when none was given
+func (r OrderRequest) CurrencyCode() string {
+	if currency := strings.ToUpper(strings.TrimSpace(r.Currency)); currency != "" {
+		return currency
+	}
+	return money.DefaultCurrency
+}
+
+// Money returns the amount in the minor unit of the request's currency, e.g. 19.99 USD as 1999
+func (r OrderRequest) Money() (money.Money, error) {
+	return money.Parse(r.Amount.String(), r.CurrencyCode())
+}
+
+// Validate checks the request before it reaches the order service.
+// It returns an *events.ValidationError listing every invalid field by its JSON path.
+func (r OrderRequest) Validate() error {
+	v := events.NewValidationError("OrderRequest")
+	amount, err := r.Money()
+	switch {
+	case r.Amount == "":
+		v.Add("amount", "is required")
+	case errors.Is(err, money.ErrUnknownCurrency):
+		// Reported on the currency below
+	case err != nil:
+		v.Add("amount", "must be a decimal number with no more fractional digits than the currency has")
+	case amount.Amount <= 0:
+		v.Add("amount", "must be greater than 0")
+	}
+	if !money.IsKnownCurrency(r.CurrencyCode()) {
+		v.Add("currency", "must be a known ISO 4217 code")
+	}
+	if strings.TrimSpace(r.Product.ID) == "" {
+		v.Add("product.id", "is required")
+	}
+	switch quantity, problem := r.Product.Quantity.Parse(); {
+	case problem != "":
+		v.Add("product.quantity", problem)
+	case quantity <= 0:
+		v.Add("product.quantity", "must be greater than 0")
+	}
+	return v.Err()
+}
+
+// OrderStats counts the orders that are not archived, in total and per status
+type OrderStats struct {
+	Total    int64            `json:"total"`
+	ByStatus map[string]int64 `json:"byStatus"`
+}
+
+// BatchOrderResponse answers a batch of orders. CorrelationID is shared by the events of every
+// order of the batch, so they can be traced together.
+type BatchOrderResponse struct {
+	CorrelationID string             `json:"correlationId"`
+	Results       []BatchOrderResult `json:"results"`
+}
+
+// BatchOrderResult is the outcome of the order at Index of a batch: the ID of the requested
+// order and the URL to poll for its status, or why it was rejected
+type BatchOrderResult struct {
+	Index     int       `json:"index"`
+	OrderID   string    `json:"orderId,omitempty"`
+	StatusURL string    `json:"statusUrl,omitempty"`
+	Error     *APIError `json:"error,omitempty"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// StoredEvent is an event stored for replay, as served by the API
+type StoredEvent struct {
+	ID         string          `json:"id"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	Status     string          `json:"status" example:"failed"` // pending, replaying, completed or failed
+	Attempts   int             `json:"attempts"`                // How often the event failed and was stored
+	Replayed   bool            `json:"replayed"`
+	ReplayedAt *time.Time      `json:"replayedAt,omitempty"`
+	Event      json.RawMessage `json:"event" swaggertype:"object"` // The stored message
+}
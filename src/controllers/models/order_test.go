@@ -0,0 +1,92 @@
+package models
+
+import (
+	"errors"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/money"
+	"testing"
+)
+
+func validOrderRequest() OrderRequest {
+	var r OrderRequest
+	r.Amount = "100"
+	r.Product.ID = "product-1"
+	r.Product.Name = "Sample Product"
+	r.Product.Quantity = "1"
+	return r
+}
+
+func TestOrderRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(r *OrderRequest)
+		wantFields []string
+	}{
+		{name: "valid request", modify: func(r *OrderRequest) {}},
+		{name: "zero amount", modify: func(r *OrderRequest) { r.Amount = "0" }, wantFields: []string{"amount"}},
+		{name: "negative amount", modify: func(r *OrderRequest) { r.Amount = "-5" }, wantFields: []string{"amount"}},
+		{name: "missing amount", modify: func(r *OrderRequest) { r.Amount = "" }, wantFields: []string{"amount"}},
+		{name: "more decimals than the currency has", modify: func(r *OrderRequest) { r.Amount = "19.999" }, wantFields: []string{"amount"}},
+		{name: "fractional yen", modify: func(r *OrderRequest) { r.Amount, r.Currency = "100.5", "JPY" }, wantFields: []string{"amount"}},
+		{name: "unknown currency", modify: func(r *OrderRequest) { r.Currency = "XYZ" }, wantFields: []string{"currency"}},
+		{name: "lower-case currency", modify: func(r *OrderRequest) { r.Currency = "eur" }},
+		{name: "missing product ID", modify: func(r *OrderRequest) { r.Product.ID = " " }, wantFields: []string{"product.id"}},
+		{name: "zero quantity", modify: func(r *OrderRequest) { r.Product.Quantity = "0" }, wantFields: []string{"product.quantity"}},
+		{name: "negative quantity", modify: func(r *OrderRequest) { r.Product.Quantity = "-1" }, wantFields: []string{"product.quantity"}},
+		{name: "fractional quantity", modify: func(r *OrderRequest) { r.Product.Quantity = "2.5" }, wantFields: []string{"product.quantity"}},
+		{name: "quoted quantity", modify: func(r *OrderRequest) { r.Product.Quantity = `"2"` }, wantFields: []string{"product.quantity"}},
+		{
+			name:       "every field invalid",
+			modify:     func(r *OrderRequest) { *r = OrderRequest{} },
+			wantFields: []string{"amount", "product.id", "product.quantity"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := validOrderRequest()
+			tt.modify(&r)
+
+			err := r.Validate()
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			var validationErr *events.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected a ValidationError, got %v", err)
+			}
+			errs := validationErr.Fields
+			if len(errs) != len(tt.wantFields) {
+				t.Fatalf("Expected %d field errors, got %v", len(tt.wantFields), errs)
+			}
+			for i, field := range tt.wantFields {
+				if errs[i].Field != field {
+					t.Errorf("Expected error %d on %s, got %s", i, field, errs[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderRequest_Money(t *testing.T) {
+	r := validOrderRequest()
+	r.Amount = "19.99"
+
+	amount, err := r.Money()
+	if err != nil || amount != money.New(1999, money.DefaultCurrency) {
+		t.Fatalf("Expected 1999 %s, got %v, %v", money.DefaultCurrency, amount, err)
+	}
+	if currency := r.CurrencyCode(); currency != money.DefaultCurrency {
+		t.Errorf("Expected the default currency %s, got %s", money.DefaultCurrency, currency)
+	}
+
+	r.Currency = " gbp "
+	if currency := r.CurrencyCode(); currency != "GBP" {
+		t.Errorf("Expected GBP, got %s", currency)
+	}
+
+	t.Log("✅ Request amount converted exactly to minor units")
+}
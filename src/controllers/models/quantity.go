@@ -0,0 +1,50 @@
+package models
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+)
+
+// Quantity is a count of items, kept as the JSON text it was sent as so Validate can report a
+// malformed one on its field. Besides integers it accepts numbers with an integral value such as
+// 2.0 or 2e1, which JSON encoders in other languages produce for whole floats; fractional numbers
+// and numbers in strings such as "2" are invalid.
+type Quantity string
+
+// maxExactFloat is the largest magnitude up to which a float64 holds every integer exactly
+const maxExactFloat = 1 << 53
+
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*q = ""
+		return nil
+	}
+	*q = Quantity(data)
+	return nil
+}
+
+// Parse returns the quantity as an int, or the problem with it in the register of a field error.
+// A missing quantity is 0.
+func (q Quantity) Parse() (int, string) {
+	if q == "" {
+		return 0, ""
+	}
+	if q[0] == '"' {
+		return 0, "must be a whole number, got a string"
+	}
+	if n, err := strconv.Atoi(string(q)); err == nil {
+		return n, ""
+	}
+	f, err := strconv.ParseFloat(string(q), 64)
+	if err != nil || f != math.Trunc(f) || math.Abs(f) > maxExactFloat {
+		return 0, "must be a whole number, got " + string(q)
+	}
+	return int(f), ""
+}
+
+// Int returns the quantity as an int, 0 when it is missing or invalid
+func (q Quantity) Int() int {
+	n, _ := q.Parse()
+	return n
+}
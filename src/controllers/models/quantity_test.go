@@ -0,0 +1,45 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestQuantity_Parse(t *testing.T) {
+	tests := []struct {
+		body        string
+		want        int
+		wantProblem string
+	}{
+		{body: `2`, want: 2},
+		{body: `2.0`, want: 2},
+		{body: `2e1`, want: 20},
+		{body: `-1`, want: -1},
+		{body: `null`, want: 0},
+		{body: `2.5`, wantProblem: "got 2.5"},
+		{body: `"2"`, wantProblem: "got a string"},
+		{body: `1e300`, wantProblem: "got 1e300"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			var q Quantity
+			if err := json.Unmarshal([]byte(tt.body), &q); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			got, problem := q.Parse()
+			if tt.wantProblem != "" {
+				if !strings.Contains(problem, tt.wantProblem) {
+					t.Errorf("Expected a problem mentioning %q, got %d, %q", tt.wantProblem, got, problem)
+				}
+				return
+			}
+			if problem != "" || got != tt.want {
+				t.Errorf("Expected %d, got %d, %q", tt.want, got, problem)
+			}
+		})
+	}
+
+	t.Log("✅ Whole-number quantities accepted, fractions and strings rejected")
+}
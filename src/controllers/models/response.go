@@ -0,0 +1,37 @@
+package models
+
+import (
+	"go-order-eda/src/services/events"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Response is the envelope of every JSON response: data holds the result, and error is set
+// when the request failed
+type Response struct {
+	Data  any       `json:"data"`
+	Error *APIError `json:"error"`
+}
+
+// APIError describes why a request failed. Fields lists the invalid fields of a rejected request.
+type APIError struct {
+	Code    string              `json:"code" example:"not_found"` // Derived from the HTTP status, e.g. bad_request for 400
+	Message string              `json:"message"`
+	Fields  []events.FieldError `json:"fields,omitempty"`
+}
+
+// NewErrorResponse returns the envelope of a failed request answered with the HTTP status
+func NewErrorResponse(status int, message string) Response {
+	return Response{Error: &APIError{Code: ErrorCode(status), Message: message}}
+}
+
+// ErrorCode returns the error code of an HTTP status, its status text in snake case,
+// e.g. not_found for 404 and internal_server_error for 500
+func ErrorCode(status int) string {
+	text := utils.StatusMessage(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"go-order-eda/src/services/notification"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationController struct {
+	notificationService notification.NotificationService
+}
+
+func NewNotificationController(notificationService notification.NotificationService) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+	}
+}
+
+func (c *NotificationController) Route(app *fiber.App) {
+	api := app.Group("/api/v1/orders")
+	api.Get("/:id/notifications", uuidParams("id"), c.GetOrderNotifications)
+}
+
+// GetOrderNotifications godoc
+// @Summary      Get order notifications
+// @Description  Lists every notification attempt recorded for an order
+// @Tags         notifications
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  models.Response{data=[]notification.NotificationRecord}
+// @Failure      400  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/orders/{id}/notifications [get]
+func (c *NotificationController) GetOrderNotifications(ctx *fiber.Ctx) error {
+	orderID := ctx.Params("id")
+	records, err := c.notificationService.GetNotificationsByOrderID(ctx.Context(), orderID)
+	if err != nil {
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+	return respond(ctx, fiber.StatusOK, records)
+}
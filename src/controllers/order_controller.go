@@ -1,74 +1,375 @@
 package controllers
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"go-order-eda/src/controllers/models"
+	"go-order-eda/src/infrastructure/tracing"
+	"go-order-eda/src/services/events"
 	"go-order-eda/src/services/order/domain"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
+const (
+	// How long create-order?wait=true waits for the order to settle, by default and at most
+	defaultCreateOrderWait = 10 * time.Second
+	maxCreateOrderWait     = 30 * time.Second
+	// Largest create-order body accepted; an order request is a few hundred bytes
+	maxCreateOrderBody = 4 << 10
+	// Most orders accepted in one batch, and the largest batch body accepted
+	maxBatchOrders    = 100
+	maxBatchOrderBody = maxBatchOrders * maxCreateOrderBody
+)
+
 type OrderController struct {
 	domain.OrderService
+	syncCreate bool // Whether create-order?wait=true waits; otherwise the order is created asynchronously
 }
 
-func NewOrderController(orderService domain.OrderService) *OrderController {
+func NewOrderController(orderService domain.OrderService, syncCreate bool) *OrderController {
 	return &OrderController{
 		OrderService: orderService,
+		syncCreate:   syncCreate,
 	}
 }
 func (c *OrderController) Route(app *fiber.App) {
 	api := app.Group("/api/v1/orders")
 	api.Post("/create-order", c.CreateOrder)
+	api.Post("/batch", c.CreateOrderBatch)
 	api.Post("/replay-failed-events", c.ReplayFailedEvents)
+	api.Get("/:id/status", uuidParams("id"), c.GetOrderStatus)
+	api.Post("/:id/cancel", uuidParams("id"), c.CancelOrder)
+	api.Delete("/:id", uuidParams("id"), c.ArchiveOrder)
+}
+
+// ArchiveOrder godoc
+// @Summary      Archive an order
+// @Description  Archives an order that is completed, cancelled or failed. Archived orders are no longer returned by the order endpoints.
+// @Tags         orders
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  models.Response
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      409  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/orders/{id} [delete]
+func (c *OrderController) ArchiveOrder(ctx *fiber.Ctx) error {
+	orderID := ctx.Params("id")
+	err := c.OrderService.ArchiveOrder(ctx.Context(), orderID)
+	if err != nil {
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			return respondError(ctx, fiber.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, domain.ErrOrderNotTerminal) {
+			return respondError(ctx, fiber.StatusConflict, err.Error())
+		}
+		return errorResponse(ctx, err)
+	}
+	return respond(ctx, fiber.StatusOK, fiber.Map{"status": "Order archived", "order_id": orderID})
+}
+
+// CancelOrder godoc
+// @Summary      Cancel an order
+// @Description  Requests cancellation of an order. Cancellation is processed asynchronously.
+// @Tags         orders
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      202  {object}  models.Response
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      409  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/orders/{id}/cancel [post]
+func (c *OrderController) CancelOrder(ctx *fiber.Ctx) error {
+	orderID := ctx.Params("id")
+	err := c.OrderService.CancelOrder(ctx.Context(), orderID)
+	if err != nil {
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			return respondError(ctx, fiber.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, domain.ErrOrderTerminal) {
+			return respondError(ctx, fiber.StatusConflict, err.Error())
+		}
+		return errorResponse(ctx, err)
+	}
+	return respond(ctx, fiber.StatusAccepted, fiber.Map{"status": "Cancellation requested", "order_id": orderID})
+}
+
+// GetOrderStatus godoc
+// @Summary      Get order status
+// @Description  Returns only the current status of an order
+// @Tags         orders
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  models.Response
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/orders/{id}/status [get]
+func (c *OrderController) GetOrderStatus(ctx *fiber.Ctx) error {
+	orderID := ctx.Params("id")
+	status, err := c.OrderService.GetOrderStatus(ctx.Context(), orderID)
+	if err != nil {
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			return respondError(ctx, fiber.StatusNotFound, err.Error())
+		}
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+	return respond(ctx, fiber.StatusOK, fiber.Map{"orderId": orderID, "status": status})
 }
 
 // ReplayFailedEvents godoc
 // @Summary      Replay failed order events
-// @Description  Replays failed order events that have not been successfully published
+// @Description  Replays failed order events that have not been successfully published and reports how many were
+// @Description  replayed, by event type. With dryRun=true it only reports the events that would be replayed,
+// @Description  without publishing them or changing their status. A replay with failures answers 500 with the summary.
+// @Description  from, to, status and eventType narrow the replay, e.g. to the events of one incident window.
 // @Tags         orders
 // @Produce      json
-// @Success      200  {object}  map[string]interface{}
-// @Failure      500  {object}  map[string]interface{}
+// @Param        dryRun     query  bool    false  "Report the events without replaying them"
+// @Param        from       query  string  false  "Replay events stored at or after this RFC 3339 time"
+// @Param        to         query  string  false  "Replay events stored before this RFC 3339 time"
+// @Param        status     query  string  false  "Comma-separated statuses to replay: pending, failed (default both)"
+// @Param        eventType  query  string  false  "Replay only events of this type, e.g. order.created"
+// @Success      200  {object}  models.Response{data=domain.ReplaySummary}
+// @Failure      400  {object}  models.Response
+// @Failure      500  {object}  models.Response{data=domain.ReplaySummary}
 // @Router       /api/v1/orders/replay-failed-events [post]
 func (c *OrderController) ReplayFailedEvents(ctx *fiber.Ctx) error {
-	err := c.OrderService.ReplayFailedEvents(ctx.Context())
+	filter := domain.ReplayFilter{EventType: ctx.Query("eventType")}
+	for _, bound := range []struct {
+		name string
+		time *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		raw := ctx.Query(bound.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return respondError(ctx, fiber.StatusBadRequest, bound.name+" must be an RFC 3339 time")
+		}
+		*bound.time = parsed
+	}
+	for _, status := range strings.Split(ctx.Query("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	summary, err := c.OrderService.ReplayFailedEvents(ctx.Context(), filter, ctx.QueryBool("dryRun"))
+	if errors.Is(err, domain.ErrInvalidReplayFilter) {
+		return respondError(ctx, fiber.StatusBadRequest, err.Error())
+	}
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		response := models.NewErrorResponse(fiber.StatusInternalServerError, err.Error())
+		response.Data = summary
+		return ctx.Status(fiber.StatusInternalServerError).JSON(response)
 	}
-	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"status": "Replay complete"})
+	return respond(ctx, fiber.StatusOK, summary)
 }
 
 // CreateOrder godoc
 // @Summary      Create a new order
-// @Description  Creates a new order and returns the status
+// @Description  Requests a new order. The order is created asynchronously, so the response carries the
+// @Description  order ID and, in statusUrl and the Location header, the URL to poll for its status.
+// @Description  With wait=true the request blocks until the order is confirmed (201) or cancelled or failed (200),
+// @Description  and falls back to 202 when the timeout elapses first. wait is ignored unless the sync_create feature is enabled.
 // @Tags         orders
 // @Accept       json
 // @Produce      json
-// @Param        order  body  models.OrderRequest  true  "Order payload"
-// @Success      201  {object}  map[string]interface{}
-// @Failure      400  {object}  map[string]interface{}
-// @Failure      500  {object}  map[string]interface{}
+// @Param        order    body   models.OrderRequest  true   "Order payload"
+// @Param        wait     query  bool                 false  "Wait for the order to settle"
+// @Param        timeout  query  string               false  "How long to wait, e.g. 5s (default 10s, at most 30s)"
+// @Success      200  {object}  models.Response
+// @Success      201  {object}  models.Response
+// @Success      202  {object}  models.Response
+// @Failure      409  {object}  models.Response
+// @Header       201,202  {string}  Location  "URL of the order status"
+// @Failure      400  {object}  models.Response
+// @Failure      415  {object}  models.Response
+// @Failure      500  {object}  models.Response
 // @Router       /api/v1/orders/create-order [post]
 func (c *OrderController) CreateOrder(ctx *fiber.Ctx) error {
 	var order domain.Order
 	var OrderRequest models.OrderRequest
-	if err := ctx.BodyParser(&OrderRequest); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	if err := decodeStrictJSON(ctx, &OrderRequest, maxCreateOrderBody); err != nil {
+		if errors.Is(err, errNotJSON) {
+			return respondError(ctx, fiber.StatusUnsupportedMediaType, err.Error())
+		}
+		return respondError(ctx, fiber.StatusBadRequest, "Invalid request: "+err.Error())
 	}
-	order = domain.Order{
-		ID:     uuid.New().String(),
-		Amount: OrderRequest.Amount,
+	if err := OrderRequest.Validate(); err != nil {
+		return errorResponse(ctx, err)
+	}
+	order = newOrder(OrderRequest)
+	wait := c.syncCreate && ctx.QueryBool("wait")
+	timeout := defaultCreateOrderWait
+	if raw := ctx.Query("timeout"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 || parsed > maxCreateOrderWait {
+			return respondError(ctx, fiber.StatusBadRequest, "timeout must be a positive duration of at most "+maxCreateOrderWait.String())
+		}
+		timeout = parsed
+	}
+
+	spanCtx, span := tracing.Tracer().Start(ctx.Context(), "OrderController.CreateOrder")
+	defer span.End()
+	var (
+		orderID, status string
+		err             error
+	)
+	if wait {
+		orderID, status, err = c.OrderService.CreateOrderAndWait(spanCtx, order, timeout)
+	} else {
+		orderID, err = c.OrderService.CreateOrder(spanCtx, order)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			return respondError(ctx, fiber.StatusConflict, err.Error())
+		}
+		return errorResponse(ctx, err)
+	}
+
+	statusURL := orderStatusURL(orderID)
+	ctx.Location(statusURL)
+	switch {
+	case status == "":
+		return respond(ctx, fiber.StatusAccepted, fiber.Map{"status": "Order requested", "order_id": orderID, "statusUrl": statusURL})
+	case status == events.OrderStatusConfirmed || status == events.OrderStatusCompleted:
+		return respond(ctx, fiber.StatusCreated, fiber.Map{"status": status, "order_id": orderID, "statusUrl": statusURL})
+	default:
+		return respond(ctx, fiber.StatusOK, fiber.Map{"status": status, "order_id": orderID, "statusUrl": statusURL})
+	}
+}
+
+// CreateOrderBatch godoc
+// @Summary      Create orders in a batch
+// @Description  Requests up to 100 orders in one call. Each order is validated and requested on its own, with
+// @Description  a correlation ID shared by the whole batch. The results list, in request order, the ID of each
+// @Description  requested order or why it was rejected. The response is 202 when every order was requested
+// @Description  and 207 when some were rejected.
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param        orders  body  []models.OrderRequest  true  "Order payloads"
+// @Success      202  {object}  models.Response{data=models.BatchOrderResponse}
+// @Success      207  {object}  models.Response{data=models.BatchOrderResponse}
+// @Failure      400  {object}  models.Response
+// @Failure      415  {object}  models.Response
+// @Router       /api/v1/orders/batch [post]
+func (c *OrderController) CreateOrderBatch(ctx *fiber.Ctx) error {
+	var requests []models.OrderRequest
+	if err := decodeStrictJSON(ctx, &requests, maxBatchOrderBody); err != nil {
+		if errors.Is(err, errNotJSON) {
+			return respondError(ctx, fiber.StatusUnsupportedMediaType, err.Error())
+		}
+		return respondError(ctx, fiber.StatusBadRequest, "Invalid request: "+err.Error())
+	}
+	if len(requests) == 0 || len(requests) > maxBatchOrders {
+		return respondError(ctx, fiber.StatusBadRequest, fmt.Sprintf("A batch must hold between 1 and %d orders, got %d", maxBatchOrders, len(requests)))
+	}
+
+	spanCtx, span := tracing.Tracer().Start(ctx.Context(), "OrderController.CreateOrderBatch")
+	defer span.End()
+	batch := models.BatchOrderResponse{CorrelationID: uuid.NewString(), Results: make([]models.BatchOrderResult, len(requests))}
+	spanCtx = events.ContextWithCorrelationID(spanCtx, batch.CorrelationID)
+	status := fiber.StatusAccepted
+	for i, request := range requests {
+		batch.Results[i] = c.createBatchOrder(spanCtx, i, request)
+		if batch.Results[i].Error != nil {
+			status = fiber.StatusMultiStatus
+		}
+	}
+	return respond(ctx, status, batch)
+}
+
+// createBatchOrder requests the order of a batch at index, returning its ID or why it was rejected
+func (c *OrderController) createBatchOrder(ctx context.Context, index int, request models.OrderRequest) models.BatchOrderResult {
+	result := models.BatchOrderResult{Index: index}
+	if err := request.Validate(); err != nil {
+		_, result.Error = apiError(err)
+		return result
+	}
+	orderID, err := c.OrderService.CreateOrder(ctx, newOrder(request))
+	switch {
+	case errors.Is(err, domain.ErrInsufficientStock):
+		result.Error = &models.APIError{Code: models.ErrorCode(fiber.StatusConflict), Message: err.Error()}
+	case err != nil:
+		_, result.Error = apiError(err)
+	default:
+		result.OrderID = orderID
+		result.StatusURL = orderStatusURL(orderID)
+	}
+	return result
+}
+
+// newOrder returns the pending order a validated request asks for, under a new ID
+func newOrder(request models.OrderRequest) domain.Order {
+	amount, _ := request.Money() // Validate has already rejected amounts that do not convert
+	return domain.Order{
+		ID:    uuid.New().String(),
+		Money: amount,
 		Product: domain.Product{
-			ID:       OrderRequest.Product.ID,
-			Name:     OrderRequest.Product.Name,
-			Quantity: OrderRequest.Product.Quantity,
+			ID:       request.Product.ID,
+			Name:     request.Product.Name,
+			Quantity: request.Product.Quantity.Int(),
 		},
 		Status: "Pending",
 	}
-	orderID, err := c.OrderService.CreateOrder(ctx.Context(), order)
-	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// errNotJSON is returned by decodeStrictJSON for a body not declared as application/json
+var errNotJSON = errors.New("content type must be application/json")
+
+// decodeStrictJSON decodes a JSON request body of at most maxBytes into v, rejecting fields v does
+// not declare, so a misspelled field such as "quanity" is reported instead of silently dropped
+func decodeStrictJSON(ctx *fiber.Ctx, v any, maxBytes int) error {
+	if !ctx.Is("json") {
+		return errNotJSON
+	}
+	body := ctx.Body()
+	if len(body) > maxBytes {
+		return fmt.Errorf("body exceeds %d bytes", maxBytes)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return errors.New(strings.TrimPrefix(err.Error(), "json: "))
+	}
+	if decoder.More() {
+		return errors.New("body must hold a single JSON object")
+	}
+	return nil
+}
+
+// orderStatusURL is where clients poll the status of an order accepted for asynchronous processing
+func orderStatusURL(orderID string) string {
+	return "/api/v1/orders/" + orderID + "/status"
+}
+
+// errorResponse reports validation failures as 400 with one entry per invalid field,
+// and any other error as 500
+func errorResponse(ctx *fiber.Ctx, err error) error {
+	status, apiErr := apiError(err)
+	return ctx.Status(status).JSON(models.Response{Error: apiErr})
+}
+
+// apiError returns the HTTP status and error errorResponse answers err with
+func apiError(err error) (int, *models.APIError) {
+	var validationErr *events.ValidationError
+	if errors.As(err, &validationErr) {
+		response := models.NewErrorResponse(fiber.StatusBadRequest, "Invalid request")
+		response.Error.Fields = validationErr.Fields
+		return fiber.StatusBadRequest, response.Error
 	}
-	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "Order created successfully", "order_id": orderID})
+	return fiber.StatusInternalServerError, models.NewErrorResponse(fiber.StatusInternalServerError, err.Error()).Error
 }
@@ -0,0 +1,627 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-order-eda/src/controllers/models"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/order/domain"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeOrderService returns fixed errors from CreateOrder and CancelOrder, and a fixed settled status from CreateOrderAndWait
+type fakeOrderService struct {
+	createErr     error
+	cancelErr     error
+	cancelled     []string
+	settledStatus string
+	waitedFor     []time.Duration
+	status        string // Returned by GetOrderStatus, Confirmed when empty
+	statusErr     error
+	replayDryRuns []bool                // dryRun of each ReplayFailedEvents call
+	replayFilters []domain.ReplayFilter // filter of each ReplayFailedEvents call
+	archiveErr    error
+	archived      []string
+	created       []string         // ID of each order passed to CreateOrder
+	productErrs   map[string]error // Returned by CreateOrder for orders of the product
+	correlations  []string         // Correlation ID each CreateOrder call publishes under
+}
+
+func (f *fakeOrderService) CreateOrder(ctx context.Context, order domain.Order) (string, error) {
+	if f.createErr != nil {
+		return "", f.createErr
+	}
+	if err := f.productErrs[order.Product.ID]; err != nil {
+		return "", err
+	}
+	f.correlations = append(f.correlations, events.CorrelationIDFromContext(ctx))
+	f.created = append(f.created, order.ID)
+	return order.ID, nil
+}
+
+func (f *fakeOrderService) CreateOrderAndWait(ctx context.Context, order domain.Order, timeout time.Duration) (string, string, error) {
+	f.waitedFor = append(f.waitedFor, timeout)
+	orderID, err := f.CreateOrder(ctx, order)
+	if err != nil {
+		return "", "", err
+	}
+	return orderID, f.settledStatus, nil
+}
+
+func (f *fakeOrderService) CancelOrder(ctx context.Context, orderID string) error {
+	if f.cancelErr != nil {
+		return f.cancelErr
+	}
+	f.cancelled = append(f.cancelled, orderID)
+	return nil
+}
+
+func (f *fakeOrderService) GetOrderStatus(ctx context.Context, orderID string) (string, error) {
+	if f.statusErr != nil {
+		return "", f.statusErr
+	}
+	if f.status != "" {
+		return f.status, nil
+	}
+	return "Confirmed", nil
+}
+
+func (f *fakeOrderService) ReplayFailedEvents(ctx context.Context, filter domain.ReplayFilter, dryRun bool) (domain.ReplaySummary, error) {
+	if err := filter.Validate(); err != nil {
+		return domain.ReplaySummary{}, err
+	}
+	f.replayDryRuns = append(f.replayDryRuns, dryRun)
+	f.replayFilters = append(f.replayFilters, filter)
+	return domain.ReplaySummary{DryRun: dryRun, Events: 3, ByEventType: map[string]int64{"order.created": 3}}, nil
+}
+
+func (f *fakeOrderService) ArchiveOrder(ctx context.Context, orderID string) error {
+	if f.archiveErr != nil {
+		return f.archiveErr
+	}
+	f.archived = append(f.archived, orderID)
+	return nil
+}
+
+func TestOrderController_CancelOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		cancelErr  error
+		wantStatus int
+	}{
+		{name: "accepted", wantStatus: fiber.StatusAccepted},
+		{name: "unknown order", cancelErr: domain.ErrOrderNotFound, wantStatus: fiber.StatusNotFound},
+		{name: "terminal order", cancelErr: fmt.Errorf("%w: %s is Cancelled", domain.ErrOrderTerminal, testOrderID), wantStatus: fiber.StatusConflict},
+		{name: "publish failure", cancelErr: fmt.Errorf("failed to publish cancellation event"), wantStatus: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOrderService{cancelErr: tt.cancelErr}
+			app := fiber.New()
+			NewOrderController(service, true).Route(app)
+
+			resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/orders/"+testOrderID+"/cancel", nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.cancelErr == nil && (len(service.cancelled) != 1 || service.cancelled[0] != testOrderID) {
+				t.Errorf("Expected CancelOrder to be called with %s, got %v", testOrderID, service.cancelled)
+			}
+		})
+	}
+}
+
+func TestOrderController_ArchiveOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		archiveErr error
+		wantStatus int
+	}{
+		{name: "archived", wantStatus: fiber.StatusOK},
+		{name: "unknown order", archiveErr: domain.ErrOrderNotFound, wantStatus: fiber.StatusNotFound},
+		{name: "order in progress", archiveErr: fmt.Errorf("%w: %s is Processing", domain.ErrOrderNotTerminal, testOrderID), wantStatus: fiber.StatusConflict},
+		{name: "database failure", archiveErr: fmt.Errorf("connection refused"), wantStatus: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOrderService{archiveErr: tt.archiveErr}
+			app := fiber.New()
+			NewOrderController(service, true).Route(app)
+
+			resp, err := app.Test(httptest.NewRequest("DELETE", "/api/v1/orders/"+testOrderID, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.archiveErr == nil && (len(service.archived) != 1 || service.archived[0] != testOrderID) {
+				t.Errorf("Expected ArchiveOrder to be called with %s, got %v", testOrderID, service.archived)
+			}
+		})
+	}
+}
+
+func TestOrderController_CreateOrderValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantFields []string
+	}{
+		{
+			name:       "valid request",
+			body:       `{"amount":100,"product":{"id":"product-1","name":"Sample","quantity":1}}`,
+			wantStatus: fiber.StatusAccepted,
+		},
+		{
+			name:       "negative amount",
+			body:       `{"amount":-1,"product":{"id":"product-1","quantity":1}}`,
+			wantStatus: fiber.StatusBadRequest,
+			wantFields: []string{"amount"},
+		},
+		{
+			name:       "decimal amount with currency",
+			body:       `{"amount":19.99,"currency":"EUR","product":{"id":"product-1","quantity":1}}`,
+			wantStatus: fiber.StatusAccepted,
+		},
+		{
+			name:       "unknown currency",
+			body:       `{"amount":19.99,"currency":"XYZ","product":{"id":"product-1","quantity":1}}`,
+			wantStatus: fiber.StatusBadRequest,
+			wantFields: []string{"currency"},
+		},
+		{
+			name:       "missing product ID and quantity",
+			body:       `{"amount":100,"product":{"name":"Sample"}}`,
+			wantStatus: fiber.StatusBadRequest,
+			wantFields: []string{"product.id", "product.quantity"},
+		},
+		{
+			name:       "integral float quantity",
+			body:       `{"amount":100,"product":{"id":"product-1","quantity":2.0}}`,
+			wantStatus: fiber.StatusAccepted,
+		},
+		{
+			name:       "fractional quantity",
+			body:       `{"amount":100,"product":{"id":"product-1","quantity":2.5}}`,
+			wantStatus: fiber.StatusBadRequest,
+			wantFields: []string{"product.quantity"},
+		},
+		{
+			name:       "quoted quantity",
+			body:       `{"amount":100,"product":{"id":"product-1","quantity":"2"}}`,
+			wantStatus: fiber.StatusBadRequest,
+			wantFields: []string{"product.quantity"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			NewOrderController(&fakeOrderService{}, true).Route(app)
+
+			req := httptest.NewRequest("POST", "/api/v1/orders/create-order", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if len(tt.wantFields) == 0 {
+				return
+			}
+
+			apiErr := decodeResponse(t, resp, nil)
+			if apiErr == nil || len(apiErr.Fields) != len(tt.wantFields) {
+				t.Fatalf("Expected %d field errors, got %+v", len(tt.wantFields), apiErr)
+			}
+			for i, field := range tt.wantFields {
+				if apiErr.Fields[i].Field != field {
+					t.Errorf("Expected error %d on %s, got %s", i, field, apiErr.Fields[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderController_CreateOrderStrictBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantError string
+	}{
+		{
+			name:      "unknown field",
+			body:      `{"amount":100,"product":{"id":"product-1","quanity":1}}`,
+			wantError: `unknown field "quanity"`,
+		},
+		{
+			name:      "oversized body",
+			body:      `{"amount":100,"product":{"id":"product-1","quantity":1,"name":"` + strings.Repeat("x", maxCreateOrderBody) + `"}}`,
+			wantError: "body exceeds",
+		},
+		{
+			name:      "trailing data",
+			body:      `{"amount":100,"product":{"id":"product-1","quantity":1}} {"amount":1}`,
+			wantError: "single JSON object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOrderService{}
+			app := fiber.New()
+			NewOrderController(service, true).Route(app)
+
+			req := httptest.NewRequest("POST", "/api/v1/orders/create-order", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusBadRequest {
+				t.Fatalf("Expected status %d, got %d", fiber.StatusBadRequest, resp.StatusCode)
+			}
+			apiErr := decodeResponse(t, resp, nil)
+			if apiErr == nil || !strings.Contains(apiErr.Message, tt.wantError) {
+				t.Errorf("Expected an error mentioning %q, got %+v", tt.wantError, apiErr)
+			}
+		})
+	}
+
+	t.Log("✅ Unknown fields and oversized bodies rejected")
+}
+
+func TestOrderController_CreateOrderContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{name: "json", contentType: "application/json", body: `{"amount":100,"product":{"id":"product-1","quantity":1}}`, wantStatus: fiber.StatusAccepted},
+		{name: "form encoded", contentType: "application/x-www-form-urlencoded", body: "amount=100&product.id=product-1", wantStatus: fiber.StatusUnsupportedMediaType},
+		{name: "text", contentType: "text/plain", body: `{"amount":100,"product":{"id":"product-1","quantity":1}}`, wantStatus: fiber.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOrderService{}
+			app := fiber.New()
+			NewOrderController(service, true).Route(app)
+
+			req := httptest.NewRequest("POST", "/api/v1/orders/create-order", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if created := len(service.created) > 0; created != (tt.wantStatus == fiber.StatusAccepted) {
+				t.Errorf("Expected an order created only for a JSON body, created %v", service.created)
+			}
+		})
+	}
+
+	t.Log("✅ Orders created from JSON bodies only")
+}
+
+func TestOrderController_CreateOrderAccepted(t *testing.T) {
+	app := fiber.New()
+	NewOrderController(&fakeOrderService{}, true).Route(app)
+
+	req := httptest.NewRequest("POST", "/api/v1/orders/create-order",
+		strings.NewReader(`{"amount":100,"product":{"id":"product-1","quantity":1}}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusAccepted, resp.StatusCode)
+	}
+
+	var body struct {
+		OrderID   string `json:"order_id"`
+		StatusURL string `json:"statusUrl"`
+	}
+	decodeResponse(t, resp, &body)
+	wantURL := "/api/v1/orders/" + body.OrderID + "/status"
+	if body.OrderID == "" || body.StatusURL != wantURL {
+		t.Errorf("Expected statusUrl %s for order %q, got %s", wantURL, body.OrderID, body.StatusURL)
+	}
+	if location := resp.Header.Get("Location"); location != wantURL {
+		t.Errorf("Expected Location %s, got %s", wantURL, location)
+	}
+
+	t.Log("✅ Order creation accepted with a status URL")
+}
+
+func TestOrderController_CreateOrderInsufficientStock(t *testing.T) {
+	app := fiber.New()
+	NewOrderController(&fakeOrderService{
+		createErr: fmt.Errorf("%w: 6 of product product-1 requested, 5 available", domain.ErrInsufficientStock),
+	}, true).Route(app)
+
+	req := httptest.NewRequest("POST", "/api/v1/orders/create-order",
+		strings.NewReader(`{"amount":100,"product":{"id":"product-1","quantity":6}}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusConflict {
+		t.Errorf("Expected status %d, got %d", fiber.StatusConflict, resp.StatusCode)
+	}
+}
+
+func TestOrderController_CreateOrderWait(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		settledStatus string
+		wantStatus    int
+		wantWait      time.Duration
+		wantBody      string
+		asyncOnly     bool // The sync_create feature is disabled
+	}{
+		{name: "fire and forget", settledStatus: "Confirmed", wantStatus: fiber.StatusAccepted, wantBody: "Order requested"},
+		{name: "wait until confirmed", query: "?wait=true", settledStatus: "Confirmed", wantStatus: fiber.StatusCreated, wantWait: 10 * time.Second, wantBody: "Confirmed"},
+		{name: "wait until cancelled", query: "?wait=true&timeout=2s", settledStatus: "Cancelled", wantStatus: fiber.StatusOK, wantWait: 2 * time.Second, wantBody: "Cancelled"},
+		{name: "wait times out", query: "?wait=true&timeout=50ms", wantStatus: fiber.StatusAccepted, wantWait: 50 * time.Millisecond, wantBody: "Order requested"},
+		{name: "timeout above the limit", query: "?wait=true&timeout=5m", wantStatus: fiber.StatusBadRequest},
+		{name: "sync create disabled", query: "?wait=true", settledStatus: "Confirmed", asyncOnly: true, wantStatus: fiber.StatusAccepted, wantBody: "Order requested"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOrderService{settledStatus: tt.settledStatus}
+			app := fiber.New()
+			NewOrderController(service, !tt.asyncOnly).Route(app)
+
+			req := httptest.NewRequest("POST", "/api/v1/orders/create-order"+tt.query,
+				strings.NewReader(`{"amount":100,"product":{"id":"product-1","quantity":1}}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus == fiber.StatusBadRequest {
+				return
+			}
+
+			if tt.wantWait == 0 && len(service.waitedFor) != 0 {
+				t.Errorf("Expected no wait, got %v", service.waitedFor)
+			}
+			if tt.wantWait != 0 && (len(service.waitedFor) != 1 || service.waitedFor[0] != tt.wantWait) {
+				t.Errorf("Expected one wait of %s, got %v", tt.wantWait, service.waitedFor)
+			}
+			var body struct {
+				Status string `json:"status"`
+			}
+			decodeResponse(t, resp, &body)
+			if body.Status != tt.wantBody {
+				t.Errorf("Expected status %q in the body, got %q", tt.wantBody, body.Status)
+			}
+			if resp.Header.Get("Location") == "" {
+				t.Error("Expected a Location header")
+			}
+		})
+	}
+}
+
+func TestOrderController_CreateOrderServiceValidationError(t *testing.T) {
+	validationErr := events.NewValidationError("OrderRequestedEvent")
+	validationErr.Add("product.id", "is required")
+	validationErr.Add("product.quantity", "must be greater than 0")
+
+	app := fiber.New()
+	NewOrderController(&fakeOrderService{createErr: fmt.Errorf("invalid order request: %w", validationErr)}, true).Route(app)
+
+	req := httptest.NewRequest("POST", "/api/v1/orders/create-order",
+		strings.NewReader(`{"amount":100,"product":{"id":"product-1","quantity":1}}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusBadRequest, resp.StatusCode)
+	}
+
+	apiErr := decodeResponse(t, resp, nil)
+	if apiErr == nil || len(apiErr.Fields) != 2 || apiErr.Fields[0].Field != "product.id" || apiErr.Fields[1].Field != "product.quantity" {
+		t.Errorf("Expected product.id and product.quantity errors, got %+v", apiErr)
+	}
+}
+
+func TestOrderController_ReplayFailedEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantDryRun bool
+	}{
+		{name: "replay", target: "/api/v1/orders/replay-failed-events", wantDryRun: false},
+		{name: "dry run", target: "/api/v1/orders/replay-failed-events?dryRun=true", wantDryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOrderService{}
+			app := fiber.New()
+			NewOrderController(service, true).Route(app)
+
+			resp, err := app.Test(httptest.NewRequest("POST", tt.target, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+			}
+			if len(service.replayDryRuns) != 1 || service.replayDryRuns[0] != tt.wantDryRun {
+				t.Errorf("Expected one replay with dryRun=%v, got %v", tt.wantDryRun, service.replayDryRuns)
+			}
+			var summary domain.ReplaySummary
+			decodeResponse(t, resp, &summary)
+			if summary.DryRun != tt.wantDryRun || summary.ByEventType["order.created"] != 3 {
+				t.Errorf("Expected the replay summary, got %+v", summary)
+			}
+		})
+	}
+
+	t.Run("filter passed through", func(t *testing.T) {
+		service := &fakeOrderService{}
+		app := fiber.New()
+		NewOrderController(service, true).Route(app)
+
+		target := "/api/v1/orders/replay-failed-events?from=2025-03-01T12:00:00Z&to=2025-03-01T14:00:00%2B01:00&status=failed,%20pending&eventType=order.created"
+		resp, err := app.Test(httptest.NewRequest("POST", target, nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if len(service.replayFilters) != 1 {
+			t.Fatalf("Expected one replay, got %d", len(service.replayFilters))
+		}
+		filter := service.replayFilters[0]
+		from := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+		if !filter.From.Equal(from) || !filter.To.Equal(from.Add(time.Hour)) || filter.EventType != "order.created" ||
+			!slices.Equal(filter.Statuses, []string{"failed", "pending"}) {
+			t.Errorf("Unexpected filter %+v", filter)
+		}
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		for _, query := range []string{
+			"from=yesterday",
+			"from=2025-03-01T13:00:00Z&to=2025-03-01T12:00:00Z",
+			"status=completed",
+		} {
+			service := &fakeOrderService{}
+			app := fiber.New()
+			NewOrderController(service, true).Route(app)
+
+			resp, err := app.Test(httptest.NewRequest("POST", "/api/v1/orders/replay-failed-events?"+query, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusBadRequest || len(service.replayFilters) != 0 {
+				t.Errorf("%s: expected 400 without a replay, got %d and %d replays", query, resp.StatusCode, len(service.replayFilters))
+			}
+		}
+	})
+
+	t.Log("✅ Replay summary returned, dry run and filter passed through")
+}
+
+func TestOrderController_CreateOrderBatch(t *testing.T) {
+	post := func(t *testing.T, service *fakeOrderService, body string) (*http.Response, models.BatchOrderResponse) {
+		t.Helper()
+		app := fiber.New()
+		NewOrderController(service, false).Route(app)
+		req := httptest.NewRequest("POST", "/api/v1/orders/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var batch models.BatchOrderResponse
+		if resp.StatusCode == fiber.StatusAccepted || resp.StatusCode == fiber.StatusMultiStatus {
+			decodeResponse(t, resp, &batch)
+		}
+		return resp, batch
+	}
+
+	t.Run("every order valid", func(t *testing.T) {
+		service := &fakeOrderService{}
+		resp, batch := post(t, service, `[
+			{"amount":100,"product":{"id":"product-1","quantity":1}},
+			{"amount":19.99,"currency":"EUR","product":{"id":"product-2","quantity":2}}
+		]`)
+		if resp.StatusCode != fiber.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", resp.StatusCode)
+		}
+		if len(batch.Results) != 2 || len(service.created) != 2 {
+			t.Fatalf("Expected both orders requested, got %+v", batch.Results)
+		}
+		for i, result := range batch.Results {
+			if result.Index != i || result.OrderID != service.created[i] || result.StatusURL != orderStatusURL(result.OrderID) || result.Error != nil {
+				t.Errorf("Expected order %d requested, got %+v", i, result)
+			}
+		}
+		if batch.CorrelationID == "" || service.correlations[0] != batch.CorrelationID || service.correlations[1] != batch.CorrelationID {
+			t.Errorf("Expected the orders published under the batch's correlation ID %q, got %v", batch.CorrelationID, service.correlations)
+		}
+	})
+
+	t.Run("some orders rejected", func(t *testing.T) {
+		service := &fakeOrderService{productErrs: map[string]error{
+			"scarce":  fmt.Errorf("%w: 6 of product scarce requested, 5 available", domain.ErrInsufficientStock),
+			"failing": errors.New("publish failed"),
+		}}
+		resp, batch := post(t, service, `[
+			{"amount":100,"product":{"id":"product-1","quantity":1}},
+			{"amount":-1,"product":{"id":"product-1","quantity":1}},
+			{"amount":100,"product":{"id":"scarce","quantity":6}},
+			{"amount":100,"product":{"id":"failing","quantity":1}}
+		]`)
+		if resp.StatusCode != fiber.StatusMultiStatus {
+			t.Fatalf("Expected status 207, got %d", resp.StatusCode)
+		}
+		if len(batch.Results) != 4 {
+			t.Fatalf("Expected a result per order, got %+v", batch.Results)
+		}
+		if batch.Results[0].OrderID == "" || batch.Results[0].Error != nil {
+			t.Errorf("Expected the valid order requested, got %+v", batch.Results[0])
+		}
+		wantCodes := []string{"", "bad_request", "conflict", "internal_server_error"}
+		for i, want := range wantCodes[1:] {
+			result := batch.Results[i+1]
+			if result.OrderID != "" || result.Error == nil || result.Error.Code != want {
+				t.Errorf("Expected order %d rejected with %s, got %+v", i+1, want, result)
+			}
+		}
+		if fields := batch.Results[1].Error.Fields; len(fields) != 1 || fields[0].Field != "amount" {
+			t.Errorf("Expected the invalid amount reported, got %+v", fields)
+		}
+		if len(service.created) != 1 {
+			t.Errorf("Expected only the valid order requested, got %v", service.created)
+		}
+	})
+
+	t.Run("empty and oversized batches", func(t *testing.T) {
+		order := `{"amount":100,"product":{"id":"product-1","quantity":1}}`
+		oversized := "[" + strings.TrimSuffix(strings.Repeat(order+",", maxBatchOrders+1), ",") + "]"
+		for _, body := range []string{"[]", oversized, order} {
+			service := &fakeOrderService{}
+			if resp, _ := post(t, service, body); resp.StatusCode != fiber.StatusBadRequest || len(service.created) != 0 {
+				t.Errorf("Expected the batch rejected with 400, got %d and %d orders", resp.StatusCode, len(service.created))
+			}
+		}
+	})
+
+	t.Log("✅ Batch orders requested one by one with per-order results")
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/order/domain"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// How often an idle order event stream sends a comment, which keeps proxies from closing it
+	// and notices clients that went away
+	orderEventsHeartbeat = 15 * time.Second
+	// How long an order event stream stays open without reaching a terminal status, e.g. for an unknown order
+	orderEventsMaxDuration = 10 * time.Minute
+)
+
+type OrderEventsController struct {
+	serverCtx   context.Context // Done when the server shuts down, which ends every stream
+	orders      domain.OrderService
+	progress    *domain.Progress
+	heartbeat   time.Duration
+	maxDuration time.Duration
+}
+
+func NewOrderEventsController(serverCtx context.Context, orders domain.OrderService, progress *domain.Progress) *OrderEventsController {
+	return &OrderEventsController{
+		serverCtx:   serverCtx,
+		orders:      orders,
+		progress:    progress,
+		heartbeat:   orderEventsHeartbeat,
+		maxDuration: orderEventsMaxDuration,
+	}
+}
+
+func (c *OrderEventsController) Route(app *fiber.App) {
+	app.Get("/api/v1/orders/:id/events", uuidParams("id"), c.StreamOrderEvents)
+}
+
+// StreamOrderEvents godoc
+// @Summary      Stream order status
+// @Description  Streams the order's status transitions as server-sent "status" events, starting with its current status. The stream ends after a terminal status (Completed, Cancelled or Failed). Only transitions handled by this instance are streamed. An order that is not stored yet is followed too, as its creation may still be in progress.
+// @Tags         orders
+// @Produce      text/event-stream
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  domain.StatusUpdate
+// @Failure      400  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/orders/{id}/events [get]
+func (c *OrderEventsController) StreamOrderEvents(ctx *fiber.Ctx) error {
+	orderID := ctx.Params("id")
+
+	// Subscribe before reading the current status, so no transition falls in between
+	updates, unsubscribe := c.progress.Subscribe(orderID)
+	status, err := c.orders.GetOrderStatus(ctx.Context(), orderID)
+	if err != nil && !errors.Is(err, domain.ErrOrderNotFound) {
+		unsubscribe()
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set(fiber.HeaderConnection, "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		if status != "" {
+			if err := writeStatusEvent(w, domain.StatusUpdate{OrderID: orderID, Status: status, At: time.Now().UTC()}); err != nil {
+				return
+			}
+			if events.IsTerminalOrderStatus(status) {
+				return
+			}
+		}
+
+		heartbeat := time.NewTicker(c.heartbeat)
+		defer heartbeat.Stop()
+		deadline := time.NewTimer(c.maxDuration)
+		defer deadline.Stop()
+		for {
+			select {
+			case update, ok := <-updates:
+				if !ok {
+					return // Closed after a terminal status
+				}
+				if update.Status == status {
+					continue // Already sent as the current status
+				}
+				status = update.Status
+				if err := writeStatusEvent(w, update); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				// A write to a client that went away fails, which ends the stream
+				if _, err := w.WriteString(": keepalive\n\n"); err != nil || w.Flush() != nil {
+					return
+				}
+			case <-deadline.C:
+				return
+			case <-c.serverCtx.Done():
+				return // Lets the server shut down without waiting for the client
+			}
+		}
+	})
+	return nil
+}
+
+// writeStatusEvent writes one server-sent event and flushes it to the client
+func writeStatusEvent(w *bufio.Writer, update domain.StatusUpdate) error {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: status\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	return w.Flush()
+}
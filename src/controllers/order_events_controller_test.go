@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/order/domain"
+	orderHandlers "go-order-eda/src/services/order/handlers"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// startOrderEventsServer serves the order events stream on a local port, as EventSource needs a real connection
+func startOrderEventsServer(t *testing.T, controller *OrderEventsController) string {
+	t.Helper()
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	controller.Route(app)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go app.Listener(listener)
+	t.Cleanup(func() { app.ShutdownWithTimeout(time.Second) })
+	return "http://" + listener.Addr().String()
+}
+
+// readStatusEvent reads server-sent events up to the next status event, skipping comments
+func readStatusEvent(t *testing.T, reader *bufio.Reader) (domain.StatusUpdate, bool) {
+	t.Helper()
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return domain.StatusUpdate{}, false
+		}
+		if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+			var update domain.StatusUpdate
+			if err := json.Unmarshal([]byte(data), &update); err != nil {
+				t.Fatalf("Invalid event data %q: %v", data, err)
+			}
+			return update, true
+		}
+	}
+}
+
+func TestOrderEventsController_StreamOrderEvents(t *testing.T) {
+	progress := domain.NewProgress()
+	baseURL := startOrderEventsServer(t, NewOrderEventsController(context.Background(), &fakeOrderService{status: events.OrderStatusRequested}, progress))
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	resp, err := client.Get(baseURL + "/api/v1/orders/" + testOrderID + "/events")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected a 200 event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	first, ok := readStatusEvent(t, reader)
+	if !ok || first.Status != events.OrderStatusRequested {
+		t.Fatalf("Expected the current status %s first, got %+v", events.OrderStatusRequested, first)
+	}
+
+	// Drive the order through its event chain the way the listener would
+	chain := []struct {
+		eventType string
+		payload   any
+	}{
+		{events.OrderCreated, events.OrderCreatedEvent{ID: testOrderID, Status: "Processing"}},
+		{events.InventoryStatusUpdated, events.InventoryStatusUpdatedEvent{OrderID: testOrderID, HasStock: true}},
+		{events.NotificationSent, events.NotificationSentEvent{OrderID: testOrderID, Message: "Order confirmed"}},
+	}
+	for _, step := range chain {
+		body, _ := json.Marshal(step.payload)
+		if err := orderHandlers.NewOrderProgressEventHandler(step.eventType, progress).Handle(context.Background(), body); err != nil {
+			t.Fatalf("Handler for %s failed: %v", step.eventType, err)
+		}
+	}
+
+	var received []string
+	for {
+		update, ok := readStatusEvent(t, reader)
+		if !ok {
+			break // The stream ends after the terminal status
+		}
+		if update.OrderID != testOrderID {
+			t.Errorf("Expected updates for the order only, got %+v", update)
+		}
+		received = append(received, update.Status)
+	}
+	want := []string{events.OrderStatusCreated, events.OrderStatusConfirmed, events.OrderStatusCompleted}
+	if strings.Join(received, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, received)
+	}
+	if n := progress.Subscribers(testOrderID); n != 0 {
+		t.Errorf("Expected no subscribers after the terminal status, got %d", n)
+	}
+
+	t.Log("✅ Order status transitions streamed until the order completed")
+}
+
+func TestOrderEventsController_ClientDisconnect(t *testing.T) {
+	progress := domain.NewProgress()
+	controller := NewOrderEventsController(context.Background(), &fakeOrderService{status: events.OrderStatusRequested}, progress)
+	controller.heartbeat = 10 * time.Millisecond
+	baseURL := startOrderEventsServer(t, controller)
+
+	resp, err := http.Get(baseURL + "/api/v1/orders/" + otherOrderID + "/events")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if _, ok := readStatusEvent(t, bufio.NewReader(resp.Body)); !ok {
+		t.Fatal("Expected the current status")
+	}
+	resp.Body.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for progress.Subscribers(otherOrderID) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the subscription to be removed after the client disconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestOrderEventsController_ServerShutdown(t *testing.T) {
+	progress := domain.NewProgress()
+	serverCtx, stopStreams := context.WithCancel(context.Background())
+	baseURL := startOrderEventsServer(t, NewOrderEventsController(serverCtx, &fakeOrderService{status: events.OrderStatusRequested}, progress))
+
+	resp, err := (&http.Client{Timeout: 5 * time.Second}).Get(baseURL + "/api/v1/orders/" + testOrderID + "/events")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	if _, ok := readStatusEvent(t, reader); !ok {
+		t.Fatal("Expected the current status")
+	}
+
+	stopStreams()
+	if update, ok := readStatusEvent(t, reader); ok {
+		t.Errorf("Expected the stream to end on shutdown, got %+v", update)
+	}
+	if n := progress.Subscribers(testOrderID); n != 0 {
+		t.Errorf("Expected no subscribers after shutdown, got %d", n)
+	}
+
+	t.Log("✅ Open streams end when the server shuts down")
+}
+
+func TestOrderEventsController_TerminalOrder(t *testing.T) {
+	progress := domain.NewProgress()
+	app := fiber.New()
+	NewOrderEventsController(context.Background(), &fakeOrderService{status: events.OrderStatusCancelled}, progress).Route(app)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/orders/"+thirdOrderID+"/events", nil))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	update, ok := readStatusEvent(t, bufio.NewReader(resp.Body))
+	if !ok || update.Status != events.OrderStatusCancelled {
+		t.Errorf("Expected only the terminal status, got %+v", update)
+	}
+	if n := progress.Subscribers(thirdOrderID); n != 0 {
+		t.Errorf("Expected no subscribers for a terminal order, got %d", n)
+	}
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"context"
+	"go-order-eda/src/controllers/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// OrderStatusCounter counts orders per status. It is satisfied by *persistence.OrderRepository.
+type OrderStatusCounter interface {
+	CountByStatus(ctx context.Context) (map[string]int64, error)
+}
+
+type OrderStatsController struct {
+	orders OrderStatusCounter
+}
+
+func NewOrderStatsController(orders OrderStatusCounter) *OrderStatsController {
+	return &OrderStatsController{
+		orders: orders,
+	}
+}
+
+func (c *OrderStatsController) Route(app *fiber.App) {
+	app.Get("/api/v1/orders/stats", c.GetOrderStats)
+}
+
+// GetOrderStats godoc
+// @Summary      Get order counts by status
+// @Description  Counts the orders in each status, e.g. to alert on orders stuck in Processing. Archived orders are left out.
+// @Tags         orders
+// @Produce      json
+// @Success      200  {object}  models.Response{data=models.OrderStats}
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/orders/stats [get]
+func (c *OrderStatsController) GetOrderStats(ctx *fiber.Ctx) error {
+	counts, err := c.orders.CountByStatus(ctx.Context())
+	if err != nil {
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+	stats := models.OrderStats{ByStatus: counts}
+	for _, count := range counts {
+		stats.Total += count
+	}
+	return respond(ctx, fiber.StatusOK, stats)
+}
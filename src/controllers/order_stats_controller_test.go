@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"go-order-eda/src/controllers/models"
+	"maps"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeOrderStatusCounter returns the configured counts, failing with err if set
+type fakeOrderStatusCounter struct {
+	counts map[string]int64
+	err    error
+}
+
+func (f *fakeOrderStatusCounter) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	return f.counts, f.err
+}
+
+func TestOrderStatsController_GetOrderStats(t *testing.T) {
+	t.Run("counts per status and total", func(t *testing.T) {
+		counts := map[string]int64{"Processing": 3, "Confirmed": 5, "Cancelled": 1}
+		app := fiber.New()
+		NewOrderStatsController(&fakeOrderStatusCounter{counts: counts}).Route(app)
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/orders/stats", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		var stats models.OrderStats
+		decodeResponse(t, resp, &stats)
+		if stats.Total != 9 || !maps.Equal(stats.ByStatus, counts) {
+			t.Errorf("Expected 9 orders by status %v, got %+v", counts, stats)
+		}
+	})
+
+	t.Run("counter failure", func(t *testing.T) {
+		app := fiber.New()
+		NewOrderStatsController(&fakeOrderStatusCounter{err: errors.New("mongo unavailable")}).Route(app)
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/orders/stats", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusInternalServerError {
+			t.Errorf("Expected status 500, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Log("✅ Order counts served by status")
+}
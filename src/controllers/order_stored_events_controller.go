@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"go-order-eda/src/controllers/models"
+	"go-order-eda/src/services/order/domain/persistence"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// OrderEventStore reads the events stored for replay. It is satisfied by *persistence.OrderRepository.
+type OrderEventStore interface {
+	GetEventsByOrderID(ctx context.Context, orderID string) ([]persistence.OrderEvent, error)
+}
+
+type OrderStoredEventsController struct {
+	events OrderEventStore
+}
+
+func NewOrderStoredEventsController(events OrderEventStore) *OrderStoredEventsController {
+	return &OrderStoredEventsController{
+		events: events,
+	}
+}
+
+// Route serves the stored events next to /events, which streams the order's status
+func (c *OrderStoredEventsController) Route(app *fiber.App) {
+	app.Get("/api/v1/orders/:id/stored-events", uuidParams("id"), c.GetStoredEvents)
+}
+
+// GetStoredEvents godoc
+// @Summary      Get stored order events
+// @Description  Lists the events stored for replay of an order, oldest first, with their status and how often they failed
+// @Tags         orders
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  models.Response{data=[]models.StoredEvent}
+// @Failure      400  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/orders/{id}/stored-events [get]
+func (c *OrderStoredEventsController) GetStoredEvents(ctx *fiber.Ctx) error {
+	stored, err := c.events.GetEventsByOrderID(ctx.Context(), ctx.Params("id"))
+	if err != nil {
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+	events := make([]models.StoredEvent, 0, len(stored))
+	for _, evt := range stored {
+		events = append(events, storedEvent(evt))
+	}
+	return respond(ctx, fiber.StatusOK, events)
+}
+
+// storedEvent converts a stored event for the API. Event data is stored as JSON, so it is served
+// as is; data that is not JSON is served as a string.
+func storedEvent(evt persistence.OrderEvent) models.StoredEvent {
+	event := json.RawMessage(evt.EventData)
+	if !json.Valid(evt.EventData) {
+		event, _ = json.Marshal(string(evt.EventData))
+	}
+	return models.StoredEvent{
+		ID:         evt.ID,
+		CreatedAt:  evt.CreatedAt,
+		Status:     evt.Status,
+		Attempts:   evt.Attempts,
+		Replayed:   evt.Replayed,
+		ReplayedAt: evt.ReplayedAt,
+		Event:      event,
+	}
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"go-order-eda/src/controllers/models"
+	"go-order-eda/src/services/order/domain/persistence"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeOrderEventStore serves the stored events of the requested order, failing with err if set
+type fakeOrderEventStore struct {
+	events []persistence.OrderEvent
+	err    error
+}
+
+func (f *fakeOrderEventStore) GetEventsByOrderID(ctx context.Context, orderID string) ([]persistence.OrderEvent, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var found []persistence.OrderEvent
+	for _, evt := range f.events {
+		if evt.OrderID == orderID {
+			found = append(found, evt)
+		}
+	}
+	return found, nil
+}
+
+func TestOrderStoredEventsController_GetStoredEvents(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	store := &fakeOrderEventStore{events: []persistence.OrderEvent{
+		{ID: "event-1", OrderID: testOrderID, EventData: []byte(`{"id":"` + testOrderID + `","status":"Processing"}`), CreatedAt: created, Status: "failed", Attempts: 2},
+		{ID: "event-2", OrderID: otherOrderID, EventData: []byte(`{"id":"` + otherOrderID + `"}`), CreatedAt: created, Status: "pending", Attempts: 1},
+		{ID: "event-3", OrderID: testOrderID, EventData: []byte("not json"), CreatedAt: created.Add(time.Second), Status: "completed", Replayed: true},
+	}}
+	app := fiber.New()
+	NewOrderStoredEventsController(store).Route(app)
+
+	t.Run("only the order's events", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/orders/"+testOrderID+"/stored-events", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		var events []models.StoredEvent
+		decodeResponse(t, resp, &events)
+		if len(events) != 2 || events[0].ID != "event-1" || events[1].ID != "event-3" {
+			t.Fatalf("Expected event-1 and event-3, got %+v", events)
+		}
+		if events[0].Status != "failed" || events[0].Attempts != 2 || !events[0].CreatedAt.Equal(created) {
+			t.Errorf("Expected the failed event with 2 attempts, got %+v", events[0])
+		}
+		if string(events[0].Event) != `{"id":"`+testOrderID+`","status":"Processing"}` {
+			t.Errorf("Expected the event decoded as JSON, got %s", events[0].Event)
+		}
+		if string(events[1].Event) != `"not json"` || !events[1].Replayed {
+			t.Errorf("Expected the replayed event as a string, got %+v", events[1])
+		}
+	})
+
+	t.Run("order without events", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/orders/"+thirdOrderID+"/stored-events", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var events []models.StoredEvent
+		decodeResponse(t, resp, &events)
+		if resp.StatusCode != fiber.StatusOK || events == nil || len(events) != 0 {
+			t.Errorf("Expected 200 with an empty list, got %d with %+v", resp.StatusCode, events)
+		}
+	})
+
+	t.Run("store failure", func(t *testing.T) {
+		failing := fiber.New()
+		NewOrderStoredEventsController(&fakeOrderEventStore{err: errors.New("mongo unavailable")}).Route(failing)
+		resp, err := failing.Test(httptest.NewRequest("GET", "/api/v1/orders/"+testOrderID+"/stored-events", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusInternalServerError {
+			t.Errorf("Expected status 500, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Log("✅ Stored events served per order")
+}
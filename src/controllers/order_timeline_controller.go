@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"go-order-eda/src/services/order/projection"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type OrderTimelineController struct {
+	timelines projection.Repository
+}
+
+func NewOrderTimelineController(timelines projection.Repository) *OrderTimelineController {
+	return &OrderTimelineController{
+		timelines: timelines,
+	}
+}
+
+func (c *OrderTimelineController) Route(app *fiber.App) {
+	app.Get("/api/v1/orders/:id/timeline", uuidParams("id"), c.GetOrderTimeline)
+}
+
+// GetOrderTimeline godoc
+// @Summary      Get order timeline
+// @Description  Returns the order's status history with timestamps and the outcome of its inventory check and notification, as projected from its events
+// @Tags         orders
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object}  models.Response{data=projection.OrderTimeline}
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+// @Router       /api/v1/orders/{id}/timeline [get]
+func (c *OrderTimelineController) GetOrderTimeline(ctx *fiber.Ctx) error {
+	orderID := ctx.Params("id")
+	timeline, err := c.timelines.Get(ctx.Context(), orderID)
+	if err != nil {
+		return respondError(ctx, fiber.StatusInternalServerError, err.Error())
+	}
+	if timeline == nil {
+		return respondError(ctx, fiber.StatusNotFound, "order timeline not found")
+	}
+	return respond(ctx, fiber.StatusOK, timeline)
+}
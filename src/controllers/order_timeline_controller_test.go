@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"go-order-eda/src/services/order/projection"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeTimelineRepository serves stored timelines by order ID
+type fakeTimelineRepository struct {
+	projection.Repository
+	timelines map[string]*projection.OrderTimeline
+}
+
+func (f *fakeTimelineRepository) Get(ctx context.Context, orderID string) (*projection.OrderTimeline, error) {
+	return f.timelines[orderID], nil
+}
+
+func TestOrderTimelineController_GetOrderTimeline(t *testing.T) {
+	repository := &fakeTimelineRepository{timelines: map[string]*projection.OrderTimeline{
+		testOrderID: {
+			OrderID:   testOrderID,
+			Status:    "Confirmed",
+			History:   []projection.StatusChange{{Status: "Requested"}, {Status: "Confirmed"}},
+			Inventory: &projection.InventoryOutcome{ProductID: "product-1", HasStock: true},
+		},
+	}}
+	app := fiber.New()
+	NewOrderTimelineController(repository).Route(app)
+
+	t.Run("known order", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/orders/"+testOrderID+"/timeline", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		var timeline projection.OrderTimeline
+		decodeResponse(t, resp, &timeline)
+		if timeline.Status != "Confirmed" || len(timeline.History) != 2 || timeline.Inventory == nil {
+			t.Errorf("Expected the stored timeline, got %+v", timeline)
+		}
+	})
+
+	t.Run("unknown order", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/orders/"+absentID+"/timeline", nil))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"errors"
+	"go-order-eda/src/controllers/models"
+	"go-order-eda/src/infrastructure/log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// respond sends data in the response envelope with the HTTP status
+func respond(ctx *fiber.Ctx, status int, data any) error {
+	return ctx.Status(status).JSON(models.Response{Data: data})
+}
+
+// respondError sends a failure in the response envelope with the HTTP status
+func respondError(ctx *fiber.Ctx, status int, message string) error {
+	return ctx.Status(status).JSON(models.NewErrorResponse(status, message))
+}
+
+// ErrorHandler answers errors returned by handlers and middleware, such as a 404 for an
+// unknown route or a recovered panic, in the response envelope
+func ErrorHandler(logger log.Logger) fiber.ErrorHandler {
+	return func(ctx *fiber.Ctx, err error) error {
+		logger.Exception(ctx.Context(), "HTTP request error", err)
+		status := fiber.StatusInternalServerError
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		}
+		return respondError(ctx, status, err.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-order-eda/src/controllers/models"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/order/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// decodeResponse decodes the response envelope, failing the test when the body does not hold
+// exactly the data and error keys. The data is decoded into data unless it is nil.
+func decodeResponse(t *testing.T, resp *http.Response, data any) *models.APIError {
+	t.Helper()
+	var envelope map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	rawData, hasData := envelope["data"]
+	rawError, hasError := envelope["error"]
+	if len(envelope) != 2 || !hasData || !hasError {
+		t.Fatalf("Expected a response with data and error, got %v", envelope)
+	}
+	if data != nil {
+		if err := json.Unmarshal(rawData, data); err != nil {
+			t.Fatalf("Failed to decode response data: %v", err)
+		}
+	}
+	var apiErr *models.APIError
+	if err := json.Unmarshal(rawError, &apiErr); err != nil {
+		t.Fatalf("Failed to decode response error: %v", err)
+	}
+	return apiErr
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	orders := &fakeOrderService{}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(log.NewLogger())})
+	NewOrderController(orders, true).Route(app)
+	app.Get("/maintenance", func(ctx *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "down for maintenance")
+	})
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		body        string
+		statusErr   error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{name: "success", method: "GET", target: "/api/v1/orders/" + testOrderID + "/status", wantStatus: fiber.StatusOK},
+		{name: "accepted", method: "POST", target: "/api/v1/orders/create-order", body: `{"amount":100,"product":{"id":"product-1","quantity":1}}`, wantStatus: fiber.StatusAccepted},
+		{name: "not found", method: "GET", target: "/api/v1/orders/" + testOrderID + "/status", statusErr: domain.ErrOrderNotFound, wantStatus: fiber.StatusNotFound, wantCode: "not_found", wantMessage: domain.ErrOrderNotFound.Error()},
+		{name: "service failure", method: "GET", target: "/api/v1/orders/" + testOrderID + "/status", statusErr: errors.New("mongo unavailable"), wantStatus: fiber.StatusInternalServerError, wantCode: "internal_server_error", wantMessage: "mongo unavailable"},
+		{name: "invalid fields", method: "POST", target: "/api/v1/orders/create-order", body: `{"amount":100,"product":{}}`, wantStatus: fiber.StatusBadRequest, wantCode: "bad_request", wantMessage: "Invalid request"},
+		{name: "unknown route", method: "GET", target: "/api/v1/unknown", wantStatus: fiber.StatusNotFound, wantCode: "not_found", wantMessage: "Cannot GET /api/v1/unknown"},
+		{name: "error returned by a handler", method: "GET", target: "/maintenance", wantStatus: fiber.StatusServiceUnavailable, wantCode: "service_unavailable", wantMessage: "down for maintenance"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders.statusErr = tt.statusErr
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			var data map[string]any
+			apiErr := decodeResponse(t, resp, &data)
+			if tt.wantCode == "" {
+				if apiErr != nil || data == nil {
+					t.Errorf("Expected data and no error, got data %v and error %+v", data, apiErr)
+				}
+				return
+			}
+			if apiErr == nil || apiErr.Code != tt.wantCode || apiErr.Message != tt.wantMessage {
+				t.Errorf("Expected error %s %q, got %+v", tt.wantCode, tt.wantMessage, apiErr)
+			}
+			if data != nil {
+				t.Errorf("Expected no data, got %v", data)
+			}
+		})
+	}
+
+	t.Log("✅ Success and error responses share the envelope")
+}
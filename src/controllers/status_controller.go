@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"go-order-eda/src/controllers/models"
+	"go-order-eda/src/infrastructure/status"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StatusController struct {
+	reporter *status.Reporter
+}
+
+func NewStatusController(reporter *status.Reporter) *StatusController {
+	return &StatusController{
+		reporter: reporter,
+	}
+}
+
+func (c *StatusController) Route(app *fiber.App) {
+	app.Get("/api/v1/status", c.GetStatus)
+}
+
+// GetStatus godoc
+// @Summary      Get subsystem status
+// @Description  Reports MongoDB, RabbitMQ, queue depths, the replay backlog and background workers in one call
+// @Tags         status
+// @Produce      json
+// @Success      200  {object}  models.Response{data=status.Report}
+// @Failure      503  {object}  models.Response{data=status.Report}
+// @Router       /api/v1/status [get]
+func (c *StatusController) GetStatus(ctx *fiber.Ctx) error {
+	report := c.reporter.Report(ctx.Context())
+	if !report.OK {
+		// The report still tells which subsystems are down
+		response := models.NewErrorResponse(fiber.StatusServiceUnavailable, "one or more subsystems are unavailable")
+		response.Data = report
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(response)
+	}
+	return respond(ctx, fiber.StatusOK, report)
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"go-order-eda/src/infrastructure/status"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestStatusController_GetStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		queueErr   error
+		wantStatus int
+	}{
+		{name: "all subsystems healthy", wantStatus: fiber.StatusOK},
+		{name: "one subsystem unhealthy", queueErr: errors.New("queue not found"), wantStatus: fiber.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reporter := status.NewReporter(time.Second)
+			reporter.Register("mongodb", func(ctx context.Context) (any, error) { return nil, nil })
+			reporter.Register("queue:order.created", func(ctx context.Context) (any, error) { return nil, tt.queueErr })
+
+			app := fiber.New()
+			NewStatusController(reporter).Route(app)
+			resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/status", nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			var report status.Report
+			decodeResponse(t, resp, &report)
+			if report.OK != (tt.queueErr == nil) || len(report.Subsystems) != 2 {
+				t.Errorf("Unexpected report: %+v", report)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Package audit records which events were consumed and how their messages were settled.
+package audit
+
+import (
+	"context"
+	mongoinfra "go-order-eda/src/infrastructure/mongo"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CollectionName is the MongoDB collection holding audit entries
+const CollectionName = "event_audit"
+
+const (
+	// How a consumed message was settled
+	OutcomeAck  = "ack"  // Every handler succeeded
+	OutcomeNack = "nack" // Requeued, after a transient failure or because shutdown interrupted it
+	OutcomeDLQ  = "dlq"  // Rejected so the broker dead-letters it
+)
+
+// Entry records one consumed message
+type Entry struct {
+	EventID       string    `bson:"eventId,omitempty" json:"eventId,omitempty"`
+	EventType     string    `bson:"eventType" json:"eventType"`
+	Queue         string    `bson:"queue" json:"queue"`
+	CorrelationID string    `bson:"correlationId,omitempty" json:"correlationId,omitempty"`
+	Outcome       string    `bson:"outcome" json:"outcome"`
+	Error         string    `bson:"error,omitempty" json:"error,omitempty"`
+	Deliveries    int64     `bson:"deliveries" json:"deliveries"` // Redeliveries before this one
+	DurationMs    float64   `bson:"durationMs" json:"durationMs"`
+	ConsumedAt    time.Time `bson:"consumedAt" json:"consumedAt"`
+}
+
+type Repository interface {
+	InsertMany(ctx context.Context, entries []Entry) error
+	// FindByCorrelationID returns the entries of one correlation ID, oldest first
+	FindByCorrelationID(ctx context.Context, correlationID string, limit int64) ([]Entry, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type repository struct {
+	collection *mongo.Collection
+	timeout    time.Duration // Upper bound for each database operation
+}
+
+func NewRepository(db *mongo.Database, timeout time.Duration) Repository {
+	return &repository{
+		collection: db.Collection(CollectionName),
+		timeout:    timeout,
+	}
+}
+
+func (r *repository) InsertMany(ctx context.Context, entries []Entry) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	documents := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		documents[i] = entry
+	}
+	_, err := r.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	return err
+}
+
+func (r *repository) FindByCorrelationID(ctx context.Context, correlationID string, limit int64) ([]Entry, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	opts := options.Find().SetLimit(limit).SetSort(bson.D{bson.E{Key: "consumedAt", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"correlationId": correlationID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []Entry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// EnsureIndexes creates the index used to trace a correlation ID
+func (r *repository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "correlationId", Value: 1}, {Key: "consumedAt", Value: 1}},
+	})
+	return err
+}
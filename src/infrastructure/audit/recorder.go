@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"go-order-eda/src/infrastructure/log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Recorder buffers audit entries in memory and writes them in batches from a background loop,
+// so recording never waits on MongoDB. When the buffer is full, entries are dropped and counted
+// rather than slowing down message processing.
+type Recorder struct {
+	repository Repository
+	logger     log.Logger
+	entries    chan Entry
+	interval   time.Duration
+	batchSize  int
+	flushMu    sync.Mutex   // Serializes flushes of the loop and of shutdown
+	dropped    atomic.Int64 // Entries discarded because the buffer was full
+	lastRun    atomic.Int64 // Unix nanoseconds of the last successful flush
+}
+
+func NewRecorder(repository Repository, logger log.Logger, bufferSize int, interval time.Duration, batchSize int) *Recorder {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &Recorder{
+		repository: repository,
+		logger:     logger,
+		entries:    make(chan Entry, bufferSize),
+		interval:   interval,
+		batchSize:  batchSize,
+	}
+}
+
+// Record queues an entry without blocking
+func (r *Recorder) Record(entry Entry) {
+	select {
+	case r.entries <- entry:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Run flushes the buffer every interval until the context is cancelled.
+// Entries recorded after that are written by a final Flush during shutdown.
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.logger.Info(ctx, "Event audit recorder started")
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info(ctx, "Event audit recorder stopped")
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.Flush(ctx); err != nil {
+			r.logger.Exception(ctx, "Event audit flush failed", err)
+		}
+	}
+}
+
+// Flush writes every buffered entry in batches. A batch that fails to be written is discarded.
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	if dropped := r.dropped.Swap(0); dropped > 0 {
+		r.logger.Warn(ctx, fmt.Sprintf("Event audit buffer full, dropped %d entries", dropped))
+	}
+
+	for {
+		batch := r.nextBatch()
+		if len(batch) == 0 {
+			r.lastRun.Store(time.Now().UTC().UnixNano())
+			return nil
+		}
+		if err := r.repository.InsertMany(ctx, batch); err != nil {
+			return fmt.Errorf("failed to write %d audit entries: %w", len(batch), err)
+		}
+	}
+}
+
+// nextBatch takes up to batchSize entries from the buffer without waiting for more
+func (r *Recorder) nextBatch() []Entry {
+	var batch []Entry
+	for len(batch) < r.batchSize {
+		select {
+		case entry := <-r.entries:
+			batch = append(batch, entry)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// LastRun returns when the recorder last flushed its buffer, or the zero time if it has not yet
+func (r *Recorder) LastRun() time.Time {
+	if nanos := r.lastRun.Load(); nanos != 0 {
+		return time.Unix(0, nanos).UTC()
+	}
+	return time.Time{}
+}
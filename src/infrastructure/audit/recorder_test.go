@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go-order-eda/src/infrastructure/log"
+)
+
+// fakeRepository collects written batches
+type fakeRepository struct {
+	mu      sync.Mutex
+	batches [][]Entry
+	err     error
+}
+
+func (r *fakeRepository) InsertMany(ctx context.Context, entries []Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.batches = append(r.batches, entries)
+	return nil
+}
+
+func (r *fakeRepository) FindByCorrelationID(ctx context.Context, correlationID string, limit int64) ([]Entry, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+func (r *fakeRepository) written() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var entries []Entry
+	for _, batch := range r.batches {
+		entries = append(entries, batch...)
+	}
+	return entries
+}
+
+func TestRecorder_Flush(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("buffered entries are written in batches", func(t *testing.T) {
+		repository := &fakeRepository{}
+		recorder := NewRecorder(repository, log.NewLogger(), 10, time.Minute, 2)
+		for i := 0; i < 5; i++ {
+			recorder.Record(Entry{EventID: fmt.Sprintf("event-%d", i), Outcome: OutcomeAck})
+		}
+		if len(repository.written()) != 0 {
+			t.Fatal("Expected nothing written before a flush")
+		}
+
+		if err := recorder.Flush(ctx); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		written := repository.written()
+		if len(written) != 5 || len(repository.batches) != 3 {
+			t.Fatalf("Expected 5 entries in 3 batches, got %d in %d", len(written), len(repository.batches))
+		}
+		for i, entry := range written {
+			if entry.EventID != fmt.Sprintf("event-%d", i) {
+				t.Errorf("Expected event-%d at %d, got %s", i, i, entry.EventID)
+			}
+		}
+		if recorder.LastRun().IsZero() {
+			t.Error("Expected LastRun to be set after a flush")
+		}
+	})
+
+	t.Run("entries beyond the buffer are dropped", func(t *testing.T) {
+		repository := &fakeRepository{}
+		recorder := NewRecorder(repository, log.NewLogger(), 3, time.Minute, 10)
+		for i := 0; i < 5; i++ {
+			recorder.Record(Entry{Outcome: OutcomeAck})
+		}
+		if dropped := recorder.dropped.Load(); dropped != 2 {
+			t.Errorf("Expected 2 dropped entries, got %d", dropped)
+		}
+		if err := recorder.Flush(ctx); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		if written := len(repository.written()); written != 3 {
+			t.Errorf("Expected 3 entries written, got %d", written)
+		}
+	})
+
+	t.Run("write failure is returned", func(t *testing.T) {
+		repository := &fakeRepository{err: errors.New("mongo unavailable")}
+		recorder := NewRecorder(repository, log.NewLogger(), 10, time.Minute, 10)
+		recorder.Record(Entry{Outcome: OutcomeDLQ})
+		if err := recorder.Flush(ctx); err == nil {
+			t.Error("Expected the write error to be returned")
+		}
+	})
+
+	t.Log("✅ Audit entries buffered and written in batches")
+}
+
+func TestRecorder_Run(t *testing.T) {
+	repository := &fakeRepository{}
+	recorder := NewRecorder(repository, log.NewLogger(), 10, 10*time.Millisecond, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		recorder.Run(ctx)
+	}()
+
+	recorder.Record(Entry{Outcome: OutcomeAck})
+	deadline := time.Now().Add(time.Second)
+	for len(repository.written()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if written := len(repository.written()); written != 1 {
+		t.Errorf("Expected the entry to be written by the loop, got %d entries", written)
+	}
+}
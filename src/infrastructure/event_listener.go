@@ -2,96 +2,451 @@ package infrastructure
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"go-order-eda/src/infrastructure/audit"
 	"go-order-eda/src/infrastructure/log"
 	rabbitmq "go-order-eda/src/infrastructure/rabbitmq"
+	"go-order-eda/src/infrastructure/retry"
+	"go-order-eda/src/infrastructure/tracing"
+	"go-order-eda/src/services/events"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type EventListener struct {
-	rabbitMQService *rabbitmq.RabbitMQServiceImpl
+	rabbitMQService rabbitmq.Consumer
 	logger          log.Logger
-	handlers        map[string]EventHandler
+	handlers        map[string][]EventHandler // By the queue they consume
+	eventTypes      map[string]string         // Event type of the messages on each consumed queue
+	inFlight        sync.WaitGroup            // Handlers still processing a message
+	workers         chan struct{}             // Bounds the number of handlers running at once
+	maxRedeliveries int64                     // Messages redelivered this many times are dead-lettered as poison
+	auditor         Auditor                   // Records how each message was settled; nil disables auditing
+	middlewares     []Middleware              // Wrapped around every handler when listening starts
+	consumeBackoff  retry.Policy              // Delays between attempts to start consuming a queue
+	handlerTimeout  time.Duration             // Upper bound for one Handle call; 0 leaves handlers unbounded
+	drainTimeout    time.Duration             // How long handlers running at shutdown may still take to finish
+	consumed        consumedCounter           // Messages settled per queue, for the queue lag
+	orderPartitions int                       // Partitions serializing the events of an order within a queue; 0 disables them
+}
+
+// Auditor records consumed messages. Record is called on the handler's goroutine after the
+// message is settled, so it must not block.
+type Auditor interface {
+	Record(entry audit.Entry)
 }
 
+// EventHandler handles one message. A returned error marked with Transient or Permanent
+// decides whether the message is requeued or dead-lettered; see IsRetryable.
 type EventHandler interface {
-	Handle(ctx context.Context, msgBody []byte)
+	Handle(ctx context.Context, msgBody []byte) error
 }
 
-func NewEventListener(rabbit *rabbitmq.RabbitMQServiceImpl, logger log.Logger) *EventListener {
+// NewEventListener creates a listener that runs at most workers handlers at a time across all queues
+// and requeues a message failing with a transient error at most maxRedeliveries times
+func NewEventListener(rabbit rabbitmq.Consumer, logger log.Logger, workers, maxRedeliveries int) *EventListener {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxRedeliveries < 0 {
+		maxRedeliveries = 0
+	}
 	return &EventListener{
 		rabbitMQService: rabbit,
 		logger:          logger,
-		handlers:        make(map[string]EventHandler),
+		handlers:        make(map[string][]EventHandler),
+		eventTypes:      make(map[string]string),
+		workers:         make(chan struct{}, workers),
+		maxRedeliveries: int64(maxRedeliveries),
+		consumeBackoff:  retry.Policy{Base: 2 * time.Second, Max: 30 * time.Second, Jitter: 0.2},
+		drainTimeout:    25 * time.Second,
 	}
 }
 
-// RegisterHandler registers an event handler for a specific event type
+// RegisterHandler adds an event handler for a specific event type. Every handler registered for
+// an event type runs for each of its messages, in registration order. The message is acknowledged
+// only when all of them succeed; otherwise it is requeued or dead-lettered as a whole, so handlers
+// that share an event type must tolerate seeing a message again after another handler failed.
+// The handler consumes the queue named like the event type; see RegisterHandlerOnQueue for other queues.
 func (el *EventListener) RegisterHandler(eventType string, handler EventHandler) {
-	el.handlers[eventType] = handler
+	el.RegisterHandlerOnQueue(eventType, eventType, handler)
+}
+
+// RegisterHandlerOnQueue adds an event handler for an event type consumed from a queue with a
+// name of its own, such as a per-instance or versioned queue bound to the event's routing key.
+// Such a queue is not part of the topology derived from events.Registry and must be declared by the caller.
+func (el *EventListener) RegisterHandlerOnQueue(eventType, queueName string, handler EventHandler) {
+	el.handlers[queueName] = append(el.handlers[queueName], handler)
+	el.eventTypes[queueName] = eventType
+}
+
+// Use adds middlewares wrapped around every registered handler, the first one outermost.
+// Each handler of an event type is wrapped separately. It must be called before StartListening.
+func (el *EventListener) Use(middlewares ...Middleware) {
+	el.middlewares = append(el.middlewares, middlewares...)
 }
 
-// StartListening starts listening for events in background goroutines
+// SetHandlerTimeout bounds each Handle call; it must be called before StartListening.
+// A handler still running at the deadline is abandoned and the message requeued as a transient
+// failure. Only handlers that honour their context actually stop; others keep running unseen.
+func (el *EventListener) SetHandlerTimeout(timeout time.Duration) {
+	el.handlerTimeout = timeout
+}
+
+// SetDrainTimeout bounds how long handlers already running when shutdown starts may take to finish;
+// it must be called before StartListening. Handlers do not see the shutdown itself: their context
+// is only cancelled once the drain timeout has passed, and their message is then requeued.
+func (el *EventListener) SetDrainTimeout(timeout time.Duration) {
+	el.drainTimeout = timeout
+}
+
+// SetAuditor records every consumed message with auditor; it must be called before StartListening
+func (el *EventListener) SetAuditor(auditor Auditor) {
+	el.auditor = auditor
+}
+
+// StartListening starts listening for events in background goroutines.
+// It returns once ctx is cancelled and every handler that was already running has finished,
+// so the caller can close MongoDB and RabbitMQ afterwards without handlers still using them.
+// It fails without consuming anything when a handler is registered on a queue the broker
+// topology does not declare, instead of retrying to consume a queue that will never exist.
 func (el *EventListener) StartListening(ctx context.Context) error {
+	if err := el.checkQueues(); err != nil {
+		return err
+	}
+
 	var wg sync.WaitGroup
 
-	for eventType, handler := range el.handlers {
+	for queueName, handlers := range el.handlers {
+		wrapped := make([]EventHandler, len(handlers))
+		for i, handler := range handlers {
+			wrapped[i] = chain(handler, el.middlewares)
+		}
+		handlers = wrapped
+
 		wg.Add(1)
-		go func(evtType string, h EventHandler) {
+		go func(queue string, hs []EventHandler) {
 			defer wg.Done()
-			el.listenToQueue(ctx, evtType, h)
-		}(eventType, handler)
+			el.listenToQueue(ctx, queue, hs)
+		}(queueName, handlers)
 	}
 
 	// Wait for all goroutines to finish (they run indefinitely unless context is cancelled)
 	wg.Wait()
+
+	// Drain handlers launched before shutdown
+	el.inFlight.Wait()
+	el.logger.Info(ctx, "All in-flight event handlers finished")
 	return nil
 }
 
-// listenToQueue listens to a specific queue and processes messages with retry logic
-func (el *EventListener) listenToQueue(ctx context.Context, eventType string, handler EventHandler) {
-	queueName := eventType
-	maxRetries := 5
-	retryDelay := time.Second * 2
+// Consumed returns the number of messages of a queue settled so far, whether acknowledged, requeued or dead-lettered
+func (el *EventListener) Consumed(queueName string) int64 {
+	return el.consumed.get(queueName)
+}
+
+// queueInspector is implemented by consumers that can check a queue exists, such as RabbitMQServiceImpl
+type queueInspector interface {
+	QueueDepth(queueName string) (int, error)
+}
 
+// checkQueues returns an error listing the registered queues that are not declared: queues named
+// after their event type must be in events.Registry, and other queues must exist on the broker
+// when the consumer can inspect it
+func (el *EventListener) checkQueues() error {
+	inspector, _ := el.rabbitMQService.(queueInspector)
+	var missing []string
+	for queueName, eventType := range el.eventTypes {
+		switch {
+		case queueName == eventType:
+			if !events.IsDeclaredQueue(queueName) {
+				missing = append(missing, queueName)
+			}
+		case inspector != nil:
+			if _, err := inspector.QueueDepth(queueName); err != nil {
+				missing = append(missing, queueName)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("handlers registered for undeclared queues: %s", strings.Join(missing, ", "))
+}
+
+// listenToQueue listens to a specific queue until ctx is done. Consuming is retried indefinitely,
+// with the capped consumeBackoff between failed attempts, and restarted whenever the delivery channel
+// closes; each attempt consumes on a fresh channel, reopening the connection if the broker went away.
+func (el *EventListener) listenToQueue(ctx context.Context, queueName string, handlers []EventHandler) {
 	el.logger.Info(ctx, "Starting to listen for events on queue: "+queueName)
 
-	for attempt := 1; attempt <= maxRetries; attempt++ {
+	partitionCtx, stopPartitions := context.WithCancel(ctx)
+	partitions := el.startPartitions(partitionCtx, ctx, queueName, handlers)
+	defer func() {
+		stopPartitions()
+		partitions.wait()
+	}()
+
+	for attempt := 1; ; attempt++ {
 		msgs, err := el.rabbitMQService.Consume(queueName)
 		if err != nil {
-			el.logger.Exception(ctx, fmt.Sprintf("Failed to start consuming queue: %s (attempt %d/%d)", queueName, attempt, maxRetries), err)
+			el.logger.Exception(ctx, fmt.Sprintf("Failed to start consuming queue: %s (attempt %d), retrying", queueName, attempt), err)
 
-			if attempt == maxRetries {
-				el.logger.Exception(ctx, "Max retries reached for queue: "+queueName+", giving up", err)
+			// Wait before retrying, unless the listener is shutting down
+			if err := el.consumeBackoff.Sleep(ctx, attempt); err != nil {
 				return
 			}
-
-			// Wait before retrying
-			time.Sleep(retryDelay)
-			retryDelay *= 2 // Exponential backoff
 			continue
 		}
 
 		el.logger.Info(ctx, "Successfully started consuming queue: "+queueName)
 
-		// Process messages
-		for {
+		if !el.consume(ctx, queueName, handlers, partitions, msgs) {
+			el.logger.Info(ctx, "Stopping event listener for queue: "+queueName)
+			return
+		}
+		el.logger.Warn(ctx, "Message channel closed for queue: "+queueName+", attempting to reconnect...")
+		attempt = 0
+
+		// Wait before consuming again so a channel that keeps closing does not spin
+		if err := el.consumeBackoff.Sleep(ctx, 1); err != nil {
+			return
+		}
+	}
+}
+
+// consume dispatches the messages delivered on msgs to the handlers. It returns true when the
+// delivery channel closes, so consumption must be restarted, and false when ctx is done.
+func (el *EventListener) consume(ctx context.Context, queueName string, handlers []EventHandler, partitions *orderPartitions, msgs <-chan amqp.Delivery) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case msg, ok := <-msgs:
+			if !ok {
+				return true
+			}
+			// Events of an order wait for the ones before them in the order's partition
+			if partition := partitions.partition(msg); partition != nil {
+				select {
+				case partition <- msg:
+				case <-ctx.Done():
+					msg.Nack(false, true)
+					return false
+				}
+				continue
+			}
+			// Process message in a separate goroutine once a worker is free
 			select {
+			case el.workers <- struct{}{}:
 			case <-ctx.Done():
-				el.logger.Info(ctx, "Stopping event listener for queue: "+queueName)
-				return
-			case msg, ok := <-msgs:
-				if !ok {
-					el.logger.Warn(ctx, "Message channel closed for queue: "+queueName+", attempting to reconnect...")
-					break // Exit inner loop to retry connection
-				}
-				// Process message in a separate goroutine to avoid blocking
-				go func() {
-					handler.Handle(ctx, msg.Body)
-					msg.Ack(false)
-				}()
+				msg.Nack(false, true)
+				return false
 			}
+			el.inFlight.Add(1)
+			go func(msg amqp.Delivery) {
+				defer func() {
+					<-el.workers
+					el.inFlight.Done()
+				}()
+				el.process(ctx, queueName, handlers, msg)
+			}(msg)
 		}
 	}
 }
+
+// process runs the handlers for one message and settles it, recording the outcome with the auditor if one is set
+func (el *EventListener) process(ctx context.Context, queueName string, handlers []EventHandler, msg amqp.Delivery) {
+	start := time.Now()
+	envelope, _ := events.DecodeEnvelope(msg.Body)
+	deliveries := deliveryCount(msg)
+
+	outcome, err := el.handle(ctx, queueName, handlers, msg, envelope, deliveries)
+	el.consumed.add(queueName)
+
+	if el.auditor == nil {
+		return
+	}
+	entry := audit.Entry{
+		EventID:       envelope.EventID,
+		EventType:     envelope.EventType,
+		Queue:         queueName,
+		CorrelationID: envelope.CorrelationID,
+		Outcome:       outcome,
+		Deliveries:    deliveries,
+		DurationMs:    float64(time.Since(start).Microseconds()) / 1000,
+		ConsumedAt:    start.UTC(),
+	}
+	if entry.EventType == "" {
+		entry.EventType = el.eventTypes[queueName] // Legacy messages carry no envelope
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	el.auditor.Record(entry)
+}
+
+// handle runs the handlers inside a span that continues the publisher's trace and settles the message.
+// The handlers receive the payload of the message's envelope, or the whole body of a legacy message,
+// and a context carrying the envelope's correlation ID so the events it publishes join the same chain.
+// Handlers running when shutdown starts keep their context until the drain timeout passes, so
+// they finish their writes; a message whose handlers succeeded is acknowledged even during shutdown,
+// and one whose handling was cut short by the drain timeout is requeued so it is redelivered after restart. A failed message is requeued when the error is transient
+// and it has not reached the redelivery limit, and otherwise rejected so the broker dead-letters it.
+// A message already redelivered more often than the limit, e.g. because it keeps crashing the
+// consumer before it can be settled, is dead-lettered without running the handler.
+// It returns how the message was settled and the handlers' error, if any.
+func (el *EventListener) handle(ctx context.Context, queueName string, handlers []EventHandler, msg amqp.Delivery, envelope events.Envelope, deliveries int64) (string, error) {
+	if deliveries > el.maxRedeliveries {
+		el.logger.Warn(ctx, fmt.Sprintf("Poison message on queue %s redelivered %d times, dead-lettering without handling it",
+			queueName, deliveries))
+		msg.Nack(false, false)
+		return audit.OutcomeDLQ, errors.New("poison message not handled")
+	}
+
+	// Messages dispatched before shutdown but not started yet are left for the next instance
+	if ctx.Err() != nil {
+		el.logger.Warn(ctx, "Shutdown started before message handling on queue: "+queueName+", requeueing message")
+		msg.Nack(false, true)
+		return audit.OutcomeNack, ctx.Err()
+	}
+
+	drainCtx, stopDrain := el.drainContext(ctx)
+	defer stopDrain()
+	handlerCtx, span := tracing.Tracer().Start(tracing.ExtractAMQP(drainCtx, msg.Headers), queueName+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer))
+	handlerCtx = context.WithValue(handlerCtx, queueKey{}, queueName)
+	handlerCtx = context.WithValue(handlerCtx, headersKey{}, msg.Headers)
+	if envelope.CorrelationID != "" {
+		handlerCtx = events.ContextWithCorrelationID(handlerCtx, envelope.CorrelationID)
+	}
+	var errs []error
+	for _, handler := range handlers {
+		if handlerErr := el.runHandler(handlerCtx, queueName, handler, envelope.Payload); handlerErr != nil {
+			errs = append(errs, handlerErr)
+		}
+	}
+	err := errors.Join(errs...)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+
+	// Work that completed is kept even when shutdown started meanwhile, so it is not redone
+	if err == nil {
+		msg.Ack(false)
+		return audit.OutcomeAck, nil
+	}
+	if drainCtx.Err() != nil {
+		el.logger.Warn(ctx, "Shutdown drain timeout interrupted message handling on queue: "+queueName+", requeueing message")
+		msg.Nack(false, true)
+		return audit.OutcomeNack, err
+	}
+
+	switch {
+	case !IsRetryable(err):
+		el.logger.Exception(ctx, "Dead-lettering message from queue: "+queueName, err)
+	case deliveries >= el.maxRedeliveries:
+		el.logger.Exception(ctx, fmt.Sprintf("Poison message on queue %s still failing after %d redeliveries, dead-lettering it",
+			queueName, deliveries), err)
+	default:
+		el.logger.Warn(ctx, fmt.Sprintf("Transient failure on queue %s (redelivery %d/%d), requeueing message: %v",
+			queueName, deliveries, el.maxRedeliveries, err))
+		msg.Nack(false, true)
+		return audit.OutcomeNack, err
+	}
+	msg.Nack(false, false)
+	return audit.OutcomeDLQ, err
+}
+
+// drainContext returns a context with the values of ctx that is not cancelled with it, but only
+// once the drain timeout has passed after ctx is done. The returned function releases its resources.
+func (el *EventListener) drainContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	drainCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		timer := time.NewTimer(el.drainTimeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancel()
+		case <-drainCtx.Done():
+		}
+	})
+	return drainCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// runHandler calls a handler with the handler timeout as its deadline and gives up when the deadline
+// passes, even if the handler ignores its context, so a hung handler does not hold a worker forever
+func (el *EventListener) runHandler(ctx context.Context, queueName string, handler EventHandler, payload []byte) error {
+	if el.handlerTimeout <= 0 {
+		return handler.Handle(ctx, payload)
+	}
+	handlerCtx, cancel := context.WithTimeout(ctx, el.handlerTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.Handle(handlerCtx, payload)
+	}()
+
+	// Cancellation of the parent context, i.e. the drain timeout, is left for handle to settle
+	timedOut := func() bool {
+		return ctx.Err() == nil && errors.Is(handlerCtx.Err(), context.DeadlineExceeded)
+	}
+	select {
+	case err := <-done:
+		if err == nil || !timedOut() {
+			return err
+		}
+	case <-handlerCtx.Done():
+		if !timedOut() {
+			return ctx.Err()
+		}
+	}
+	el.logger.Warn(ctx, fmt.Sprintf("Handler on queue %s timed out after %s", queueName, el.handlerTimeout))
+	return Transient(fmt.Errorf("handler timed out after %s", el.handlerTimeout))
+}
+
+// deliveryCount returns how many times the broker has redelivered a message. Quorum queues track
+// this on the broker in x-delivery-count, so it survives consumer reconnects; a message that went
+// through dead-lettering before also carries x-death entries, whose counts are used if higher.
+func deliveryCount(msg amqp.Delivery) int64 {
+	count := headerInt(msg.Headers["x-delivery-count"])
+
+	deaths, _ := msg.Headers["x-death"].([]interface{})
+	var dead int64
+	for _, death := range deaths {
+		if table, ok := death.(amqp.Table); ok {
+			dead += headerInt(table["count"])
+		}
+	}
+	return max(count, dead)
+}
+
+// headerInt reads an AMQP integer header of any width
+func headerInt(value interface{}) int64 {
+	switch n := value.(type) {
+	case int64:
+		return n
+	case int32:
+		return int64(n)
+	case int16:
+		return int64(n)
+	case int8:
+		return int64(n)
+	case int:
+		return int64(n)
+	}
+	return 0
+}
@@ -0,0 +1,951 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-order-eda/src/infrastructure/audit"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/tracing"
+	"go-order-eda/src/services/events"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fakeConsumer hands out one delivery channel per queue
+type fakeConsumer struct {
+	mu     sync.Mutex
+	queues map[string]chan amqp.Delivery
+}
+
+func newFakeConsumer() *fakeConsumer {
+	return &fakeConsumer{queues: make(map[string]chan amqp.Delivery)}
+}
+
+func (c *fakeConsumer) queue(name string) chan amqp.Delivery {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.queues[name]; !ok {
+		c.queues[name] = make(chan amqp.Delivery, 10)
+	}
+	return c.queues[name]
+}
+
+func (c *fakeConsumer) Consume(queueName string) (<-chan amqp.Delivery, error) {
+	return c.queue(queueName), nil
+}
+
+// reconnectingConsumer hands out a new delivery channel on every Consume call, like a broker
+// whose channel was closed and reopened
+type reconnectingConsumer struct {
+	consumed chan chan amqp.Delivery // Receives each channel handed out
+}
+
+func (c *reconnectingConsumer) Consume(queueName string) (<-chan amqp.Delivery, error) {
+	msgs := make(chan amqp.Delivery, 1)
+	c.consumed <- msgs
+	return msgs, nil
+}
+
+// unavailableConsumer fails to consume until failures attempts were made, like a broker that is restarting
+type unavailableConsumer struct {
+	failures int
+	attempts atomic.Int32
+	msgs     chan amqp.Delivery
+}
+
+func (c *unavailableConsumer) Consume(queueName string) (<-chan amqp.Delivery, error) {
+	if int(c.attempts.Add(1)) <= c.failures {
+		return nil, errors.New("connection is closed")
+	}
+	return c.msgs, nil
+}
+
+// fakeAcknowledger records how a delivery was settled
+type fakeAcknowledger struct {
+	acked    atomic.Bool
+	requeued atomic.Bool
+	settled  chan struct{}
+}
+
+func newFakeAcknowledger() *fakeAcknowledger {
+	return &fakeAcknowledger{settled: make(chan struct{})}
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.acked.Store(true)
+	close(a.settled)
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	a.requeued.Store(requeue)
+	close(a.settled)
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+// fakeResource stands in for a MongoDB or RabbitMQ connection closed at shutdown
+type fakeResource struct {
+	closed         atomic.Bool
+	usedAfterClose atomic.Bool
+}
+
+func (r *fakeResource) use() {
+	if r.closed.Load() {
+		r.usedAfterClose.Store(true)
+	}
+}
+
+// slowHandler blocks until its context is cancelled, then still touches the resource like a handler
+// writing its result, and reports the cancellation
+type slowHandler struct {
+	resource *fakeResource
+	started  chan struct{}
+}
+
+func (h *slowHandler) Handle(ctx context.Context, msgBody []byte) error {
+	close(h.started)
+	<-ctx.Done()
+	time.Sleep(20 * time.Millisecond) // Work still finishing after cancellation
+	h.resource.use()
+	return ctx.Err()
+}
+
+func TestEventListener_ShutdownWaitsForInFlightHandlers(t *testing.T) {
+	consumer := newFakeConsumer()
+	resource := &fakeResource{}
+	handler := &slowHandler{resource: resource, started: make(chan struct{})}
+
+	listener := NewEventListener(consumer, log.NewLogger(), 10, 5)
+	listener.SetDrainTimeout(50 * time.Millisecond)
+	listener.RegisterHandler("order.created", handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.StartListening(ctx)
+	}()
+
+	ack := newFakeAcknowledger()
+	consumer.queue("order.created") <- amqp.Delivery{Acknowledger: ack, Body: []byte(`{"id":"order-1"}`)}
+
+	select {
+	case <-handler.started:
+	case <-time.After(time.Second):
+		t.Fatal("Handler was not started")
+	}
+
+	// Shut down while the handler is mid-flight, then close resources once the listener returns
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Listener did not return after shutdown")
+	}
+	resource.closed.Store(true)
+
+	if resource.usedAfterClose.Load() {
+		t.Error("Expected no resource use after close")
+	}
+	if ack.acked.Load() {
+		t.Error("Expected interrupted message not to be acknowledged")
+	}
+	if !ack.requeued.Load() {
+		t.Error("Expected interrupted message to be requeued")
+	}
+
+	t.Log("✅ Shutdown waited for the in-flight handler")
+}
+
+func TestEventListener_ShutdownAcksHandlersFinishingDuringDrain(t *testing.T) {
+	consumer := newFakeConsumer()
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	handlerErr := make(chan error, 1)
+
+	listener := NewEventListener(consumer, log.NewLogger(), 10, 5)
+	listener.RegisterHandler("order.created", HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+		close(started)
+		<-finish
+		handlerErr <- ctx.Err() // Still usable for the handler's last writes
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.StartListening(ctx)
+	}()
+
+	ack := newFakeAcknowledger()
+	consumer.queue("order.created") <- amqp.Delivery{Acknowledger: ack, Body: []byte(`{"id":"order-1"}`)}
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("Handler was not started")
+	}
+
+	// Shutdown starts while the handler runs, which then completes its work
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	close(finish)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Listener did not return after shutdown")
+	}
+
+	if err := <-handlerErr; err != nil {
+		t.Errorf("Expected the handler's context to outlive the shutdown, got %v", err)
+	}
+	if !ack.acked.Load() {
+		t.Error("Expected the message handled during the drain to be acknowledged")
+	}
+
+	t.Log("✅ Message handled during shutdown was acknowledged instead of redelivered")
+}
+
+func TestEventListener_RejectsUndeclaredQueues(t *testing.T) {
+	consumer := newFakeConsumer()
+	noop := HandlerFunc(func(ctx context.Context, msgBody []byte) error { return nil })
+
+	listener := NewEventListener(consumer, log.NewLogger(), 10, 5)
+	listener.RegisterHandler("order.created", noop)
+	listener.RegisterHandler("order.shipped", noop)
+	listener.RegisterHandler("order.archived", noop)
+
+	done := make(chan error, 1)
+	go func() { done <- listener.StartListening(context.Background()) }()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Expected an error for the undeclared queues")
+		}
+		if !strings.Contains(err.Error(), "order.archived, order.shipped") {
+			t.Errorf("Expected the error to list the undeclared queues, got %v", err)
+		}
+		if strings.Contains(err.Error(), "order.created") {
+			t.Errorf("Expected the declared queue not to be listed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("StartListening did not fail fast")
+	}
+
+	consumer.mu.Lock()
+	consumed := len(consumer.queues)
+	consumer.mu.Unlock()
+	if consumed != 0 {
+		t.Errorf("Expected no queue to be consumed, got %d", consumed)
+	}
+
+	t.Log("✅ Startup failed with the undeclared queues listed")
+}
+
+func TestEventListener_ConsumesCustomQueues(t *testing.T) {
+	consumer := newFakeConsumer()
+	auditor := &fakeAuditor{}
+	handled := make(chan string, 1)
+
+	listener := NewEventListener(consumer, log.NewLogger(), 10, 5)
+	listener.SetAuditor(auditor)
+	listener.RegisterHandlerOnQueue(events.OrderCreated, "order.created.v2", HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+		handled <- QueueFromContext(ctx)
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- listener.StartListening(ctx) }()
+
+	ack := newFakeAcknowledger()
+	consumer.queue("order.created.v2") <- amqp.Delivery{Acknowledger: ack, Body: []byte(`{"id":"order-1"}`)}
+
+	select {
+	case queue := <-handled:
+		if queue != "order.created.v2" {
+			t.Errorf("Expected the handler to run for order.created.v2, got %s", queue)
+		}
+	case err := <-done:
+		t.Fatalf("Listener stopped: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("Message on the custom queue was not handled")
+	}
+	<-ack.settled
+	cancel()
+	<-done
+
+	consumer.mu.Lock()
+	_, consumedDefault := consumer.queues[events.OrderCreated]
+	consumer.mu.Unlock()
+	if consumedDefault {
+		t.Error("Expected the queue named after the event type not to be consumed")
+	}
+	auditor.mu.Lock()
+	defer auditor.mu.Unlock()
+	if entries := auditor.entries; len(entries) != 1 || entries[0].EventType != events.OrderCreated || entries[0].Queue != "order.created.v2" {
+		t.Errorf("Expected one audit entry for order.created on order.created.v2, got %+v", entries)
+	}
+
+	t.Log("✅ Handler consumed from a queue named apart from its event type")
+}
+
+func TestEventListener_ResumesAfterDeliveryChannelCloses(t *testing.T) {
+	consumer := &reconnectingConsumer{consumed: make(chan chan amqp.Delivery)}
+	handled := make(chan string, 1)
+
+	listener := NewEventListener(consumer, log.NewLogger(), 1, 5)
+	listener.consumeBackoff.Wait = func(ctx context.Context, d time.Duration) error { return ctx.Err() }
+	listener.RegisterHandler(events.OrderCreated, HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+		handled <- string(msgBody)
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- listener.StartListening(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// next waits for the listener to consume the queue again
+	next := func(t *testing.T) chan amqp.Delivery {
+		t.Helper()
+		select {
+		case msgs := <-consumer.consumed:
+			return msgs
+		case <-time.After(time.Second):
+			t.Fatal("Listener did not consume the queue again")
+			return nil
+		}
+	}
+
+	// Channels keep closing, more often than any retry budget
+	msgs := next(t)
+	for i := 0; i < 6; i++ {
+		close(msgs)
+		msgs = next(t)
+	}
+
+	ack := newFakeAcknowledger()
+	msgs <- amqp.Delivery{Acknowledger: ack, Body: []byte(`{"id":"order-1"}`)}
+	select {
+	case body := <-handled:
+		if body != `{"id":"order-1"}` {
+			t.Errorf("Expected the delivered message handled, got %s", body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Message delivered after the channel reopened was not handled")
+	}
+	<-ack.settled
+
+	t.Log("✅ Consumption resumed after the delivery channel closed")
+}
+
+func TestEventListener_KeepsRetryingToConsume(t *testing.T) {
+	consumer := &unavailableConsumer{failures: 20, msgs: make(chan amqp.Delivery, 1)}
+	handled := make(chan struct{}, 1)
+
+	listener := NewEventListener(consumer, log.NewLogger(), 1, 5)
+	var delays []time.Duration
+	listener.consumeBackoff.Wait = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	listener.RegisterHandler(events.OrderCreated, HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+		handled <- struct{}{}
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- listener.StartListening(ctx) }()
+
+	ack := newFakeAcknowledger()
+	consumer.msgs <- amqp.Delivery{Acknowledger: ack, Body: []byte(`{"id":"order-1"}`)}
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("Listener stopped retrying before the broker came back")
+	}
+	<-ack.settled
+	cancel()
+	<-done
+
+	if attempts := consumer.attempts.Load(); attempts != 21 {
+		t.Errorf("Expected 21 attempts to consume, got %d", attempts)
+	}
+	for _, delay := range delays {
+		if delay > listener.consumeBackoff.Max {
+			t.Errorf("Expected delays capped at %s, got %s", listener.consumeBackoff.Max, delay)
+		}
+	}
+
+	t.Log("✅ Consuming retried with capped backoff until the broker came back")
+}
+
+func TestEventListener_HandlerTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	release := make(chan struct{})
+	defer close(release)
+
+	tests := []struct {
+		name    string
+		handler HandlerFunc
+	}{
+		{name: "handler ignoring its context", handler: func(ctx context.Context, msgBody []byte) error {
+			<-release // Wedged, e.g. on a call without a timeout of its own
+			return nil
+		}},
+		{name: "handler honouring its context", handler: func(ctx context.Context, msgBody []byte) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := newFakeConsumer()
+			// A single worker: the second message is only handled once the timed-out one freed it
+			listener := NewEventListener(consumer, log.NewLogger(), 1, 5)
+			listener.SetHandlerTimeout(timeout)
+			var calls atomic.Int32
+			listener.RegisterHandler("order.created", HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+				if calls.Add(1) == 1 {
+					return tt.handler(ctx, msgBody)
+				}
+				return nil
+			}))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				listener.StartListening(ctx)
+			}()
+
+			hung, next := newFakeAcknowledger(), newFakeAcknowledger()
+			start := time.Now()
+			consumer.queue("order.created") <- amqp.Delivery{Acknowledger: hung, Body: []byte(`{"id":"order-1"}`)}
+			consumer.queue("order.created") <- amqp.Delivery{Acknowledger: next, Body: []byte(`{"id":"order-2"}`)}
+
+			for _, ack := range []*fakeAcknowledger{hung, next} {
+				select {
+				case <-ack.settled:
+				case <-time.After(time.Second):
+					t.Fatal("Message was not settled; the timeout did not fire")
+				}
+			}
+			cancel()
+			<-done
+
+			if elapsed := time.Since(start); elapsed < timeout {
+				t.Errorf("Expected the message to be settled after the %s timeout, took %s", timeout, elapsed)
+			}
+			if hung.acked.Load() || !hung.requeued.Load() {
+				t.Error("Expected the timed-out message to be requeued")
+			}
+			if !next.acked.Load() {
+				t.Error("Expected the next message to be handled once the worker was freed")
+			}
+		})
+	}
+
+	t.Log("✅ Handler timeout requeued the message and freed the worker")
+}
+
+func TestEventListener_AcksCompletedMessages(t *testing.T) {
+	consumer := newFakeConsumer()
+
+	listener := NewEventListener(consumer, log.NewLogger(), 10, 5)
+	listener.RegisterHandler("order.created", HandlerFunc(func(ctx context.Context, msgBody []byte) error { return nil }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.StartListening(ctx)
+	}()
+
+	ack := newFakeAcknowledger()
+	consumer.queue("order.created") <- amqp.Delivery{Acknowledger: ack, Body: []byte(`{"id":"order-1"}`)}
+
+	select {
+	case <-ack.settled:
+	case <-time.After(time.Second):
+		t.Fatal("Message was not settled")
+	}
+	cancel()
+	<-done
+
+	if !ack.acked.Load() {
+		t.Error("Expected completed message to be acknowledged")
+	}
+}
+
+func TestEventListener_BoundsConcurrentHandlers(t *testing.T) {
+	const workers = 2
+	consumer := newFakeConsumer()
+
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	listener := NewEventListener(consumer, log.NewLogger(), workers, 5)
+	listener.RegisterHandler("order.created", HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		running.Add(-1)
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.StartListening(ctx)
+	}()
+
+	acks := make([]*fakeAcknowledger, 5)
+	for i := range acks {
+		acks[i] = newFakeAcknowledger()
+		consumer.queue("order.created") <- amqp.Delivery{Acknowledger: acks[i], Body: []byte(`{"id":"order-1"}`)}
+	}
+
+	// Give the listener time to start more handlers than it should
+	time.Sleep(50 * time.Millisecond)
+	if n := running.Load(); n != workers {
+		t.Errorf("Expected %d running handlers, got %d", workers, n)
+	}
+
+	close(release)
+	for _, ack := range acks {
+		select {
+		case <-ack.settled:
+		case <-time.After(time.Second):
+			t.Fatal("Message was not settled")
+		}
+	}
+	cancel()
+	<-done
+
+	if p := peak.Load(); p > workers {
+		t.Errorf("Expected at most %d concurrent handlers, got %d", workers, p)
+	}
+
+	t.Log("✅ Handlers bounded by the worker pool")
+}
+
+func TestEventListener_SettlesFailedMessagesByErrorType(t *testing.T) {
+	errTimeout := errors.New("mongo timeout")
+	errMalformed := errors.New("malformed message")
+
+	tests := []struct {
+		name         string
+		err          error
+		headers      amqp.Table
+		wantRequeued bool
+	}{
+		{name: "transient error is requeued", err: Transient(errTimeout), wantRequeued: true},
+		{name: "unmarked error is treated as transient", err: errTimeout, wantRequeued: true},
+		{name: "permanent error is dead-lettered", err: Permanent(errMalformed)},
+		{
+			name:    "transient error past the redelivery limit is dead-lettered",
+			err:     Transient(errTimeout),
+			headers: amqp.Table{"x-death": []interface{}{amqp.Table{"count": int64(5)}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := newFakeConsumer()
+			listener := NewEventListener(consumer, log.NewLogger(), 10, 5)
+			listener.RegisterHandler("order.created", HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+				return tt.err
+			}))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				listener.StartListening(ctx)
+			}()
+
+			ack := newFakeAcknowledger()
+			consumer.queue("order.created") <- amqp.Delivery{Acknowledger: ack, Headers: tt.headers, Body: []byte(`{"id":"order-1"}`)}
+
+			select {
+			case <-ack.settled:
+			case <-time.After(time.Second):
+				t.Fatal("Message was not settled")
+			}
+			cancel()
+			<-done
+
+			if ack.acked.Load() {
+				t.Error("Expected failed message not to be acknowledged")
+			}
+			if ack.requeued.Load() != tt.wantRequeued {
+				t.Errorf("Expected requeued=%v, got %v", tt.wantRequeued, ack.requeued.Load())
+			}
+		})
+	}
+
+	t.Log("✅ Transient failures requeued, permanent ones dead-lettered")
+}
+
+func TestEventListener_DeadLettersPoisonMessages(t *testing.T) {
+	const maxRedeliveries = 3
+	consumer := newFakeConsumer()
+
+	var calls atomic.Int32
+	listener := NewEventListener(consumer, log.NewLogger(), 10, maxRedeliveries)
+	listener.RegisterHandler("order.created", HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+		calls.Add(1)
+		return Transient(errors.New("mongo timeout"))
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.StartListening(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// deliver hands the message over as the broker would on its nth redelivery and reports whether it was requeued
+	deliver := func(redeliveries int64) bool {
+		ack := newFakeAcknowledger()
+		headers := amqp.Table{}
+		if redeliveries > 0 {
+			headers["x-delivery-count"] = redeliveries
+		}
+		consumer.queue("order.created") <- amqp.Delivery{Acknowledger: ack, Headers: headers, Redelivered: redeliveries > 0, Body: []byte(`{"id":"order-1"}`)}
+		select {
+		case <-ack.settled:
+		case <-time.After(time.Second):
+			t.Fatal("Message was not settled")
+		}
+		if ack.acked.Load() {
+			t.Fatal("Expected failing message not to be acknowledged")
+		}
+		return ack.requeued.Load()
+	}
+
+	t.Run("requeued until the redelivery limit", func(t *testing.T) {
+		var redeliveries int64
+		for deliver(redeliveries) {
+			redeliveries++
+			if redeliveries > maxRedeliveries {
+				t.Fatalf("Expected the message to be dead-lettered after %d redeliveries", maxRedeliveries)
+			}
+		}
+		if redeliveries != maxRedeliveries {
+			t.Errorf("Expected dead-lettering after %d redeliveries, got %d", maxRedeliveries, redeliveries)
+		}
+		if n := calls.Load(); n != maxRedeliveries+1 {
+			t.Errorf("Expected %d handler calls, got %d", maxRedeliveries+1, n)
+		}
+	})
+
+	t.Run("past the limit it is dead-lettered without handling", func(t *testing.T) {
+		before := calls.Load()
+		if deliver(maxRedeliveries + 1) {
+			t.Error("Expected the poison message to be dead-lettered")
+		}
+		if calls.Load() != before {
+			t.Error("Expected the handler not to run for a poison message")
+		}
+	})
+
+	t.Log("✅ Poison message dead-lettered after the configured redeliveries")
+}
+
+func TestEventListener_UnwrapsEnvelopes(t *testing.T) {
+	payload := `{"id":"order-1"}`
+	enveloped, _ := json.Marshal(events.NewEnvelope(events.OrderCreated, "correlation-1", []byte(payload)))
+
+	tests := []struct {
+		name            string
+		body            []byte
+		wantCorrelation string
+	}{
+		{name: "enveloped message", body: enveloped, wantCorrelation: "correlation-1"},
+		{name: "legacy bare message", body: []byte(payload)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := newFakeConsumer()
+			var gotBody, gotCorrelation string
+			listener := NewEventListener(consumer, log.NewLogger(), 10, 5)
+			listener.RegisterHandler("order.created", HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+				gotBody, gotCorrelation = string(msgBody), events.CorrelationIDFromContext(ctx)
+				return nil
+			}))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				listener.StartListening(ctx)
+			}()
+
+			ack := newFakeAcknowledger()
+			consumer.queue("order.created") <- amqp.Delivery{Acknowledger: ack, Body: tt.body}
+			select {
+			case <-ack.settled:
+			case <-time.After(time.Second):
+				t.Fatal("Message was not settled")
+			}
+			cancel()
+			<-done
+
+			if gotBody != payload {
+				t.Errorf("Expected handler to receive %s, got %s", payload, gotBody)
+			}
+			if gotCorrelation != tt.wantCorrelation {
+				t.Errorf("Expected correlation ID %q, got %q", tt.wantCorrelation, gotCorrelation)
+			}
+		})
+	}
+}
+
+func TestEventListener_RunsEveryHandlerForAnEvent(t *testing.T) {
+	errTimeout := Transient(errors.New("mongo timeout"))
+	errMalformed := Permanent(errors.New("malformed message"))
+
+	tests := []struct {
+		name         string
+		errs         [2]error
+		wantAcked    bool
+		wantRequeued bool
+	}{
+		{name: "both succeed", wantAcked: true},
+		{name: "one transient failure requeues", errs: [2]error{nil, errTimeout}, wantRequeued: true},
+		{name: "a permanent failure dead-letters", errs: [2]error{errTimeout, errMalformed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := newFakeConsumer()
+			var calls [2]atomic.Int32
+			listener := NewEventListener(consumer, log.NewLogger(), 10, 5)
+			for i := range calls {
+				listener.RegisterHandler("order.created", HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+					calls[i].Add(1)
+					return tt.errs[i]
+				}))
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				listener.StartListening(ctx)
+			}()
+
+			ack := newFakeAcknowledger()
+			consumer.queue("order.created") <- amqp.Delivery{Acknowledger: ack, Body: []byte(`{"id":"order-1"}`)}
+			select {
+			case <-ack.settled:
+			case <-time.After(time.Second):
+				t.Fatal("Message was not settled")
+			}
+			cancel()
+			<-done
+
+			for i := range calls {
+				if n := calls[i].Load(); n != 1 {
+					t.Errorf("Expected handler %d to be invoked once, got %d", i+1, n)
+				}
+			}
+			if ack.acked.Load() != tt.wantAcked || ack.requeued.Load() != tt.wantRequeued {
+				t.Errorf("Expected acked=%v requeued=%v, got acked=%v requeued=%v",
+					tt.wantAcked, tt.wantRequeued, ack.acked.Load(), ack.requeued.Load())
+			}
+		})
+	}
+
+	t.Log("✅ Every handler for the event ran")
+}
+
+// fakeAuditor collects audit entries
+type fakeAuditor struct {
+	mu      sync.Mutex
+	entries []audit.Entry
+}
+
+func (a *fakeAuditor) Record(entry audit.Entry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+}
+
+func TestEventListener_AuditsEveryConsumedMessage(t *testing.T) {
+	consumer := newFakeConsumer()
+	auditor := &fakeAuditor{}
+	listener := NewEventListener(consumer, log.NewLogger(), 10, 5)
+	listener.SetAuditor(auditor)
+	listener.RegisterHandler("order.created", HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+		var body struct {
+			Fail string `json:"fail"`
+		}
+		json.Unmarshal(msgBody, &body)
+		switch body.Fail {
+		case "transient":
+			return Transient(errors.New("mongo timeout"))
+		case "permanent":
+			return Permanent(errors.New("malformed message"))
+		}
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.StartListening(ctx)
+	}()
+
+	messages := []struct {
+		body        []byte
+		headers     amqp.Table
+		wantOutcome string
+	}{
+		{body: mustEnvelope(t, `{}`), wantOutcome: audit.OutcomeAck},
+		{body: mustEnvelope(t, `{"fail":"transient"}`), wantOutcome: audit.OutcomeNack},
+		{body: mustEnvelope(t, `{"fail":"permanent"}`), wantOutcome: audit.OutcomeDLQ},
+		{body: mustEnvelope(t, `{}`), headers: amqp.Table{"x-delivery-count": int64(6)}, wantOutcome: audit.OutcomeDLQ},
+	}
+	for _, message := range messages {
+		ack := newFakeAcknowledger()
+		consumer.queue("order.created") <- amqp.Delivery{Acknowledger: ack, Headers: message.headers, Body: message.body}
+		select {
+		case <-ack.settled:
+		case <-time.After(time.Second):
+			t.Fatal("Message was not settled")
+		}
+	}
+	cancel()
+	<-done
+
+	auditor.mu.Lock()
+	defer auditor.mu.Unlock()
+	if len(auditor.entries) != len(messages) {
+		t.Fatalf("Expected %d audit entries, got %d", len(messages), len(auditor.entries))
+	}
+	for i, entry := range auditor.entries {
+		if entry.Outcome != messages[i].wantOutcome {
+			t.Errorf("Message %d: expected outcome %s, got %s", i, messages[i].wantOutcome, entry.Outcome)
+		}
+		if entry.EventType != events.OrderCreated || entry.Queue != "order.created" || entry.CorrelationID != "correlation-1" {
+			t.Errorf("Message %d: expected order.created with correlation-1, got %+v", i, entry)
+		}
+		if entry.EventID == "" || entry.ConsumedAt.IsZero() {
+			t.Errorf("Message %d: expected event ID and consumption time, got %+v", i, entry)
+		}
+		if (entry.Error == "") != (messages[i].wantOutcome == audit.OutcomeAck) {
+			t.Errorf("Message %d: unexpected error %q for outcome %s", i, entry.Error, entry.Outcome)
+		}
+	}
+
+	t.Log("✅ One audit entry per consumed message")
+}
+
+func mustEnvelope(t *testing.T, payload string) []byte {
+	t.Helper()
+	body, err := json.Marshal(events.NewEnvelope(events.OrderCreated, "correlation-1", []byte(payload)))
+	if err != nil {
+		t.Fatalf("Failed to marshal envelope: %v", err)
+	}
+	return body
+}
+
+func TestEventListener_ContinuesPublisherTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	if _, err := tracing.Setup(context.Background(), ""); err != nil {
+		t.Fatalf("Tracing setup failed: %v", err)
+	}
+
+	consumer := newFakeConsumer()
+	var handlerSpan trace.SpanContext
+	listener := NewEventListener(consumer, log.NewLogger(), 10, 5)
+	listener.RegisterHandler("order.created", HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.StartListening(ctx)
+	}()
+
+	// Publish side: inject the producer span into the message headers, as RabbitMQServiceImpl.Publish does
+	publishCtx, publishSpan := tracing.Tracer().Start(context.Background(), "order.created publish",
+		trace.WithSpanKind(trace.SpanKindProducer))
+	headers := amqp.Table{}
+	tracing.InjectAMQP(publishCtx, headers)
+	publishSpan.End()
+
+	ack := newFakeAcknowledger()
+	consumer.queue("order.created") <- amqp.Delivery{Acknowledger: ack, Headers: headers, Body: []byte(`{"id":"order-1"}`)}
+	select {
+	case <-ack.settled:
+	case <-time.After(time.Second):
+		t.Fatal("Message was not settled")
+	}
+	cancel()
+	<-done
+
+	var consumerSpan *tracetest.SpanStub
+	spans := exporter.GetSpans()
+	for i := range spans {
+		if spans[i].Name == "order.created process" {
+			consumerSpan = &spans[i]
+		}
+	}
+	if consumerSpan == nil {
+		t.Fatalf("Expected a consumer span, got %v", spans)
+	}
+
+	parent := publishSpan.SpanContext()
+	if consumerSpan.Parent.SpanID() != parent.SpanID() || consumerSpan.SpanContext.TraceID() != parent.TraceID() {
+		t.Errorf("Expected consumer span to be a child of %s, got parent %s in trace %s",
+			parent.SpanID(), consumerSpan.Parent.SpanID(), consumerSpan.SpanContext.TraceID())
+	}
+	if handlerSpan.SpanID() != consumerSpan.SpanContext.SpanID() {
+		t.Errorf("Expected the handler to run inside the consumer span")
+	}
+
+	t.Log("✅ Trace context survived the publish/consume hop")
+}
@@ -0,0 +1,54 @@
+package infrastructure
+
+import "errors"
+
+// HandlerError tells the event listener whether a failed message is worth redelivering
+type HandlerError struct {
+	Err       error
+	Retryable bool
+}
+
+func (e *HandlerError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *HandlerError) Unwrap() error {
+	return e.Err
+}
+
+// Transient marks a failure that may succeed on redelivery, such as a MongoDB timeout or a failed publish.
+// The message is requeued until its redelivery count reaches the listener's limit.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &HandlerError{Err: err, Retryable: true}
+}
+
+// Permanent marks a failure that redelivery cannot fix, such as a malformed message.
+// The message is dead-lettered to its queue's DLQ straight away.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &HandlerError{Err: err, Retryable: false}
+}
+
+// IsRetryable reports whether a handler error should be redelivered.
+// Errors not marked with Transient or Permanent are treated as transient, and
+// errors joined from several handlers are retryable only if each of them is.
+func IsRetryable(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			if !IsRetryable(e) {
+				return false
+			}
+		}
+		return true
+	}
+	var handlerErr *HandlerError
+	if errors.As(err, &handlerErr) {
+		return handlerErr.Retryable
+	}
+	return true
+}
@@ -40,6 +40,7 @@ type Logger interface {
 	ResponseWithLevel(ctx context.Context, withFields *Field, level logrus.Level)
 	InfoWithExtra(ctx context.Context, message string, dictionary map[string]any)
 	WarnWithExtra(ctx context.Context, message string, dictionary map[string]any)
+	Alert(ctx context.Context, message string, error error, dictionary map[string]any)
 }
 
 type logger struct {
@@ -86,6 +87,21 @@ func (l *logger) Exception(ctx context.Context, message string, err error) {
 		"Exception": err}).Error(message)
 }
 
+// Alert logs an error an operator has to act on. The entry is marked with Severity "critical"
+// so alerting rules can fire on it rather than on every error logged.
+func (l *logger) Alert(ctx context.Context, message string, err error, dictionary map[string]any) {
+	var fields = logrus.Fields{
+		"DateTime":  time.Now(),
+		"Exception": err,
+		"Severity":  "critical",
+	}
+	for key, value := range dictionary {
+		fields[key] = value
+	}
+
+	l.withContext(ctx).WithFields(fields).Error(message)
+}
+
 func (l *logger) RequestResponse(ctx context.Context, withFields *Field) {
 	var fields = logrus.Fields{
 		"DateTime":       time.Now(),
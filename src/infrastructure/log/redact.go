@@ -0,0 +1,80 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Mask replaces the value of a redacted field
+const Mask = "***"
+
+// Redactor masks the values of sensitive fields, such as a customer's email or phone, in request and
+// response bodies before they are logged
+type Redactor struct {
+	fields map[string]struct{}
+}
+
+// NewRedactor returns a redactor masking the given field names, ignoring case
+func NewRedactor(fields []string) *Redactor {
+	redactor := &Redactor{fields: make(map[string]struct{}, len(fields))}
+	for _, field := range fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			redactor.fields[field] = struct{}{}
+		}
+	}
+	return redactor
+}
+
+// Redact returns a JSON body with the value of every configured field masked, at any depth.
+// A body that is not JSON cannot be redacted field by field, so it is masked as a whole
+// when it mentions a configured field and returned unchanged otherwise.
+func (r *Redactor) Redact(body string) string {
+	if r == nil || len(r.fields) == 0 || body == "" {
+		return body
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber() // Keep numbers as they were sent
+	var value any
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return r.redactText(body)
+	}
+	var redacted bytes.Buffer
+	encoder := json.NewEncoder(&redacted)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(r.redactValue(value)); err != nil {
+		return Mask
+	}
+	return strings.TrimSuffix(redacted.String(), "\n")
+}
+
+// redactValue masks the configured fields of objects within a decoded JSON value
+func (r *Redactor) redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if _, sensitive := r.fields[strings.ToLower(key)]; sensitive {
+				v[key] = Mask
+			} else {
+				v[key] = r.redactValue(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = r.redactValue(item)
+		}
+	}
+	return value
+}
+
+// redactText masks a body that is not JSON when it mentions any configured field
+func (r *Redactor) redactText(body string) string {
+	lower := strings.ToLower(body)
+	for field := range r.fields {
+		if strings.Contains(lower, field) {
+			return Mask
+		}
+	}
+	return body
+}
@@ -0,0 +1,58 @@
+package log
+
+import "testing"
+
+func TestRedactor_Redact(t *testing.T) {
+	redactor := NewRedactor([]string{"email", " Phone ", ""})
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "configured fields masked",
+			body: `{"amount":19.99,"customer":{"email":"jane@example.com","phone":"+31 6 1234"}}`,
+			want: `{"amount":19.99,"customer":{"email":"***","phone":"***"}}`,
+		},
+		{
+			name: "field names matched ignoring case",
+			body: `{"Email":"jane@example.com","PHONE":12345}`,
+			want: `{"Email":"***","PHONE":"***"}`,
+		},
+		{
+			name: "fields in arrays masked",
+			body: `{"contacts":[{"email":"a@example.com","name":"A"},{"email":"b@example.com"}]}`,
+			want: `{"contacts":[{"email":"***","name":"A"},{"email":"***"}]}`,
+		},
+		{
+			name: "other fields pass through",
+			body: `{"product":{"id":"product-1","name":"<Mug>","quantity":2},"amount":10000000000000000001}`,
+			want: `{"amount":10000000000000000001,"product":{"id":"product-1","name":"<Mug>","quantity":2}}`,
+		},
+		{
+			name: "text mentioning a field masked whole",
+			body: "email=jane@example.com&quantity=1",
+			want: Mask,
+		},
+		{name: "other text unchanged", body: "quantity=1", want: "quantity=1"},
+		{name: "empty body", body: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactor.Redact(tt.body); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("no fields configured", func(t *testing.T) {
+		body := `{"email":"jane@example.com"}`
+		if got := NewRedactor(nil).Redact(body); got != body {
+			t.Errorf("Expected the body unchanged, got %s", got)
+		}
+	})
+
+	t.Log("✅ Configured fields masked in logged bodies")
+}
@@ -0,0 +1,140 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"go-order-eda/src/infrastructure/log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// Middleware wraps an event handler with a cross-cutting concern such as panic recovery or metrics
+type Middleware func(EventHandler) EventHandler
+
+// HandlerFunc adapts a function to an EventHandler
+type HandlerFunc func(ctx context.Context, msgBody []byte) error
+
+func (f HandlerFunc) Handle(ctx context.Context, msgBody []byte) error {
+	return f(ctx, msgBody)
+}
+
+type queueKey struct{}
+
+// QueueFromContext returns the queue the message being handled was consumed from
+func QueueFromContext(ctx context.Context) string {
+	queue, _ := ctx.Value(queueKey{}).(string)
+	return queue
+}
+
+type headersKey struct{}
+
+// HeadersFromContext returns the AMQP headers of the message being handled, e.g. the x-death
+// entries the broker adds when it dead-letters a message
+func HeadersFromContext(ctx context.Context) amqp.Table {
+	headers, _ := ctx.Value(headersKey{}).(amqp.Table)
+	return headers
+}
+
+// chain wraps handler in middlewares so that the first one runs outermost
+func chain(handler EventHandler, middlewares []Middleware) EventHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Recovery turns a handler panic into a permanent error, so the message is dead-lettered
+// instead of the panic taking down the service together with every other in-flight message
+func Recovery(logger log.Logger) Middleware {
+	return func(next EventHandler) EventHandler {
+		return HandlerFunc(func(ctx context.Context, msgBody []byte) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = Permanent(fmt.Errorf("handler panicked: %v", r))
+					logger.Exception(ctx, fmt.Sprintf("Recovered from handler panic on queue %s\n%s", QueueFromContext(ctx), debug.Stack()), err)
+				}
+			}()
+			return next.Handle(ctx, msgBody)
+		})
+	}
+}
+
+// Logging logs the outcome and duration of every handler call
+func Logging(logger log.Logger) Middleware {
+	return func(next EventHandler) EventHandler {
+		return HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+			start := time.Now()
+			err := next.Handle(ctx, msgBody)
+			elapsed := time.Since(start).Round(time.Microsecond)
+			if err != nil {
+				logger.Warn(ctx, fmt.Sprintf("Handler on queue %s failed after %s: %v", QueueFromContext(ctx), elapsed, err))
+			} else {
+				logger.Info(ctx, fmt.Sprintf("Handler on queue %s completed in %s", QueueFromContext(ctx), elapsed))
+			}
+			return err
+		})
+	}
+}
+
+// HandlerStats are the counters of the handlers of one queue
+type HandlerStats struct {
+	Handled       int64   `json:"handled"`
+	Failed        int64   `json:"failed"`
+	AvgDurationMs float64 `json:"avgDurationMs"`
+}
+
+// HandlerMetrics counts handler calls and their duration per queue
+type HandlerMetrics struct {
+	mu       sync.Mutex
+	handled  map[string]int64
+	failed   map[string]int64
+	duration map[string]time.Duration
+}
+
+func NewHandlerMetrics() *HandlerMetrics {
+	return &HandlerMetrics{
+		handled:  make(map[string]int64),
+		failed:   make(map[string]int64),
+		duration: make(map[string]time.Duration),
+	}
+}
+
+// Middleware returns the middleware feeding these metrics
+func (m *HandlerMetrics) Middleware() Middleware {
+	return func(next EventHandler) EventHandler {
+		return HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+			start := time.Now()
+			err := next.Handle(ctx, msgBody)
+			m.observe(QueueFromContext(ctx), time.Since(start), err)
+			return err
+		})
+	}
+}
+
+func (m *HandlerMetrics) observe(queue string, elapsed time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handled[queue]++
+	m.duration[queue] += elapsed
+	if err != nil {
+		m.failed[queue]++
+	}
+}
+
+// Snapshot returns the current counters keyed by queue
+func (m *HandlerMetrics) Snapshot() map[string]HandlerStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make(map[string]HandlerStats, len(m.handled))
+	for queue, handled := range m.handled {
+		stats[queue] = HandlerStats{
+			Handled:       handled,
+			Failed:        m.failed[queue],
+			AvgDurationMs: float64(m.duration[queue].Microseconds()) / 1000 / float64(handled),
+		}
+	}
+	return stats
+}
@@ -0,0 +1,107 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"go-order-eda/src/infrastructure/log"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+func TestEventListener_MiddlewaresWrapHandlersInOrder(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	record := func(call string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	}
+	tracking := func(name string) Middleware {
+		return func(next EventHandler) EventHandler {
+			return HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+				record(name + " before")
+				err := next.Handle(ctx, msgBody)
+				record(name + " after")
+				return err
+			})
+		}
+	}
+
+	consumer := newFakeConsumer()
+	listener := NewEventListener(consumer, log.NewLogger(), 10, 5)
+	listener.RegisterHandler("order.created", HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+		record("handler on " + QueueFromContext(ctx))
+		return nil
+	}))
+	listener.Use(tracking("first"), tracking("second"))
+	listener.Use(tracking("third"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.StartListening(ctx)
+	}()
+
+	ack := newFakeAcknowledger()
+	consumer.queue("order.created") <- amqp.Delivery{Acknowledger: ack, Body: []byte(`{"id":"order-1"}`)}
+	select {
+	case <-ack.settled:
+	case <-time.After(time.Second):
+		t.Fatal("Message was not settled")
+	}
+	cancel()
+	<-done
+
+	want := []string{
+		"first before", "second before", "third before",
+		"handler on order.created",
+		"third after", "second after", "first after",
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, calls)
+	}
+	if !ack.acked.Load() {
+		t.Error("Expected the message to be acknowledged")
+	}
+
+	t.Log("✅ Middlewares run in order around the handler")
+}
+
+func TestRecovery(t *testing.T) {
+	handler := Recovery(log.NewLogger())(HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+		panic("nil map")
+	}))
+
+	err := handler.Handle(context.Background(), nil)
+	if err == nil || IsRetryable(err) {
+		t.Errorf("Expected a permanent error, got %v", err)
+	}
+}
+
+func TestHandlerMetrics(t *testing.T) {
+	metrics := NewHandlerMetrics()
+	succeed := metrics.Middleware()(HandlerFunc(func(ctx context.Context, msgBody []byte) error { return nil }))
+	fail := metrics.Middleware()(HandlerFunc(func(ctx context.Context, msgBody []byte) error { return errors.New("mongo timeout") }))
+
+	ctx := context.WithValue(context.Background(), queueKey{}, "order.created")
+	succeed.Handle(ctx, nil)
+	succeed.Handle(ctx, nil)
+	fail.Handle(ctx, nil)
+
+	stats, ok := metrics.Snapshot()["order.created"]
+	if !ok {
+		t.Fatal("Expected stats for order.created")
+	}
+	if stats.Handled != 3 || stats.Failed != 1 {
+		t.Errorf("Expected 3 handled and 1 failed, got %+v", stats)
+	}
+}
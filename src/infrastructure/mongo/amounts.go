@@ -0,0 +1,32 @@
+package mongo
+
+import (
+	"context"
+	"go-order-eda/src/services/money"
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrateLegacyAmounts converts the float amount field of documents written before amounts were
+// kept in minor units, rounding it to the minor unit of money.DefaultCurrency and setting that
+// currency. Converted amounts are integers, so running it again only touches documents written
+// since by an older instance. It returns the number of documents converted.
+func MigrateLegacyAmounts(ctx context.Context, coll *mongo.Collection) (int64, error) {
+	exp, err := money.Exponent(money.DefaultCurrency)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.M{"amount": bson.M{"$type": "double"}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.D{
+		{Key: "amount", Value: bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{"$amount", math.Pow10(exp)}}, 0}}}},
+		{Key: "currency", Value: bson.M{"$ifNull": bson.A{"$currency", money.DefaultCurrency}}},
+	}}}}
+	result, err := coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
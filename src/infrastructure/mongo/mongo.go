@@ -2,13 +2,21 @@ package mongo
 
 import (
 	"context"
+	"fmt"
 	"go-order-eda/src/config"
+	"go-order-eda/src/infrastructure/tlsconfig"
 	"log"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 var (
@@ -21,7 +29,12 @@ func GetMongoClient(cfg *config.Config) (*mongo.Client, error) {
 	clientOnce.Do(func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		client, e := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoDBConnectionString))
+		opts, e := ClientOptions(cfg)
+		if e != nil {
+			err = e
+			return
+		}
+		client, e := mongo.Connect(ctx, opts)
 		if e != nil {
 			err = e
 			return
@@ -31,6 +44,82 @@ func GetMongoClient(cfg *config.Config) (*mongo.Client, error) {
 	return clientInstance, err
 }
 
+// ClientOptions builds the driver options from the configuration: the URI, the connection
+// pool size, the timeouts for server selection, connecting and socket reads and writes, TLS, and the
+// write and read concerns every database and collection handle of the client inherits
+func ClientOptions(cfg *config.Config) (*options.ClientOptions, error) {
+	opts := options.Client().ApplyURI(cfg.MongoDBConnectionString)
+	if cfg.MongoMaxPoolSize > 0 {
+		opts.SetMaxPoolSize(cfg.MongoMaxPoolSize)
+	}
+	if cfg.MongoServerSelectionTimeout > 0 {
+		opts.SetServerSelectionTimeout(cfg.MongoServerSelectionTimeout)
+	}
+	if cfg.MongoConnectTimeout > 0 {
+		opts.SetConnectTimeout(cfg.MongoConnectTimeout)
+	}
+	if cfg.MongoSocketTimeout > 0 {
+		opts.SetSocketTimeout(cfg.MongoSocketTimeout)
+	}
+	tlsConfig, err := tlsconfig.New(cfg.MongoTLS)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MongoDB TLS configuration: %w", err)
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+	writeConcern, err := WriteConcern(cfg.MongoWriteConcern, cfg.MongoWriteJournal)
+	if err != nil {
+		return nil, err
+	}
+	if writeConcern != nil {
+		opts.SetWriteConcern(writeConcern)
+	}
+	readConcern, err := ReadConcern(cfg.MongoReadConcern)
+	if err != nil {
+		return nil, err
+	}
+	if readConcern != nil {
+		opts.SetReadConcern(readConcern)
+	}
+	return opts, nil
+}
+
+// WriteConcern returns the write concern acknowledged by w, a number of members, "majority" or a
+// custom tag set, and by the journal when journal is set; nil keeps the server's default
+func WriteConcern(w string, journal bool) (*writeconcern.WriteConcern, error) {
+	if w == "" && !journal {
+		return nil, nil
+	}
+	concern := &writeconcern.WriteConcern{}
+	if n, err := strconv.Atoi(w); err == nil {
+		if n < 0 {
+			return nil, fmt.Errorf("invalid MongoDB write concern %q: must not be negative", w)
+		}
+		concern.W = n
+	} else if w != "" {
+		concern.W = w
+	}
+	if journal {
+		concern.Journal = &journal
+	}
+	return concern, nil
+}
+
+// readConcernLevels are the read concern levels MongoDB supports
+var readConcernLevels = []string{"local", "available", "majority", "linearizable", "snapshot"}
+
+// ReadConcern returns the read concern of the given level; an empty level keeps the server's default
+func ReadConcern(level string) (*readconcern.ReadConcern, error) {
+	if level == "" {
+		return nil, nil
+	}
+	if !slices.Contains(readConcernLevels, level) {
+		return nil, fmt.Errorf("invalid MongoDB read concern %q: must be one of %s", level, strings.Join(readConcernLevels, ", "))
+	}
+	return &readconcern.ReadConcern{Level: level}, nil
+}
+
 func GetCollection(cfg *config.Config, collectionName string) *mongo.Collection {
 	client, err := GetMongoClient(cfg)
 	if err != nil {
@@ -38,3 +127,23 @@ func GetCollection(cfg *config.Config, collectionName string) *mongo.Collection
 	}
 	return client.Database(cfg.MongoDBDatabaseName).Collection(collectionName)
 }
+
+// OperationContext bounds a single database operation with the configured timeout. The parent's
+// cancellation and deadline still apply; a non-positive timeout adds no deadline of its own.
+func OperationContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// ServerVersion returns the version of the MongoDB server the client is connected to, as reported by buildInfo
+func ServerVersion(ctx context.Context, client *mongo.Client) (string, error) {
+	var info struct {
+		Version string `bson:"version"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&info); err != nil {
+		return "", fmt.Errorf("failed to get MongoDB build info: %w", err)
+	}
+	return info.Version, nil
+}
@@ -0,0 +1,134 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"go-order-eda/src/config"
+	"go-order-eda/src/infrastructure/tlsconfig/tlsconfigtest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestClientOptions(t *testing.T) {
+	cfg := &config.Config{
+		MongoDBConnectionString:     "mongodb://localhost:27017",
+		MongoMaxPoolSize:            25,
+		MongoServerSelectionTimeout: 3 * time.Second,
+		MongoConnectTimeout:         4 * time.Second,
+		MongoSocketTimeout:          7 * time.Second,
+	}
+
+	opts, err := ClientOptions(cfg)
+	if err != nil {
+		t.Fatalf("ClientOptions failed: %v", err)
+	}
+
+	if opts.MaxPoolSize == nil || *opts.MaxPoolSize != 25 {
+		t.Errorf("Expected max pool size 25, got %v", opts.MaxPoolSize)
+	}
+	if opts.ServerSelectionTimeout == nil || *opts.ServerSelectionTimeout != 3*time.Second {
+		t.Errorf("Expected server selection timeout 3s, got %v", opts.ServerSelectionTimeout)
+	}
+	if opts.ConnectTimeout == nil || *opts.ConnectTimeout != 4*time.Second {
+		t.Errorf("Expected connect timeout 4s, got %v", opts.ConnectTimeout)
+	}
+	if opts.SocketTimeout == nil || *opts.SocketTimeout != 7*time.Second {
+		t.Errorf("Expected socket timeout 7s, got %v", opts.SocketTimeout)
+	}
+	if len(opts.Hosts) != 1 || opts.Hosts[0] != "localhost:27017" {
+		t.Errorf("Expected URI host localhost:27017, got %v", opts.Hosts)
+	}
+
+	t.Run("unset values keep the driver defaults", func(t *testing.T) {
+		opts, err := ClientOptions(&config.Config{MongoDBConnectionString: "mongodb://localhost:27017"})
+		if err != nil {
+			t.Fatalf("ClientOptions failed: %v", err)
+		}
+		if opts.MaxPoolSize != nil || opts.SocketTimeout != nil || opts.TLSConfig != nil {
+			t.Errorf("Expected driver defaults, got pool %v, socket timeout %v and TLS %v", opts.MaxPoolSize, opts.SocketTimeout, opts.TLSConfig)
+		}
+	})
+
+	t.Run("TLS from the certificate files", func(t *testing.T) {
+		certs := tlsconfigtest.Write(t)
+		opts, err := ClientOptions(&config.Config{MongoDBConnectionString: "mongodb://localhost:27017", MongoTLS: certs.TLS})
+		if err != nil {
+			t.Fatalf("ClientOptions failed: %v", err)
+		}
+		if opts.TLSConfig == nil || opts.TLSConfig.RootCAs == nil || len(opts.TLSConfig.Certificates) != 1 {
+			t.Fatalf("Expected a TLS configuration with the CA and client certificate, got %+v", opts.TLSConfig)
+		}
+		if !bytes.Equal(opts.TLSConfig.Certificates[0].Certificate[0], certs.Client.Raw) {
+			t.Errorf("Expected the client certificate from %s", certs.CertFile)
+		}
+	})
+
+	t.Run("invalid TLS files", func(t *testing.T) {
+		if _, err := ClientOptions(&config.Config{MongoDBConnectionString: "mongodb://localhost:27017", MongoTLS: config.TLS{KeyFile: "client-key.pem"}}); err == nil {
+			t.Error("Expected an error for a key without a certificate")
+		}
+	})
+
+	t.Log("✅ Client options applied from config")
+}
+
+func TestClientOptions_Concerns(t *testing.T) {
+	t.Run("database handles carry the configured concerns", func(t *testing.T) {
+		opts, err := ClientOptions(&config.Config{
+			MongoDBConnectionString: "mongodb://localhost:27017",
+			MongoWriteConcern:       "majority",
+			MongoWriteJournal:       true,
+			MongoReadConcern:        "majority",
+		})
+		if err != nil {
+			t.Fatalf("ClientOptions failed: %v", err)
+		}
+		// Connecting does not wait for the server, so the handles can be inspected without one
+		client, err := mongo.Connect(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("Connect failed: %v", err)
+		}
+		defer client.Disconnect(context.Background())
+
+		// The repositories take their collections from this handle, and collections inherit its concerns
+		db := client.Database("order-db")
+		if wc := db.WriteConcern(); wc == nil || wc.W != "majority" || wc.Journal == nil || !*wc.Journal {
+			t.Errorf("Expected journaled majority writes, got %+v", wc)
+		}
+		if rc := db.ReadConcern(); rc == nil || rc.Level != "majority" {
+			t.Errorf("Expected majority reads, got %+v", rc)
+		}
+	})
+
+	t.Run("number of members", func(t *testing.T) {
+		wc, err := WriteConcern("2", false)
+		if err != nil || wc == nil || wc.W != 2 || wc.Journal != nil {
+			t.Errorf("Expected w:2 without journaling, got %+v, %v", wc, err)
+		}
+	})
+
+	t.Run("unset values keep the server defaults", func(t *testing.T) {
+		opts, err := ClientOptions(&config.Config{MongoDBConnectionString: "mongodb://localhost:27017"})
+		if err != nil {
+			t.Fatalf("ClientOptions failed: %v", err)
+		}
+		if opts.WriteConcern != nil || opts.ReadConcern != nil {
+			t.Errorf("Expected server defaults, got write concern %+v and read concern %+v", opts.WriteConcern, opts.ReadConcern)
+		}
+	})
+
+	t.Run("invalid concerns", func(t *testing.T) {
+		for _, cfg := range []*config.Config{
+			{MongoDBConnectionString: "mongodb://localhost:27017", MongoWriteConcern: "-1"},
+			{MongoDBConnectionString: "mongodb://localhost:27017", MongoReadConcern: "strong"},
+		} {
+			if _, err := ClientOptions(cfg); err == nil {
+				t.Errorf("Expected an error for write concern %q and read concern %q", cfg.MongoWriteConcern, cfg.MongoReadConcern)
+			}
+		}
+	})
+
+	t.Log("✅ Write and read concerns applied from config")
+}
@@ -0,0 +1,139 @@
+package outbox
+
+import (
+	"context"
+	mongoinfra "go-order-eda/src/infrastructure/mongo"
+	"go-order-eda/src/infrastructure/tracing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CollectionName is the MongoDB collection holding outbox messages.
+// Writers insert into it within the same transaction as their business write.
+const CollectionName = "outbox"
+
+const (
+	// Outbox message statuses
+	StatusPending = "pending" // Written with the business change, waiting to be published
+	StatusSent    = "sent"    // Published to the broker by the relay
+)
+
+// Message is an event waiting to be published to the broker
+type Message struct {
+	ID          string     `bson:"_id"`
+	AggregateID string     `bson:"aggregateId"`
+	Topic       string     `bson:"topic"`
+	Payload     []byte     `bson:"payload"`
+	Status      string     `bson:"status"`
+	Attempts    int        `bson:"attempts"`
+	LastError   string     `bson:"lastError,omitempty"`
+	CreatedAt   time.Time  `bson:"createdAt"`
+	SentAt      *time.Time `bson:"sentAt,omitempty"`
+	// Trace context of the writer, restored by the relay so the trace continues across the outbox
+	TraceContext map[string]string `bson:"traceContext,omitempty"`
+}
+
+// NewMessage creates a pending outbox message for the given topic
+func NewMessage(ctx context.Context, aggregateID, topic string, payload []byte) Message {
+	traceContext := map[string]string{}
+	tracing.Inject(ctx, traceContext)
+	return Message{
+		ID:           primitive.NewObjectID().Hex(),
+		AggregateID:  aggregateID,
+		Topic:        topic,
+		Payload:      payload,
+		Status:       StatusPending,
+		CreatedAt:    time.Now().UTC(),
+		TraceContext: traceContext,
+	}
+}
+
+type Repository interface {
+	FetchPending(ctx context.Context, limit int64) ([]Message, error)
+	MarkSent(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id string, publishErr error) error
+	EnsureIndexes(ctx context.Context, sentRetention time.Duration) error
+}
+
+type repository struct {
+	collection *mongo.Collection
+	timeout    time.Duration // Upper bound for each database operation
+}
+
+func NewRepository(db *mongo.Database, timeout time.Duration) Repository {
+	return &repository{
+		collection: db.Collection(CollectionName),
+		timeout:    timeout,
+	}
+}
+
+// EnsureIndexes creates the index the relay polls pending messages with and a TTL index that
+// removes sent messages sentRetention after they were published. Pending messages carry no
+// sentAt and never expire. The TTL index has to be dropped before sentRetention changes.
+func (r *repository) EnsureIndexes(ctx context.Context, sentRetention time.Duration) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}}, // FetchPending
+		},
+		{
+			Keys:    bson.D{{Key: "sentAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(sentRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+// FetchPending returns pending messages in FIFO order (oldest first)
+func (r *repository) FetchPending(ctx context.Context, limit int64) ([]Message, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	opts := options.Find().SetLimit(limit).SetSort(bson.D{bson.E{Key: "createdAt", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"status": StatusPending}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var messages []Message
+	for cursor.Next(ctx) {
+		var msg Message
+		if err := cursor.Decode(&msg); err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// MarkSent marks a message as published
+func (r *repository) MarkSent(ctx context.Context, id string) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"status": StatusSent, "sentAt": now},
+		"$inc": bson.M{"attempts": 1},
+	})
+	return err
+}
+
+// MarkFailed records a failed publish attempt; the message stays pending for the next relay pass
+func (r *repository) MarkFailed(ctx context.Context, id string, publishErr error) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"lastError": publishErr.Error()},
+		"$inc": bson.M{"attempts": 1},
+	})
+	return err
+}
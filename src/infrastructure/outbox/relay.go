@@ -0,0 +1,94 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/rabbitmq"
+	"go-order-eda/src/infrastructure/tracing"
+	"sync/atomic"
+	"time"
+)
+
+// Relay polls the outbox and publishes pending messages to the broker.
+// Delivery is at-least-once: a crash between publishing and marking a message
+// as sent causes it to be published again on the next pass.
+type Relay struct {
+	repository Repository
+	publisher  rabbitmq.Publisher
+	logger     log.Logger
+	interval   time.Duration
+	batchSize  int64
+	lastRun    atomic.Int64 // Unix nanoseconds of the last successful pass
+}
+
+func NewRelay(repository Repository, publisher rabbitmq.Publisher, logger log.Logger, interval time.Duration, batchSize int64) *Relay {
+	return &Relay{
+		repository: repository,
+		publisher:  publisher,
+		logger:     logger,
+		interval:   interval,
+		batchSize:  batchSize,
+	}
+}
+
+// Run relays pending messages every interval until the context is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.logger.Info(ctx, "Outbox relay started")
+	for {
+		if _, err := r.RelayPending(ctx); err != nil {
+			r.logger.Exception(ctx, "Outbox relay pass failed", err)
+		} else {
+			r.lastRun.Store(time.Now().UTC().UnixNano())
+		}
+
+		select {
+		case <-ctx.Done():
+			r.logger.Info(ctx, "Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// LastRun returns when the relay last completed a pass, or the zero time if it has not yet
+func (r *Relay) LastRun() time.Time {
+	if nanos := r.lastRun.Load(); nanos != 0 {
+		return time.Unix(0, nanos).UTC()
+	}
+	return time.Time{}
+}
+
+// RelayPending publishes one batch of pending messages and returns how many were sent.
+// Messages that fail to publish stay pending and are retried on the next pass.
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	messages, err := r.repository.FetchPending(ctx, r.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch pending outbox messages: %w", err)
+	}
+
+	sent := 0
+	for _, msg := range messages {
+		if err := r.publisher.Publish(tracing.Extract(ctx, msg.TraceContext), msg.Topic, msg.Payload); err != nil {
+			r.logger.Warn(ctx, fmt.Sprintf("Failed to relay outbox message %s to %s: %v", msg.ID, msg.Topic, err))
+			if markErr := r.repository.MarkFailed(ctx, msg.ID, err); markErr != nil {
+				r.logger.Exception(ctx, "Failed to record outbox publish failure for message: "+msg.ID, markErr)
+			}
+			continue
+		}
+
+		if err := r.repository.MarkSent(ctx, msg.ID); err != nil {
+			r.logger.Exception(ctx, "Failed to mark outbox message as sent: "+msg.ID, err)
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		r.logger.Info(ctx, fmt.Sprintf("Outbox relay published %d of %d pending messages", sent, len(messages)))
+	}
+	return sent, nil
+}
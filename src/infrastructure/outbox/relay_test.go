@@ -0,0 +1,147 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-order-eda/src/infrastructure/log"
+)
+
+// fakeRepository is an in-memory outbox Repository shared across relay instances,
+// standing in for the outbox collection that survives a process restart
+type fakeRepository struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+func (r *fakeRepository) insert(msg Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, &msg)
+}
+
+func (r *fakeRepository) FetchPending(ctx context.Context, limit int64) ([]Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var pending []Message
+	for _, msg := range r.messages {
+		if msg.Status == StatusPending && int64(len(pending)) < limit {
+			pending = append(pending, *msg)
+		}
+	}
+	return pending, nil
+}
+
+func (r *fakeRepository) MarkSent(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range r.messages {
+		if msg.ID == id {
+			now := time.Now().UTC()
+			msg.Status = StatusSent
+			msg.SentAt = &now
+			msg.Attempts++
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) EnsureIndexes(ctx context.Context, sentRetention time.Duration) error {
+	return nil
+}
+
+func (r *fakeRepository) MarkFailed(ctx context.Context, id string, publishErr error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range r.messages {
+		if msg.ID == id {
+			msg.LastError = publishErr.Error()
+			msg.Attempts++
+		}
+	}
+	return nil
+}
+
+// fakePublisher records published topics and optionally fails every publish
+type fakePublisher struct {
+	mu     sync.Mutex
+	topics []string
+	err    error
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, topic string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.topics = append(p.topics, topic)
+	return nil
+}
+
+func TestRelay_RelayPending(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("written message is relayed once and marked sent", func(t *testing.T) {
+		repo := &fakeRepository{}
+		publisher := &fakePublisher{}
+		relay := NewRelay(repo, publisher, log.NewLogger(), time.Second, 10)
+
+		repo.insert(NewMessage(context.Background(), "order-1", "order.created", []byte(`{"id":"order-1"}`)))
+
+		sent, err := relay.RelayPending(ctx)
+		if err != nil || sent != 1 {
+			t.Fatalf("Expected 1 message relayed, got %d (err: %v)", sent, err)
+		}
+		if repo.messages[0].Status != StatusSent || repo.messages[0].SentAt == nil {
+			t.Errorf("Expected message to be marked sent, got %+v", repo.messages[0])
+		}
+
+		// A second pass must not publish the message again
+		if sent, _ := relay.RelayPending(ctx); sent != 0 {
+			t.Errorf("Expected nothing to relay on second pass, got %d", sent)
+		}
+		if len(publisher.topics) != 1 || publisher.topics[0] != "order.created" {
+			t.Errorf("Expected a single order.created publish, got %v", publisher.topics)
+		}
+	})
+
+	t.Run("message written before a crash is relayed after restart", func(t *testing.T) {
+		repo := &fakeRepository{}
+		repo.insert(NewMessage(context.Background(), "order-1", "order.created", []byte(`{"id":"order-1"}`)))
+
+		// The process that wrote the message crashed before relaying; a fresh relay recovers it
+		publisher := &fakePublisher{}
+		restarted := NewRelay(repo, publisher, log.NewLogger(), time.Second, 10)
+
+		if sent, err := restarted.RelayPending(ctx); err != nil || sent != 1 {
+			t.Fatalf("Expected recovered message to be relayed, got %d (err: %v)", sent, err)
+		}
+		if repo.messages[0].Status != StatusSent {
+			t.Errorf("Expected recovered message to be marked sent, got %s", repo.messages[0].Status)
+		}
+	})
+
+	t.Run("failed publish keeps message pending for the next pass", func(t *testing.T) {
+		repo := &fakeRepository{}
+		publisher := &fakePublisher{err: errors.New("connection to RabbitMQ is closed")}
+		relay := NewRelay(repo, publisher, log.NewLogger(), time.Second, 10)
+
+		repo.insert(NewMessage(context.Background(), "order-1", "order.created", []byte(`{"id":"order-1"}`)))
+
+		if sent, _ := relay.RelayPending(ctx); sent != 0 {
+			t.Fatalf("Expected nothing relayed while broker is down, got %d", sent)
+		}
+		if repo.messages[0].Status != StatusPending || repo.messages[0].Attempts != 1 || repo.messages[0].LastError == "" {
+			t.Errorf("Expected pending message with recorded failure, got %+v", repo.messages[0])
+		}
+
+		publisher.err = nil
+		if sent, _ := relay.RelayPending(ctx); sent != 1 {
+			t.Errorf("Expected message to be relayed once broker recovers, got %d", sent)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package infrastructure
+
+import (
+	"context"
+	"go-order-eda/src/services/events"
+	"hash/fnv"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// partitionBuffer is the number of messages waiting for each partition before the queue's
+// dispatcher blocks, holding back further deliveries
+const partitionBuffer = 16
+
+// SetOrderPartitions serializes the handling of events of the same order within a queue;
+// it must be called before StartListening. Each queue's messages are spread by order ID over
+// n partitions that handle one message at a time, so the events of one order are handled in
+// the order they were delivered while different orders still run concurrently, bounded by the
+// worker pool. Messages that refer to no order are handled concurrently as before. A requeued
+// message is redelivered behind later events of its order, so ordering holds only until a retry.
+// With n below 1 every message is handled concurrently.
+func (el *EventListener) SetOrderPartitions(n int) {
+	el.orderPartitions = max(n, 0)
+}
+
+// orderPartitions dispatches the messages of one queue to its partitions
+type orderPartitions struct {
+	queues []chan amqp.Delivery
+	wg     sync.WaitGroup
+}
+
+// startPartitions starts the partition workers of a queue; they run until ctx is done, after which
+// the messages still waiting in them are requeued. It returns nil when partitioning is off.
+func (el *EventListener) startPartitions(ctx, handleCtx context.Context, queueName string, handlers []EventHandler) *orderPartitions {
+	if el.orderPartitions < 1 {
+		return nil
+	}
+	p := &orderPartitions{queues: make([]chan amqp.Delivery, el.orderPartitions)}
+	for i := range p.queues {
+		p.queues[i] = make(chan amqp.Delivery, partitionBuffer)
+		p.wg.Add(1)
+		go func(msgs <-chan amqp.Delivery) {
+			defer p.wg.Done()
+			el.runPartition(ctx, handleCtx, queueName, handlers, msgs)
+		}(p.queues[i])
+	}
+	return p
+}
+
+// partition returns the partition handling the order a message refers to, or nil for messages
+// without an order ID
+func (p *orderPartitions) partition(msg amqp.Delivery) chan<- amqp.Delivery {
+	if p == nil {
+		return nil
+	}
+	envelope, _ := events.DecodeEnvelope(msg.Body)
+	orderID := events.OrderIDOf(envelope.Payload)
+	if orderID == "" {
+		return nil
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(orderID))
+	return p.queues[hash.Sum32()%uint32(len(p.queues))]
+}
+
+// wait returns once every partition worker has stopped
+func (p *orderPartitions) wait() {
+	if p != nil {
+		p.wg.Wait()
+	}
+}
+
+// runPartition handles the messages of one partition one after another, each once a worker is free.
+// The partition stops when ctx is done; handlers run with handleCtx, the listener's context.
+func (el *EventListener) runPartition(ctx, handleCtx context.Context, queueName string, handlers []EventHandler, msgs <-chan amqp.Delivery) {
+	for {
+		select {
+		case <-ctx.Done():
+			requeueWaiting(msgs)
+			return
+		case msg := <-msgs:
+			if ctx.Err() != nil {
+				msg.Nack(false, true)
+				requeueWaiting(msgs)
+				return
+			}
+			select {
+			case el.workers <- struct{}{}:
+			case <-ctx.Done():
+				msg.Nack(false, true)
+				requeueWaiting(msgs)
+				return
+			}
+			el.inFlight.Add(1)
+			el.process(handleCtx, queueName, handlers, msg)
+			<-el.workers
+			el.inFlight.Done()
+		}
+	}
+}
+
+// requeueWaiting puts the messages still waiting in a stopped partition back on the queue
+func requeueWaiting(msgs <-chan amqp.Delivery) {
+	for {
+		select {
+		case msg := <-msgs:
+			msg.Nack(false, true)
+		default:
+			return
+		}
+	}
+}
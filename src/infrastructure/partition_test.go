@@ -0,0 +1,108 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/events"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+func TestEventListener_SerializesEventsPerOrder(t *testing.T) {
+	consumer := newFakeConsumer()
+	var mu sync.Mutex
+	var handled []string
+	otherOrderHandled := make(chan struct{})
+	otherOrderSeen := false
+
+	listener := NewEventListener(consumer, log.NewLogger(), 10, 5)
+	listener.SetOrderPartitions(4)
+	listener.RegisterHandler("order.created", HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+		var event struct {
+			ID      string `json:"id"`
+			OrderID string `json:"orderId"`
+			Status  string `json:"status"`
+		}
+		_ = json.Unmarshal(msgBody, &event)
+		switch {
+		case event.ID == "order-2":
+			close(otherOrderHandled)
+		case event.Status == "confirmed":
+			// Hold the first event of order-1: its next event must wait, order-2 must not
+			select {
+			case <-otherOrderHandled:
+				otherOrderSeen = true
+			case <-time.After(time.Second):
+			}
+			time.Sleep(50 * time.Millisecond) // Time for the next event to overtake if it could
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, event.ID+event.OrderID+":"+event.Status)
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.StartListening(ctx)
+	}()
+
+	confirmed, _ := json.Marshal(events.NewEnvelope(events.OrderCreated, "", []byte(`{"orderId":"order-1","status":"confirmed"}`)))
+	bodies := [][]byte{
+		confirmed,
+		[]byte(`{"orderId":"order-1","status":"cancelled"}`),
+		[]byte(`{"id":"order-2","status":"created"}`),
+	}
+	acks := make([]*fakeAcknowledger, len(bodies))
+	for i, body := range bodies {
+		acks[i] = newFakeAcknowledger()
+		consumer.queue("order.created") <- amqp.Delivery{Acknowledger: acks[i], Body: body}
+	}
+	for _, ack := range acks {
+		select {
+		case <-ack.settled:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Message was not settled")
+		}
+	}
+	cancel()
+	<-done
+
+	if !otherOrderSeen {
+		t.Error("Expected order-2 to be handled while order-1 was held")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if i, j := slices.Index(handled, "order-1:confirmed"), slices.Index(handled, "order-1:cancelled"); i < 0 || j < i {
+		t.Errorf("Expected order-1 confirmed before cancelled, got %v", handled)
+	}
+
+	t.Log("✅ Events of one order handled in order, other orders concurrently")
+}
+
+func TestOrderPartitions_Partition(t *testing.T) {
+	p := &orderPartitions{queues: make([]chan amqp.Delivery, 4)}
+	for i := range p.queues {
+		p.queues[i] = make(chan amqp.Delivery)
+	}
+	enveloped, _ := json.Marshal(events.NewEnvelope(events.OrderCancelled, "", []byte(`{"orderId":"order-1"}`)))
+
+	if p.partition(amqp.Delivery{Body: enveloped}) != p.partition(amqp.Delivery{Body: []byte(`{"id":"order-1"}`)}) {
+		t.Error("Expected events of one order in the same partition")
+	}
+	if p.partition(amqp.Delivery{Body: []byte(`{"productId":"product-1"}`)}) != nil {
+		t.Error("Expected no partition for an event without an order")
+	}
+	if (*orderPartitions)(nil).partition(amqp.Delivery{Body: enveloped}) != nil {
+		t.Error("Expected no partition when partitioning is off")
+	}
+
+	t.Log("✅ Messages partitioned by order ID")
+}
@@ -0,0 +1,163 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"go-order-eda/src/infrastructure/log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// consumedCounter counts the messages settled on each queue
+type consumedCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (c *consumedCounter) add(queueName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+	}
+	c.counts[queueName]++
+}
+
+func (c *consumedCounter) get(queueName string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[queueName]
+}
+
+// consumptionSource reports how many messages of a queue were consumed so far.
+// It is satisfied by *EventListener.
+type consumptionSource interface {
+	Consumed(queueName string) int64
+}
+
+// QueueLag is how far behind the consumers of one queue are
+type QueueLag struct {
+	Depth       int       `json:"depth"`       // Messages waiting at the last sample
+	ConsumeRate float64   `json:"consumeRate"` // Messages consumed per second, smoothed over recent samples
+	LagSeconds  *float64  `json:"lagSeconds"`  // Time to drain Depth at ConsumeRate; null while nothing is consumed
+	SampledAt   time.Time `json:"sampledAt"`
+}
+
+// queueSample is the previous reading of a queue the next one is compared with
+type queueSample struct {
+	consumed int64
+	at       time.Time
+}
+
+// rateSmoothing is the weight of the latest rate against the earlier ones, so a single
+// quiet or busy interval does not swing the estimate
+const rateSmoothing = 0.5
+
+// QueueLagSampler estimates every interval how long the consumers of each queue need to catch
+// up: the queue depth divided by the rate messages were consumed at since the previous sample.
+// Sampling in the background keeps the status endpoint from querying the broker for it.
+type QueueLagSampler struct {
+	inspector queueInspector
+	consumed  consumptionSource
+	logger    log.Logger
+	queues    []string
+	interval  time.Duration
+	mu        sync.Mutex
+	samples   map[string]queueSample
+	lags      map[string]QueueLag
+	lastRun   atomic.Int64 // Unix nanoseconds of the last sample of every queue
+}
+
+func NewQueueLagSampler(inspector queueInspector, consumed consumptionSource, logger log.Logger, queues []string, interval time.Duration) *QueueLagSampler {
+	return &QueueLagSampler{
+		inspector: inspector,
+		consumed:  consumed,
+		logger:    logger,
+		queues:    queues,
+		interval:  interval,
+		samples:   make(map[string]queueSample),
+		lags:      make(map[string]QueueLag),
+	}
+}
+
+// Run samples the queues every interval until the context is cancelled
+func (s *QueueLagSampler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sample(time.Now()); err != nil {
+			s.logger.Warn(ctx, "Queue lag sampling failed: "+err.Error())
+		} else {
+			s.lastRun.Store(time.Now().UTC().UnixNano())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample reads the depth and consumed count of every queue and updates its lag.
+// A queue whose depth cannot be read keeps its previous lag; the first error is returned.
+func (s *QueueLagSampler) sample(now time.Time) error {
+	var firstErr error
+	for _, queueName := range s.queues {
+		depth, err := s.inspector.QueueDepth(queueName)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("queue %s: %w", queueName, err)
+			}
+			continue
+		}
+		s.update(queueName, depth, s.consumed.Consumed(queueName), now)
+	}
+	return firstErr
+}
+
+func (s *QueueLagSampler) update(queueName string, depth int, consumed int64, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lag := s.lags[queueName]
+	if previous, ok := s.samples[queueName]; ok && now.After(previous.at) {
+		rate := float64(consumed-previous.consumed) / now.Sub(previous.at).Seconds()
+		lag.ConsumeRate = rateSmoothing*rate + (1-rateSmoothing)*lag.ConsumeRate
+	}
+	s.samples[queueName] = queueSample{consumed: consumed, at: now}
+
+	lag.Depth = depth
+	lag.SampledAt = now.UTC()
+	switch {
+	case depth == 0:
+		lag.LagSeconds = new(float64)
+	case lag.ConsumeRate > 0:
+		seconds := float64(depth) / lag.ConsumeRate
+		lag.LagSeconds = &seconds
+	default:
+		lag.LagSeconds = nil
+	}
+	s.lags[queueName] = lag
+}
+
+// Snapshot returns the latest lag keyed by queue
+func (s *QueueLagSampler) Snapshot() map[string]QueueLag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lags := make(map[string]QueueLag, len(s.lags))
+	for queueName, lag := range s.lags {
+		lags[queueName] = lag
+	}
+	return lags
+}
+
+// LastRun returns when every queue was last sampled, or the zero time if that has not happened yet
+func (s *QueueLagSampler) LastRun() time.Time {
+	if nanos := s.lastRun.Load(); nanos != 0 {
+		return time.Unix(0, nanos).UTC()
+	}
+	return time.Time{}
+}
@@ -0,0 +1,90 @@
+package infrastructure
+
+import (
+	"errors"
+	"go-order-eda/src/infrastructure/log"
+	"testing"
+	"time"
+)
+
+// fakeQueueInspector returns the depths set per queue, failing for queues without one
+type fakeQueueInspector struct {
+	depths map[string]int
+}
+
+func (i *fakeQueueInspector) QueueDepth(queueName string) (int, error) {
+	depth, ok := i.depths[queueName]
+	if !ok {
+		return 0, errors.New("queue not found")
+	}
+	return depth, nil
+}
+
+// fakeConsumption returns the consumed counts set per queue
+type fakeConsumption map[string]int64
+
+func (c fakeConsumption) Consumed(queueName string) int64 {
+	return c[queueName]
+}
+
+func TestQueueLagSampler(t *testing.T) {
+	const queue = "order.created"
+	inspector := &fakeQueueInspector{depths: map[string]int{queue: 100}}
+	consumed := fakeConsumption{queue: 0}
+	sampler := NewQueueLagSampler(inspector, consumed, log.NewLogger(), []string{queue}, time.Second)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sample := func(t *testing.T, after time.Duration) QueueLag {
+		t.Helper()
+		if err := sampler.sample(start.Add(after)); err != nil {
+			t.Fatalf("Sampling failed: %v", err)
+		}
+		return sampler.Snapshot()[queue]
+	}
+
+	t.Run("unknown lag before anything was consumed", func(t *testing.T) {
+		lag := sample(t, 0)
+		if lag.Depth != 100 || lag.ConsumeRate != 0 || lag.LagSeconds != nil {
+			t.Errorf("Expected depth 100 with unknown lag, got %+v", lag)
+		}
+	})
+
+	t.Run("lag from the consumption rate", func(t *testing.T) {
+		consumed[queue] = 100 // 10 messages per second
+		inspector.depths[queue] = 80
+		lag := sample(t, 10*time.Second)
+		if lag.Depth != 80 || lag.ConsumeRate != 5 || lag.LagSeconds == nil || *lag.LagSeconds != 16 {
+			t.Errorf("Expected 80 messages at 5/s, 16s behind, got %+v", lag)
+		}
+	})
+
+	t.Run("rate smoothed as consumers speed up", func(t *testing.T) {
+		consumed[queue] = 400 // 30 messages per second
+		inspector.depths[queue] = 35
+		lag := sample(t, 20*time.Second)
+		if lag.Depth != 35 || lag.ConsumeRate != 17.5 || lag.LagSeconds == nil || *lag.LagSeconds != 2 {
+			t.Errorf("Expected 35 messages at 17.5/s, 2s behind, got %+v", lag)
+		}
+	})
+
+	t.Run("no lag once drained", func(t *testing.T) {
+		consumed[queue] = 435
+		inspector.depths[queue] = 0
+		lag := sample(t, 30*time.Second)
+		if lag.Depth != 0 || lag.LagSeconds == nil || *lag.LagSeconds != 0 {
+			t.Errorf("Expected no lag, got %+v", lag)
+		}
+	})
+
+	t.Run("failed depth keeps the previous lag", func(t *testing.T) {
+		delete(inspector.depths, queue)
+		if err := sampler.sample(start.Add(40 * time.Second)); err == nil {
+			t.Error("Expected the failed depth reported")
+		}
+		if lag := sampler.Snapshot()[queue]; !lag.SampledAt.Equal(start.Add(30 * time.Second)) {
+			t.Errorf("Expected the lag of the previous sample, got %+v", lag)
+		}
+	})
+
+	t.Log("✅ Queue lag follows depth and consumption rate")
+}
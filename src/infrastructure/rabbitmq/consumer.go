@@ -0,0 +1,63 @@
+package rabbitmq
+
+import (
+	"go-order-eda/src/config"
+	"go-order-eda/src/services/events"
+	"slices"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// ConsumerOptions configures how the service's queues are declared and consumed
+type ConsumerOptions struct {
+	// Tag prefixes the consumer tag of every queue consumed, followed by the queue name, so the
+	// consumers of a replica can be told apart in the broker; empty lets the broker generate tags
+	Tag string
+	// SingleActiveQueues are declared with x-single-active-consumer: the broker delivers each of
+	// them to one consumer at a time and fails over to another replica's consumer when it goes away
+	SingleActiveQueues []string
+	// MessageTTL overrides the events.EventType.MessageTTL of event queues, keyed by queue name;
+	// 0 keeps messages until they are consumed
+	MessageTTL map[string]time.Duration
+}
+
+// NewConsumerOptions builds the consumer options from the configuration. Everything that declares
+// the topology uses them, as the broker rejects redeclaring a queue with different arguments.
+func NewConsumerOptions(cfg *config.Config) ConsumerOptions {
+	return ConsumerOptions{
+		Tag:                cfg.RabbitMQConsumerTag,
+		SingleActiveQueues: cfg.RabbitMQSingleActiveQueues,
+		MessageTTL:         cfg.RabbitMQQueueTTL,
+	}
+}
+
+// consumerTag returns the consumer tag for a queue
+func (o ConsumerOptions) consumerTag(queueName string) string {
+	if o.Tag == "" {
+		return ""
+	}
+	return o.Tag + "." + queueName
+}
+
+// messageTTL returns the time a message waits unconsumed in the event type's queue before it is
+// dead-lettered, 0 for no limit
+func (o ConsumerOptions) messageTTL(eventType events.EventType) time.Duration {
+	if ttl, ok := o.MessageTTL[eventType.Queue]; ok {
+		return ttl
+	}
+	return eventType.MessageTTL
+}
+
+// queueArgs returns the declaration arguments of a queue, adding x-single-active-consumer to args
+// when the queue is one of SingleActiveQueues
+func (o ConsumerOptions) queueArgs(queueName string, args amqp.Table) amqp.Table {
+	if !slices.Contains(o.SingleActiveQueues, queueName) {
+		return args
+	}
+	withSingleActive := amqp.Table{"x-single-active-consumer": true}
+	for key, value := range args {
+		withSingleActive[key] = value
+	}
+	return withSingleActive
+}
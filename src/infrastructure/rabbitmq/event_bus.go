@@ -0,0 +1,91 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-order-eda/src/infrastructure/retry"
+)
+
+var (
+	// ErrInvalidEvent wraps the error of an event failing its own validation
+	ErrInvalidEvent = errors.New("invalid event")
+	// ErrMarshalEvent wraps the error of an event that cannot be encoded as JSON
+	ErrMarshalEvent = errors.New("failed to marshal event")
+)
+
+// Validatable is implemented by events that check their fields before they are published
+type Validatable interface {
+	Validate() error
+}
+
+// PublishEvent validates the event if it is Validatable, marshals it to JSON and publishes it to the
+// topic. Validation and marshaling errors wrap ErrInvalidEvent and ErrMarshalEvent and are returned
+// before anything is published; unlike a failed publish, retrying them cannot succeed.
+// Pass an EventBus as the publisher to retry failed publishes.
+func PublishEvent[T any](ctx context.Context, publisher Publisher, topic string, event T) error {
+	body, err := MarshalEvent(topic, event)
+	if err != nil {
+		return err
+	}
+	return publisher.Publish(ctx, topic, body)
+}
+
+// MarshalEvent validates the event if it is Validatable and marshals it to JSON, for events that are
+// published later, e.g. through the outbox. Its errors wrap ErrInvalidEvent and ErrMarshalEvent like
+// those of PublishEvent.
+func MarshalEvent[T any](topic string, event T) ([]byte, error) {
+	if err := validate(&event); err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrInvalidEvent, topic, err)
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrMarshalEvent, topic, err)
+	}
+	return body, nil
+}
+
+// IsUnpublishable reports whether PublishEvent failed before publishing, because the event is
+// invalid or cannot be marshaled. Publishing the same event again fails the same way.
+func IsUnpublishable(err error) bool {
+	return errors.Is(err, ErrInvalidEvent) || errors.Is(err, ErrMarshalEvent)
+}
+
+// validate calls the event's Validate method, whether it is declared on the event or on a pointer to it
+func validate[T any](event *T) error {
+	if v, ok := any(*event).(Validatable); ok {
+		return v.Validate()
+	}
+	if v, ok := any(event).(Validatable); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// EventBus is a Publisher retrying a failed publish up to Attempts times, waiting between attempts
+// as Backoff describes. It stops retrying once ctx is done; see retry.Policy.Do.
+type EventBus struct {
+	Publisher Publisher
+	Backoff   retry.Policy
+	Attempts  int // Publishes tried per message; less than 1 tries once
+	// OnFailure, if set, is called after every failed attempt, e.g. to log it
+	OnFailure func(ctx context.Context, topic string, attempt int, err error)
+	// OnExhausted, if set, is called once the last attempt failed, e.g. to alert on it.
+	// It is not called when ctx ends the retries early.
+	OnExhausted func(ctx context.Context, topic string, err error)
+}
+
+func (b EventBus) Publish(ctx context.Context, topic string, body []byte) error {
+	attempts := max(b.Attempts, 1)
+	return b.Backoff.Do(ctx, attempts, func(attempt int) error {
+		err := b.Publisher.Publish(ctx, topic, body)
+		if err != nil && b.OnFailure != nil {
+			b.OnFailure(ctx, topic, attempt, err)
+		}
+		if err != nil && attempt == attempts && b.OnExhausted != nil {
+			b.OnExhausted(ctx, topic, err)
+		}
+		return err
+	})
+}
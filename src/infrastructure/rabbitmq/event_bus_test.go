@@ -0,0 +1,162 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-order-eda/src/infrastructure/retry"
+	"go-order-eda/src/services/events"
+	"testing"
+	"time"
+)
+
+// recordingPublisher records published messages, failing the first failures publishes
+type recordingPublisher struct {
+	published [][]byte
+	attempts  int
+	failures  int
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, topic string, body []byte) error {
+	p.attempts++
+	if p.attempts <= p.failures {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, body)
+	return nil
+}
+
+// valueEvent validates with a value receiver
+type valueEvent struct {
+	ID string `json:"id"`
+}
+
+func (e valueEvent) Validate() error {
+	if e.ID == "" {
+		return errors.New("id is required")
+	}
+	return nil
+}
+
+func TestPublishEvent(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("valid event is published as JSON", func(t *testing.T) {
+		publisher := &recordingPublisher{}
+		event := events.OrderCancelledEvent{OrderID: "order-1", Status: events.OrderStatusCancelled}
+		if err := PublishEvent(ctx, publisher, events.OrderCancelled, event); err != nil {
+			t.Fatalf("PublishEvent failed: %v", err)
+		}
+		if len(publisher.published) != 1 {
+			t.Fatalf("Expected 1 message, got %d", len(publisher.published))
+		}
+		var decoded events.OrderCancelledEvent
+		if err := json.Unmarshal(publisher.published[0], &decoded); err != nil || decoded.OrderID != "order-1" {
+			t.Errorf("Expected the event as JSON, got %s, %v", publisher.published[0], err)
+		}
+	})
+
+	t.Run("validation failure short-circuits", func(t *testing.T) {
+		publisher := &recordingPublisher{}
+		// Validate is declared on *OrderCancelledEvent, the event is passed by value
+		err := PublishEvent(ctx, publisher, events.OrderCancelled, events.OrderCancelledEvent{Status: events.OrderStatusCancelled})
+		if !errors.Is(err, ErrInvalidEvent) || !IsUnpublishable(err) {
+			t.Errorf("Expected ErrInvalidEvent, got %v", err)
+		}
+		var validationErr *events.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected the validation error to be wrapped, got %v", err)
+		}
+
+		if err := PublishEvent(ctx, publisher, "test", valueEvent{}); !errors.Is(err, ErrInvalidEvent) {
+			t.Errorf("Expected ErrInvalidEvent for a value receiver, got %v", err)
+		}
+		if publisher.attempts != 0 {
+			t.Errorf("Expected nothing published, got %d attempts", publisher.attempts)
+		}
+	})
+
+	t.Run("marshaling error is wrapped", func(t *testing.T) {
+		publisher := &recordingPublisher{}
+		err := PublishEvent(ctx, publisher, "test", map[string]any{"unsupported": make(chan int)})
+		if !errors.Is(err, ErrMarshalEvent) || !IsUnpublishable(err) {
+			t.Errorf("Expected ErrMarshalEvent, got %v", err)
+		}
+		var unsupported *json.UnsupportedTypeError
+		if !errors.As(err, &unsupported) {
+			t.Errorf("Expected the JSON error to be wrapped, got %v", err)
+		}
+		if publisher.attempts != 0 {
+			t.Errorf("Expected nothing published, got %d attempts", publisher.attempts)
+		}
+	})
+
+	t.Run("publish failure is returned as is", func(t *testing.T) {
+		publisher := &recordingPublisher{failures: 1}
+		err := PublishEvent(ctx, publisher, "test", valueEvent{ID: "1"})
+		if err == nil || IsUnpublishable(err) {
+			t.Errorf("Expected a publish error, got %v", err)
+		}
+	})
+
+	t.Log("✅ Events validated and marshaled before they are published")
+}
+
+func TestEventBus_RetriesFailedPublishes(t *testing.T) {
+	ctx := context.Background()
+	skipWait := func(ctx context.Context, d time.Duration) error { return nil }
+
+	t.Run("succeeds after failures", func(t *testing.T) {
+		publisher := &recordingPublisher{failures: 2}
+		var failed []int
+		bus := EventBus{
+			Publisher: publisher,
+			Backoff:   retry.Policy{Wait: skipWait},
+			Attempts:  3,
+			OnFailure: func(ctx context.Context, topic string, attempt int, err error) { failed = append(failed, attempt) },
+		}
+		if err := PublishEvent(ctx, bus, "test", valueEvent{ID: "1"}); err != nil {
+			t.Fatalf("PublishEvent failed: %v", err)
+		}
+		if publisher.attempts != 3 || len(publisher.published) != 1 || len(failed) != 2 {
+			t.Errorf("Expected 3 attempts with 2 failures, got %d attempts, %d published, failures %v", publisher.attempts, len(publisher.published), failed)
+		}
+	})
+
+	t.Run("gives up after the attempts", func(t *testing.T) {
+		publisher := &recordingPublisher{failures: 5}
+		var exhausted []string
+		bus := EventBus{
+			Publisher:   publisher,
+			Backoff:     retry.Policy{Wait: skipWait},
+			Attempts:    2,
+			OnExhausted: func(ctx context.Context, topic string, err error) { exhausted = append(exhausted, topic) },
+		}
+		if err := bus.Publish(ctx, "test", []byte("{}")); err == nil {
+			t.Error("Expected an error")
+		}
+		if publisher.attempts != 2 {
+			t.Errorf("Expected 2 attempts, got %d", publisher.attempts)
+		}
+		if len(exhausted) != 1 || exhausted[0] != "test" {
+			t.Errorf("Expected OnExhausted called once for test, got %v", exhausted)
+		}
+	})
+
+	t.Run("invalid event is not retried", func(t *testing.T) {
+		publisher := &recordingPublisher{}
+		bus := EventBus{Publisher: publisher, Backoff: retry.Policy{Wait: skipWait}, Attempts: 3}
+		if err := PublishEvent(ctx, bus, "test", valueEvent{}); !errors.Is(err, ErrInvalidEvent) || publisher.attempts != 0 {
+			t.Errorf("Expected ErrInvalidEvent without attempts, got %v after %d attempts", err, publisher.attempts)
+		}
+	})
+
+	t.Run("zero attempts tries once", func(t *testing.T) {
+		publisher := &recordingPublisher{failures: 1}
+		if err := (EventBus{Publisher: publisher}).Publish(ctx, "test", []byte("{}")); err == nil || publisher.attempts != 1 {
+			t.Errorf("Expected one failed attempt, got %v after %d attempts", err, publisher.attempts)
+		}
+	})
+
+	t.Log("✅ Event bus retried failed publishes")
+}
@@ -1,32 +1,148 @@
 package rabbitmq
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"go-order-eda/src/config"
+	"go-order-eda/src/infrastructure/tlsconfig"
+	"go-order-eda/src/infrastructure/tracing"
+	"go-order-eda/src/services/events"
+	"sync"
+	"time"
 
 	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Publisher publishes messages to a topic on the exchange.
+// It is satisfied by RabbitMQServiceImpl and allows handlers to be tested without a broker.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, body []byte) error
+}
+
+// Consumer delivers messages from a queue.
+// It is satisfied by RabbitMQServiceImpl and allows the event listener to be tested without a broker.
+type Consumer interface {
+	Consume(queueName string) (<-chan amqp.Delivery, error)
+}
+
 // RabbitMQServiceImpl is an implementation of the RabbitMQService interface.
 type RabbitMQServiceImpl struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
+	mu        sync.RWMutex // Guards conn, channel and closed, which change when the connection is reopened
+	conn      connection
+	channel   channel // Publishes and declares; every consumed queue has a channel of its own
+	closed    bool    // Set by Close, after which the connection is not reopened
+	redial    func() (connection, error)
+	exchange  string // Exchange every publish targets and every queue is bound to
+	closeOnce sync.Once
+	topology  Topology // Exchanges, queues and bindings declared on creation
+	consumer  ConsumerOptions
+	server    map[string]any // Broker identity announced when the connection opened
+}
+
+// connection is the part of *amqp.Connection the service uses
+type connection interface {
+	Channel() (channel, error)
+	IsClosed() bool
+	Close() error
+}
+
+// amqpConnection adapts *amqp.Connection to connection
+type amqpConnection struct {
+	*amqp.Connection
 }
 
-func NewRabbitMQService(host, exchange, queueName string) (*RabbitMQServiceImpl, error) {
-	conn, err := amqp.Dial(host)
+func (c amqpConnection) Channel() (channel, error) {
+	ch, err := c.Connection.Channel()
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// channel is the part of *amqp.Channel the service uses, so the topology and
+// publishing can be tested without a broker
+type channel interface {
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	Get(queue string, autoAck bool) (amqp.Delivery, bool, error)
+	QueueInspect(name string) (amqp.Queue, error)
+	Close() error
+}
+
+// dial opens the AMQP connection; tests replace it to inspect the dial configuration
+var dial = amqp.DialConfig
+
+// DialConfig builds the connection settings from the configuration: the heartbeat interval, the
+// timeout for opening the connection, the handshake locale and TLS. A missed heartbeat closes the
+// connection, so a dead broker is noticed within about two intervals instead of on the next write.
+// As with amqp.DialTLS, TLS is only used for amqps:// URIs.
+func DialConfig(cfg *config.Config) (amqp.Config, error) {
+	tlsConfig, err := tlsconfig.New(cfg.RabbitMQTLS)
+	if err != nil {
+		return amqp.Config{}, fmt.Errorf("invalid RabbitMQ TLS configuration: %w", err)
+	}
+	dialConfig := amqp.Config{
+		Heartbeat:       cfg.RabbitMQHeartbeat,
+		Locale:          cfg.RabbitMQLocale,
+		TLSClientConfig: tlsConfig,
+	}
+	if cfg.RabbitMQConnectionTimeout > 0 {
+		dialConfig.Dial = amqp.DefaultDial(cfg.RabbitMQConnectionTimeout)
+	}
+	return dialConfig, nil
+}
+
+// DeadLetterQueue returns the name of the catch-all DLQ NewRabbitMQService declares for the queue,
+// bound to the fanout dead-letter exchange
+func DeadLetterQueue(queueName string) string {
+	return queueName + ".dlq"
+}
+
+func NewRabbitMQService(host, exchange, queueName string, dialConfig amqp.Config, consumer ConsumerOptions) (*RabbitMQServiceImpl, error) {
+	dialed, err := dial(host, dialConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
+	conn := amqpConnection{dialed}
 
 	ch, err := conn.Channel()
 	if err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("failed to open a channel: %w", err)
 	}
 
 	// Remove publisher confirmation for now to avoid timeout issues
 	// TODO: Implement proper publisher confirmation later if needed
 
-	err = ch.ExchangeDeclare(
+	service, err := newRabbitMQService(conn, ch, exchange, queueName, consumer)
+	if err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, err
+	}
+	service.server = serverInfo(dialed.Properties)
+	service.redial = func() (connection, error) {
+		dialed, err := dial(host, dialConfig)
+		if err != nil {
+			return nil, err
+		}
+		return amqpConnection{dialed}, nil
+	}
+	return service, nil
+}
+
+// newRabbitMQService declares the topology on the configured exchange and returns a service publishing to it
+func newRabbitMQService(conn connection, declared channel, exchange, queueName string, consumer ConsumerOptions) (*RabbitMQServiceImpl, error) {
+	var topology Topology
+	ch := recordingChannel{channel: declared, topology: &topology}
+
+	err := ch.ExchangeDeclare(
 		exchange,
 		"topic",
 		true,
@@ -53,14 +169,14 @@ func NewRabbitMQService(host, exchange, queueName string) (*RabbitMQServiceImpl,
 		return nil, fmt.Errorf("failed to declare a dead-letter exchange: %w", err)
 	}
 
-	dlqName := queueName + ".dlq"
+	dlqName := DeadLetterQueue(queueName)
 	_, err = ch.QueueDeclare(
 		dlqName,
 		true,
 		false,
 		false,
 		false,
-		nil,
+		consumer.queueArgs(dlqName, nil),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to declare a dead-letter queue: %w", err)
@@ -88,55 +204,53 @@ func NewRabbitMQService(host, exchange, queueName string) (*RabbitMQServiceImpl,
 		false,
 		false,
 		false,
-		args,
+		consumer.queueArgs(queueName, args),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to declare a queue: %w", err)
 	}
 
-	// Declare event-specific queues
-	eventQueues := []string{
-		"order.requested", // New: Initial order request queue
-		"order.created",
-		"order.cancelled",
-		"inventory.status.updated",
-		"notification.sent",
-	}
-
-	for _, eventQueue := range eventQueues {
+	// Declare the queues of every event type in events.Registry, see eventQueueArgs
+	for _, eventType := range events.Registry {
 		_, err = ch.QueueDeclare(
-			eventQueue,
+			eventType.Queue,
 			true,
 			false,
 			false,
 			false,
-			args,
+			consumer.queueArgs(eventType.Queue, eventQueueArgs(exchange, eventType.DLQ, consumer.messageTTL(eventType))),
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to declare event queue %s: %w", eventQueue, err)
+			return nil, fmt.Errorf("failed to declare event queue %s: %w", eventType.Queue, err)
 		}
 
 		// Bind queue to exchange with routing key
 		err = ch.QueueBind(
-			eventQueue, // queue name
-			eventQueue, // routing key (same as queue name)
-			exchange,   // exchange
+			eventType.Queue,             // queue name
+			eventType.QueueRoutingKey(), // routing key
+			exchange,                    // exchange
 			false,
 			nil,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to bind event queue %s: %w", eventQueue, err)
+			return nil, fmt.Errorf("failed to bind event queue %s: %w", eventType.Queue, err)
+		}
+
+		if eventType.DelayQueue != "" {
+			if err := declareDelayQueue(ch, exchange, eventType); err != nil {
+				return nil, err
+			}
 		}
 
 		// Declare DLQ for each event queue
-		dlqName := eventQueue + ".dlq"
+		dlqName := eventType.DLQ
 		_, err = ch.QueueDeclare(
 			dlqName,
 			true,
 			false,
 			false,
 			false,
-			nil,
+			consumer.queueArgs(dlqName, nil),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to declare DLQ %s: %w", dlqName, err)
@@ -156,15 +270,65 @@ func NewRabbitMQService(host, exchange, queueName string) (*RabbitMQServiceImpl,
 	}
 
 	return &RabbitMQServiceImpl{
-		conn:    conn,
-		channel: ch,
+		conn:     conn,
+		channel:  declared,
+		exchange: exchange,
+		topology: topology,
+		consumer: consumer,
 	}, nil
 }
 
+// eventQueueArgs returns the declaration arguments of an event queue. Messages the broker dead-letters,
+// rejected or expired after ttl, are routed through the topic exchange to the queue's own DLQ rather
+// than to the catch-all DLQ. Event queues are quorum queues so the broker counts redeliveries in the
+// x-delivery-count header, including those caused by a consumer disconnecting mid-message, which the
+// listener uses to stop requeueing poison messages.
+func eventQueueArgs(exchange, dlq string, ttl time.Duration) amqp.Table {
+	args := amqp.Table{
+		"x-queue-type":              "quorum",
+		"x-dead-letter-exchange":    exchange,
+		"x-dead-letter-routing-key": dlq,
+	}
+	if ttl > 0 {
+		args["x-message-ttl"] = ttl.Milliseconds()
+	}
+	return args
+}
+
+// declareDelayQueue declares the queue holding the messages of a delayed event type. It has no
+// consumer: each message expires after the event type's delay and is dead-lettered through the
+// exchange to the event queue.
+func declareDelayQueue(ch channel, exchange string, eventType events.EventType) error {
+	_, err := ch.QueueDeclare(
+		eventType.DelayQueue,
+		true,
+		false,
+		false,
+		false,
+		amqp.Table{
+			"x-dead-letter-exchange":    exchange,
+			"x-dead-letter-routing-key": eventType.QueueRoutingKey(),
+			"x-message-ttl":             eventType.Delay.Milliseconds(),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare delay queue %s: %w", eventType.DelayQueue, err)
+	}
+
+	err = ch.QueueBind(eventType.DelayQueue, eventType.RoutingKey, exchange, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to bind delay queue %s: %w", eventType.DelayQueue, err)
+	}
+	return nil
+}
+
 // Publish sends a message to a topic on the exchange with proper error handling.
-// The message is made persistent to ensure durability across broker restarts.
+// The body is wrapped in an events.Envelope whose type is the topic and whose correlation ID
+// continues that of the event being handled in ctx, if any.
+// The message is made persistent to ensure durability across broker restarts, and carries
+// the trace context of ctx in its headers so consumers continue the same trace.
 // Returns an error if the connection is closed or publishing fails.
-func (s *RabbitMQServiceImpl) Publish(topic string, body []byte) error {
+func (s *RabbitMQServiceImpl) Publish(ctx context.Context, topic string, body []byte) (err error) {
 	// Validate input parameters
 	if topic == "" {
 		return fmt.Errorf("topic cannot be empty")
@@ -174,24 +338,44 @@ func (s *RabbitMQServiceImpl) Publish(topic string, body []byte) error {
 	}
 
 	// Check connection health
-	if s.conn.IsClosed() {
+	conn, ch := s.link()
+	if conn.IsClosed() {
 		return fmt.Errorf("connection to RabbitMQ is closed")
 	}
-	if s.channel == nil {
+	if ch == nil {
 		return fmt.Errorf("channel is not initialized")
 	}
 
+	ctx, span := tracing.Tracer().Start(ctx, topic+" publish", trace.WithSpanKind(trace.SpanKindProducer))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	headers := amqp.Table{}
+	tracing.InjectAMQP(ctx, headers)
+
+	envelope := events.NewEnvelope(topic, events.CorrelationIDFromContext(ctx), body)
+	enveloped, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to wrap message for topic '%s': %w", topic, err)
+	}
+
 	// Publish the message
-	err := s.channel.Publish(
-		"order_events", // exchange
-		topic,          // routing key
-		false,          // mandatory
-		false,          // immediate
+	err = ch.Publish(
+		s.exchange, // exchange
+		topic,      // routing key
+		false,      // mandatory
+		false,      // immediate
 		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			DeliveryMode: amqp.Persistent,                        // Make message persistent for durability
-			MessageId:    fmt.Sprintf("%s_%d", topic, len(body)), // Simple message ID for tracking
+			ContentType:   "application/json",
+			Headers:       headers,
+			Body:          enveloped,
+			DeliveryMode:  amqp.Persistent, // Make message persistent for durability
+			MessageId:     envelope.EventID,
+			CorrelationId: envelope.CorrelationID,
+			Type:          envelope.EventType,
 		},
 	)
 	if err != nil {
@@ -204,35 +388,168 @@ func (s *RabbitMQServiceImpl) Publish(topic string, body []byte) error {
 	return nil
 }
 
-// Close closes the connection to RabbitMQ.
+// Republish publishes a consumed message again with the given routing key, keeping its body
+// and metadata as they are, e.g. to return a dead-lettered message to its source queue.
+// Unlike Publish it does not wrap the body in a new envelope.
+func (s *RabbitMQServiceImpl) Republish(ctx context.Context, routingKey string, msg amqp.Delivery) error {
+	conn, ch := s.link()
+	if conn.IsClosed() {
+		return fmt.Errorf("connection to RabbitMQ is closed")
+	}
+
+	headers := amqp.Table{}
+	for key, value := range msg.Headers {
+		if key != "x-death" { // The broker's record of earlier dead-lettering, not part of the message
+			headers[key] = value
+		}
+	}
+	err := ch.Publish(s.exchange, routingKey, false, false, amqp.Publishing{
+		ContentType:   msg.ContentType,
+		Headers:       headers,
+		Body:          msg.Body,
+		DeliveryMode:  amqp.Persistent,
+		MessageId:     msg.MessageId,
+		CorrelationId: msg.CorrelationId,
+		Type:          msg.Type,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to republish message to '%s': %w", routingKey, err)
+	}
+	return nil
+}
+
+// Close closes the channel and the connection to RabbitMQ. It is safe to call more than once
+// and on a service that was never connected, e.g. while unwinding a failed startup.
 func (s *RabbitMQServiceImpl) Close() {
-	s.channel.Close()
-	s.conn.Close()
+	if s == nil {
+		return
+	}
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.closed = true
+		if s.channel != nil {
+			s.channel.Close()
+		}
+		if s.conn != nil {
+			s.conn.Close()
+		}
+	})
 }
 
-// Consume starts consuming messages from a queue.
-func (s *RabbitMQServiceImpl) Consume(queueName string) (<-chan amqp.Delivery, error) {
-	// Check if connection and channel are still open
-	if s.conn.IsClosed() {
+// link returns the current connection and the channel publishing on it
+func (s *RabbitMQServiceImpl) link() (connection, channel) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.conn, s.channel
+}
+
+// reconnect replaces a closed connection, e.g. after a broker restart, with a new one and opens the
+// publishing channel on it. The declared exchanges and queues are durable and survive the restart,
+// so they are not declared again. It fails once the service is closed.
+func (s *RabbitMQServiceImpl) reconnect() (connection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.redial == nil {
 		return nil, fmt.Errorf("connection is closed")
 	}
+	if !s.conn.IsClosed() {
+		return s.conn, nil // Reopened by another queue's consumer in the meantime
+	}
 
-	msgs, err := s.channel.Consume(
-		queueName, // queue
-		"",        // consumer
-		false,     // auto-ack
-		false,     // exclusive
-		false,     // no-local
-		false,     // no-wait
-		nil,       // args
+	conn, err := s.redial()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconnect to RabbitMQ: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+	s.conn, s.channel = conn, ch
+	return conn, nil
+}
+
+// Consume starts consuming messages from a queue on a channel of its own, reopening the connection
+// first when it was closed. A channel error, e.g. from settling a delivery twice, then only closes
+// that queue's deliveries, and consuming the queue again starts over on a fresh channel.
+func (s *RabbitMQServiceImpl) Consume(queueName string) (<-chan amqp.Delivery, error) {
+	conn, _ := s.link()
+	if conn.IsClosed() {
+		var err error
+		if conn, err = s.reconnect(); err != nil {
+			return nil, err
+		}
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a consumer channel: %w", err)
+	}
+	msgs, err := ch.Consume(
+		queueName,                         // queue
+		s.consumer.consumerTag(queueName), // consumer
+		false,                             // auto-ack
+		false,                             // exclusive
+		false,                             // no-local
+		false,                             // no-wait
+		nil,                               // args
 	)
 	if err != nil {
+		ch.Close()
 		return nil, fmt.Errorf("failed to start consuming queue: %w", err)
 	}
 	return msgs, nil
 }
 
+// Get takes the next message from a queue without subscribing to it, reporting false when the
+// queue is empty. The message must be acknowledged or rejected by the caller.
+func (s *RabbitMQServiceImpl) Get(queueName string) (amqp.Delivery, bool, error) {
+	conn, ch := s.link()
+	if conn.IsClosed() {
+		return amqp.Delivery{}, false, fmt.Errorf("connection is closed")
+	}
+	msg, ok, err := ch.Get(queueName, false)
+	if err != nil {
+		return amqp.Delivery{}, false, fmt.Errorf("failed to get a message from queue %s: %w", queueName, err)
+	}
+	return msg, ok, nil
+}
+
 // IsHealthy checks if the RabbitMQ connection is healthy
 func (s *RabbitMQServiceImpl) IsHealthy() bool {
-	return !s.conn.IsClosed() && s.channel != nil
+	conn, ch := s.link()
+	return !conn.IsClosed() && ch != nil
+}
+
+// QueueDepth returns the number of messages ready in a queue.
+// The queue is inspected on its own channel because inspecting a missing queue closes the channel.
+func (s *RabbitMQServiceImpl) QueueDepth(queueName string) (int, error) {
+	conn, _ := s.link()
+	if conn.IsClosed() {
+		return 0, fmt.Errorf("connection is closed")
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open an inspection channel: %w", err)
+	}
+	defer ch.Close()
+
+	queue, err := ch.QueueInspect(queueName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect queue %s: %w", queueName, err)
+	}
+	return queue.Messages, nil
+}
+
+// Topology returns the exchanges, queues and bindings the service declared when it was created
+func (s *RabbitMQServiceImpl) Topology() Topology {
+	return s.topology
+}
+
+// ServerInfo returns the product, version, platform and cluster name the broker announced
+// when the connection opened
+func (s *RabbitMQServiceImpl) ServerInfo() map[string]any {
+	return s.server
 }
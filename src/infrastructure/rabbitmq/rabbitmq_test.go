@@ -0,0 +1,644 @@
+package rabbitmq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go-order-eda/src/config"
+	"go-order-eda/src/infrastructure/tlsconfig/tlsconfigtest"
+	"go-order-eda/src/services/events"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+type binding struct {
+	queue, key, exchange string
+}
+
+type publishing struct {
+	exchange, key string
+	msg           amqp.Publishing
+}
+
+// fakeChannel records the topology declared on it and the messages published through it
+type fakeChannel struct {
+	exchanges map[string]string // name -> kind
+	queues    map[string]amqp.Table
+	bindings  []binding
+	published []publishing
+	consumers map[string]string // Consumer tag per consumed queue
+	closes    int
+}
+
+func newFakeChannel() *fakeChannel {
+	return &fakeChannel{exchanges: make(map[string]string), queues: make(map[string]amqp.Table), consumers: make(map[string]string)}
+}
+
+func (c *fakeChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	c.exchanges[name] = kind
+	return nil
+}
+
+func (c *fakeChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	c.queues[name] = args
+	return amqp.Queue{Name: name}, nil
+}
+
+func (c *fakeChannel) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	c.bindings = append(c.bindings, binding{queue: name, key: key, exchange: exchange})
+	return nil
+}
+
+func (c *fakeChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	c.published = append(c.published, publishing{exchange: exchange, key: key, msg: msg})
+	return nil
+}
+
+func (c *fakeChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	c.consumers[queue] = consumer
+	return make(chan amqp.Delivery), nil
+}
+
+func (c *fakeChannel) Get(queue string, autoAck bool) (amqp.Delivery, bool, error) {
+	return amqp.Delivery{}, false, nil
+}
+
+func (c *fakeChannel) QueueInspect(name string) (amqp.Queue, error) {
+	return amqp.Queue{Name: name}, nil
+}
+
+func (c *fakeChannel) Close() error {
+	c.closes++
+	return nil
+}
+
+// fakeConnection is a connection opening ch as every channel, or failing to open one when ch is nil
+type fakeConnection struct {
+	ch     *fakeChannel
+	closed bool
+}
+
+func (c fakeConnection) Channel() (channel, error) {
+	if c.ch == nil {
+		return nil, amqp.ErrClosed
+	}
+	return c.ch, nil
+}
+
+func (c fakeConnection) IsClosed() bool { return c.closed }
+func (fakeConnection) Close() error     { return nil }
+
+// closingConnection is an open connection counting how often it is closed
+type closingConnection struct {
+	fakeConnection
+	closes int
+}
+
+func (c *closingConnection) Close() error {
+	c.closes++
+	return nil
+}
+
+func TestRabbitMQService_ConfiguredExchange(t *testing.T) {
+	const exchange = "shop_events"
+	ch := newFakeChannel()
+
+	service, err := newRabbitMQService(fakeConnection{}, ch, exchange, "shop_events_queue", ConsumerOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	t.Run("topology is declared on the configured exchange", func(t *testing.T) {
+		if ch.exchanges[exchange] != "topic" {
+			t.Errorf("Expected topic exchange %s, got %v", exchange, ch.exchanges)
+		}
+		for name := range ch.exchanges {
+			if !strings.HasPrefix(name, exchange) {
+				t.Errorf("Unexpected exchange %s", name)
+			}
+		}
+		for _, b := range ch.bindings {
+			if !strings.HasPrefix(b.exchange, exchange) {
+				t.Errorf("Queue %s bound to %s instead of the configured exchange", b.queue, b.exchange)
+			}
+		}
+	})
+
+	t.Run("publishes target the configured exchange", func(t *testing.T) {
+		if err := service.Publish(context.Background(), "order.created", []byte(`{}`)); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+		if len(ch.published) != 1 {
+			t.Fatalf("Expected one publish, got %d", len(ch.published))
+		}
+		if got := ch.published[0]; got.exchange != exchange || got.key != "order.created" {
+			t.Errorf("Expected publish to %s with key order.created, got %s with key %s", exchange, got.exchange, got.key)
+		}
+	})
+
+	t.Run("publishes are wrapped in an envelope", func(t *testing.T) {
+		ctx := events.ContextWithCorrelationID(context.Background(), "correlation-1")
+		if err := service.Publish(ctx, "inventory.status.updated", []byte(`{"orderId":"order-1"}`)); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+		msg := ch.published[len(ch.published)-1].msg
+
+		envelope, ok := events.DecodeEnvelope(msg.Body)
+		if !ok {
+			t.Fatalf("Expected an enveloped body, got %s", msg.Body)
+		}
+		if envelope.EventType != "inventory.status.updated" || envelope.CorrelationID != "correlation-1" {
+			t.Errorf("Unexpected envelope metadata: %+v", envelope)
+		}
+		if string(envelope.Payload) != `{"orderId":"order-1"}` {
+			t.Errorf("Unexpected payload: %s", envelope.Payload)
+		}
+		if msg.MessageId != envelope.EventID || msg.CorrelationId != "correlation-1" {
+			t.Errorf("Expected AMQP properties to mirror the envelope, got message ID %q and correlation ID %q", msg.MessageId, msg.CorrelationId)
+		}
+	})
+
+	t.Log("✅ Topology and publishes use the configured exchange")
+}
+
+// deadLetterRoute returns the queues a message rejected from queue is routed to, following the
+// queue's dead-letter arguments and the bindings of the dead-letter exchange
+func (c *fakeChannel) deadLetterRoute(queue string) []string {
+	args := c.queues[queue]
+	dlx, _ := args["x-dead-letter-exchange"].(string)
+	if dlx == "" {
+		return nil
+	}
+	// Without an explicit dead-letter routing key the message keeps its original one
+	key, ok := args["x-dead-letter-routing-key"].(string)
+	if !ok {
+		key = queue
+	}
+
+	var routed []string
+	for _, b := range c.bindings {
+		if b.exchange == dlx && (c.exchanges[dlx] == "fanout" || b.key == key) {
+			routed = append(routed, b.queue)
+		}
+	}
+	return routed
+}
+
+func TestRabbitMQService_DeadLetterRouting(t *testing.T) {
+	ch := newFakeChannel()
+	if _, err := newRabbitMQService(fakeConnection{}, ch, "order_events", "order_events_queue", ConsumerOptions{}); err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	for _, eventType := range events.Registry {
+		t.Run(eventType.Queue, func(t *testing.T) {
+			routed := ch.deadLetterRoute(eventType.Queue)
+			if len(routed) != 1 || routed[0] != eventType.DLQ {
+				t.Errorf("Expected a rejected message to reach %s, got %v", eventType.DLQ, routed)
+			}
+		})
+	}
+
+	t.Run("main queue keeps the catch-all DLQ", func(t *testing.T) {
+		routed := ch.deadLetterRoute("order_events_queue")
+		if len(routed) != 1 || routed[0] != "order_events_queue.dlq" {
+			t.Errorf("Expected order_events_queue.dlq, got %v", routed)
+		}
+	})
+
+	t.Log("✅ Rejected messages reach their per-event DLQ")
+}
+
+func TestRabbitMQService_DelayedRetry(t *testing.T) {
+	ch := newFakeChannel()
+	if _, err := newRabbitMQService(fakeConnection{}, ch, "order_events", "order_events_queue", ConsumerOptions{}); err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	retry, _ := events.LookupEventType(events.NotificationRetry)
+
+	args := ch.queues[retry.DelayQueue]
+	if ttl, ok := args["x-message-ttl"].(int64); !ok || ttl != retry.Delay.Milliseconds() {
+		t.Errorf("Expected x-message-ttl %d on %s, got %v", retry.Delay.Milliseconds(), retry.DelayQueue, args["x-message-ttl"])
+	}
+	// An expired message goes on to the retry queue, not to a DLQ
+	if routed := ch.deadLetterRoute(retry.DelayQueue); len(routed) != 1 || routed[0] != retry.Queue {
+		t.Errorf("Expected a held message to reach %s, got %v", retry.Queue, routed)
+	}
+
+	t.Log("✅ Notification retries are held for their delay before they are handled")
+}
+
+func TestRabbitMQService_MessageTTL(t *testing.T) {
+	ch := newFakeChannel()
+	_, err := newRabbitMQService(fakeConnection{}, ch, "order_events", "order_events_queue", ConsumerOptions{
+		MessageTTL: map[string]time.Duration{events.NotificationSent: 1500 * time.Millisecond, "unknown.queue": time.Second},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	t.Run("configured queue expires messages to its DLQ", func(t *testing.T) {
+		args := ch.queues[events.NotificationSent]
+		if ttl, ok := args["x-message-ttl"].(int64); !ok || ttl != 1500 {
+			t.Errorf("Expected x-message-ttl 1500, got %v", args["x-message-ttl"])
+		}
+		// An expired message is dead-lettered like a rejected one
+		if routed := ch.deadLetterRoute(events.NotificationSent); len(routed) != 1 || routed[0] != events.NotificationSent+".dlq" {
+			t.Errorf("Expected an expired message to reach %s.dlq, got %v", events.NotificationSent, routed)
+		}
+	})
+
+	t.Run("other queues keep their messages", func(t *testing.T) {
+		for queue, args := range ch.queues {
+			if _, ok := args["x-message-ttl"]; ok && queue != events.NotificationSent && !strings.HasSuffix(queue, ".delay") {
+				t.Errorf("Expected no TTL on %s, got %v", queue, args)
+			}
+		}
+		if _, ok := ch.queues["unknown.queue"]; ok {
+			t.Error("Expected no queue declared for an unknown TTL entry")
+		}
+	})
+
+	t.Run("the registry default is overridden by the options", func(t *testing.T) {
+		eventType := events.EventType{Queue: "notification.sent", MessageTTL: time.Minute}
+		if ttl := (ConsumerOptions{}).messageTTL(eventType); ttl != time.Minute {
+			t.Errorf("Expected the registry TTL, got %s", ttl)
+		}
+		options := ConsumerOptions{MessageTTL: map[string]time.Duration{"notification.sent": 0}}
+		if ttl := options.messageTTL(eventType); ttl != 0 {
+			t.Errorf("Expected the configured TTL to turn it off, got %s", ttl)
+		}
+	})
+
+	t.Log("✅ Per-queue message TTL declared and dead-lettered to the queue's DLQ")
+}
+
+func TestRabbitMQService_TopologyMatchesRegistry(t *testing.T) {
+	const exchange = "order_events"
+	ch := newFakeChannel()
+	if _, err := newRabbitMQService(fakeConnection{}, ch, exchange, "order_events_queue", ConsumerOptions{}); err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	bound := func(queue, key string) bool {
+		for _, b := range ch.bindings {
+			if b.queue == queue && b.key == key && b.exchange == exchange {
+				return true
+			}
+		}
+		return false
+	}
+
+	registered := map[string]bool{"order_events_queue": true, "order_events_queue.dlq": true}
+	for _, eventType := range events.Registry {
+		registered[eventType.Queue], registered[eventType.DLQ] = true, true
+
+		if _, ok := ch.queues[eventType.Queue]; !ok {
+			t.Errorf("Queue %s of %s not declared", eventType.Queue, eventType.Name)
+		}
+		if _, ok := ch.queues[eventType.DLQ]; !ok {
+			t.Errorf("DLQ %s of %s not declared", eventType.DLQ, eventType.Name)
+		}
+		if !bound(eventType.Queue, eventType.QueueRoutingKey()) {
+			t.Errorf("Queue %s not bound with routing key %s", eventType.Queue, eventType.QueueRoutingKey())
+		}
+		if !bound(eventType.DLQ, eventType.DLQ) {
+			t.Errorf("DLQ %s not bound to the exchange", eventType.DLQ)
+		}
+		if eventType.DelayQueue != "" {
+			registered[eventType.DelayQueue] = true
+			if !bound(eventType.DelayQueue, eventType.RoutingKey) {
+				t.Errorf("Delay queue %s not bound with routing key %s", eventType.DelayQueue, eventType.RoutingKey)
+			}
+		}
+	}
+
+	for queue := range ch.queues {
+		if !registered[queue] {
+			t.Errorf("Queue %s declared but not in events.Registry", queue)
+		}
+	}
+
+	t.Log("✅ Declared topology matches events.Registry")
+}
+
+func TestRabbitMQService_Topology(t *testing.T) {
+	ch := newFakeChannel()
+	service, err := newRabbitMQService(fakeConnection{}, ch, "order_events", "order_events_queue", ConsumerOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	topology := service.Topology()
+	if len(topology.Exchanges) != len(ch.exchanges) || topology.Exchanges[0] != "order_events" || topology.Exchanges[1] != "order_events.dlx" {
+		t.Errorf("Expected the declared exchanges, got %v", topology.Exchanges)
+	}
+	if len(topology.Queues) != len(ch.queues) {
+		t.Errorf("Expected %d queues, got %v", len(ch.queues), topology.Queues)
+	}
+	for _, queue := range topology.Queues {
+		if _, ok := ch.queues[queue]; !ok {
+			t.Errorf("Queue %s reported but not declared", queue)
+		}
+	}
+	if len(topology.Bindings) != len(ch.bindings) {
+		t.Fatalf("Expected %d bindings, got %d", len(ch.bindings), len(topology.Bindings))
+	}
+	for i, b := range ch.bindings {
+		if got := topology.Bindings[i]; got.Queue != b.queue || got.RoutingKey != b.key || got.Exchange != b.exchange {
+			t.Errorf("Expected binding %+v, got %+v", b, got)
+		}
+	}
+
+	info := serverInfo(amqp.Table{"product": "RabbitMQ", "version": "3.13.7", "capabilities": amqp.Table{"publisher_confirms": true}})
+	if len(info) != 2 || info["product"] != "RabbitMQ" || info["version"] != "3.13.7" {
+		t.Errorf("Expected only the product and version, got %v", info)
+	}
+
+	t.Log("✅ Declared topology and broker identity reported")
+}
+
+func TestRabbitMQService_ConsumerOptions(t *testing.T) {
+	t.Run("tag and single active consumer applied", func(t *testing.T) {
+		ch := newFakeChannel()
+		service, err := newRabbitMQService(fakeConnection{ch: ch}, ch, "order_events", "order_events_queue", ConsumerOptions{
+			Tag:                "orders-1",
+			SingleActiveQueues: []string{events.OrderCreated, "order_events_queue"},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create service: %v", err)
+		}
+
+		created := ch.queues[events.OrderCreated]
+		if created["x-single-active-consumer"] != true || created["x-queue-type"] != "quorum" {
+			t.Errorf("Expected %s to keep its arguments and have a single active consumer, got %v", events.OrderCreated, created)
+		}
+		if main := ch.queues["order_events_queue"]; main["x-single-active-consumer"] != true || main["x-dead-letter-exchange"] != "order_events.dlx" {
+			t.Errorf("Expected order_events_queue to keep its arguments and have a single active consumer, got %v", main)
+		}
+		for queue, args := range ch.queues {
+			if queue != events.OrderCreated && queue != "order_events_queue" && args["x-single-active-consumer"] != nil {
+				t.Errorf("Expected %s to allow several consumers, got %v", queue, args)
+			}
+		}
+
+		if _, err := service.Consume(events.OrderCreated); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+		if tag := ch.consumers[events.OrderCreated]; tag != "orders-1."+events.OrderCreated {
+			t.Errorf("Expected consumer tag orders-1.%s, got %q", events.OrderCreated, tag)
+		}
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		ch := newFakeChannel()
+		service, err := newRabbitMQService(fakeConnection{ch: ch}, ch, "order_events", "order_events_queue", ConsumerOptions{})
+		if err != nil {
+			t.Fatalf("Failed to create service: %v", err)
+		}
+		for queue, args := range ch.queues {
+			if args["x-single-active-consumer"] != nil {
+				t.Errorf("Expected %s to allow several consumers, got %v", queue, args)
+			}
+		}
+		if _, err := service.Consume(events.OrderCreated); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+		if tag, ok := ch.consumers[events.OrderCreated]; !ok || tag != "" {
+			t.Errorf("Expected the broker to generate the consumer tag, got %q", tag)
+		}
+	})
+
+	t.Run("built from the configuration", func(t *testing.T) {
+		options := NewConsumerOptions(&config.Config{
+			RabbitMQConsumerTag:        "orders-1",
+			RabbitMQSingleActiveQueues: []string{events.OrderCreated},
+			RabbitMQQueueTTL:           map[string]time.Duration{events.NotificationSent: time.Minute},
+		})
+		ch := newFakeChannel()
+		if _, err := newRabbitMQService(fakeConnection{}, ch, "order_events", "order_events_queue", options); err != nil {
+			t.Fatalf("Failed to create service: %v", err)
+		}
+		if ch.queues[events.OrderCreated]["x-single-active-consumer"] != true {
+			t.Errorf("Expected %s to have a single active consumer, got %v", events.OrderCreated, ch.queues[events.OrderCreated])
+		}
+		if ttl, ok := ch.queues[events.NotificationSent]["x-message-ttl"].(int64); !ok || ttl != time.Minute.Milliseconds() {
+			t.Errorf("Expected the configured TTL on %s, got %v", events.NotificationSent, ch.queues[events.NotificationSent])
+		}
+	})
+
+	t.Log("✅ Consumer tag and single active consumer applied")
+}
+
+func TestDialConfig(t *testing.T) {
+	cfg := &config.Config{
+		RabbitMQHeartbeat:         5 * time.Second,
+		RabbitMQConnectionTimeout: 50 * time.Millisecond,
+		RabbitMQLocale:            "en_US",
+	}
+
+	t.Run("config values reach the dial", func(t *testing.T) {
+		var dialed amqp.Config
+		errDial := errors.New("dial refused")
+		dial = func(url string, dialConfig amqp.Config) (*amqp.Connection, error) {
+			dialed = dialConfig
+			return nil, errDial
+		}
+		t.Cleanup(func() { dial = amqp.DialConfig })
+
+		if _, err := NewRabbitMQService("amqp://localhost:5672/", "order_events", "order_events_queue", mustDialConfig(t, cfg), ConsumerOptions{}); !errors.Is(err, errDial) {
+			t.Fatalf("Expected the dial error, got %v", err)
+		}
+		if dialed.Heartbeat != 5*time.Second {
+			t.Errorf("Expected heartbeat 5s, got %s", dialed.Heartbeat)
+		}
+		if dialed.Locale != "en_US" {
+			t.Errorf("Expected locale en_US, got %q", dialed.Locale)
+		}
+		if dialed.Dial == nil {
+			t.Fatal("Expected a dial function bounded by the connection timeout")
+		}
+	})
+
+	t.Run("connection timeout bounds the handshake", func(t *testing.T) {
+		// A broker that accepts the connection but never answers the handshake
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		defer listener.Close()
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			if conn, err := listener.Accept(); err == nil {
+				defer conn.Close()
+				<-done
+			}
+		}()
+
+		conn, err := mustDialConfig(t, cfg).Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer conn.Close()
+
+		start := time.Now()
+		var netErr net.Error
+		if _, err := conn.Read(make([]byte, 1)); !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Fatalf("Expected a timeout, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected the read to time out after about 50ms, took %s", elapsed)
+		}
+	})
+
+	t.Run("unset timeout keeps the library default", func(t *testing.T) {
+		if dialConfig := mustDialConfig(t, &config.Config{}); dialConfig.Dial != nil || dialConfig.TLSClientConfig != nil {
+			t.Error("Expected no dial function or TLS, so amqp applies its defaults")
+		}
+	})
+
+	t.Run("TLS from the certificate files", func(t *testing.T) {
+		certs := tlsconfigtest.Write(t)
+		tlsConfig := mustDialConfig(t, &config.Config{RabbitMQTLS: certs.TLS}).TLSClientConfig
+		if tlsConfig == nil || tlsConfig.RootCAs == nil || len(tlsConfig.Certificates) != 1 {
+			t.Fatalf("Expected a TLS configuration with the CA and client certificate, got %+v", tlsConfig)
+		}
+		if !bytes.Equal(tlsConfig.Certificates[0].Certificate[0], certs.Client.Raw) {
+			t.Errorf("Expected the client certificate from %s", certs.CertFile)
+		}
+
+		if _, err := DialConfig(&config.Config{RabbitMQTLS: config.TLS{CAFile: certs.KeyFile}}); err == nil {
+			t.Error("Expected an error for a CA file without certificates")
+		}
+	})
+
+	t.Log("✅ Heartbeat, connection timeout, locale and TLS passed to the AMQP dial")
+}
+
+// mustDialConfig builds the dial configuration or fails the test
+func mustDialConfig(t *testing.T, cfg *config.Config) amqp.Config {
+	t.Helper()
+	dialConfig, err := DialConfig(cfg)
+	if err != nil {
+		t.Fatalf("DialConfig failed: %v", err)
+	}
+	return dialConfig
+}
+
+// openingConnection opens a new fake channel on every call, keeping them in the order they were opened
+type openingConnection struct {
+	opened []*fakeChannel
+	closed bool
+}
+
+func (c *openingConnection) Channel() (channel, error) {
+	ch := newFakeChannel()
+	c.opened = append(c.opened, ch)
+	return ch, nil
+}
+
+func (c *openingConnection) IsClosed() bool { return c.closed }
+func (c *openingConnection) Close() error   { c.closed = true; return nil }
+
+func TestRabbitMQService_Consume(t *testing.T) {
+	// newService returns a service on conn that reopens its connection as redialed
+	newService := func(t *testing.T, conn connection, redialed *openingConnection) *RabbitMQServiceImpl {
+		t.Helper()
+		service, err := newRabbitMQService(conn, newFakeChannel(), "order_events", "order_events_queue", ConsumerOptions{})
+		if err != nil {
+			t.Fatalf("Failed to create service: %v", err)
+		}
+		service.redial = func() (connection, error) { return redialed, nil }
+		return service
+	}
+
+	t.Run("every queue consumed on a channel of its own", func(t *testing.T) {
+		conn := &openingConnection{}
+		service := newService(t, conn, nil)
+
+		for _, queue := range []string{events.OrderCreated, events.OrderCreated, events.OrderCancelled} {
+			if _, err := service.Consume(queue); err != nil {
+				t.Fatalf("Consume failed: %v", err)
+			}
+		}
+		if len(conn.opened) != 3 {
+			t.Fatalf("Expected a fresh channel per Consume, got %d channels", len(conn.opened))
+		}
+		if _, ok := conn.opened[1].consumers[events.OrderCreated]; !ok {
+			t.Errorf("Expected %s consumed again on the second channel", events.OrderCreated)
+		}
+	})
+
+	t.Run("closed connection reopened", func(t *testing.T) {
+		redialed := &openingConnection{}
+		service := newService(t, &openingConnection{closed: true}, redialed)
+
+		if _, err := service.Consume(events.OrderCreated); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+		// The publishing channel is opened first, then the queue's channel
+		if len(redialed.opened) != 2 {
+			t.Fatalf("Expected the publishing and consumer channels opened on the new connection, got %d", len(redialed.opened))
+		}
+		if _, ok := redialed.opened[1].consumers[events.OrderCreated]; !ok {
+			t.Errorf("Expected %s consumed on the new connection", events.OrderCreated)
+		}
+		if err := service.Publish(context.Background(), events.OrderCreated, []byte(`{}`)); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+		if len(redialed.opened[0].published) != 1 {
+			t.Error("Expected publishing to continue on the new connection")
+		}
+	})
+
+	t.Run("not reopened once closed", func(t *testing.T) {
+		redialed := &openingConnection{}
+		conn := &openingConnection{}
+		service := newService(t, conn, redialed)
+		service.Close()
+
+		if _, err := service.Consume(events.OrderCreated); err == nil {
+			t.Error("Expected Consume to fail on a closed service")
+		}
+		if len(redialed.opened) != 0 {
+			t.Error("Expected no new connection after Close")
+		}
+	})
+
+	t.Log("✅ Queues consumed on channels of their own and the connection reopened when closed")
+}
+
+func TestRabbitMQService_Close(t *testing.T) {
+	t.Run("closing twice closes once", func(t *testing.T) {
+		conn, ch := &closingConnection{}, newFakeChannel()
+		service, err := newRabbitMQService(conn, ch, "order_events", "order_events_queue", ConsumerOptions{})
+		if err != nil {
+			t.Fatalf("Failed to create service: %v", err)
+		}
+
+		service.Close()
+		service.Close()
+
+		if ch.closes != 1 || conn.closes != 1 {
+			t.Errorf("Expected the channel and connection to be closed once, got %d and %d", ch.closes, conn.closes)
+		}
+	})
+
+	t.Run("zero-value service", func(t *testing.T) {
+		var service RabbitMQServiceImpl
+		service.Close()
+		service.Close()
+	})
+
+	t.Run("nil service", func(t *testing.T) {
+		var service *RabbitMQServiceImpl
+		service.Close()
+	})
+
+	t.Log("✅ Close is idempotent and safe without a connection")
+}
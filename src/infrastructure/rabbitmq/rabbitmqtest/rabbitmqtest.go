@@ -0,0 +1,182 @@
+// Package rabbitmqtest provides an in-memory broker for testing code that publishes
+// or consumes messages without a running RabbitMQ.
+package rabbitmqtest
+
+import (
+	"context"
+	"go-order-eda/src/infrastructure/rabbitmq"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+var (
+	_ rabbitmq.Publisher = (*Broker)(nil)
+	_ rabbitmq.Consumer  = (*Broker)(nil)
+)
+
+// Message is a message published to the broker
+type Message struct {
+	Topic string
+	Body  []byte
+}
+
+// Broker records published messages and feeds deliveries to consumers.
+// Published messages are not routed to queues; tests call Deliver to hand a message to a consumer.
+type Broker struct {
+	mu        sync.Mutex
+	published []Message
+	attempts  int
+	failures  int
+	failErr   error
+	queues    map[string]chan amqp.Delivery
+}
+
+func NewBroker() *Broker {
+	return &Broker{queues: make(map[string]chan amqp.Delivery)}
+}
+
+// FailPublishes makes the next n calls to Publish return err without recording the message
+func (b *Broker) FailPublishes(n int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures, b.failErr = n, err
+}
+
+// Publish records the message, or fails if FailPublishes has failures left
+func (b *Broker) Publish(ctx context.Context, topic string, body []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.attempts++
+	if b.failures > 0 {
+		b.failures--
+		return b.failErr
+	}
+	b.published = append(b.published, Message{Topic: topic, Body: body})
+	return nil
+}
+
+// Published returns the bodies published to topic, in order
+func (b *Broker) Published(topic string) [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var bodies [][]byte
+	for _, msg := range b.published {
+		if msg.Topic == topic {
+			bodies = append(bodies, msg.Body)
+		}
+	}
+	return bodies
+}
+
+// Attempts returns how many times Publish was called, including failed calls
+func (b *Broker) Attempts() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.attempts
+}
+
+// Messages returns every published message, in order
+func (b *Broker) Messages() []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Message(nil), b.published...)
+}
+
+// Consume returns the delivery channel of a queue; every call for the same queue shares one channel
+func (b *Broker) Consume(queueName string) (<-chan amqp.Delivery, error) {
+	return b.queue(queueName), nil
+}
+
+// Republish records the message as published with the routing key, keeping its body as it is
+func (b *Broker) Republish(ctx context.Context, routingKey string, msg amqp.Delivery) error {
+	return b.Publish(ctx, routingKey, msg.Body)
+}
+
+// Get takes the next undelivered message of a queue, reporting false when there is none
+func (b *Broker) Get(queueName string) (amqp.Delivery, bool, error) {
+	select {
+	case msg := <-b.queue(queueName):
+		return msg, true, nil
+	default:
+		return amqp.Delivery{}, false, nil
+	}
+}
+
+// QueueDepth returns the number of undelivered messages of a queue
+func (b *Broker) QueueDepth(queueName string) (int, error) {
+	return len(b.queue(queueName)), nil
+}
+
+// Deliver hands a message to the consumer of a queue and returns the acknowledger that records how it was settled.
+// The queue buffers up to 100 undelivered messages.
+func (b *Broker) Deliver(queueName string, body []byte) *Acknowledger {
+	return b.DeliverWithHeaders(queueName, body, nil)
+}
+
+// DeliverWithHeaders is Deliver for a message carrying AMQP headers, e.g. the x-death entries
+// of a message the broker dead-lettered
+func (b *Broker) DeliverWithHeaders(queueName string, body []byte, headers amqp.Table) *Acknowledger {
+	ack := &Acknowledger{settled: make(chan struct{})}
+	b.queue(queueName) <- amqp.Delivery{Acknowledger: ack, Body: body, RoutingKey: queueName, Headers: headers}
+	return ack
+}
+
+func (b *Broker) queue(name string) chan amqp.Delivery {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.queues[name]; !ok {
+		b.queues[name] = make(chan amqp.Delivery, 100)
+	}
+	return b.queues[name]
+}
+
+// Acknowledger records how a delivery was settled
+type Acknowledger struct {
+	mu       sync.Mutex
+	acked    bool
+	requeued bool
+	once     sync.Once
+	settled  chan struct{}
+}
+
+func (a *Acknowledger) Ack(tag uint64, multiple bool) error {
+	a.settle(true, false)
+	return nil
+}
+
+func (a *Acknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	a.settle(false, requeue)
+	return nil
+}
+
+func (a *Acknowledger) Reject(tag uint64, requeue bool) error {
+	a.settle(false, requeue)
+	return nil
+}
+
+func (a *Acknowledger) settle(acked, requeued bool) {
+	a.mu.Lock()
+	a.acked, a.requeued = acked, requeued
+	a.mu.Unlock()
+	a.once.Do(func() { close(a.settled) })
+}
+
+// Settled is closed once the delivery has been acked, nacked or rejected
+func (a *Acknowledger) Settled() <-chan struct{} {
+	return a.settled
+}
+
+// Acked reports whether the delivery was acknowledged
+func (a *Acknowledger) Acked() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.acked
+}
+
+// Requeued reports whether the delivery was nacked or rejected with requeue
+func (a *Acknowledger) Requeued() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.requeued
+}
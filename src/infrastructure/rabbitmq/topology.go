@@ -0,0 +1,63 @@
+package rabbitmq
+
+import "github.com/streadway/amqp"
+
+// Binding routes the messages published to Exchange with RoutingKey to Queue
+type Binding struct {
+	Queue      string `json:"queue"`
+	Exchange   string `json:"exchange"`
+	RoutingKey string `json:"routingKey"`
+}
+
+// Topology is what the service declared on the broker, in declaration order
+type Topology struct {
+	Exchanges []string  `json:"exchanges"`
+	Queues    []string  `json:"queues"`
+	Bindings  []Binding `json:"bindings"`
+}
+
+// recordingChannel records the topology successfully declared through the channel
+type recordingChannel struct {
+	channel
+	topology *Topology
+}
+
+func (c recordingChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	if err := c.channel.ExchangeDeclare(name, kind, durable, autoDelete, internal, noWait, args); err != nil {
+		return err
+	}
+	c.topology.Exchanges = append(c.topology.Exchanges, name)
+	return nil
+}
+
+func (c recordingChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	queue, err := c.channel.QueueDeclare(name, durable, autoDelete, exclusive, noWait, args)
+	if err != nil {
+		return queue, err
+	}
+	c.topology.Queues = append(c.topology.Queues, name)
+	return queue, nil
+}
+
+func (c recordingChannel) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	if err := c.channel.QueueBind(name, key, exchange, noWait, args); err != nil {
+		return err
+	}
+	c.topology.Bindings = append(c.topology.Bindings, Binding{Queue: name, Exchange: exchange, RoutingKey: key})
+	return nil
+}
+
+// serverInfoKeys are the server properties identifying the broker; the others, such as its
+// capabilities, are left out of ServerInfo
+var serverInfoKeys = []string{"product", "version", "platform", "cluster_name"}
+
+// serverInfo picks the properties identifying the broker from those it announced
+func serverInfo(properties amqp.Table) map[string]any {
+	info := make(map[string]any)
+	for _, key := range serverInfoKeys {
+		if value, ok := properties[key]; ok {
+			info[key] = value
+		}
+	}
+	return info
+}
@@ -0,0 +1,86 @@
+// Package retry retries operations with a jittered exponential backoff.
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes the delays between attempts: Base doubled after every attempt, capped at Max,
+// and shortened by a random fraction of up to Jitter so that callers failing together do not
+// retry together.
+type Policy struct {
+	Base   time.Duration // Delay after the first attempt
+	Max    time.Duration // Upper bound of any delay; 0 leaves delays uncapped
+	Jitter float64       // Largest fraction of a delay removed at random, between 0 and 1
+	// Wait blocks for d or until ctx is done, returning ctx's error in that case.
+	// Nil waits on a timer; tests replace it to skip or record the delays.
+	Wait func(ctx context.Context, d time.Duration) error
+}
+
+// Default backs off from 1s to at most 30s, with up to 20% jitter
+var Default = Policy{Base: time.Second, Max: 30 * time.Second, Jitter: 0.2}
+
+// Backoff returns the delay after the given attempt, counted from 1, under the Default policy
+func Backoff(attempt int) time.Duration {
+	return Default.Backoff(attempt)
+}
+
+// Do calls fn up to maxAttempts times under the Default policy; see Policy.Do
+func Do(ctx context.Context, maxAttempts int, fn func(attempt int) error) error {
+	return Default.Do(ctx, maxAttempts, fn)
+}
+
+// Backoff returns the delay after the given attempt, counted from 1.
+// The delay lies between (1-Jitter) and 1 times min(Base*2^(attempt-1), Max).
+func (p Policy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := p.Base
+	for i := 1; i < attempt && (p.Max <= 0 || delay < p.Max); i++ {
+		delay *= 2
+	}
+	if p.Max > 0 && delay > p.Max {
+		delay = p.Max
+	}
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+	return delay
+}
+
+// Sleep waits for the backoff after the given attempt, returning early with ctx's error when it is done
+func (p Policy) Sleep(ctx context.Context, attempt int) error {
+	if p.Wait != nil {
+		return p.Wait(ctx, p.Backoff(attempt))
+	}
+	timer := time.NewTimer(p.Backoff(attempt))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Do calls fn until it succeeds or maxAttempts attempts failed, sleeping the backoff in between.
+// It returns nil on success, the last error of fn once attempts run out, or ctx's error when
+// ctx is done before the next attempt, in which case no further attempts are made.
+func (p Policy) Do(ctx context.Context, maxAttempts int, fn func(attempt int) error) error {
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = fn(attempt); err == nil {
+			return nil
+		}
+		if attempt == maxAttempts {
+			break
+		}
+		if waitErr := p.Sleep(ctx, attempt); waitErr != nil {
+			return waitErr
+		}
+	}
+	return err
+}
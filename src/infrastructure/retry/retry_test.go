@@ -0,0 +1,114 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPolicy_Backoff(t *testing.T) {
+	t.Run("doubles from the base up to the cap", func(t *testing.T) {
+		policy := Policy{Base: 100 * time.Millisecond, Max: time.Second}
+		want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+		for i, expected := range want {
+			if got := policy.Backoff(i + 1); got != expected {
+				t.Errorf("Attempt %d: expected %s, got %s", i+1, expected, got)
+			}
+		}
+		if got := policy.Backoff(1000); got != time.Second {
+			t.Errorf("Expected a large attempt to stay at the cap, got %s", got)
+		}
+	})
+
+	t.Run("jitter stays within its range", func(t *testing.T) {
+		policy := Policy{Base: time.Second, Max: 8 * time.Second, Jitter: 0.25}
+		for attempt := 1; attempt <= 6; attempt++ {
+			ceiling := min(time.Second<<(attempt-1), 8*time.Second)
+			floor := ceiling - ceiling/4
+			varied := false
+			first := policy.Backoff(attempt)
+			for i := 0; i < 200; i++ {
+				got := policy.Backoff(attempt)
+				if got < floor || got > ceiling {
+					t.Fatalf("Attempt %d: %s outside [%s, %s]", attempt, got, floor, ceiling)
+				}
+				varied = varied || got != first
+			}
+			if !varied {
+				t.Errorf("Attempt %d: expected jittered delays to vary", attempt)
+			}
+		}
+	})
+
+	t.Log("✅ Backoff grows, caps and jitters within bounds")
+}
+
+func TestPolicy_Do(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	t.Run("stops at the first success", func(t *testing.T) {
+		var waits []time.Duration
+		policy := Policy{Base: time.Second, Wait: func(ctx context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		}}
+
+		calls := 0
+		err := policy.Do(context.Background(), 5, func(attempt int) error {
+			calls++
+			if attempt < 3 {
+				return errFailed
+			}
+			return nil
+		})
+		if err != nil || calls != 3 {
+			t.Fatalf("Expected success on the third call, got %d calls and %v", calls, err)
+		}
+		if len(waits) != 2 || waits[0] != time.Second || waits[1] != 2*time.Second {
+			t.Errorf("Expected waits [1s 2s], got %v", waits)
+		}
+	})
+
+	t.Run("returns the last error once attempts run out", func(t *testing.T) {
+		policy := Policy{Wait: func(ctx context.Context, d time.Duration) error { return nil }}
+		calls := 0
+		err := policy.Do(context.Background(), 3, func(attempt int) error {
+			calls++
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) || calls != 3 {
+			t.Errorf("Expected 3 calls and the last error, got %d calls and %v", calls, err)
+		}
+	})
+
+	t.Run("cancellation aborts the remaining attempts", func(t *testing.T) {
+		policy := Policy{Base: time.Minute}
+		ctx, cancel := context.WithCancel(context.Background())
+
+		calls := 0
+		done := make(chan error, 1)
+		go func() {
+			done <- policy.Do(ctx, 5, func(attempt int) error {
+				calls++
+				return errFailed
+			})
+		}()
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Do kept waiting after the context was cancelled")
+		}
+		if calls != 1 {
+			t.Errorf("Expected 1 call before cancellation, got %d", calls)
+		}
+	})
+
+	t.Log("✅ Do retries, gives up and honours cancellation")
+}
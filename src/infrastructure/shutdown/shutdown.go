@@ -0,0 +1,68 @@
+// Package shutdown stops the application's components in a fixed order.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-order-eda/src/infrastructure/log"
+	"time"
+)
+
+// StopFunc stops one component. It should return once the component has stopped or ctx is done.
+type StopFunc func(ctx context.Context) error
+
+type stage struct {
+	name    string
+	timeout time.Duration
+	stop    StopFunc
+}
+
+// Shutdowner runs shutdown stages one after another, in the order they were added.
+// Each stage gets its own deadline, so a stage that overruns does not eat into the
+// time of the stages after it, and a failed stage does not prevent later ones from running.
+type Shutdowner struct {
+	logger log.Logger
+	stages []stage
+}
+
+func NewShutdowner(logger log.Logger) *Shutdowner {
+	return &Shutdowner{logger: logger}
+}
+
+// Add appends a stage that must complete within timeout
+func (s *Shutdowner) Add(name string, timeout time.Duration, stop StopFunc) {
+	s.stages = append(s.stages, stage{name: name, timeout: timeout, stop: stop})
+}
+
+// Shutdown runs every stage and returns the joined errors of those that failed or timed out
+func (s *Shutdowner) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, st := range s.stages {
+		if err := s.run(ctx, st); err != nil {
+			s.logger.Exception(ctx, "Shutdown stage failed: "+st.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", st.name, err))
+			continue
+		}
+		s.logger.Info(ctx, "Shutdown stage completed: "+st.name)
+	}
+	return errors.Join(errs...)
+}
+
+// run executes one stage and gives up at its deadline, even if the stop function ignores its context
+func (s *Shutdowner) run(ctx context.Context, st stage) error {
+	ctx, cancel := context.WithTimeout(ctx, st.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- st.stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", st.timeout)
+	}
+}
@@ -0,0 +1,132 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"go-order-eda/src/infrastructure/log"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder keeps the order in which stages ran
+type recorder struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (r *recorder) record(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.order = append(r.order, name)
+}
+
+// fakeConnection fails the in-flight request if it is closed before the request finishes
+type fakeConnection struct {
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *fakeConnection) use() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return errors.New("connection closed")
+	}
+	return nil
+}
+
+func (c *fakeConnection) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+}
+
+func TestShutdowner_Order(t *testing.T) {
+	rec := &recorder{}
+	mongo := &fakeConnection{}
+	rabbit := &fakeConnection{}
+
+	// An in-flight HTTP request that still needs both connections while the server drains
+	var requestErr error
+	requestDone := make(chan struct{})
+	go func() {
+		defer close(requestDone)
+		time.Sleep(30 * time.Millisecond)
+		if err := mongo.use(); err != nil {
+			requestErr = err
+			return
+		}
+		requestErr = rabbit.use()
+	}()
+
+	listenersStopped := false
+	s := NewShutdowner(log.NewLogger())
+	s.Add("http server", time.Second, func(ctx context.Context) error {
+		<-requestDone
+		rec.record("http server")
+		return nil
+	})
+	s.Add("event listeners", time.Second, func(ctx context.Context) error {
+		listenersStopped = true
+		rec.record("event listeners")
+		return nil
+	})
+	s.Add("rabbitmq", time.Second, func(ctx context.Context) error {
+		rabbit.close()
+		rec.record("rabbitmq")
+		return nil
+	})
+	s.Add("mongodb", time.Second, func(ctx context.Context) error {
+		if !listenersStopped {
+			t.Error("MongoDB closed before the event listeners stopped")
+		}
+		mongo.close()
+		rec.record("mongodb")
+		return nil
+	})
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Unexpected shutdown error: %v", err)
+	}
+
+	if requestErr != nil {
+		t.Errorf("In-flight request failed during drain: %v", requestErr)
+	}
+	want := []string{"http server", "event listeners", "rabbitmq", "mongodb"}
+	if len(rec.order) != len(want) {
+		t.Fatalf("Expected stages %v, got %v", want, rec.order)
+	}
+	for i := range want {
+		if rec.order[i] != want[i] {
+			t.Errorf("Expected stage %d to be %s, got %s", i, want[i], rec.order[i])
+		}
+	}
+
+	t.Log("✅ Connections closed only after the HTTP drain completed")
+}
+
+func TestShutdowner_StageTimeoutDoesNotBlockLaterStages(t *testing.T) {
+	closed := false
+	s := NewShutdowner(log.NewLogger())
+	s.Add("event listeners", 20*time.Millisecond, func(ctx context.Context) error {
+		time.Sleep(time.Second) // A handler that never finishes
+		return nil
+	})
+	s.Add("mongodb", time.Second, func(ctx context.Context) error {
+		closed = true
+		return nil
+	})
+
+	start := time.Now()
+	err := s.Shutdown(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the stuck stage to be cut off, shutdown took %s", elapsed)
+	}
+	if err == nil {
+		t.Error("Expected the timed out stage to be reported")
+	}
+	if !closed {
+		t.Error("Expected later stages to run after a timeout")
+	}
+}
@@ -0,0 +1,26 @@
+package startup
+
+import (
+	"context"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/rabbitmq"
+)
+
+// Dependencies records what the service connected to, for support to tell from the logs
+// which MongoDB and RabbitMQ servers an instance ran against and what it declared on the broker
+type Dependencies struct {
+	MongoVersion   string
+	RabbitMQServer map[string]any // Properties identifying the broker, see RabbitMQServiceImpl.ServerInfo
+	Topology       rabbitmq.Topology
+}
+
+// LogDependencies logs the dependencies as the structured fields of a single entry
+func LogDependencies(ctx context.Context, logger log.Logger, deps Dependencies) {
+	logger.InfoWithExtra(ctx, "Connected to dependencies", map[string]any{
+		"mongoVersion":   deps.MongoVersion,
+		"rabbitmqServer": deps.RabbitMQServer,
+		"exchanges":      deps.Topology.Exchanges,
+		"queues":         deps.Topology.Queues,
+		"bindings":       deps.Topology.Bindings,
+	})
+}
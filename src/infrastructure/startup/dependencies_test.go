@@ -0,0 +1,56 @@
+package startup
+
+import (
+	"context"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/rabbitmq"
+	"reflect"
+	"testing"
+)
+
+// entry is a message logged with extra fields
+type entry struct {
+	message string
+	fields  map[string]any
+}
+
+// recordingLogger records the entries passed to InfoWithExtra
+type recordingLogger struct {
+	log.Logger
+	entries []entry
+}
+
+func (l *recordingLogger) InfoWithExtra(ctx context.Context, message string, dictionary map[string]any) {
+	l.entries = append(l.entries, entry{message: message, fields: dictionary})
+}
+
+func TestLogDependencies(t *testing.T) {
+	logger := &recordingLogger{Logger: log.NewLogger()}
+	server := map[string]any{"product": "RabbitMQ", "version": "3.13.7", "cluster_name": "rabbit@broker-1"}
+	topology := rabbitmq.Topology{
+		Exchanges: []string{"order_events", "order_events.dlx"},
+		Queues:    []string{"order.created", "order.created.dlq"},
+		Bindings: []rabbitmq.Binding{
+			{Queue: "order.created", Exchange: "order_events", RoutingKey: "order.created"},
+			{Queue: "order.created.dlq", Exchange: "order_events", RoutingKey: "order.created.dlq"},
+		},
+	}
+
+	LogDependencies(context.Background(), logger, Dependencies{MongoVersion: "7.0.14", RabbitMQServer: server, Topology: topology})
+
+	if len(logger.entries) != 1 {
+		t.Fatalf("Expected one log entry, got %d", len(logger.entries))
+	}
+	want := map[string]any{
+		"mongoVersion":   "7.0.14",
+		"rabbitmqServer": server,
+		"exchanges":      topology.Exchanges,
+		"queues":         topology.Queues,
+		"bindings":       topology.Bindings,
+	}
+	if got := logger.entries[0].fields; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected fields %v, got %v", want, got)
+	}
+
+	t.Log("✅ Dependency versions and topology logged as fields")
+}
@@ -0,0 +1,58 @@
+// Package startup waits for the application's dependencies to become reachable before it starts.
+package startup
+
+import (
+	"context"
+	"fmt"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/retry"
+	"time"
+)
+
+// CheckFunc reports whether a dependency is reachable, returning the reason when it is not
+type CheckFunc func(ctx context.Context) error
+
+// Waiter retries dependency checks with backoff until they succeed or a deadline passes,
+// so a cold start does not crash-loop while MongoDB or RabbitMQ are still starting up
+type Waiter struct {
+	logger  log.Logger
+	timeout time.Duration
+	backoff retry.Policy // Delays between checks
+}
+
+// NewWaiter returns a waiter giving each dependency up to timeout; with no timeout a
+// dependency is checked once, so startup fails right away when it is unreachable
+func NewWaiter(logger log.Logger, timeout time.Duration) *Waiter {
+	return &Waiter{
+		logger:  logger,
+		timeout: timeout,
+		backoff: retry.Policy{Base: 500 * time.Millisecond, Max: 5 * time.Second, Jitter: 0.2},
+	}
+}
+
+// Wait calls check until it succeeds, logging every failed attempt. It returns the last
+// error of check once the timeout passes or ctx is done before the next attempt.
+func (w *Waiter) Wait(ctx context.Context, name string, check CheckFunc) error {
+	if w.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.timeout)
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := check(ctx)
+		if err == nil {
+			if attempt > 1 {
+				w.logger.Info(ctx, fmt.Sprintf("%s is ready after %d attempts", name, attempt))
+			}
+			return nil
+		}
+		if w.timeout <= 0 {
+			return fmt.Errorf("%s not ready: %w", name, err)
+		}
+		w.logger.Warn(ctx, fmt.Sprintf("Waiting for %s, attempt %d failed: %v", name, attempt, err))
+		if w.backoff.Sleep(ctx, attempt) != nil {
+			return fmt.Errorf("%s not ready within %s after %d attempts: %w", name, w.timeout, attempt, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"go-order-eda/src/infrastructure/log"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDependency fails its check until it was checked healthyAfter times
+type fakeDependency struct {
+	healthyAfter int
+	checks       int
+}
+
+func (d *fakeDependency) check(ctx context.Context) error {
+	d.checks++
+	if d.checks < d.healthyAfter {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestWaiter_Wait(t *testing.T) {
+	t.Run("succeeds once the dependency becomes healthy", func(t *testing.T) {
+		waiter := NewWaiter(log.NewLogger(), time.Minute)
+		var waits []time.Duration
+		waiter.backoff.Jitter = 0
+		waiter.backoff.Wait = func(ctx context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		}
+		mongo := &fakeDependency{healthyAfter: 4}
+
+		if err := waiter.Wait(context.Background(), "MongoDB", mongo.check); err != nil {
+			t.Fatalf("Expected startup to succeed, got %v", err)
+		}
+		if mongo.checks != 4 {
+			t.Errorf("Expected 4 checks, got %d", mongo.checks)
+		}
+		if want := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second}; !slices.Equal(waits, want) {
+			t.Errorf("Expected backoff %v, got %v", want, waits)
+		}
+	})
+
+	t.Run("fails after the deadline", func(t *testing.T) {
+		waiter := NewWaiter(log.NewLogger(), 50*time.Millisecond)
+		waiter.backoff.Base = 5 * time.Millisecond
+		rabbit := &fakeDependency{healthyAfter: 1000}
+
+		start := time.Now()
+		err := waiter.Wait(context.Background(), "RabbitMQ", rabbit.check)
+		if err == nil || !strings.Contains(err.Error(), "RabbitMQ not ready within 50ms") || !strings.Contains(err.Error(), "connection refused") {
+			t.Errorf("Expected the last check error after the deadline, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected Wait to give up at the deadline, took %s", elapsed)
+		}
+		if rabbit.checks < 2 {
+			t.Errorf("Expected the check retried before the deadline, got %d checks", rabbit.checks)
+		}
+	})
+
+	t.Run("checked once without a timeout", func(t *testing.T) {
+		waiter := NewWaiter(log.NewLogger(), 0)
+		mongo := &fakeDependency{healthyAfter: 2}
+
+		if err := waiter.Wait(context.Background(), "MongoDB", mongo.check); err == nil || mongo.checks != 1 {
+			t.Errorf("Expected one failed check, got %d checks and %v", mongo.checks, err)
+		}
+	})
+
+	t.Log("✅ Startup waits for dependencies until the deadline")
+}
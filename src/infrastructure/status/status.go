@@ -0,0 +1,135 @@
+// Package status aggregates the health of the service's subsystems into a single report.
+package status
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Probe checks one subsystem. It returns details to include in the report, such as a
+// queue depth, and an error when the subsystem is unhealthy.
+type Probe func(ctx context.Context) (any, error)
+
+// Result is the outcome of a single probe
+type Result struct {
+	Name     string `json:"name"`
+	OK       bool   `json:"ok"`
+	Details  any    `json:"details,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the aggregated status of every registered subsystem
+type Report struct {
+	OK         bool      `json:"ok"`
+	CheckedAt  time.Time `json:"checkedAt"`
+	Subsystems []Result  `json:"subsystems"`
+}
+
+// Reporter runs registered probes concurrently, each bounded by its own timeout,
+// so one slow subsystem does not hold up the rest of the report
+type Reporter struct {
+	timeout time.Duration
+	mu      sync.Mutex
+	probes  map[string]Probe
+}
+
+func NewReporter(timeout time.Duration) *Reporter {
+	return &Reporter{
+		timeout: timeout,
+		probes:  make(map[string]Probe),
+	}
+}
+
+// Register adds a probe under a name, replacing an earlier probe with the same name
+func (r *Reporter) Register(name string, probe Probe) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.probes[name] = probe
+}
+
+// Report runs every probe and returns the results sorted by name.
+// The report is OK only when every probe succeeded within its timeout.
+func (r *Reporter) Report(ctx context.Context) Report {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.probes))
+	for name := range r.probes {
+		names = append(names, name)
+	}
+	probes := make(map[string]Probe, len(r.probes))
+	for name, probe := range r.probes {
+		probes[name] = probe
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, name, probes[name])
+		}()
+	}
+	wg.Wait()
+
+	report := Report{OK: true, CheckedAt: time.Now().UTC(), Subsystems: results}
+	for _, result := range results {
+		if !result.OK {
+			report.OK = false
+		}
+	}
+	return report
+}
+
+// run executes one probe and gives up when its timeout expires, even if the probe ignores its context
+func (r *Reporter) run(ctx context.Context, name string, probe Probe) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	type outcome struct {
+		details any
+		err     error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		details, err := probe(ctx)
+		done <- outcome{details, err}
+	}()
+
+	result := Result{Name: name}
+	select {
+	case o := <-done:
+		result.Details = o.details
+		if o.err != nil {
+			result.Error = o.err.Error()
+		} else {
+			result.OK = true
+		}
+	case <-ctx.Done():
+		result.Error = fmt.Sprintf("timed out after %s", r.timeout)
+	}
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+	return result
+}
+
+// WorkerProbe reports a background worker's last run, failing when it has not completed
+// a run within maxAge, e.g. because it is stuck or its dependencies keep failing
+func WorkerProbe(lastRun func() time.Time, maxAge time.Duration) Probe {
+	return func(ctx context.Context) (any, error) {
+		last := lastRun()
+		if last.IsZero() {
+			return nil, fmt.Errorf("has not completed a run yet")
+		}
+		details := map[string]any{"lastRun": last}
+		if age := time.Since(last); age > maxAge {
+			return details, fmt.Errorf("last run %s ago exceeds %s", age.Round(time.Second), maxAge)
+		}
+		return details, nil
+	}
+}
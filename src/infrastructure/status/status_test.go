@@ -0,0 +1,81 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReporter_MixedHealth(t *testing.T) {
+	reporter := NewReporter(50 * time.Millisecond)
+	reporter.Register("mongodb", func(ctx context.Context) (any, error) { return nil, nil })
+	reporter.Register("rabbitmq", func(ctx context.Context) (any, error) { return nil, errors.New("connection is closed") })
+	reporter.Register("queue:order.created", func(ctx context.Context) (any, error) {
+		return map[string]int{"messages": 3, "dlq": 0}, nil
+	})
+	reporter.Register("replayBacklog", func(ctx context.Context) (any, error) {
+		time.Sleep(time.Second) // Ignores its context, like a probe stuck on a dead connection
+		return nil, nil
+	})
+
+	start := time.Now()
+	report := reporter.Report(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the slow probe to be cut off by its timeout, report took %s", elapsed)
+	}
+
+	if report.OK {
+		t.Error("Expected the report not to be OK")
+	}
+	want := map[string]bool{"mongodb": true, "queue:order.created": true, "rabbitmq": false, "replayBacklog": false}
+	if len(report.Subsystems) != len(want) {
+		t.Fatalf("Expected %d subsystems, got %+v", len(want), report.Subsystems)
+	}
+	for _, result := range report.Subsystems {
+		if result.OK != want[result.Name] {
+			t.Errorf("Expected %s ok=%v, got %+v", result.Name, want[result.Name], result)
+		}
+	}
+	if report.Subsystems[0].Name != "mongodb" {
+		t.Errorf("Expected subsystems sorted by name, got %s first", report.Subsystems[0].Name)
+	}
+	for _, result := range report.Subsystems {
+		if result.Name == "replayBacklog" && result.Error != "timed out after 50ms" {
+			t.Errorf("Expected a timeout error, got %q", result.Error)
+		}
+	}
+
+	t.Log("✅ Aggregated status reflects every probe")
+}
+
+func TestReporter_AllHealthy(t *testing.T) {
+	reporter := NewReporter(time.Second)
+	reporter.Register("mongodb", func(ctx context.Context) (any, error) { return nil, nil })
+	reporter.Register("rabbitmq", func(ctx context.Context) (any, error) { return nil, nil })
+
+	if report := reporter.Report(context.Background()); !report.OK {
+		t.Errorf("Expected the report to be OK, got %+v", report)
+	}
+}
+
+func TestWorkerProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		lastRun time.Time
+		wantErr bool
+	}{
+		{name: "never ran", lastRun: time.Time{}, wantErr: true},
+		{name: "recent run", lastRun: time.Now().UTC().Add(-time.Second), wantErr: false},
+		{name: "stale run", lastRun: time.Now().UTC().Add(-time.Hour), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := WorkerProbe(func() time.Time { return tt.lastRun }, time.Minute)
+			if _, err := probe(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Expected error=%v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Package tlsconfig builds the TLS client configuration of the broker and database connections.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"go-order-eda/src/config"
+	"os"
+)
+
+var ErrIncompleteKeyPair = errors.New("client certificate and key must be configured together")
+
+// New builds the TLS configuration from the settings: the CA certificates trusted instead of the
+// system roots, the client certificate and whether server verification is skipped. It returns nil
+// when nothing is configured, so the connection URI alone decides whether TLS is used.
+func New(settings config.TLS) (*tls.Config, error) {
+	if !settings.Enabled() {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+	}
+
+	if settings.CAFile != "" {
+		pem, err := os.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", settings.CAFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	if (settings.CertFile == "") != (settings.KeyFile == "") {
+		return nil, ErrIncompleteKeyPair
+	}
+	if settings.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,69 @@
+package tlsconfig
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"go-order-eda/src/config"
+	"go-order-eda/src/infrastructure/tlsconfig/tlsconfigtest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	certs := tlsconfigtest.Write(t)
+
+	t.Run("CA and client certificate from the files", func(t *testing.T) {
+		tlsConfig, err := New(certs.TLS)
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if tlsConfig.RootCAs == nil {
+			t.Fatal("Expected the CA file to set the root CAs")
+		}
+		if _, err := certs.Client.Verify(x509.VerifyOptions{Roots: tlsConfig.RootCAs, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+			t.Errorf("Expected the client certificate to verify against the loaded CA: %v", err)
+		}
+		if len(tlsConfig.Certificates) != 1 || !bytes.Equal(tlsConfig.Certificates[0].Certificate[0], certs.Client.Raw) {
+			t.Errorf("Expected the client certificate from %s", certs.CertFile)
+		}
+		if tlsConfig.InsecureSkipVerify {
+			t.Error("Expected server verification")
+		}
+	})
+
+	t.Run("nothing configured", func(t *testing.T) {
+		if tlsConfig, err := New(config.TLS{}); tlsConfig != nil || err != nil {
+			t.Errorf("Expected no TLS configuration, got %v, %v", tlsConfig, err)
+		}
+	})
+
+	t.Run("skip verify alone", func(t *testing.T) {
+		tlsConfig, err := New(config.TLS{InsecureSkipVerify: true})
+		if err != nil || tlsConfig == nil || !tlsConfig.InsecureSkipVerify || tlsConfig.RootCAs != nil {
+			t.Errorf("Expected a configuration skipping verification, got %+v, %v", tlsConfig, err)
+		}
+	})
+
+	t.Run("certificate without key", func(t *testing.T) {
+		if _, err := New(config.TLS{CertFile: certs.CertFile}); !errors.Is(err, ErrIncompleteKeyPair) {
+			t.Errorf("Expected ErrIncompleteKeyPair, got %v", err)
+		}
+	})
+
+	t.Run("missing CA file", func(t *testing.T) {
+		if _, err := New(config.TLS{CAFile: filepath.Join(t.TempDir(), "missing.pem")}); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Expected a missing file error, got %v", err)
+		}
+	})
+
+	t.Run("CA file without certificates", func(t *testing.T) {
+		// The key file is PEM but holds no certificate
+		if _, err := New(config.TLS{CAFile: certs.KeyFile}); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Log("✅ TLS configuration built from the certificate files")
+}
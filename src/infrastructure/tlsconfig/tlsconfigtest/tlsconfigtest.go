@@ -0,0 +1,94 @@
+// Package tlsconfigtest writes throwaway certificates for testing TLS configuration.
+package tlsconfigtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"go-order-eda/src/config"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Certificates are the files written by Write, and the certificates they hold
+type Certificates struct {
+	config.TLS
+	CA     *x509.Certificate
+	Client *x509.Certificate
+}
+
+// Write creates a CA and a client certificate signed by it in a temporary directory,
+// returning their paths as TLS settings
+func Write(t *testing.T) Certificates {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate client key: %v", err)
+	}
+	clientTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTemplate, ca, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create client certificate: %v", err)
+	}
+	client, _ := x509.ParseCertificate(clientDER)
+	keyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal client key: %v", err)
+	}
+
+	certs := Certificates{
+		TLS: config.TLS{
+			CAFile:   filepath.Join(dir, "ca.pem"),
+			CertFile: filepath.Join(dir, "client.pem"),
+			KeyFile:  filepath.Join(dir, "client-key.pem"),
+		},
+		CA:     ca,
+		Client: client,
+	}
+	writePEM(t, certs.CAFile, "CERTIFICATE", caDER)
+	writePEM(t, certs.CertFile, "CERTIFICATE", clientDER)
+	writePEM(t, certs.KeyFile, "EC PRIVATE KEY", keyDER)
+	return certs
+}
+
+// writePEM writes one PEM block to the file
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName identifies spans created by this service
+const TracerName = "go-order-eda"
+
+// Setup installs the W3C trace context propagator and, when endpoint is set, a tracer provider
+// exporting spans over OTLP/HTTP. With no endpoint the global no-op provider stays in place.
+// The returned function flushes and stops the provider.
+func Setup(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the service tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// InjectAMQP writes the trace context of ctx into AMQP message headers
+func InjectAMQP(ctx context.Context, headers amqp.Table) {
+	otel.GetTextMapPropagator().Inject(ctx, amqpHeaderCarrier(headers))
+}
+
+// ExtractAMQP returns ctx carrying the trace context found in AMQP message headers
+func ExtractAMQP(ctx context.Context, headers amqp.Table) context.Context {
+	if headers == nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, amqpHeaderCarrier(headers))
+}
+
+// Inject writes the trace context of ctx into a string map, e.g. one stored with an outbox message
+func Inject(ctx context.Context, carrier map[string]string) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(carrier))
+}
+
+// Extract returns ctx carrying the trace context found in a string map
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if carrier == nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// amqpHeaderCarrier adapts amqp.Table to propagation.TextMapCarrier
+type amqpHeaderCarrier amqp.Table
+
+func (c amqpHeaderCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+func (c amqpHeaderCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c amqpHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
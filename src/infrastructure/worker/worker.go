@@ -0,0 +1,79 @@
+// Package worker runs the application's background workers and stops them on shutdown.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"go-order-eda/src/infrastructure/log"
+	"strings"
+	"sync"
+)
+
+// Func runs a worker until ctx is cancelled
+type Func func(ctx context.Context)
+
+type worker struct {
+	name   string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Manager tracks the background workers it started, such as the outbox relay or the
+// reservation sweeper, so they can be stopped together and waited for
+type Manager struct {
+	logger  log.Logger
+	mu      sync.Mutex
+	workers []*worker
+}
+
+func NewManager(logger log.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Start runs the worker in a goroutine with a context derived from ctx; the worker stops when
+// ctx is cancelled or StopAll is called. The name identifies the worker in the logs.
+func (m *Manager) Start(ctx context.Context, name string, run Func) {
+	ctx, cancel := context.WithCancel(ctx)
+	w := &worker{name: name, cancel: cancel, done: make(chan struct{})}
+
+	m.mu.Lock()
+	m.workers = append(m.workers, w)
+	m.mu.Unlock()
+
+	go func() {
+		defer close(w.done)
+		run(ctx)
+	}()
+	m.logger.Info(ctx, "Background worker started: "+name)
+}
+
+// StopAll cancels every worker and waits for them to return until ctx is done.
+// It returns an error naming the workers that were still running at the deadline.
+func (m *Manager) StopAll(ctx context.Context) error {
+	m.mu.Lock()
+	workers := append([]*worker(nil), m.workers...)
+	m.mu.Unlock()
+
+	for _, w := range workers {
+		w.cancel()
+	}
+
+	var running []string
+	for _, w := range workers {
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+		}
+		select {
+		case <-w.done:
+			m.logger.Info(ctx, "Background worker stopped: "+w.name)
+		default:
+			running = append(running, w.name)
+		}
+	}
+	if len(running) == 0 {
+		return nil
+	}
+	m.logger.Warn(ctx, "Background workers did not stop in time: "+strings.Join(running, ", "))
+	return fmt.Errorf("workers still running: %s: %w", strings.Join(running, ", "), ctx.Err())
+}
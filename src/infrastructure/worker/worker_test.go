@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"go-order-eda/src/infrastructure/log"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestManager_StopAll(t *testing.T) {
+	t.Run("workers observe cancellation", func(t *testing.T) {
+		manager := NewManager(log.NewLogger())
+		var cancelled atomic.Int32
+		fake := func(ctx context.Context) {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond) // Work still finishing after cancellation
+			cancelled.Add(1)
+		}
+		manager.Start(context.Background(), "relay", fake)
+		manager.Start(context.Background(), "sweeper", fake)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := manager.StopAll(ctx); err != nil {
+			t.Fatalf("StopAll failed: %v", err)
+		}
+		if got := cancelled.Load(); got != 2 {
+			t.Errorf("Expected both workers to observe cancellation, got %d", got)
+		}
+	})
+
+	t.Run("worker ignoring cancellation", func(t *testing.T) {
+		manager := NewManager(log.NewLogger())
+		release := make(chan struct{})
+		defer close(release)
+		manager.Start(context.Background(), "stopping", func(ctx context.Context) { <-ctx.Done() })
+		manager.Start(context.Background(), "wedged", func(ctx context.Context) { <-release })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err := manager.StopAll(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "wedged") || strings.Contains(err.Error(), "stopping") {
+			t.Errorf("Expected only the wedged worker reported, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected StopAll to give up at the deadline, took %s", elapsed)
+		}
+	})
+
+	t.Run("parent context stops workers", func(t *testing.T) {
+		manager := NewManager(log.NewLogger())
+		parent, cancelParent := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		manager.Start(parent, "cleaner", func(ctx context.Context) {
+			<-ctx.Done()
+			close(stopped)
+		})
+
+		cancelParent()
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("Worker did not stop with its parent context")
+		}
+	})
+
+	t.Log("✅ Background workers stopped within the deadline")
+}
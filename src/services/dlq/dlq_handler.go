@@ -3,13 +3,26 @@ package dlq
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"go-order-eda/src/infrastructure"
 	"go-order-eda/src/infrastructure/log"
 	"go-order-eda/src/services/events"
-	"go-order-eda/src/services/order/domain/persistence"
+
+	"github.com/streadway/amqp"
 )
 
+// CatchAll is the event type the catch-all DLQ handler is registered with, as dead-lettered
+// messages of every event type end up on the same queue
+const CatchAll = "dlq.catch_all"
+
+// EventStore stores failed events in order_events, with the event type they are replayed as,
+// for inspection and replay
+type EventStore interface {
+	StoreEventForReplay(ctx context.Context, orderID, eventType string, eventData []byte) error
+}
+
 type DLQHandler struct {
-	orderRepository *persistence.OrderRepository
+	orderRepository EventStore
 	logger          log.Logger
 }
 
@@ -26,8 +39,12 @@ type InventoryStatusUpdatedDLQHandler struct {
 	*DLQHandler
 }
 
+type CatchAllDLQHandler struct {
+	*DLQHandler
+}
+
 func NewDLQHandler(
-	orderRepo *persistence.OrderRepository,
+	orderRepo EventStore,
 	logger log.Logger,
 ) *DLQHandler {
 	return &DLQHandler{
@@ -48,21 +65,29 @@ func (d *DLQHandler) NewInventoryStatusUpdatedDLQHandler() *InventoryStatusUpdat
 	return &InventoryStatusUpdatedDLQHandler{DLQHandler: d}
 }
 
+func (d *DLQHandler) NewCatchAllDLQHandler() *CatchAllDLQHandler {
+	return &CatchAllDLQHandler{DLQHandler: d}
+}
+
 // EventHandler interface implementations
-func (h *OrderCreatedDLQHandler) Handle(ctx context.Context, msgBody []byte) {
-	h.HandleOrderCreatedDLQ(ctx, msgBody)
+func (h *OrderCreatedDLQHandler) Handle(ctx context.Context, msgBody []byte) error {
+	return h.HandleOrderCreatedDLQ(ctx, msgBody)
 }
 
-func (h *OrderCancelledDLQHandler) Handle(ctx context.Context, msgBody []byte) {
-	h.HandleOrderCancelledDLQ(ctx, msgBody)
+func (h *OrderCancelledDLQHandler) Handle(ctx context.Context, msgBody []byte) error {
+	return h.HandleOrderCancelledDLQ(ctx, msgBody)
 }
 
-func (h *InventoryStatusUpdatedDLQHandler) Handle(ctx context.Context, msgBody []byte) {
-	h.HandleInventoryStatusUpdatedDLQ(ctx, msgBody)
+func (h *InventoryStatusUpdatedDLQHandler) Handle(ctx context.Context, msgBody []byte) error {
+	return h.HandleInventoryStatusUpdatedDLQ(ctx, msgBody)
+}
+
+func (h *CatchAllDLQHandler) Handle(ctx context.Context, msgBody []byte) error {
+	return h.HandleCatchAllDLQ(ctx, msgBody)
 }
 
 // HandleOrderCreatedDLQ handles failed OrderCreated events from DLQ
-func (h *DLQHandler) HandleOrderCreatedDLQ(ctx context.Context, msgBody []byte) {
+func (h *DLQHandler) HandleOrderCreatedDLQ(ctx context.Context, msgBody []byte) error {
 	h.logger.Info(ctx, "Processing OrderCreated DLQ event")
 
 	// Try to extract orderID from the event
@@ -73,16 +98,17 @@ func (h *DLQHandler) HandleOrderCreatedDLQ(ctx context.Context, msgBody []byte)
 	}
 
 	// Store the failed event for replay
-	err := h.orderRepository.StoreEventForReplay(ctx, orderID, msgBody)
+	err := h.orderRepository.StoreEventForReplay(ctx, orderID, events.OrderCreated, msgBody)
 	if err != nil {
 		h.logger.Exception(ctx, "Failed to store OrderCreated DLQ event for replay", err)
-	} else {
-		h.logger.Info(ctx, "OrderCreated DLQ event stored for replay, orderID: "+orderID)
+		return infrastructure.Transient(err)
 	}
+	h.logger.Info(ctx, "OrderCreated DLQ event stored for replay, orderID: "+orderID)
+	return nil
 }
 
 // HandleOrderCancelledDLQ handles failed OrderCancelled events from DLQ
-func (h *DLQHandler) HandleOrderCancelledDLQ(ctx context.Context, msgBody []byte) {
+func (h *DLQHandler) HandleOrderCancelledDLQ(ctx context.Context, msgBody []byte) error {
 	h.logger.Info(ctx, "Processing OrderCancelled DLQ event")
 
 	// Try to extract orderID from the event
@@ -93,16 +119,17 @@ func (h *DLQHandler) HandleOrderCancelledDLQ(ctx context.Context, msgBody []byte
 	}
 
 	// Store the failed event for replay
-	err := h.orderRepository.StoreEventForReplay(ctx, orderID, msgBody)
+	err := h.orderRepository.StoreEventForReplay(ctx, orderID, events.OrderCancelled, msgBody)
 	if err != nil {
 		h.logger.Exception(ctx, "Failed to store OrderCancelled DLQ event for replay", err)
-	} else {
-		h.logger.Info(ctx, "OrderCancelled DLQ event stored for replay, orderID: "+orderID)
+		return infrastructure.Transient(err)
 	}
+	h.logger.Info(ctx, "OrderCancelled DLQ event stored for replay, orderID: "+orderID)
+	return nil
 }
 
 // HandleInventoryStatusUpdatedDLQ handles failed InventoryStatusUpdated events from DLQ
-func (h *DLQHandler) HandleInventoryStatusUpdatedDLQ(ctx context.Context, msgBody []byte) {
+func (h *DLQHandler) HandleInventoryStatusUpdatedDLQ(ctx context.Context, msgBody []byte) error {
 	h.logger.Info(ctx, "Processing InventoryStatusUpdated DLQ event")
 
 	// Try to extract orderID from the event
@@ -113,10 +140,94 @@ func (h *DLQHandler) HandleInventoryStatusUpdatedDLQ(ctx context.Context, msgBod
 	}
 
 	// Store the failed event for replay
-	err := h.orderRepository.StoreEventForReplay(ctx, orderID, msgBody)
+	err := h.orderRepository.StoreEventForReplay(ctx, orderID, events.InventoryStatusUpdated, msgBody)
 	if err != nil {
 		h.logger.Exception(ctx, "Failed to store InventoryStatusUpdated DLQ event for replay", err)
-	} else {
-		h.logger.Info(ctx, "InventoryStatusUpdated DLQ event stored for replay, orderID: "+orderID)
+		return infrastructure.Transient(err)
+	}
+	h.logger.Info(ctx, "InventoryStatusUpdated DLQ event stored for replay, orderID: "+orderID)
+	return nil
+}
+
+// HandleCatchAllDLQ handles messages the broker dead-lettered from the main queue through the
+// fanout dead-letter exchange. They can be of any event type, so the order ID is taken from
+// whichever of the usual fields the message has, the event type from the x-death entry, and the
+// x-death entry is logged to tell why the message was dead-lettered. A message that is not JSON
+// cannot be stored and is dropped.
+func (h *DLQHandler) HandleCatchAllDLQ(ctx context.Context, msgBody []byte) error {
+	headers := infrastructure.HeadersFromContext(ctx)
+	death := describeDeath(headers)
+	h.logger.Warn(ctx, "Processing dead-lettered message from the catch-all DLQ: "+death)
+
+	if !json.Valid(msgBody) {
+		h.logger.Warn(ctx, "Dropping dead-lettered message that is not JSON: "+death)
+		return infrastructure.Permanent(fmt.Errorf("dead-lettered message is not JSON (%s)", death))
+	}
+
+	// Try to extract orderID from the event
+	var event struct {
+		ID      string `json:"id"`
+		OrderID string `json:"orderId"`
+	}
+	orderID := "unknown"
+	if err := json.Unmarshal(msgBody, &event); err == nil {
+		switch {
+		case event.OrderID != "":
+			orderID = event.OrderID
+		case event.ID != "":
+			orderID = event.ID
+		}
+	}
+
+	// Store the failed event for replay
+	eventType := deadLetteredEventType(headers)
+	err := h.orderRepository.StoreEventForReplay(ctx, orderID, eventType, msgBody)
+	if err != nil {
+		h.logger.Exception(ctx, "Failed to store dead-lettered message for replay", err)
+		return infrastructure.Transient(err)
+	}
+	if _, ok := events.LookupEventType(eventType); !ok {
+		h.logger.Warn(ctx, fmt.Sprintf("Dead-lettered message of unknown event type %q stored for order %s; it is not replayed", eventType, orderID))
+		return nil
+	}
+	h.logger.Info(ctx, fmt.Sprintf("Dead-lettered %s message stored for replay, orderID: %s", eventType, orderID))
+	return nil
+}
+
+// deadLetteredEventType returns the event type of a dead-lettered message: the routing key it was
+// published with, as the most recent x-death entry records it, or else the event type whose queue it
+// was dead-lettered from. Without either it returns CatchAll; replay skips both it and routing keys
+// of no event type.
+func deadLetteredEventType(headers amqp.Table) string {
+	deaths, _ := headers["x-death"].([]interface{})
+	if len(deaths) == 0 {
+		return CatchAll
+	}
+	death, _ := deaths[0].(amqp.Table)
+	var key string
+	if keys, _ := death["routing-keys"].([]interface{}); len(keys) > 0 {
+		key, _ = keys[0].(string)
+	}
+	queue, _ := death["queue"].(string)
+	for _, eventType := range events.Registry {
+		if eventType.RoutingKey == key || (key == "" && eventType.Queue == queue) {
+			return eventType.Name
+		}
+	}
+	if key != "" {
+		return key // Recorded as published, but never replayed
+	}
+	return CatchAll
+}
+
+// describeDeath summarizes the most recent x-death entry of a dead-lettered message: the queue
+// it was dead-lettered from, why, and how often
+func describeDeath(headers amqp.Table) string {
+	deaths, _ := headers["x-death"].([]interface{})
+	if len(deaths) == 0 {
+		return "no x-death header"
 	}
+	// The broker puts the most recent death first
+	death, _ := deaths[0].(amqp.Table)
+	return fmt.Sprintf("queue %v, reason %v, count %v", death["queue"], death["reason"], death["count"])
 }
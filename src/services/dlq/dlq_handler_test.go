@@ -0,0 +1,191 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"go-order-eda/src/infrastructure"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/rabbitmq"
+	"go-order-eda/src/infrastructure/rabbitmq/rabbitmqtest"
+	"go-order-eda/src/services/events"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// storedEvent is an event passed to StoreEventForReplay
+type storedEvent struct {
+	orderID   string
+	eventType string
+	data      string
+}
+
+// fakeEventStore records stored events, failing with err if set
+type fakeEventStore struct {
+	mu     sync.Mutex
+	stored []storedEvent
+	err    error
+}
+
+func (s *fakeEventStore) StoreEventForReplay(ctx context.Context, orderID, eventType string, eventData []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.stored = append(s.stored, storedEvent{orderID: orderID, eventType: eventType, data: string(eventData)})
+	return nil
+}
+
+func (s *fakeEventStore) events() []storedEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]storedEvent(nil), s.stored...)
+}
+
+// waitSettled fails the test if the delivery is not settled in time
+func waitSettled(t *testing.T, ack *rabbitmqtest.Acknowledger) {
+	t.Helper()
+	select {
+	case <-ack.Settled():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Message was not settled")
+	}
+}
+
+func TestCatchAllDLQHandler(t *testing.T) {
+	const mainQueue = "order_events_queue"
+	dlqName := rabbitmq.DeadLetterQueue(mainQueue)
+
+	// listen consumes the main queue with a handler failing permanently and its DLQ with the catch-all handler
+	listen := func(t *testing.T, store *fakeEventStore) *rabbitmqtest.Broker {
+		broker := rabbitmqtest.NewBroker()
+		listener := infrastructure.NewEventListener(broker, log.NewLogger(), 1, 5)
+		listener.RegisterHandlerOnQueue(events.OrderCreated, mainQueue, infrastructure.HandlerFunc(func(ctx context.Context, msgBody []byte) error {
+			return infrastructure.Permanent(errors.New("malformed order"))
+		}))
+		listener.RegisterHandlerOnQueue(CatchAll, dlqName, NewDLQHandler(store, log.NewLogger()).NewCatchAllDLQHandler())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			listener.StartListening(ctx)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+		return broker
+	}
+	// deadLetter delivers the message to the DLQ the way the broker does after it was rejected
+	deadLetter := func(broker *rabbitmqtest.Broker, body string) *rabbitmqtest.Acknowledger {
+		return broker.DeliverWithHeaders(dlqName, []byte(body), amqp.Table{
+			"x-death": []interface{}{amqp.Table{
+				"queue": mainQueue, "reason": "rejected", "count": int64(1), "routing-keys": []interface{}{events.OrderCreated},
+			}},
+		})
+	}
+
+	t.Run("dead-lettered message is stored for replay", func(t *testing.T) {
+		store := &fakeEventStore{}
+		broker := listen(t, store)
+
+		body := `{"id":"order-1","status":"created"}`
+		rejected := broker.Deliver(mainQueue, []byte(body))
+		waitSettled(t, rejected)
+		if rejected.Acked() || rejected.Requeued() {
+			t.Fatal("Expected the failed message to be rejected without requeue")
+		}
+
+		ack := deadLetter(broker, body)
+		waitSettled(t, ack)
+		if !ack.Acked() {
+			t.Error("Expected the dead-lettered message to be acknowledged")
+		}
+		stored := store.events()
+		if len(stored) != 1 || stored[0].orderID != "order-1" || stored[0].eventType != events.OrderCreated || stored[0].data != body {
+			t.Errorf("Expected the message stored as %s for order-1, got %+v", events.OrderCreated, stored)
+		}
+	})
+
+	t.Run("order ID of events referring to an order", func(t *testing.T) {
+		store := &fakeEventStore{}
+		broker := listen(t, store)
+
+		waitSettled(t, deadLetter(broker, `{"orderId":"order-2","status":"cancelled"}`))
+		if stored := store.events(); len(stored) != 1 || stored[0].orderID != "order-2" {
+			t.Errorf("Expected the message stored for order-2, got %+v", stored)
+		}
+	})
+
+	t.Run("message that is not JSON is dropped", func(t *testing.T) {
+		store := &fakeEventStore{}
+		broker := listen(t, store)
+
+		ack := deadLetter(broker, "not json")
+		waitSettled(t, ack)
+		if ack.Acked() || ack.Requeued() {
+			t.Error("Expected the message to be rejected without requeue")
+		}
+		if stored := store.events(); len(stored) != 0 {
+			t.Errorf("Expected nothing stored, got %+v", stored)
+		}
+	})
+
+	t.Run("store failure requeues the message", func(t *testing.T) {
+		store := &fakeEventStore{err: errors.New("mongo unavailable")}
+		broker := listen(t, store)
+
+		ack := deadLetter(broker, `{"id":"order-3"}`)
+		waitSettled(t, ack)
+		if !ack.Requeued() {
+			t.Error("Expected the message to be requeued")
+		}
+	})
+
+	t.Log("✅ Catch-all DLQ stored dead-lettered messages")
+}
+
+func TestDescribeDeath(t *testing.T) {
+	headers := amqp.Table{"x-death": []interface{}{
+		amqp.Table{"queue": "order_events_queue", "reason": "expired", "count": int64(2)},
+		amqp.Table{"queue": "order.created", "reason": "rejected", "count": int64(1)},
+	}}
+	if got, want := describeDeath(headers), "queue order_events_queue, reason expired, count 2"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := describeDeath(nil); got != "no x-death header" {
+		t.Errorf("Expected no x-death header, got %q", got)
+	}
+
+	t.Log("✅ Most recent x-death entry described")
+}
+
+func TestDeadLetteredEventType(t *testing.T) {
+	death := func(entry amqp.Table) amqp.Table {
+		return amqp.Table{"x-death": []interface{}{entry}}
+	}
+	tests := []struct {
+		name    string
+		headers amqp.Table
+		want    string
+	}{
+		{"routing key of an event type", death(amqp.Table{"queue": "order_events_queue", "routing-keys": []interface{}{events.NotificationSent}}), events.NotificationSent},
+		{"queue of an event type", death(amqp.Table{"queue": events.OrderCancelled}), events.OrderCancelled},
+		{"routing key of no event type", death(amqp.Table{"queue": "order_events_queue", "routing-keys": []interface{}{"legacy.key"}}), "legacy.key"},
+		{"queue of no event type", death(amqp.Table{"queue": "order_events_queue"}), CatchAll},
+		{"no x-death header", nil, CatchAll},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deadLetteredEventType(tt.headers); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Log("✅ Event type of dead-lettered messages taken from their x-death entry")
+}
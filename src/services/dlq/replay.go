@@ -0,0 +1,76 @@
+package dlq
+
+import (
+	"context"
+	"fmt"
+	"go-order-eda/src/services/events"
+
+	"github.com/streadway/amqp"
+)
+
+// Broker is the part of the RabbitMQ service used to inspect and drain dead-letter queues.
+// It is satisfied by *rabbitmq.RabbitMQServiceImpl and by the in-memory rabbitmqtest.Broker.
+type Broker interface {
+	QueueDepth(queueName string) (int, error)
+	Get(queueName string) (amqp.Delivery, bool, error)
+	Republish(ctx context.Context, routingKey string, msg amqp.Delivery) error
+}
+
+// QueueDepth is the number of messages waiting in an event type's DLQ
+type QueueDepth struct {
+	EventType string
+	DLQ       string
+	Messages  int
+}
+
+// Depths returns the DLQ depth of every event type in events.Registry, in registry order
+func Depths(broker Broker) ([]QueueDepth, error) {
+	depths := make([]QueueDepth, 0, len(events.Registry))
+	for _, eventType := range events.Registry {
+		messages, err := broker.QueueDepth(eventType.DLQ)
+		if err != nil {
+			return nil, err
+		}
+		depths = append(depths, QueueDepth{EventType: eventType.Name, DLQ: eventType.DLQ, Messages: messages})
+	}
+	return depths, nil
+}
+
+// Replay moves up to limit messages from a DLQ back to the queue of its event type and returns
+// how many were moved. Each message is acknowledged only after it was republished; a message that
+// could not be republished is returned to the DLQ and the replay stops.
+func Replay(ctx context.Context, broker Broker, dlqName string, limit int) (int, error) {
+	var source *events.EventType
+	for _, eventType := range events.Registry {
+		if eventType.DLQ == dlqName {
+			source = &eventType
+			break
+		}
+	}
+	if source == nil {
+		return 0, fmt.Errorf("%s is not the DLQ of a registered event type", dlqName)
+	}
+
+	replayed := 0
+	for replayed < limit {
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+		msg, ok, err := broker.Get(dlqName)
+		if err != nil {
+			return replayed, err
+		}
+		if !ok {
+			break
+		}
+		if err := broker.Republish(ctx, source.RoutingKey, msg); err != nil {
+			msg.Nack(false, true)
+			return replayed, err
+		}
+		if err := msg.Ack(false); err != nil {
+			return replayed, fmt.Errorf("message republished to %s but not removed from %s: %w", source.Queue, dlqName, err)
+		}
+		replayed++
+	}
+	return replayed, nil
+}
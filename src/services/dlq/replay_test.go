@@ -0,0 +1,113 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go-order-eda/src/infrastructure/rabbitmq/rabbitmqtest"
+	"go-order-eda/src/services/events"
+
+	"github.com/streadway/amqp"
+)
+
+// failingBroker fails every republish
+type failingBroker struct {
+	*rabbitmqtest.Broker
+}
+
+func (b failingBroker) Republish(ctx context.Context, routingKey string, msg amqp.Delivery) error {
+	return errors.New("broker unavailable")
+}
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	orderCreated, _ := events.LookupEventType(events.OrderCreated)
+
+	t.Run("moves up to limit messages back to the source queue", func(t *testing.T) {
+		broker := rabbitmqtest.NewBroker()
+		var acks []*rabbitmqtest.Acknowledger
+		for i := 0; i < 5; i++ {
+			acks = append(acks, broker.Deliver(orderCreated.DLQ, []byte(fmt.Sprintf(`{"id":"order-%d"}`, i))))
+		}
+
+		replayed, err := Replay(ctx, broker, orderCreated.DLQ, 3)
+		if err != nil || replayed != 3 {
+			t.Fatalf("Expected 3 replayed messages, got %d and %v", replayed, err)
+		}
+
+		published := broker.Published(orderCreated.RoutingKey)
+		for i, body := range published {
+			if want := fmt.Sprintf(`{"id":"order-%d"}`, i); string(body) != want {
+				t.Errorf("Expected message %d to be %s, got %s", i, want, body)
+			}
+		}
+		if len(published) != 3 {
+			t.Errorf("Expected 3 republished messages, got %d", len(published))
+		}
+		for i, ack := range acks[:3] {
+			if !ack.Acked() {
+				t.Errorf("Expected replayed message %d to be acknowledged", i)
+			}
+		}
+		if depth, _ := broker.QueueDepth(orderCreated.DLQ); depth != 2 {
+			t.Errorf("Expected 2 messages left in the DLQ, got %d", depth)
+		}
+	})
+
+	t.Run("stops when the DLQ is empty", func(t *testing.T) {
+		broker := rabbitmqtest.NewBroker()
+		broker.Deliver(orderCreated.DLQ, []byte(`{}`))
+
+		replayed, err := Replay(ctx, broker, orderCreated.DLQ, 100)
+		if err != nil || replayed != 1 {
+			t.Errorf("Expected 1 replayed message, got %d and %v", replayed, err)
+		}
+	})
+
+	t.Run("a failed republish returns the message to the DLQ", func(t *testing.T) {
+		broker := failingBroker{rabbitmqtest.NewBroker()}
+		ack := broker.Deliver(orderCreated.DLQ, []byte(`{}`))
+
+		replayed, err := Replay(ctx, broker, orderCreated.DLQ, 10)
+		if err == nil || replayed != 0 {
+			t.Errorf("Expected an error and nothing replayed, got %d and %v", replayed, err)
+		}
+		if ack.Acked() || !ack.Requeued() {
+			t.Error("Expected the message to be requeued to the DLQ")
+		}
+	})
+
+	t.Run("unknown DLQ is rejected", func(t *testing.T) {
+		if _, err := Replay(ctx, rabbitmqtest.NewBroker(), "order.unknown.dlq", 10); err == nil {
+			t.Error("Expected an error for an unknown DLQ")
+		}
+	})
+
+	t.Log("✅ DLQ messages replayed to their source queue")
+}
+
+func TestDepths(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	orderCancelled, _ := events.LookupEventType(events.OrderCancelled)
+	broker.Deliver(orderCancelled.DLQ, []byte(`{}`))
+	broker.Deliver(orderCancelled.DLQ, []byte(`{}`))
+
+	depths, err := Depths(broker)
+	if err != nil {
+		t.Fatalf("Depths failed: %v", err)
+	}
+	if len(depths) != len(events.Registry) {
+		t.Fatalf("Expected one depth per event type, got %d", len(depths))
+	}
+	for _, depth := range depths {
+		want := 0
+		if depth.DLQ == orderCancelled.DLQ {
+			want = 2
+		}
+		if depth.Messages != want {
+			t.Errorf("Expected %d messages in %s, got %d", want, depth.DLQ, depth.Messages)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-order-eda/src/services/money"
+)
+
+// UnmarshalJSON reads the amount in minor units. Events published before amounts carried a
+// currency have a float amount in DefaultCurrency, which is converted on the way in.
+func (e *OrderRequestedEvent) UnmarshalJSON(data []byte) error {
+	type plain OrderRequestedEvent
+	var decoded struct {
+		plain
+		Amount json.Number `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*e = OrderRequestedEvent(decoded.plain)
+	var err error
+	e.Money, err = decodeAmount(decoded.Amount, decoded.Currency)
+	return err
+}
+
+// UnmarshalJSON reads the amount in minor units, converting the float amount of legacy events
+// as OrderRequestedEvent does
+func (e *OrderCreatedEvent) UnmarshalJSON(data []byte) error {
+	type plain OrderCreatedEvent
+	var decoded struct {
+		plain
+		Amount json.Number `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*e = OrderCreatedEvent(decoded.plain)
+	var err error
+	e.Money, err = decodeAmount(decoded.Amount, decoded.Currency)
+	return err
+}
+
+// decodeAmount returns the money of an event amount. An amount without a currency is a legacy
+// float in DefaultCurrency; anything else is already in minor units.
+func decodeAmount(amount json.Number, currency string) (money.Money, error) {
+	if amount == "" {
+		return money.New(0, currency), nil
+	}
+	if currency != "" {
+		minor, err := amount.Int64()
+		if err != nil {
+			return money.Money{}, fmt.Errorf("amount %s is not in minor units: %w", amount, err)
+		}
+		return money.New(minor, currency), nil
+	}
+
+	major, err := amount.Float64()
+	if err != nil {
+		return money.Money{}, fmt.Errorf("invalid legacy amount %s: %w", amount, err)
+	}
+	minor, err := money.FromMajor(major, money.DefaultCurrency)
+	return money.New(minor, money.DefaultCurrency), err
+}
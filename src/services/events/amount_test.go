@@ -0,0 +1,69 @@
+package events
+
+import (
+	"encoding/json"
+	"go-order-eda/src/services/money"
+	"testing"
+)
+
+func TestOrderRequestedEvent_AmountRoundTrip(t *testing.T) {
+	amount, err := money.Parse("19.99", "EUR")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	event := OrderRequestedEvent{ID: "order-1", Product: Product{ID: "product-1", Quantity: 1}, Money: amount}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded OrderRequestedEvent
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if decoded.Amount != 1999 || decoded.Currency != "EUR" {
+		t.Fatalf("Expected 1999 EUR, got %d %s from %s", decoded.Amount, decoded.Currency, body)
+	}
+	if formatted, _ := money.FormatMinor(decoded.Amount, decoded.Currency); formatted != "19.99" {
+		t.Errorf("Expected 19.99, got %s", formatted)
+	}
+
+	t.Log("✅ 19.99 round-tripped exactly")
+}
+
+func TestOrderEvents_DecodeLegacyAmounts(t *testing.T) {
+	legacy := []byte(`{"id":"order-1","product":{"id":"product-1","quantity":1},"amount":19.99,"status":"Requested"}`)
+
+	t.Run("OrderRequestedEvent", func(t *testing.T) {
+		var event OrderRequestedEvent
+		if err := json.Unmarshal(legacy, &event); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if event.Amount != 1999 || event.Currency != money.DefaultCurrency {
+			t.Errorf("Expected 1999 %s, got %d %s", money.DefaultCurrency, event.Amount, event.Currency)
+		}
+		if event.ID != "order-1" || event.Product.ID != "product-1" {
+			t.Errorf("Expected the other fields to be decoded, got %+v", event)
+		}
+	})
+
+	t.Run("OrderCreatedEvent", func(t *testing.T) {
+		var event OrderCreatedEvent
+		if err := json.Unmarshal(legacy, &event); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if event.Amount != 1999 || event.Currency != money.DefaultCurrency {
+			t.Errorf("Expected 1999 %s, got %d %s", money.DefaultCurrency, event.Amount, event.Currency)
+		}
+	})
+
+	t.Run("fractional minor units are rejected", func(t *testing.T) {
+		var event OrderRequestedEvent
+		if err := json.Unmarshal([]byte(`{"id":"order-1","amount":19.99,"currency":"USD"}`), &event); err == nil {
+			t.Errorf("Expected an error, got %+v", event)
+		}
+	})
+
+	t.Log("✅ Legacy float amounts converted to minor units")
+}
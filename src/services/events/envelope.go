@@ -0,0 +1,78 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EnvelopeSchemaVersion is the version of the envelope format; payloads carry their own Version
+const EnvelopeSchemaVersion = 1
+
+// Envelope carries an event together with its metadata. Every message is published inside an
+// envelope, so consumers can identify, deduplicate and correlate events without knowing the payload.
+type Envelope struct {
+	EventID       string          `json:"eventId"`
+	EventType     string          `json:"eventType"`
+	CorrelationID string          `json:"correlationId"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	SchemaVersion int             `json:"schemaVersion"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// NewEnvelope wraps a payload in an envelope with a new event ID.
+// Without a correlation ID the event starts a new chain and correlates with itself.
+func NewEnvelope(eventType, correlationID string, payload []byte) Envelope {
+	eventID := uuid.NewString()
+	if correlationID == "" {
+		correlationID = eventID
+	}
+	return Envelope{
+		EventID:       eventID,
+		EventType:     eventType,
+		CorrelationID: correlationID,
+		OccurredAt:    time.Now().UTC(),
+		SchemaVersion: EnvelopeSchemaVersion,
+		Payload:       payload,
+	}
+}
+
+// DecodeEnvelope reads the envelope of a consumed message. A legacy message published before
+// envelopes were introduced is returned as the payload of an otherwise empty envelope, with ok false.
+func DecodeEnvelope(body []byte) (envelope Envelope, ok bool) {
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.EventType != "" && len(envelope.Payload) > 0 {
+		return envelope, true
+	}
+	return Envelope{Payload: body}, false
+}
+
+// OrderIDOf returns the order an event payload refers to: its orderId, or the id of an order's own
+// events such as OrderCreatedEvent. It returns "" for payloads that refer to no order.
+func OrderIDOf(payload []byte) string {
+	var event struct {
+		ID      string `json:"id"`
+		OrderID string `json:"orderId"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return ""
+	}
+	if event.OrderID != "" {
+		return event.OrderID
+	}
+	return event.ID
+}
+
+type correlationIDKey struct{}
+
+// ContextWithCorrelationID returns a context whose publishes continue the given correlation chain
+func ContextWithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation ID of the event being handled, or "" outside a handler
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestEnvelope_RoundTrip(t *testing.T) {
+	payload := []byte(`{"orderId":"order-1","productId":"product-1","hasStock":true}`)
+	envelope := NewEnvelope(InventoryStatusUpdated, "correlation-1", payload)
+
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("Failed to marshal envelope: %v", err)
+	}
+	decoded, ok := DecodeEnvelope(body)
+	if !ok {
+		t.Fatal("Expected an enveloped message")
+	}
+
+	if decoded.EventID == "" || decoded.EventID != envelope.EventID {
+		t.Errorf("Expected event ID %q, got %q", envelope.EventID, decoded.EventID)
+	}
+	if decoded.EventType != InventoryStatusUpdated || decoded.CorrelationID != "correlation-1" {
+		t.Errorf("Unexpected metadata: %+v", decoded)
+	}
+	if decoded.SchemaVersion != EnvelopeSchemaVersion || !decoded.OccurredAt.Equal(envelope.OccurredAt) {
+		t.Errorf("Expected schema version %d and occurredAt %v, got %+v", EnvelopeSchemaVersion, envelope.OccurredAt, decoded)
+	}
+	if string(decoded.Payload) != string(payload) {
+		t.Errorf("Expected payload %s, got %s", payload, decoded.Payload)
+	}
+
+	t.Run("new chain correlates with itself", func(t *testing.T) {
+		envelope := NewEnvelope(OrderRequested, "", payload)
+		if envelope.CorrelationID != envelope.EventID {
+			t.Errorf("Expected correlation ID %q, got %q", envelope.EventID, envelope.CorrelationID)
+		}
+	})
+
+	t.Log("✅ Envelope round-tripped")
+}
+
+func TestDecodeEnvelope_Legacy(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "bare event", body: `{"id":"order-1","product":{"id":"product-1","quantity":1},"amount":10,"status":"Requested"}`},
+		{name: "invalid JSON", body: `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope, ok := DecodeEnvelope([]byte(tt.body))
+			if ok {
+				t.Error("Expected a legacy message")
+			}
+			if string(envelope.Payload) != tt.body {
+				t.Errorf("Expected the whole body as payload, got %s", envelope.Payload)
+			}
+			if envelope.CorrelationID != "" {
+				t.Errorf("Expected no correlation ID, got %q", envelope.CorrelationID)
+			}
+		})
+	}
+}
+
+func TestCorrelationIDFromContext(t *testing.T) {
+	if id := CorrelationIDFromContext(context.Background()); id != "" {
+		t.Errorf("Expected no correlation ID, got %q", id)
+	}
+	ctx := ContextWithCorrelationID(context.Background(), "correlation-1")
+	if id := CorrelationIDFromContext(ctx); id != "correlation-1" {
+		t.Errorf("Expected correlation-1, got %q", id)
+	}
+}
+
+func TestOrderIDOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{name: "event referring to an order", payload: `{"orderId":"order-1","productId":"product-1"}`, want: "order-1"},
+		{name: "order's own event", payload: `{"id":"order-2","status":"created"}`, want: "order-2"},
+		{name: "event without an order", payload: `{"productId":"product-1","quantity":3}`},
+		{name: "not JSON", payload: "order-3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OrderIDOf([]byte(tt.payload)); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
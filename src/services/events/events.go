@@ -1,62 +1,97 @@
+// Package events defines the messages exchanged between services.
+//
+// All timestamps, in events and in stored documents, are taken with time.Now().UTC().
+// encoding/json then renders them as RFC3339 with a "Z" suffix, so they compare
+// correctly across instances running in different time zones.
 package events
 
 import (
-	"errors"
+	"go-order-eda/src/services/money"
+	"strings"
 	"time"
 )
 
 const (
 	// Event types
-	OrderRequested         = "order.requested"     // New: Initial order request
+	OrderRequested         = "order.requested" // New: Initial order request
 	OrderCreated           = "order.created"
 	OrderCancelled         = "order.cancelled"
 	InventoryStatusUpdated = "inventory.status.updated"
 	NotificationSent       = "notification.sent"
-	
+	LowStock               = "inventory.low.stock"
+	NotificationRetry      = "notification.retry" // Carries InventoryStatusUpdated events whose notifications failed
+
 	// Event status enums for order_events collection
 	EventStatusPending   = "pending"   // Event is waiting to be processed
 	EventStatusFailed    = "failed"    // Event processing failed, needs replay
 	EventStatusCompleted = "completed" // Event was successfully processed
 	EventStatusReplaying = "replaying" // Event is currently being replayed
-	
+	EventStatusDead      = "dead"      // Event failed for longer than the retention window and was archived
+
 	// Order status enums
 	OrderStatusRequested = "Requested"
 	OrderStatusCreated   = "Created"
+	OrderStatusConfirmed = "Confirmed" // Stock reserved for the order
 	OrderStatusCancelled = "Cancelled"
 	OrderStatusCompleted = "Completed"
 	OrderStatusFailed    = "Failed"
 )
 
+// IsTerminalOrderStatus reports whether an order can no longer change, e.g. to be cancelled or expired
+func IsTerminalOrderStatus(status string) bool {
+	return status == OrderStatusCancelled || status == OrderStatusCompleted || status == OrderStatusFailed
+}
+
 type OrderRequestedEvent struct {
-	ID        string    `json:"id"`
-	Product   Product   `json:"product"`
-	Amount    float64   `json:"amount"`
+	ID      string  `json:"id"`
+	Product Product `json:"product"`
+	money.Money
 	Status    string    `json:"status"`
 	Version   int       `json:"version"`
 	TimeStamp time.Time `json:"timestamp"`
 }
 
 func (e *OrderRequestedEvent) Validate() error {
-	if e.ID == "" || e.Product.ID == "" || e.Product.Quantity <= 0 {
-		return errors.New("missing required fields in OrderRequestedEvent")
+	v := NewValidationError("OrderRequestedEvent")
+	if e.ID == "" {
+		v.Add("id", "is required")
+	}
+	if e.Product.ID == "" {
+		v.Add("product.id", "is required")
+	}
+	if e.Product.Quantity <= 0 {
+		v.Add("product.quantity", "must be greater than 0")
+	}
+	if e.Amount <= 0 {
+		v.Add("amount", "must be greater than 0")
+	}
+	if !money.IsKnownCurrency(e.Currency) {
+		v.Add("currency", "must be a known ISO 4217 code")
 	}
-	return nil
+	return v.Err()
 }
 
 type OrderCreatedEvent struct {
-	ID        string    `json:"id"`
-	Product   Product   `json:"product"`
-	Amount    float64   `json:"amount"`
+	ID      string  `json:"id"`
+	Product Product `json:"product"`
+	money.Money
 	Status    string    `json:"status"`
 	Version   int       `json:"version"`
 	TimeStamp time.Time `json:"timestamp"`
 }
 
 func (e *OrderCreatedEvent) Validate() error {
-	if e.ID == "" || e.Product.ID == "" || e.Status == "" {
-		return errors.New("missing required fields in OrderCreatedEvent")
+	v := NewValidationError("OrderCreatedEvent")
+	if e.ID == "" {
+		v.Add("id", "is required")
 	}
-	return nil
+	if e.Product.ID == "" {
+		v.Add("product.id", "is required")
+	}
+	if e.Status == "" {
+		v.Add("status", "is required")
+	}
+	return v.Err()
 }
 
 type Product struct {
@@ -73,10 +108,14 @@ type OrderCancelledEvent struct {
 }
 
 func (e *OrderCancelledEvent) Validate() error {
-	if e.OrderID == "" || e.Status == "" {
-		return errors.New("missing required fields in OrderCancelledEvent")
+	v := NewValidationError("OrderCancelledEvent")
+	if e.OrderID == "" {
+		v.Add("orderId", "is required")
 	}
-	return nil
+	if e.Status == "" {
+		v.Add("status", "is required")
+	}
+	return v.Err()
 }
 
 type InventoryStatusUpdatedEvent struct {
@@ -88,22 +127,76 @@ type InventoryStatusUpdatedEvent struct {
 }
 
 func (e *InventoryStatusUpdatedEvent) Validate() error {
-	if e.OrderID == "" || e.ProductID == "" {
-		return errors.New("missing required fields in InventoryStatusUpdatedEvent")
+	v := NewValidationError("InventoryStatusUpdatedEvent")
+	if e.OrderID == "" {
+		v.Add("orderId", "is required")
+	}
+	if e.ProductID == "" {
+		v.Add("productId", "is required")
 	}
-	return nil
+	return v.Err()
 }
 
+// Delivery outcomes of a notification channel, as reported in NotificationSentEvent.Status
+const (
+	NotificationDelivered = "delivered"
+	NotificationFailed    = "failed"
+)
+
+// Kinds of notification, as reported in NotificationSentEvent.MessageType
+const (
+	NotificationConfirmation = "confirmation"
+	NotificationCancellation = "cancellation"
+)
+
 type NotificationSentEvent struct {
-	OrderID   string    `json:"orderId"`
-	Message   string    `json:"message"`
-	Version   int       `json:"version"`
-	TimeStamp time.Time `json:"timestamp"`
+	OrderID   string `json:"orderId"`
+	ProductID string `json:"productId,omitempty"`
+	Message   string `json:"message"`
+	// Whether the notification confirms or cancels the order; events published before it was reported carry none
+	MessageType string `json:"messageType,omitempty"`
+	// Channels the notification was sent through, and the outcome of each keyed by channel
+	Channels  []string          `json:"channels,omitempty"`
+	Status    map[string]string `json:"status,omitempty"`
+	Version   int               `json:"version"`
+	TimeStamp time.Time         `json:"timestamp"`
 }
 
 func (e *NotificationSentEvent) Validate() error {
-	if e.OrderID == "" || e.Message == "" {
-		return errors.New("missing required fields in NotificationSentEvent")
+	v := NewValidationError("NotificationSentEvent")
+	if e.OrderID == "" {
+		v.Add("orderId", "is required")
+	}
+	if e.Message == "" {
+		v.Add("message", "is required")
+	}
+	return v.Err()
+}
+
+// Confirms reports whether the notification confirms the order, which completes it. Events without
+// a message type are told apart by their message, which only cancellations start with "Order cancelled".
+func (e *NotificationSentEvent) Confirms() bool {
+	if e.MessageType != "" {
+		return e.MessageType == NotificationConfirmation
+	}
+	return !strings.HasPrefix(e.Message, "Order cancelled")
+}
+
+type LowStockEvent struct {
+	ProductID         string    `json:"productId"`
+	RemainingQuantity int       `json:"remainingQuantity"`
+	Threshold         int       `json:"threshold"`
+	Version           int       `json:"version"`
+	TimeStamp         time.Time `json:"timestamp"`
+}
+
+func (e *LowStockEvent) Validate() error {
+	v := NewValidationError("LowStockEvent")
+	if e.ProductID == "" {
+		v.Add("productId", "is required")
+	}
+	if e.RemainingQuantity < 0 {
+		v.Add("remainingQuantity", "must not be negative")
 	}
-	return nil
+	return v.Err()
 }
@@ -0,0 +1,87 @@
+package events
+
+import (
+	"reflect"
+	"time"
+)
+
+// EventType describes how one event type travels through the broker
+type EventType struct {
+	Name       string       // Event type, as used in envelopes and handler registrations
+	RoutingKey string       // Key the event is published with
+	Queue      string       // Queue bound to the routing key and consumed by the event's handler
+	DLQ        string       // Queue receiving dead-lettered messages, bound with its own name as routing key
+	Payload    reflect.Type // Struct carried in the envelope's payload, described by Schema
+	// Time a message waits unconsumed in Queue before the broker dead-letters it to DLQ, for events
+	// only worth handling promptly; 0 keeps messages until they are consumed
+	MessageTTL time.Duration
+	// Time a message published with RoutingKey is held in DelayQueue before the broker routes it to
+	// Queue, e.g. to retry a failed step after a pause; 0 routes it to Queue straight away
+	Delay      time.Duration
+	DelayQueue string // Queue holding messages for Delay, empty unless Delay is set
+}
+
+// QueueRoutingKey returns the key Queue is bound with. An event type with a delay binds its
+// DelayQueue to RoutingKey instead, and Queue to the key DelayQueue dead-letters expired messages with.
+func (t EventType) QueueRoutingKey() string {
+	if t.DelayQueue == "" {
+		return t.RoutingKey
+	}
+	return t.RoutingKey + ".due"
+}
+
+// Registry declares every event type. The broker topology, the event listener's handlers, the
+// status probes and the published schemas are all derived from it, so adding an event type only
+// takes an entry here.
+var Registry = []EventType{
+	newEventType(OrderRequested, OrderRequestedEvent{}),
+	newEventType(OrderCreated, OrderCreatedEvent{}),
+	newEventType(OrderCancelled, OrderCancelledEvent{}),
+	newEventType(InventoryStatusUpdated, InventoryStatusUpdatedEvent{}),
+	newEventType(NotificationSent, NotificationSentEvent{}),
+	newEventType(LowStock, LowStockEvent{}),
+	newDelayedEventType(NotificationRetry, InventoryStatusUpdatedEvent{}, 30*time.Second),
+}
+
+// newEventType routes an event whose payload is of the type of payload through a queue of the
+// same name and a ".dlq" queue
+func newEventType(name string, payload any) EventType {
+	return EventType{
+		Name:       name,
+		RoutingKey: name,
+		Queue:      name,
+		DLQ:        name + ".dlq",
+		Payload:    reflect.TypeOf(payload),
+	}
+}
+
+// newDelayedEventType is newEventType for an event held for delay in a ".delay" queue before it is
+// handled. The delay is part of the queue's declaration, which the broker rejects redeclaring with
+// a different one, so changing it means deleting the ".delay" queue first.
+func newDelayedEventType(name string, payload any, delay time.Duration) EventType {
+	eventType := newEventType(name, payload)
+	eventType.Delay = delay
+	eventType.DelayQueue = name + ".delay"
+	return eventType
+}
+
+// LookupEventType returns the registry entry for an event type
+func LookupEventType(name string) (EventType, bool) {
+	for _, eventType := range Registry {
+		if eventType.Name == name {
+			return eventType, true
+		}
+	}
+	return EventType{}, false
+}
+
+// IsDeclaredQueue reports whether the broker topology declares a queue, i.e. whether it is the
+// queue or the DLQ of an event type in the registry
+func IsDeclaredQueue(name string) bool {
+	for _, eventType := range Registry {
+		if eventType.Queue == name || eventType.DLQ == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package events
+
+import "testing"
+
+func TestRegistry(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, eventType := range Registry {
+		for _, name := range []string{eventType.Queue, eventType.DLQ} {
+			if name == "" {
+				t.Errorf("Event type %s has an empty queue name", eventType.Name)
+			}
+			if seen[name] {
+				t.Errorf("Queue %s used by more than one event type", name)
+			}
+			seen[name] = true
+		}
+		if !IsDeclaredQueue(eventType.Queue) || !IsDeclaredQueue(eventType.DLQ) {
+			t.Errorf("Expected the queues of %s to be declared", eventType.Name)
+		}
+		if found, ok := LookupEventType(eventType.Name); !ok || found != eventType {
+			t.Errorf("Expected LookupEventType to find %s, got %+v", eventType.Name, found)
+		}
+	}
+
+	if _, ok := LookupEventType("order.unknown"); ok {
+		t.Error("Expected an unknown event type not to be found")
+	}
+	if IsDeclaredQueue("order.unknown") {
+		t.Error("Expected an unknown queue not to be declared")
+	}
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// schemaDialect is the JSON Schema version the schemas are written in
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema. The schemas of event payloads are derived from their structs by
+// reflection, following the encoding/json rules, so they cannot drift from what is published.
+type Schema struct {
+	Dialect              string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"` // Fields always published, i.e. not omitempty
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Schema returns the JSON Schema of the event type's payload
+func (t EventType) Schema() *Schema {
+	schema := schemaOf(t.Payload)
+	schema.Dialect = schemaDialect
+	schema.Title = t.Payload.Name()
+	schema.Description = "Payload of the " + t.Name + " event, carried in the envelope's payload field"
+	return schema
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaOf describes how encoding/json renders a value of type t
+func schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawJSONType:
+		return &Schema{} // Any JSON value
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string"} // Bytes are rendered base64-encoded
+		}
+		return &Schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem())}
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addFields(schema, t)
+		return schema
+	}
+	return &Schema{}
+}
+
+// addFields adds the exported fields of struct t to schema, flattening embedded structs without
+// a JSON name into it as encoding/json does
+func addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			addFields(schema, fieldType)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = schemaOf(field.Type)
+		if !strings.Contains(","+options+",", ",omitempty,") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
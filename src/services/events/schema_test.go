@@ -0,0 +1,84 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestEventType_Schema(t *testing.T) {
+	t.Run("OrderCreatedEvent", func(t *testing.T) {
+		eventType, _ := LookupEventType(OrderCreated)
+		schema := eventType.Schema()
+		if schema.Title != "OrderCreatedEvent" || schema.Type != "object" || schema.Dialect != schemaDialect {
+			t.Fatalf("Expected the object schema of OrderCreatedEvent, got %+v", schema)
+		}
+
+		want := map[string]string{
+			"id":        "string",
+			"product":   "object",
+			"amount":    "integer", // Flattened from the embedded money.Money
+			"currency":  "string",
+			"status":    "string",
+			"version":   "integer",
+			"timestamp": "string",
+		}
+		if len(schema.Properties) != len(want) {
+			t.Errorf("Expected properties %v, got %v", want, schema.Properties)
+		}
+		for name, wantType := range want {
+			if property := schema.Properties[name]; property == nil || property.Type != wantType {
+				t.Errorf("Expected %s to be a %s, got %+v", name, wantType, property)
+			}
+		}
+		if format := schema.Properties["timestamp"].Format; format != "date-time" {
+			t.Errorf("Expected timestamp to be a date-time, got %q", format)
+		}
+		product := schema.Properties["product"]
+		for _, name := range []string{"id", "name", "quantity"} {
+			if product.Properties[name] == nil || !slices.Contains(product.Required, name) {
+				t.Errorf("Expected product.%s to be a required property, got %+v", name, product)
+			}
+		}
+		if !slices.Contains(schema.Required, "amount") || len(schema.Required) != len(want) {
+			t.Errorf("Expected every property required, got %v", schema.Required)
+		}
+	})
+
+	t.Run("omitempty fields are optional", func(t *testing.T) {
+		eventType, _ := LookupEventType(NotificationSent)
+		schema := eventType.Schema()
+		for _, name := range []string{"productId", "channels", "status"} {
+			if schema.Properties[name] == nil || slices.Contains(schema.Required, name) {
+				t.Errorf("Expected %s to be an optional property, got %v", name, schema.Required)
+			}
+		}
+		if channels := schema.Properties["channels"]; channels.Type != "array" || channels.Items.Type != "string" {
+			t.Errorf("Expected channels to be an array of strings, got %+v", channels)
+		}
+		if status := schema.Properties["status"]; status.Type != "object" || status.AdditionalProperties.Type != "string" {
+			t.Errorf("Expected status to map channels to strings, got %+v", status)
+		}
+	})
+
+	t.Run("every registered payload is described", func(t *testing.T) {
+		for _, eventType := range Registry {
+			schema := eventType.Schema()
+			if len(schema.Properties) == 0 {
+				t.Errorf("Expected properties for %s", eventType.Name)
+			}
+			// A published payload only holds properties of its schema
+			sample, _ := json.Marshal(reflect.New(eventType.Payload).Interface())
+			var fields map[string]any
+			json.Unmarshal(sample, &fields)
+			for name := range fields {
+				if schema.Properties[name] == nil {
+					t.Errorf("Field %s of %s is missing from its schema", name, eventType.Name)
+				}
+			}
+		}
+	})
+
+	t.Log("✅ Event schemas derived from the payload structs")
+}
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes one invalid field, using its JSON path such as "product.id"
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field found while validating a payload
+type ValidationError struct {
+	Subject string       `json:"-"` // What was validated, e.g. "OrderRequestedEvent"
+	Fields  []FieldError `json:"fields"`
+}
+
+// NewValidationError starts an empty validation result for subject
+func NewValidationError(subject string) *ValidationError {
+	return &ValidationError{Subject: subject}
+}
+
+// Add records an invalid field
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns the validation error, or nil when no field was invalid
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return fmt.Sprintf("validation failed for %s: %s", e.Subject, strings.Join(parts, "; "))
+}
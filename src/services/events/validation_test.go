@@ -0,0 +1,90 @@
+package events
+
+import (
+	"errors"
+	"go-order-eda/src/services/money"
+	"strings"
+	"testing"
+)
+
+// fieldsOf returns the invalid field paths reported by err
+func fieldsOf(t *testing.T, err error) []string {
+	t.Helper()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	fields := make([]string, len(validationErr.Fields))
+	for i, f := range validationErr.Fields {
+		fields[i] = f.Field
+	}
+	return fields
+}
+
+func TestEventValidation_ReportsEveryInvalidField(t *testing.T) {
+	tests := []struct {
+		name       string
+		validate   func() error
+		wantFields []string
+	}{
+		{
+			name:       "OrderRequestedEvent",
+			validate:   (&OrderRequestedEvent{Product: Product{Quantity: -1}}).Validate,
+			wantFields: []string{"id", "product.id", "product.quantity", "amount", "currency"},
+		},
+		{
+			name:       "OrderCreatedEvent",
+			validate:   (&OrderCreatedEvent{ID: "order-1"}).Validate,
+			wantFields: []string{"product.id", "status"},
+		},
+		{
+			name:       "OrderCancelledEvent",
+			validate:   (&OrderCancelledEvent{}).Validate,
+			wantFields: []string{"orderId", "status"},
+		},
+		{
+			name:       "InventoryStatusUpdatedEvent",
+			validate:   (&InventoryStatusUpdatedEvent{}).Validate,
+			wantFields: []string{"orderId", "productId"},
+		},
+		{
+			name:       "NotificationSentEvent",
+			validate:   (&NotificationSentEvent{OrderID: "order-1"}).Validate,
+			wantFields: []string{"message"},
+		},
+		{
+			name:       "LowStockEvent",
+			validate:   (&LowStockEvent{RemainingQuantity: -1}).Validate,
+			wantFields: []string{"productId", "remainingQuantity"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := fieldsOf(t, tt.validate())
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("Expected fields %v, got %v", tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+func TestValidationError_Error(t *testing.T) {
+	event := OrderRequestedEvent{ID: "order-1", Money: money.New(1999, "USD")}
+	err := event.Validate()
+	if err == nil {
+		t.Fatal("Expected a validation error")
+	}
+
+	want := "validation failed for OrderRequestedEvent: product.id is required; product.quantity must be greater than 0"
+	if err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestValidationError_ValidEventReturnsNil(t *testing.T) {
+	event := OrderRequestedEvent{ID: "order-1", Product: Product{ID: "product-1", Quantity: 1}, Money: money.New(1999, "USD")}
+	if err := event.Validate(); err != nil {
+		t.Errorf("Expected nil error interface, got %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package inventory
+
+import "errors"
+
+var (
+	// ErrProductNotFound is returned when the requested product does not exist
+	ErrProductNotFound = errors.New("product not found")
+	// ErrNegativeQuantity is returned when a stock quantity below zero is requested
+	ErrNegativeQuantity = errors.New("quantity cannot be negative")
+	// ErrNonPositiveRestock is returned when a restock delta is zero or negative
+	ErrNonPositiveRestock = errors.New("restock quantity must be greater than 0")
+	// ErrQuantityBelowReserved is returned when a new stock quantity would be less than the reserved amount
+	ErrQuantityBelowReserved = errors.New("quantity cannot be less than the reserved amount")
+	// ErrReservationExists is returned when an order already holds an active reservation of the product
+	ErrReservationExists = errors.New("order already has an active reservation of the product")
+	// ErrOrderNotFound is returned by an OrderStore for an order it does not know
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrReservationMismatch is returned when an order's reservations are for other products
+	ErrReservationMismatch = errors.New("order reservation is for a different product")
+)
@@ -3,28 +3,36 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"go-order-eda/src/infrastructure"
 	"go-order-eda/src/infrastructure/log"
-	rabbitmq "go-order-eda/src/infrastructure/rabbitmq"
 	"go-order-eda/src/services/events"
 	"go-order-eda/src/services/inventory"
 	"go-order-eda/src/services/order/domain/persistence"
+
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// cancelledOrderStore reads and cancels the cancelled order. It is satisfied by *persistence.OrderRepository;
+// GetOrderByID returns mongo.ErrNoDocuments for an unknown or archived order.
+type cancelledOrderStore interface {
+	GetOrderByID(ctx context.Context, id string) (*persistence.OrderDocument, error)
+	CancelActiveOrder(ctx context.Context, id string) (bool, error)
+}
+
 type OrderCancelledEventHandler struct {
-	rabbitMQService  *rabbitmq.RabbitMQServiceImpl
-	orderRepository  *persistence.OrderRepository
+	orderRepository  cancelledOrderStore
 	inventoryService inventory.InventoryService
 	logger           log.Logger
 }
 
 func NewOrderCancelledEventHandler(
-	rabbit *rabbitmq.RabbitMQServiceImpl,
 	orderRepo *persistence.OrderRepository,
 	inventoryService inventory.InventoryService,
 	logger log.Logger,
 ) *OrderCancelledEventHandler {
 	return &OrderCancelledEventHandler{
-		rabbitMQService:  rabbit,
 		orderRepository:  orderRepo,
 		inventoryService: inventoryService,
 		logger:           logger,
@@ -32,52 +40,54 @@ func NewOrderCancelledEventHandler(
 }
 
 // Handle processes the OrderCancelledEvent message
-func (h *OrderCancelledEventHandler) Handle(ctx context.Context, msgBody []byte) {
+func (h *OrderCancelledEventHandler) Handle(ctx context.Context, msgBody []byte) error {
 	var event events.OrderCancelledEvent
 	if err := json.Unmarshal(msgBody, &event); err != nil {
 		h.logger.Exception(ctx, "Failed to unmarshal OrderCancelledEvent", err)
-		h.sendToDLQ(msgBody)
-		return
+		return infrastructure.Permanent(err)
 	}
 
-	// Get the order to retrieve product information
 	order, err := h.orderRepository.GetOrderByID(ctx, event.OrderID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Unknown or archived; a redelivery would not find it either
+		h.logger.Warn(ctx, "Order not found for cancellation: "+event.OrderID)
+		return nil
+	}
 	if err != nil {
 		h.logger.Exception(ctx, "Failed to get order for cancellation", err)
-		h.sendToDLQ(msgBody)
-		return
+		return infrastructure.Transient(err)
 	}
 
-	if order == nil {
-		h.logger.Warn(ctx, "Order not found for cancellation: "+event.OrderID)
-		return
+	// Release exactly what the reservations ledger holds for the order, so products whose reservation
+	// failed are not returned to stock; releasing twice is a no-op. A confirmed order the ledger holds
+	// nothing for was reserved before the ledger existed and releases its product instead.
+	var unrecorded *inventory.Reservation
+	if order.Status == events.OrderStatusConfirmed {
+		unrecorded = &inventory.Reservation{OrderID: order.ID, ProductID: order.Product.ID, Quantity: order.Product.Quantity}
 	}
-
-	// Delegate to inventory service to release reserved product
-	err = h.inventoryService.ReleaseReservedProduct(ctx, order.Product.ID, order.Product.Quantity)
+	released, err := h.inventoryService.ReleaseOrderReservations(ctx, event.OrderID, unrecorded)
 	if err != nil {
-		h.logger.Exception(ctx, "Error releasing reserved product through inventory service", err)
-		h.sendToDLQ(msgBody)
-		return
+		h.logger.Exception(ctx, "Error releasing reserved products through inventory service", err)
+		return infrastructure.Transient(err)
+	}
+	if len(released) == 0 {
+		h.logger.Info(ctx, "No active reservation to release for cancelled order: "+event.OrderID)
+	}
+	for _, reservation := range released {
+		h.logger.Info(ctx, fmt.Sprintf("Released %d of %s for cancelled order %s", reservation.Quantity, reservation.ProductID, event.OrderID))
 	}
 
-	// Update order status to cancelled
-	update := map[string]any{"status": "Cancelled"}
-	err = h.orderRepository.UpdateOrder(ctx, event.OrderID, update)
+	// Only a non-terminal order is cancelled, so a completion that won the race is kept
+	cancelled, err := h.orderRepository.CancelActiveOrder(ctx, event.OrderID)
 	if err != nil {
 		h.logger.Exception(ctx, "Failed to update order status to cancelled", err)
-		h.sendToDLQ(msgBody)
-		return
+		return infrastructure.Transient(err)
+	}
+	if !cancelled {
+		h.logger.Warn(ctx, "Order already in a terminal status, not cancelled: "+event.OrderID)
+		return nil
 	}
 
 	h.logger.Info(ctx, "Order cancelled and inventory released for order: "+event.OrderID)
-}
-
-func (h *OrderCancelledEventHandler) sendToDLQ(body []byte) {
-	// Simply send to DLQ queue - another process will handle storing to MongoDB
-	err := h.rabbitMQService.Publish("order.cancelled.dlq", body)
-	if err != nil {
-		// Use context.TODO() since we don't have ctx in this method
-		h.logger.Exception(context.TODO(), "Failed to send event to DLQ", err)
-	}
+	return nil
 }
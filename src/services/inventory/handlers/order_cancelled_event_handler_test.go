@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-order-eda/src/infrastructure"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/inventory"
+	"go-order-eda/src/services/order/domain/persistence"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeCancelledOrderStore serves one stored order and records the orders it cancelled
+type fakeCancelledOrderStore struct {
+	order     *persistence.OrderDocument
+	cancelled []string
+}
+
+func (s *fakeCancelledOrderStore) GetOrderByID(ctx context.Context, id string) (*persistence.OrderDocument, error) {
+	if s.order == nil || s.order.ID != id {
+		return nil, mongo.ErrNoDocuments
+	}
+	return s.order, nil
+}
+
+func (s *fakeCancelledOrderStore) CancelActiveOrder(ctx context.Context, id string) (bool, error) {
+	if s.order == nil || s.order.ID != id || events.IsTerminalOrderStatus(s.order.Status) {
+		return false, nil
+	}
+	s.order.Status = events.OrderStatusCancelled
+	s.cancelled = append(s.cancelled, id)
+	return true, nil
+}
+
+// ledgerInventory releases the reservations recorded per order, failing with err if set.
+// Methods the tests do not use fall through to the nil embedded interface.
+type ledgerInventory struct {
+	inventory.InventoryService
+	ledger     map[string][]inventory.Reservation
+	released   []string               // Orders whose reservations were released
+	unrecorded *inventory.Reservation // Released in place of an empty ledger entry
+	err        error
+}
+
+func (f *ledgerInventory) ReleaseOrderReservations(ctx context.Context, orderID string, unrecorded *inventory.Reservation) ([]inventory.Reservation, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.released = append(f.released, orderID)
+	f.unrecorded = unrecorded
+	reservations := f.ledger[orderID]
+	delete(f.ledger, orderID)
+	return reservations, nil
+}
+
+func TestOrderCancelledEventHandler_ReleasesTheLedger(t *testing.T) {
+	cancelled, _ := json.Marshal(events.OrderCancelledEvent{OrderID: "order-1"})
+	newOrderStore := func() *fakeCancelledOrderStore {
+		return &fakeCancelledOrderStore{order: &persistence.OrderDocument{
+			ID:      "order-1",
+			Status:  "Processing",
+			Product: persistence.ProductDocument{ID: "product-3", Quantity: 4}, // Its reservation failed
+		}}
+	}
+
+	t.Run("partially reserved order releases the reserved products only", func(t *testing.T) {
+		orders := newOrderStore()
+		stock := &ledgerInventory{ledger: map[string][]inventory.Reservation{"order-1": {
+			{OrderID: "order-1", ProductID: "product-1", Quantity: 2, Status: inventory.ReservationActive},
+			{OrderID: "order-1", ProductID: "product-2", Quantity: 5, Status: inventory.ReservationActive},
+		}}}
+		handler := &OrderCancelledEventHandler{orderRepository: orders, inventoryService: stock, logger: log.NewLogger()}
+
+		if err := handler.Handle(context.Background(), cancelled); err != nil {
+			t.Fatalf("Handle failed: %v", err)
+		}
+		if len(stock.released) != 1 || stock.released[0] != "order-1" {
+			t.Errorf("Expected the ledger of order-1 released, got %v", stock.released)
+		}
+		if orders.order.Status != events.OrderStatusCancelled {
+			t.Errorf("Expected the order cancelled, got %s", orders.order.Status)
+		}
+	})
+
+	t.Run("order without reservations is still cancelled", func(t *testing.T) {
+		orders := newOrderStore()
+		handler := &OrderCancelledEventHandler{orderRepository: orders, inventoryService: &ledgerInventory{}, logger: log.NewLogger()}
+
+		if err := handler.Handle(context.Background(), cancelled); err != nil {
+			t.Fatalf("Handle failed: %v", err)
+		}
+		if orders.order.Status != events.OrderStatusCancelled {
+			t.Errorf("Expected the order cancelled, got %s", orders.order.Status)
+		}
+	})
+
+	t.Run("order with a failed reservation releases no unrecorded stock", func(t *testing.T) {
+		stock := &ledgerInventory{}
+		handler := &OrderCancelledEventHandler{orderRepository: newOrderStore(), inventoryService: stock, logger: log.NewLogger()}
+
+		if err := handler.Handle(context.Background(), cancelled); err != nil {
+			t.Fatalf("Handle failed: %v", err)
+		}
+		if stock.unrecorded != nil {
+			t.Errorf("Expected nothing released outside the ledger, got %+v", stock.unrecorded)
+		}
+	})
+
+	t.Run("confirmed order reserved before the ledger releases its product", func(t *testing.T) {
+		orders := newOrderStore()
+		orders.order.Status = events.OrderStatusConfirmed
+		stock := &ledgerInventory{}
+		handler := &OrderCancelledEventHandler{orderRepository: orders, inventoryService: stock, logger: log.NewLogger()}
+
+		if err := handler.Handle(context.Background(), cancelled); err != nil {
+			t.Fatalf("Handle failed: %v", err)
+		}
+		if stock.unrecorded == nil || stock.unrecorded.ProductID != "product-3" || stock.unrecorded.Quantity != 4 {
+			t.Errorf("Expected the order's product released, got %+v", stock.unrecorded)
+		}
+	})
+
+	t.Run("missing order is acknowledged", func(t *testing.T) {
+		orders := &fakeCancelledOrderStore{}
+		stock := &ledgerInventory{}
+		handler := &OrderCancelledEventHandler{orderRepository: orders, inventoryService: stock, logger: log.NewLogger()}
+
+		if err := handler.Handle(context.Background(), cancelled); err != nil {
+			t.Fatalf("Expected a missing order to be acknowledged, got %v", err)
+		}
+		if len(stock.released) != 0 || len(orders.cancelled) != 0 {
+			t.Errorf("Expected nothing released or cancelled, got %v and %v", stock.released, orders.cancelled)
+		}
+	})
+
+	t.Run("completed order is not overwritten", func(t *testing.T) {
+		orders := newOrderStore()
+		orders.order.Status = events.OrderStatusCompleted
+		handler := &OrderCancelledEventHandler{orderRepository: orders, inventoryService: &ledgerInventory{}, logger: log.NewLogger()}
+
+		if err := handler.Handle(context.Background(), cancelled); err != nil {
+			t.Fatalf("Handle failed: %v", err)
+		}
+		if orders.order.Status != events.OrderStatusCompleted || len(orders.cancelled) != 0 {
+			t.Errorf("Expected the completed order kept, got %s", orders.order.Status)
+		}
+	})
+
+	t.Run("release failure is retried", func(t *testing.T) {
+		orders := newOrderStore()
+		stock := &ledgerInventory{err: errors.New("mongo unavailable")}
+		handler := &OrderCancelledEventHandler{orderRepository: orders, inventoryService: stock, logger: log.NewLogger()}
+
+		err := handler.Handle(context.Background(), cancelled)
+		if err == nil || !infrastructure.IsRetryable(err) {
+			t.Errorf("Expected a transient error, got %v", err)
+		}
+		if len(orders.cancelled) != 0 {
+			t.Error("Expected the order not to be cancelled before its stock is released")
+		}
+	})
+
+	t.Log("✅ Cancelled orders release exactly their recorded reservations")
+}
@@ -3,6 +3,9 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"go-order-eda/src/infrastructure"
 	"go-order-eda/src/infrastructure/log"
 	rabbitmq "go-order-eda/src/infrastructure/rabbitmq"
 	"go-order-eda/src/services/events"
@@ -12,14 +15,14 @@ import (
 )
 
 type OrderCreatedEventHandler struct {
-	rabbitMQService  *rabbitmq.RabbitMQServiceImpl
+	rabbitMQService  rabbitmq.Publisher
 	orderRepository  *persistence.OrderRepository
 	inventoryService inventory.InventoryService
 	logger           log.Logger
 }
 
 func NewOrderCreatedEventHandler(
-	rabbit *rabbitmq.RabbitMQServiceImpl,
+	rabbit rabbitmq.Publisher,
 	orderRepo *persistence.OrderRepository,
 	inventoryService inventory.InventoryService,
 	logger log.Logger,
@@ -32,75 +35,71 @@ func NewOrderCreatedEventHandler(
 	}
 }
 
-// Handle processes the OrderCreatedEvent message
-func (h *OrderCreatedEventHandler) Handle(ctx context.Context, msgBody []byte) {
+// Handle processes the OrderCreatedEvent message. A redelivered message whose reservation was
+// already recorded skips the reservation and finishes confirming the order.
+func (h *OrderCreatedEventHandler) Handle(ctx context.Context, msgBody []byte) error {
 	var event events.OrderCreatedEvent
 	if err := json.Unmarshal(msgBody, &event); err != nil {
 		h.logger.Exception(ctx, "Failed to unmarshal OrderCreatedEvent", err)
-		h.sendToDLQ(msgBody)
-		return
+		return infrastructure.Permanent(err)
 	}
 
 	// Delegate to inventory service for business logic
-	ok, err := h.inventoryService.ReserveProduct(ctx, event.Product.ID, event.Product.Quantity)
+	product, err := h.inventoryService.ReserveProductForOrder(ctx, event.ID, event.Product.ID, event.Product.Quantity)
+	reserved := product != nil
+	if errors.Is(err, inventory.ErrReservationExists) {
+		h.logger.Info(ctx, "Inventory already reserved for order: "+event.ID)
+		reserved, err = true, nil
+	}
 	if err != nil {
 		h.logger.Exception(ctx, "Error reserving product through inventory service", err)
-		h.sendToDLQ(msgBody)
-		return
+		return infrastructure.Transient(err)
 	}
 
-	if ok {
-		// Update order status to confirmed
-		update := map[string]any{"status": "Confirmed"}
-		err := h.orderRepository.UpdateOrder(ctx, event.ID, update)
-		if err != nil {
-			h.logger.Exception(ctx, "Failed to update order status", err)
-			h.sendToDLQ(msgBody)
-			return
-		}
-		h.logger.Info(ctx, "Order confirmed and inventory reserved for order: "+event.ID)
-
-		// Publish InventoryStatusUpdated event to continue the chain
-		h.publishInventoryStatusUpdated(ctx, event.ID, event.Product.ID, true)
-	} else {
+	if !reserved {
 		h.logger.Warn(ctx, "Product not found or not enough quantity for order: "+event.ID)
 
 		// Publish InventoryStatusUpdated event with HasStock=false
-		h.publishInventoryStatusUpdated(ctx, event.ID, event.Product.ID, false)
-		h.sendToDLQ(msgBody)
+		if err := h.publishInventoryStatusUpdated(ctx, event.ID, event.Product.ID, false); err != nil {
+			return err
+		}
+		return infrastructure.Permanent(fmt.Errorf("insufficient stock of product %s for order %s", event.Product.ID, event.ID))
 	}
-}
 
-func (h *OrderCreatedEventHandler) sendToDLQ(body []byte) {
-	// Simply send to DLQ queue - another process will handle storing to MongoDB
-	err := h.rabbitMQService.Publish("order.created.dlq", body)
-	if err != nil {
-		// Use context.TODO() since we don't have ctx in this method
-		h.logger.Exception(context.TODO(), "Failed to send event to DLQ", err)
+	// Update order status to confirmed
+	update := map[string]any{"status": events.OrderStatusConfirmed}
+	if err := h.orderRepository.UpdateOrder(ctx, event.ID, update); err != nil {
+		h.logger.Exception(ctx, "Failed to update order status", err)
+		return infrastructure.Transient(err)
 	}
+	h.logger.Info(ctx, "Order confirmed and inventory reserved for order: "+event.ID)
+
+	// Publish InventoryStatusUpdated event to continue the chain
+	if err := h.publishInventoryStatusUpdated(ctx, event.ID, event.Product.ID, true); err != nil {
+		return err
+	}
+	return nil
 }
 
-// publishInventoryStatusUpdated publishes the inventory status event to continue the event chain
-func (h *OrderCreatedEventHandler) publishInventoryStatusUpdated(ctx context.Context, orderID, productID string, hasStock bool) {
+// publishInventoryStatusUpdated publishes the inventory status event to continue the event chain.
+// A failed publish is transient; an event that cannot be published at all is permanent.
+func (h *OrderCreatedEventHandler) publishInventoryStatusUpdated(ctx context.Context, orderID, productID string, hasStock bool) error {
 	inventoryEvent := events.InventoryStatusUpdatedEvent{
 		OrderID:   orderID, // Maintain event chain with OrderID
 		ProductID: productID,
 		HasStock:  hasStock,
 		Version:   1,
-		TimeStamp: time.Now().Local(),
-	}
-
-	eventJSON, err := json.Marshal(inventoryEvent)
-	if err != nil {
-		h.logger.Exception(ctx, "Failed to marshal InventoryStatusUpdatedEvent", err)
-		return
+		TimeStamp: time.Now().UTC(),
 	}
 
-	err = h.rabbitMQService.Publish(events.InventoryStatusUpdated, eventJSON)
-	if err != nil {
+	if err := rabbitmq.PublishEvent(ctx, h.rabbitMQService, events.InventoryStatusUpdated, inventoryEvent); err != nil {
 		h.logger.Exception(ctx, "Failed to publish InventoryStatusUpdatedEvent", err)
-		return
+		if rabbitmq.IsUnpublishable(err) {
+			return infrastructure.Permanent(err)
+		}
+		return infrastructure.Transient(err)
 	}
 
 	h.logger.Info(ctx, "Published InventoryStatusUpdated event for order: "+orderID+" product: "+productID)
+	return nil
 }
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-order-eda/src/infrastructure"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/rabbitmq"
+	"go-order-eda/src/infrastructure/rabbitmq/rabbitmqtest"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/inventory"
+	"testing"
+)
+
+// outOfStockInventory reports every product as out of stock.
+// Methods the tests do not use fall through to the nil embedded interface.
+type outOfStockInventory struct {
+	inventory.InventoryService
+}
+
+func (outOfStockInventory) ReserveProductForOrder(ctx context.Context, orderID, productID string, quantity int) (*inventory.Product, error) {
+	return nil, nil
+}
+
+func TestOrderCreatedEventHandler_RejectsInvalidInventoryStatusAtTheSource(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	handler := NewOrderCreatedEventHandler(broker, nil, outOfStockInventory{}, log.NewLogger())
+
+	t.Run("publishing without an order ID", func(t *testing.T) {
+		err := handler.publishInventoryStatusUpdated(context.Background(), "", "product-1", true)
+		if !rabbitmq.IsUnpublishable(err) || infrastructure.IsRetryable(err) {
+			t.Errorf("Expected a permanent unpublishable error, got %v", err)
+		}
+	})
+
+	t.Run("order created without an ID", func(t *testing.T) {
+		body, _ := json.Marshal(events.OrderCreatedEvent{Product: events.Product{ID: "product-1", Quantity: 1}, Status: "Processing"})
+		err := handler.Handle(context.Background(), body)
+		if !rabbitmq.IsUnpublishable(err) || infrastructure.IsRetryable(err) {
+			t.Errorf("Expected the invalid InventoryStatusUpdated event to dead-letter the message, got %v", err)
+		}
+	})
+
+	if attempts := broker.Attempts(); attempts != 0 {
+		t.Errorf("Expected nothing published, got %d attempts", attempts)
+	}
+
+	t.Log("✅ Invalid InventoryStatusUpdated event rejected before publishing")
+}
@@ -2,29 +2,65 @@ package inventory
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/rabbitmq"
+	"go-order-eda/src/services/events"
+	"time"
+
+	"github.com/google/uuid"
 )
 
+// EventPublisher publishes inventory events to the message broker
+type EventPublisher interface {
+	Publish(ctx context.Context, topic string, body []byte) error
+}
+
 type inventoryService struct {
-	logger            log.Logger
-	productRepository ProductRepository
+	logger                log.Logger
+	productRepository     ProductRepository
+	reservationRepository ReservationRepository
+	publisher             EventPublisher
+	lowStockThreshold     int
+	reservationMetrics    *ReservationMetrics // Reservation outcomes per product; nil disables them
 }
 
 type InventoryService interface {
 	// Business logic methods for inventory management
 	GetProductStock(ctx context.Context, productID string) (*Product, error)
+	GetProductAvailability(ctx context.Context, productID string) (*ProductAvailability, error)
+	GetProductHistory(ctx context.Context, productID string, limit int64) ([]ProductChange, error)
 	UpdateProductQuantity(ctx context.Context, productID string, quantity int) error
+	// Development tools restoring products to a known stock, see SetProductStock
+	SetProductStock(ctx context.Context, productID string, quantity int) (*Product, error)
+	ResetInventory(ctx context.Context) ([]Product, error)
+	RestockProduct(ctx context.Context, productID string, quantity int) error
 	GetLowStockProducts(ctx context.Context, threshold int) ([]Product, error)
 	AddProduct(ctx context.Context, product Product) error
-	GetAllProducts(ctx context.Context) ([]Product, error)
-	ReserveProduct(ctx context.Context, productID string, quantity int) (bool, error)
-	ReleaseReservedProduct(ctx context.Context, productID string, quantity int) error
+	// GetAllProducts returns the products of a category, or every product when category is empty
+	GetAllProducts(ctx context.Context, category string) ([]Product, error)
+	// Reserve and release return the product after the change; ReserveProduct returns nil when stock is insufficient
+	ReserveProduct(ctx context.Context, productID string, quantity int) (*Product, error)
+	ReleaseReservedProduct(ctx context.Context, productID string, quantity int) (*Product, error)
+	// Reservations held on behalf of orders, tracked in the reservations ledger
+	ReserveProductForOrder(ctx context.Context, orderID, productID string, quantity int) (*Product, error)
+	ReleaseOrderReservation(ctx context.Context, orderID, productID string, quantity int) (*Product, error)
+	ReleaseOrderReservations(ctx context.Context, orderID string, unrecorded *Reservation) ([]Reservation, error)
+	CompleteOrderReservation(ctx context.Context, orderID string) error
 }
 
-func NewInventoryService(logger log.Logger, productRepo ProductRepository) InventoryService {
+// NewInventoryService creates an inventory service. lowStockThreshold is the default
+// reorder threshold for products that do not define their own. Reservations are counted
+// in reservationMetrics unless it is nil.
+func NewInventoryService(logger log.Logger, productRepo ProductRepository, reservationRepo ReservationRepository, publisher EventPublisher, lowStockThreshold int, reservationMetrics *ReservationMetrics) InventoryService {
 	return &inventoryService{
-		logger:            logger,
-		productRepository: productRepo,
+		logger:                logger,
+		productRepository:     productRepo,
+		reservationRepository: reservationRepo,
+		publisher:             publisher,
+		lowStockThreshold:     lowStockThreshold,
+		reservationMetrics:    reservationMetrics,
 	}
 }
 
@@ -33,11 +69,118 @@ func (s *inventoryService) GetProductStock(ctx context.Context, productID string
 	return s.productRepository.GetProductById(ctx, productID)
 }
 
-// UpdateProductQuantity updates the available quantity of a product
+// GetProductAvailability returns the available, reserved and total stock of a product
+func (s *inventoryService) GetProductAvailability(ctx context.Context, productID string) (*ProductAvailability, error) {
+	product, err := s.productRepository.GetProductById(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	availability := product.Availability()
+	return &availability, nil
+}
+
+// GetProductHistory returns the latest limit stock changes of a product, oldest first
+func (s *inventoryService) GetProductHistory(ctx context.Context, productID string, limit int64) ([]ProductChange, error) {
+	product, err := s.productRepository.GetProductById(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	return s.productRepository.GetProductHistory(ctx, productID, limit)
+}
+
+// UpdateProductQuantity updates the available quantity of a product.
+// Negative quantities are rejected, and the repository rejects quantities below the currently
+// reserved amount in the same update, so a concurrent reservation cannot slip in between.
 func (s *inventoryService) UpdateProductQuantity(ctx context.Context, productID string, quantity int) error {
+	if quantity < 0 {
+		return ErrNegativeQuantity
+	}
 	return s.productRepository.UpdateProductQuantity(ctx, productID, quantity)
 }
 
+// SetProductStock sets the available quantity of a product and clears its reserved stock, releasing
+// the product's active ledger reservations without returning their stock, so the expiry sweeper does
+// not release them again. Unlike UpdateProductQuantity it discards what orders hold, and is meant
+// for development only.
+func (s *inventoryService) SetProductStock(ctx context.Context, productID string, quantity int) (*Product, error) {
+	if quantity < 0 {
+		return nil, ErrNegativeQuantity
+	}
+	product, err := s.productRepository.GetProductById(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+
+	released, err := s.reservationRepository.MarkProductReleased(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if released > 0 {
+		s.logger.Warn(ctx, fmt.Sprintf("Discarded %d active reservations of product %s", released, productID))
+	}
+	product, err = s.productRepository.SetProductStock(ctx, productID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
+
+// ResetInventory restores the sample products to their SeedProducts stock with SetProductStock and
+// seeds those no longer stored again. Other products are left as they are. It returns the sample
+// products as reset.
+func (s *inventoryService) ResetInventory(ctx context.Context) ([]Product, error) {
+	products, err := s.productRepository.GetAllProducts(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	reset := []Product{}
+	for _, seed := range SeedProducts {
+		found := false
+		for _, product := range products {
+			if product.Name != seed.Name {
+				continue
+			}
+			found = true
+			updated, err := s.SetProductStock(ctx, product.ID, seed.Quantity)
+			if err != nil {
+				return nil, fmt.Errorf("failed to reset product %s: %w", product.ID, err)
+			}
+			reset = append(reset, *updated)
+		}
+		if !found {
+			product := seed
+			product.ID = uuid.NewString()
+			if err := s.productRepository.SeedProduct(ctx, product); err != nil {
+				return nil, fmt.Errorf("failed to seed product %s: %w", product.Name, err)
+			}
+			reset = append(reset, product)
+		}
+	}
+	s.logger.Info(ctx, fmt.Sprintf("Inventory reset, %d sample products restored", len(reset)))
+	return reset, nil
+}
+
+// RestockProduct atomically adds quantity to the available stock of a product
+func (s *inventoryService) RestockProduct(ctx context.Context, productID string, quantity int) error {
+	if quantity <= 0 {
+		return ErrNonPositiveRestock
+	}
+	return s.productRepository.IncreaseStock(ctx, productID, quantity)
+}
+
 // GetLowStockProducts returns products with stock below the threshold
 func (s *inventoryService) GetLowStockProducts(ctx context.Context, threshold int) ([]Product, error) {
 	return s.productRepository.GetLowStockProducts(ctx, threshold)
@@ -48,17 +191,199 @@ func (s *inventoryService) AddProduct(ctx context.Context, product Product) erro
 	return s.productRepository.AddProduct(ctx, product)
 }
 
-// GetAllProducts retrieves all products in the inventory
-func (s *inventoryService) GetAllProducts(ctx context.Context) ([]Product, error) {
-	return s.productRepository.GetAllProducts(ctx)
+// GetAllProducts retrieves the products of a category, or all products in the inventory when category is empty
+func (s *inventoryService) GetAllProducts(ctx context.Context, category string) ([]Product, error) {
+	return s.productRepository.GetAllProducts(ctx, category)
 }
 
-// ReserveProduct reserves a quantity of a product for an order
-func (s *inventoryService) ReserveProduct(ctx context.Context, productID string, quantity int) (bool, error) {
-	return s.productRepository.CheckAndReserveProduct(ctx, productID, quantity)
+// ReserveProduct reserves a quantity of a product for an order and returns the updated product,
+// or nil when the product does not exist or has too little stock.
+// A LowStock event is published when the reservation drops stock to or below the reorder threshold.
+func (s *inventoryService) ReserveProduct(ctx context.Context, productID string, quantity int) (*Product, error) {
+	outcome, err := s.productRepository.CheckAndReserveProduct(ctx, productID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	switch outcome.Result {
+	case ReserveNotFound:
+		// Not a stockout, which also keeps unknown product IDs out of the metrics
+		return nil, nil
+	case ReserveInsufficientStock:
+		s.reservationMetrics.observe(productID, true)
+		return nil, nil
+	}
+
+	s.reservationMetrics.observe(productID, false)
+	s.checkLowStock(ctx, outcome.Product, quantity)
+	return outcome.Product, nil
 }
 
-// ReleaseReservedProduct releases reserved quantity back to available stock
-func (s *inventoryService) ReleaseReservedProduct(ctx context.Context, productID string, quantity int) error {
+// checkLowStock publishes a LowStock event only when the last reservation crossed the threshold,
+// so repeated reservations below the threshold do not produce duplicate alerts.
+// product is the state returned by the reservation itself, not a later read.
+func (s *inventoryService) checkLowStock(ctx context.Context, product *Product, reserved int) {
+	productID := product.ID
+	threshold := product.ReorderThreshold
+	if threshold <= 0 {
+		threshold = s.lowStockThreshold
+	}
+
+	previousQuantity := product.Quantity + reserved
+	if previousQuantity <= threshold || product.Quantity > threshold {
+		return
+	}
+
+	lowStockEvent := events.LowStockEvent{
+		ProductID:         productID,
+		RemainingQuantity: product.Quantity,
+		Threshold:         threshold,
+		Version:           1,
+		TimeStamp:         time.Now().UTC(),
+	}
+
+	if err := rabbitmq.PublishEvent(ctx, s.publisher, events.LowStock, lowStockEvent); err != nil {
+		s.logger.Exception(ctx, "Failed to publish LowStockEvent for product: "+productID, err)
+		return
+	}
+
+	s.logger.Info(ctx, fmt.Sprintf("LowStock event published for product %s (remaining: %d, threshold: %d)",
+		productID, product.Quantity, threshold))
+}
+
+// ReleaseReservedProduct releases reserved quantity back to available stock and returns the updated product
+func (s *inventoryService) ReleaseReservedProduct(ctx context.Context, productID string, quantity int) (*Product, error) {
 	return s.productRepository.ReleaseReservedProduct(ctx, productID, quantity)
 }
+
+// ReserveProductForOrder reserves stock of a product for an order and records the reservation in the
+// ledger with its reservedAt time, so the expiry sweeper can release it if the order stalls.
+// The ledger entry is claimed before the stock is reserved, so concurrent deliveries of the same
+// order reserve its stock once; the others fail with ErrReservationExists.
+func (s *inventoryService) ReserveProductForOrder(ctx context.Context, orderID, productID string, quantity int) (*Product, error) {
+	reservation := Reservation{
+		OrderID:    orderID,
+		ProductID:  productID,
+		Quantity:   quantity,
+		Status:     ReservationActive,
+		ReservedAt: time.Now().UTC(),
+	}
+	claimed, err := s.reservationRepository.Claim(ctx, reservation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record reservation for order %s: %w", orderID, err)
+	}
+	if !claimed {
+		return nil, fmt.Errorf("%w: %s reserved %s", ErrReservationExists, orderID, productID)
+	}
+
+	product, err := s.ReserveProduct(ctx, productID, quantity)
+	if err != nil || product == nil {
+		// The claim holds no stock, so the sweeper must not release it later
+		if unclaimErr := s.reservationRepository.Unclaim(ctx, orderID, productID); unclaimErr != nil {
+			s.logger.Exception(ctx, "Failed to remove the reservation claim of order: "+orderID, unclaimErr)
+			return nil, errors.Join(err, fmt.Errorf("failed to remove reservation claim for order %s: %w", orderID, unclaimErr))
+		}
+		return nil, err
+	}
+	return product, nil
+}
+
+// ReleaseOrderReservation returns the stock of a product held for an order. The ledger entry is claimed first,
+// so a reservation already released by the sweeper or a previous cancellation is not released twice.
+// Orders reserved before the ledger existed have no entry and are released with the given quantity.
+func (s *inventoryService) ReleaseOrderReservation(ctx context.Context, orderID, productID string, quantity int) (*Product, error) {
+	reservations, err := s.reservationRepository.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(reservations) == 0 {
+		return s.ReleaseReservedProduct(ctx, productID, quantity)
+	}
+	reservation := findReservation(reservations, productID)
+	if reservation == nil {
+		return nil, fmt.Errorf("%w: order %s did not reserve %s", ErrReservationMismatch, orderID, productID)
+	}
+
+	claimed, err := s.reservationRepository.MarkReleased(ctx, orderID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		s.logger.Info(ctx, fmt.Sprintf("Reservation for order %s is already %s", orderID, reservation.Status))
+		return s.productRepository.GetProductById(ctx, productID)
+	}
+	return s.releaseClaimed(ctx, *reservation)
+}
+
+// ReleaseOrderReservations returns the stock of every active reservation the ledger holds for an order
+// and returns the reservations it released. Only what the order actually reserved is returned, so a
+// partially fulfilled order releases the products it holds and nothing for the ones that failed.
+// Reservations already released or completed are skipped, which makes a repeated call a no-op.
+// An order the ledger holds nothing for, e.g. one reserved before the ledger existed, has unrecorded
+// recorded and released instead, so a repeated call still releases it once; callers pass nil when
+// the order is not known to hold stock.
+func (s *inventoryService) ReleaseOrderReservations(ctx context.Context, orderID string, unrecorded *Reservation) ([]Reservation, error) {
+	reservations, err := s.reservationRepository.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(reservations) == 0 && unrecorded != nil {
+		reservation := *unrecorded
+		reservation.OrderID, reservation.Status, reservation.ReservedAt = orderID, ReservationActive, time.Now().UTC()
+		claimed, err := s.reservationRepository.Claim(ctx, reservation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record reservation for order %s: %w", orderID, err)
+		}
+		if claimed {
+			reservations = []Reservation{reservation}
+		}
+	}
+
+	var released []Reservation
+	for _, reservation := range reservations {
+		if reservation.Status != ReservationActive {
+			continue
+		}
+		claimed, err := s.reservationRepository.MarkReleased(ctx, orderID, reservation.ProductID)
+		if err != nil {
+			return released, err
+		}
+		if !claimed {
+			continue // Released by the sweeper in the meantime
+		}
+		if _, err := s.releaseClaimed(ctx, reservation); err != nil {
+			return released, err
+		}
+		released = append(released, reservation)
+	}
+	return released, nil
+}
+
+// releaseClaimed returns the stock of a reservation MarkReleased claimed. When that fails the claim is
+// reverted, so the redelivered message or the sweeper still finds the reservation active and releases it.
+func (s *inventoryService) releaseClaimed(ctx context.Context, reservation Reservation) (*Product, error) {
+	product, err := s.ReleaseReservedProduct(ctx, reservation.ProductID, reservation.Quantity)
+	if err == nil {
+		return product, nil
+	}
+	err = fmt.Errorf("failed to release %s for order %s: %w", reservation.ProductID, reservation.OrderID, err)
+	if revertErr := s.reservationRepository.RevertReleased(ctx, reservation.OrderID, reservation.ProductID); revertErr != nil {
+		s.logger.Exception(ctx, "Failed to revert the released reservation of order: "+reservation.OrderID, revertErr)
+		return nil, errors.Join(err, revertErr)
+	}
+	return nil, err
+}
+
+// findReservation returns the reservation of the product among an order's reservations, or nil
+func findReservation(reservations []Reservation, productID string) *Reservation {
+	for i := range reservations {
+		if reservations[i].ProductID == productID {
+			return &reservations[i]
+		}
+	}
+	return nil
+}
+
+// CompleteOrderReservation marks the reservations of a settled order so they no longer expire
+func (s *inventoryService) CompleteOrderReservation(ctx context.Context, orderID string) error {
+	return s.reservationRepository.MarkCompleted(ctx, orderID)
+}
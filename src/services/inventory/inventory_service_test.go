@@ -0,0 +1,544 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/events"
+)
+
+// fakePublisher records published messages instead of sending them to RabbitMQ
+type fakePublisher struct {
+	mu       sync.Mutex
+	messages map[string][][]byte
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, topic string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.messages == nil {
+		p.messages = make(map[string][][]byte)
+	}
+	p.messages[topic] = append(p.messages[topic], body)
+	return nil
+}
+
+func (p *fakePublisher) published(topic string) [][]byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.messages[topic]
+}
+
+// fakeProductRepository is an in-memory ProductRepository used by service tests
+type fakeProductRepository struct {
+	mu         sync.Mutex
+	products   map[string]*Product
+	reads      int   // GetProductById calls
+	releaseErr error // Returned by ReleaseReservedProduct when set
+}
+
+func newFakeProductRepository(products ...Product) *fakeProductRepository {
+	repo := &fakeProductRepository{products: make(map[string]*Product)}
+	for i := range products {
+		p := products[i]
+		repo.products[p.ID] = &p
+	}
+	return repo
+}
+
+func (r *fakeProductRepository) CheckAndReserveProduct(ctx context.Context, productID string, quantity int) (ReservationOutcome, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[productID]
+	if !ok {
+		return ReservationOutcome{Result: ReserveNotFound}, nil
+	}
+	if p.Quantity < quantity {
+		copied := *p
+		return ReservationOutcome{Result: ReserveInsufficientStock, Product: &copied}, nil
+	}
+	p.Quantity -= quantity
+	p.Reserved += quantity
+	copied := *p
+	return ReservationOutcome{Result: ReserveOK, Product: &copied}, nil
+}
+
+func (r *fakeProductRepository) ReleaseReservedProduct(ctx context.Context, productID string, quantity int) (*Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.releaseErr != nil {
+		return nil, r.releaseErr
+	}
+	p, ok := r.products[productID]
+	if !ok {
+		return nil, nil
+	}
+	p.Quantity += quantity
+	p.Reserved -= quantity
+	copied := *p
+	return &copied, nil
+}
+
+func (r *fakeProductRepository) SeedProduct(ctx context.Context, product Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.products[product.ID]; !ok {
+		r.products[product.ID] = &product
+	}
+	return nil
+}
+
+func (r *fakeProductRepository) GetProductById(ctx context.Context, productID string) (*Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reads++
+	p, ok := r.products[productID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *p
+	return &copied, nil
+}
+
+func (r *fakeProductRepository) UpdateProductQuantity(ctx context.Context, productID string, quantity int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[productID]
+	if !ok {
+		return ErrProductNotFound
+	}
+	if quantity < p.Reserved {
+		return ErrQuantityBelowReserved
+	}
+	p.Quantity = quantity
+	return nil
+}
+
+func (r *fakeProductRepository) SetProductStock(ctx context.Context, productID string, quantity int) (*Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[productID]
+	if !ok {
+		return nil, nil
+	}
+	p.Quantity = quantity
+	p.Reserved = 0
+	copied := *p
+	return &copied, nil
+}
+
+func (r *fakeProductRepository) IncreaseStock(ctx context.Context, productID string, delta int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[productID]
+	if !ok {
+		return ErrProductNotFound
+	}
+	p.Quantity += delta
+	return nil
+}
+
+func (r *fakeProductRepository) GetLowStockProducts(ctx context.Context, threshold int) ([]Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var products []Product
+	for _, p := range r.products {
+		if p.Quantity < threshold {
+			products = append(products, *p)
+		}
+	}
+	return products, nil
+}
+
+func (r *fakeProductRepository) AddProduct(ctx context.Context, product Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.products[product.ID] = &product
+	return nil
+}
+
+func (r *fakeProductRepository) GetAllProducts(ctx context.Context, category string) ([]Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var products []Product
+	for _, p := range r.products {
+		if category == "" || p.Category == category {
+			products = append(products, *p)
+		}
+	}
+	return products, nil
+}
+
+func (r *fakeProductRepository) GetProductHistory(ctx context.Context, productID string, limit int64) ([]ProductChange, error) {
+	return []ProductChange{}, nil
+}
+
+func (r *fakeProductRepository) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+func TestInventoryService_UpdateProductQuantity(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("negative quantity is rejected", func(t *testing.T) {
+		repo := newFakeProductRepository(Product{ID: "product-1", Quantity: 10})
+		service := NewInventoryService(log.NewLogger(), repo, newFakeReservationRepository(), &fakePublisher{}, 10, nil)
+
+		err := service.UpdateProductQuantity(ctx, "product-1", -1)
+		if !errors.Is(err, ErrNegativeQuantity) {
+			t.Fatalf("Expected ErrNegativeQuantity, got %v", err)
+		}
+
+		product, _ := repo.GetProductById(ctx, "product-1")
+		if product.Quantity != 10 {
+			t.Errorf("Quantity should remain 10, got %d", product.Quantity)
+		}
+	})
+
+	t.Run("quantity below reserved amount is rejected", func(t *testing.T) {
+		repo := newFakeProductRepository(Product{ID: "product-1", Quantity: 10, Reserved: 5})
+		service := NewInventoryService(log.NewLogger(), repo, newFakeReservationRepository(), &fakePublisher{}, 10, nil)
+
+		err := service.UpdateProductQuantity(ctx, "product-1", 4)
+		if !errors.Is(err, ErrQuantityBelowReserved) {
+			t.Fatalf("Expected ErrQuantityBelowReserved, got %v", err)
+		}
+
+		product, _ := repo.GetProductById(ctx, "product-1")
+		if product.Quantity != 10 {
+			t.Errorf("Quantity should remain 10, got %d", product.Quantity)
+		}
+	})
+
+	t.Run("missing product is reported", func(t *testing.T) {
+		service := NewInventoryService(log.NewLogger(), newFakeProductRepository(), newFakeReservationRepository(), &fakePublisher{}, 10, nil)
+
+		err := service.UpdateProductQuantity(ctx, "missing", 5)
+		if !errors.Is(err, ErrProductNotFound) {
+			t.Fatalf("Expected ErrProductNotFound, got %v", err)
+		}
+	})
+
+	t.Run("valid quantity is applied", func(t *testing.T) {
+		repo := newFakeProductRepository(Product{ID: "product-1", Quantity: 10, Reserved: 5})
+		service := NewInventoryService(log.NewLogger(), repo, newFakeReservationRepository(), &fakePublisher{}, 10, nil)
+
+		if err := service.UpdateProductQuantity(ctx, "product-1", 5); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		product, _ := repo.GetProductById(ctx, "product-1")
+		if product.Quantity != 5 {
+			t.Errorf("Expected quantity 5, got %d", product.Quantity)
+		}
+	})
+}
+
+func TestInventoryService_RestockProduct(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("quantity added to the available stock", func(t *testing.T) {
+		repo := newFakeProductRepository(Product{ID: "product-1", Quantity: 10, Reserved: 3})
+		service := NewInventoryService(log.NewLogger(), repo, newFakeReservationRepository(), &fakePublisher{}, 10, nil)
+
+		if err := service.RestockProduct(ctx, "product-1", 5); err != nil {
+			t.Fatalf("RestockProduct failed: %v", err)
+		}
+		product, _ := repo.GetProductById(ctx, "product-1")
+		if product.Quantity != 15 || product.Reserved != 3 {
+			t.Errorf("Expected 15 available and 3 reserved, got %d and %d", product.Quantity, product.Reserved)
+		}
+	})
+
+	t.Run("non-positive quantity is rejected", func(t *testing.T) {
+		service := NewInventoryService(log.NewLogger(), newFakeProductRepository(Product{ID: "product-1", Quantity: 10}), newFakeReservationRepository(), &fakePublisher{}, 10, nil)
+
+		for _, quantity := range []int{0, -5} {
+			if err := service.RestockProduct(ctx, "product-1", quantity); !errors.Is(err, ErrNonPositiveRestock) {
+				t.Errorf("Expected ErrNonPositiveRestock for %d, got %v", quantity, err)
+			}
+		}
+	})
+
+	t.Run("missing product is reported", func(t *testing.T) {
+		service := NewInventoryService(log.NewLogger(), newFakeProductRepository(), newFakeReservationRepository(), &fakePublisher{}, 10, nil)
+
+		if err := service.RestockProduct(ctx, "missing", 5); !errors.Is(err, ErrProductNotFound) {
+			t.Fatalf("Expected ErrProductNotFound, got %v", err)
+		}
+	})
+}
+
+func TestInventoryService_SetProductStock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reserved stock and its reservations are cleared", func(t *testing.T) {
+		repo := newFakeProductRepository(Product{ID: "product-1", Quantity: 4, Reserved: 6}, Product{ID: "product-2", Quantity: 5, Reserved: 1})
+		reservations := newFakeReservationRepository()
+		reservations.Record(ctx, Reservation{OrderID: "order-1", ProductID: "product-1", Quantity: 6, Status: ReservationActive})
+		reservations.Record(ctx, Reservation{OrderID: "order-1", ProductID: "product-2", Quantity: 1, Status: ReservationActive})
+		service := NewInventoryService(log.NewLogger(), repo, reservations, &fakePublisher{}, 10, nil)
+
+		product, err := service.SetProductStock(ctx, "product-1", 20)
+		if err != nil {
+			t.Fatalf("SetProductStock failed: %v", err)
+		}
+		if product.Quantity != 20 || product.Reserved != 0 {
+			t.Errorf("Expected 20 available and none reserved, got %d and %d", product.Quantity, product.Reserved)
+		}
+		if status := reservations.status("order-1", "product-1"); status != ReservationReleased {
+			t.Errorf("Expected the product's reservation released, got %s", status)
+		}
+		if status := reservations.status("order-1", "product-2"); status != ReservationActive {
+			t.Errorf("Expected other products' reservations kept, got %s", status)
+		}
+	})
+
+	t.Run("negative quantity and missing product are rejected", func(t *testing.T) {
+		service := NewInventoryService(log.NewLogger(), newFakeProductRepository(Product{ID: "product-1"}), newFakeReservationRepository(), &fakePublisher{}, 10, nil)
+
+		if _, err := service.SetProductStock(ctx, "product-1", -1); !errors.Is(err, ErrNegativeQuantity) {
+			t.Errorf("Expected ErrNegativeQuantity, got %v", err)
+		}
+		if _, err := service.SetProductStock(ctx, "missing", 5); !errors.Is(err, ErrProductNotFound) {
+			t.Errorf("Expected ErrProductNotFound, got %v", err)
+		}
+	})
+
+	t.Log("✅ Product stock set with reserved stock cleared")
+}
+
+func TestInventoryService_ResetInventory(t *testing.T) {
+	ctx := context.Background()
+	laptop, mouse := SeedProducts[0], SeedProducts[1]
+	repo := newFakeProductRepository(
+		Product{ID: "laptop", Name: laptop.Name, Quantity: 3, Reserved: 7},
+		Product{ID: "custom", Name: "Custom Product", Quantity: 1, Reserved: 2},
+	)
+	service := NewInventoryService(log.NewLogger(), repo, newFakeReservationRepository(), &fakePublisher{}, 10, nil)
+
+	reset, err := service.ResetInventory(ctx)
+	if err != nil {
+		t.Fatalf("ResetInventory failed: %v", err)
+	}
+	if len(reset) != len(SeedProducts) {
+		t.Fatalf("Expected %d sample products reset, got %d", len(SeedProducts), len(reset))
+	}
+
+	stored, _ := repo.GetProductById(ctx, "laptop")
+	if stored.Quantity != laptop.Quantity || stored.Reserved != 0 {
+		t.Errorf("Expected the laptop restored to %d, got %+v", laptop.Quantity, stored)
+	}
+	custom, _ := repo.GetProductById(ctx, "custom")
+	if custom.Quantity != 1 || custom.Reserved != 2 {
+		t.Errorf("Expected other products left alone, got %+v", custom)
+	}
+	products, _ := repo.GetAllProducts(ctx, "")
+	reseeded := 0
+	for _, product := range products {
+		if product.Name == mouse.Name && product.Quantity == mouse.Quantity && product.ID != "" {
+			reseeded++
+		}
+	}
+	if reseeded != 1 || len(products) != len(SeedProducts)+1 {
+		t.Errorf("Expected the missing sample products seeded once, got %+v", products)
+	}
+
+	t.Log("✅ Sample products restored to their seed stock")
+}
+
+func TestInventoryService_LowStockEvent(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("event fires only when crossing the threshold", func(t *testing.T) {
+		repo := newFakeProductRepository(Product{ID: "product-1", Quantity: 15})
+		publisher := &fakePublisher{}
+		service := NewInventoryService(log.NewLogger(), repo, newFakeReservationRepository(), publisher, 10, nil)
+
+		// 15 -> 12: still above threshold
+		if product, err := service.ReserveProduct(ctx, "product-1", 3); err != nil || product == nil {
+			t.Fatalf("Reservation failed: product=%v, err=%v", product, err)
+		}
+		if n := len(publisher.published(events.LowStock)); n != 0 {
+			t.Fatalf("Expected no LowStock event above threshold, got %d", n)
+		}
+
+		// 12 -> 10: crosses the threshold
+		if product, err := service.ReserveProduct(ctx, "product-1", 2); err != nil || product == nil {
+			t.Fatalf("Reservation failed: product=%v, err=%v", product, err)
+		}
+		published := publisher.published(events.LowStock)
+		if len(published) != 1 {
+			t.Fatalf("Expected 1 LowStock event after crossing threshold, got %d", len(published))
+		}
+
+		var event events.LowStockEvent
+		if err := json.Unmarshal(published[0], &event); err != nil {
+			t.Fatalf("Failed to unmarshal LowStockEvent: %v", err)
+		}
+		if event.ProductID != "product-1" || event.RemainingQuantity != 10 || event.Threshold != 10 {
+			t.Errorf("Unexpected LowStockEvent: %+v", event)
+		}
+
+		// 10 -> 9: already below threshold, no new event
+		if product, err := service.ReserveProduct(ctx, "product-1", 1); err != nil || product == nil {
+			t.Fatalf("Reservation failed: product=%v, err=%v", product, err)
+		}
+		if n := len(publisher.published(events.LowStock)); n != 1 {
+			t.Errorf("Expected no additional LowStock event below threshold, got %d total", n)
+		}
+	})
+
+	t.Run("per-product threshold overrides the default", func(t *testing.T) {
+		repo := newFakeProductRepository(Product{ID: "product-1", Quantity: 30, ReorderThreshold: 25})
+		publisher := &fakePublisher{}
+		service := NewInventoryService(log.NewLogger(), repo, newFakeReservationRepository(), publisher, 10, nil)
+
+		if product, err := service.ReserveProduct(ctx, "product-1", 5); err != nil || product == nil {
+			t.Fatalf("Reservation failed: product=%v, err=%v", product, err)
+		}
+		if n := len(publisher.published(events.LowStock)); n != 1 {
+			t.Errorf("Expected 1 LowStock event using product threshold, got %d", n)
+		}
+	})
+
+	t.Run("failed reservation does not publish", func(t *testing.T) {
+		repo := newFakeProductRepository(Product{ID: "product-1", Quantity: 5})
+		publisher := &fakePublisher{}
+		service := NewInventoryService(log.NewLogger(), repo, newFakeReservationRepository(), publisher, 10, nil)
+
+		if product, _ := service.ReserveProduct(ctx, "product-1", 6); product != nil {
+			t.Fatal("Reservation should have failed")
+		}
+		if n := len(publisher.published(events.LowStock)); n != 0 {
+			t.Errorf("Expected no LowStock event, got %d", n)
+		}
+	})
+}
+
+func TestInventoryService_GetProductAvailability(t *testing.T) {
+	ctx := context.Background()
+	service := NewInventoryService(log.NewLogger(), newFakeProductRepository(Product{ID: "product-1", Quantity: 7, Reserved: 3}), newFakeReservationRepository(), &fakePublisher{}, 10, nil)
+
+	t.Run("total is available plus reserved", func(t *testing.T) {
+		availability, err := service.GetProductAvailability(ctx, "product-1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if availability.Available != 7 || availability.Reserved != 3 || availability.Total != 10 {
+			t.Errorf("Unexpected availability: %+v", availability)
+		}
+	})
+
+	t.Run("JSON shape uses lowerCamel keys", func(t *testing.T) {
+		availability, _ := service.GetProductAvailability(ctx, "product-1")
+		body, err := json.Marshal(availability)
+		if err != nil {
+			t.Fatalf("Failed to marshal availability: %v", err)
+		}
+
+		var fields map[string]any
+		if err := json.Unmarshal(body, &fields); err != nil {
+			t.Fatalf("Failed to unmarshal availability: %v", err)
+		}
+		expected := map[string]any{"productId": "product-1", "available": 7.0, "reserved": 3.0, "total": 10.0}
+		if len(fields) != len(expected) {
+			t.Errorf("Expected keys %v, got %v", expected, fields)
+		}
+		for key, value := range expected {
+			if fields[key] != value {
+				t.Errorf("Expected %s=%v, got %v", key, value, fields[key])
+			}
+		}
+	})
+
+	t.Run("missing product is reported", func(t *testing.T) {
+		if _, err := service.GetProductAvailability(ctx, "missing"); !errors.Is(err, ErrProductNotFound) {
+			t.Fatalf("Expected ErrProductNotFound, got %v", err)
+		}
+	})
+}
+
+func TestInventoryService_ReserveAndReleaseReturnProduct(t *testing.T) {
+	ctx := context.Background()
+	service := NewInventoryService(log.NewLogger(), newFakeProductRepository(Product{ID: "product-1", Quantity: 10, Reserved: 1}), newFakeReservationRepository(), &fakePublisher{}, 0, nil)
+
+	reserved, err := service.ReserveProduct(ctx, "product-1", 4)
+	if err != nil || reserved == nil {
+		t.Fatalf("Reservation failed: product=%v, err=%v", reserved, err)
+	}
+	if reserved.Quantity != 6 || reserved.Reserved != 5 {
+		t.Errorf("Expected quantity 6 and reserved 5 after reserving, got %d and %d", reserved.Quantity, reserved.Reserved)
+	}
+
+	released, err := service.ReleaseReservedProduct(ctx, "product-1", 3)
+	if err != nil || released == nil {
+		t.Fatalf("Release failed: product=%v, err=%v", released, err)
+	}
+	if released.Quantity != 9 || released.Reserved != 2 {
+		t.Errorf("Expected quantity 9 and reserved 2 after releasing, got %d and %d", released.Quantity, released.Reserved)
+	}
+
+	t.Log("✅ Reserve and release return the updated stock")
+}
+
+func TestInventoryService_ReserveAndReleaseUseReturnedDocument(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeProductRepository(Product{ID: "product-1", Quantity: 12})
+	publisher := &fakePublisher{}
+	service := NewInventoryService(log.NewLogger(), repo, newFakeReservationRepository(), publisher, 10, nil)
+
+	if product, err := service.ReserveProduct(ctx, "product-1", 3); err != nil || product == nil {
+		t.Fatalf("Reservation failed: product=%v, err=%v", product, err)
+	}
+	if _, err := service.ReleaseReservedProduct(ctx, "product-1", 1); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+
+	if repo.reads != 0 {
+		t.Errorf("Expected no separate product reads, got %d", repo.reads)
+	}
+	if n := len(publisher.published(events.LowStock)); n != 1 {
+		t.Errorf("Expected the low stock check to use the returned document, got %d events", n)
+	}
+}
+
+func TestInventoryService_ReservationMetrics(t *testing.T) {
+	ctx := context.Background()
+	metrics := NewReservationMetrics()
+	repo := newFakeProductRepository(Product{ID: "product-1", Quantity: 5}, Product{ID: "product-2", Quantity: 1})
+	service := NewInventoryService(log.NewLogger(), repo, newFakeReservationRepository(), &fakePublisher{}, 0, metrics)
+
+	reservations := []struct {
+		productID string
+		quantity  int
+	}{
+		{"product-1", 2}, // reserved
+		{"product-1", 2}, // reserved
+		{"product-1", 2}, // insufficient stock
+		{"product-2", 3}, // insufficient stock
+		{"missing", 1},   // not a stockout
+	}
+	for _, r := range reservations {
+		if _, err := service.ReserveProduct(ctx, r.productID, r.quantity); err != nil {
+			t.Fatalf("ReserveProduct(%s, %d) failed: %v", r.productID, r.quantity, err)
+		}
+	}
+
+	stats := metrics.Snapshot()
+	if got, want := stats["product-1"], (ReservationStats{Attempts: 3, Failures: 1, FailureRatio: 1.0 / 3}); got != want {
+		t.Errorf("Expected %+v for product-1, got %+v", want, got)
+	}
+	if got, want := stats["product-2"], (ReservationStats{Attempts: 1, Failures: 1, FailureRatio: 1}); got != want {
+		t.Errorf("Expected %+v for product-2, got %+v", want, got)
+	}
+	if _, ok := stats["missing"]; ok || len(stats) != 2 {
+		t.Errorf("Expected only existing products counted, got %+v", stats)
+	}
+
+	t.Log("✅ Reservation attempts and stockouts counted per product")
+}
@@ -0,0 +1,165 @@
+package inventory
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ProductSnapshot is the stock of a product, as pushed to live subscribers
+type ProductSnapshot struct {
+	ProductID string    `json:"productId"`
+	Kind      string    `json:"kind"` // Kind of the change that led to the snapshot, empty for the current stock
+	Quantity  int       `json:"quantity"`
+	Reserved  int       `json:"reserved"`
+	At        time.Time `json:"at"`
+}
+
+// snapshotOf returns the stock of a product after the change
+func snapshotOf(change ProductChange) ProductSnapshot {
+	return ProductSnapshot{
+		ProductID: change.ProductID,
+		Kind:      change.Kind,
+		Quantity:  change.QuantityAfter,
+		Reserved:  change.ReservedAfter,
+		At:        change.At,
+	}
+}
+
+// CurrentSnapshot returns the stock of the product as it is now
+func CurrentSnapshot(product Product) ProductSnapshot {
+	return ProductSnapshot{
+		ProductID: product.ID,
+		Quantity:  product.Quantity,
+		Reserved:  product.Reserved,
+		At:        time.Now().UTC(),
+	}
+}
+
+// ProductFeed streams product stock changes to subscribers in this instance, such as inventory
+// dashboards. The product repository publishes every change it records in a product's history.
+// It is in-process: changes made by another instance are not seen.
+type ProductFeed struct {
+	mu          sync.Mutex
+	subscribers map[*ProductSubscription]struct{}
+}
+
+func NewProductFeed() *ProductFeed {
+	return &ProductFeed{subscribers: make(map[*ProductSubscription]struct{})}
+}
+
+// Subscribe returns a subscription to the changes of the given products, or of every product
+// when none are given. It must be closed to stop receiving changes.
+func (f *ProductFeed) Subscribe(productIDs ...string) *ProductSubscription {
+	s := &ProductSubscription{
+		feed:    f,
+		pending: make(map[string]ProductSnapshot),
+		ready:   make(chan struct{}, 1),
+	}
+	s.Follow(productIDs...)
+
+	f.mu.Lock()
+	f.subscribers[s] = struct{}{}
+	f.mu.Unlock()
+	return s
+}
+
+// Publish hands a change to every subscriber following its product. It never blocks: a subscriber
+// that has not read the product's previous change yet only keeps the latest one.
+// Publishing to a nil feed does nothing, so the feed is optional for its publishers.
+func (f *ProductFeed) Publish(change ProductChange) {
+	if f == nil {
+		return
+	}
+	snapshot := snapshotOf(change)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for s := range f.subscribers {
+		s.offer(snapshot)
+	}
+}
+
+// Subscribers returns how many subscriptions are open
+func (f *ProductFeed) Subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subscribers)
+}
+
+// ProductSubscription receives the stock changes of some or all products. Rather than queueing
+// every change, it keeps the latest snapshot of each changed product until it is read, so a slow
+// subscriber skips intermediate stock levels instead of buffering without bound.
+type ProductSubscription struct {
+	feed     *ProductFeed
+	mu       sync.Mutex
+	products map[string]bool // nil follows every product
+	pending  map[string]ProductSnapshot
+	ready    chan struct{}
+}
+
+// Follow replaces the products the subscription follows; none follows every product.
+// Pending snapshots of products no longer followed are dropped.
+func (s *ProductSubscription) Follow(productIDs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.products = nil
+	if len(productIDs) > 0 {
+		s.products = make(map[string]bool, len(productIDs))
+		for _, id := range productIDs {
+			s.products[id] = true
+		}
+	}
+	for id := range s.pending {
+		if !s.follows(id) {
+			delete(s.pending, id)
+		}
+	}
+}
+
+// Ready is signalled when snapshots are pending; read them with Next
+func (s *ProductSubscription) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Next takes the pending snapshots, ordered by product ID
+func (s *ProductSubscription) Next() []ProductSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshots := make([]ProductSnapshot, 0, len(s.pending))
+	for id, snapshot := range s.pending {
+		snapshots = append(snapshots, snapshot)
+		delete(s.pending, id)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].ProductID < snapshots[j].ProductID })
+	return snapshots
+}
+
+// Close stops the subscription. Closing it again does nothing.
+func (s *ProductSubscription) Close() {
+	s.feed.mu.Lock()
+	delete(s.feed.subscribers, s)
+	s.feed.mu.Unlock()
+}
+
+// offer keeps the snapshot if the subscription follows its product, replacing any unread one
+func (s *ProductSubscription) offer(snapshot ProductSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.follows(snapshot.ProductID) {
+		return
+	}
+	s.pending[snapshot.ProductID] = snapshot
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// follows reports whether the subscription follows the product; s.mu must be held
+func (s *ProductSubscription) follows(productID string) bool {
+	return s.products == nil || s.products[productID]
+}
@@ -0,0 +1,76 @@
+package inventory
+
+import (
+	"testing"
+)
+
+func TestProductFeed_KeepsLatestSnapshotForSlowSubscribers(t *testing.T) {
+	feed := NewProductFeed()
+	subscription := feed.Subscribe()
+	defer subscription.Close()
+
+	// The subscriber reads nothing while the product changes three times
+	product := Product{ID: "product-1", Quantity: 10}
+	for i := 0; i < 3; i++ {
+		after := product
+		after.Quantity--
+		after.Reserved++
+		feed.Publish(newProductChange(ChangeReserve, product, after))
+		product = after
+	}
+	feed.Publish(newProductChange(ChangeRestock, Product{ID: "product-2"}, Product{ID: "product-2", Quantity: 5}))
+
+	select {
+	case <-subscription.Ready():
+	default:
+		t.Fatal("Expected the subscription to be ready")
+	}
+	snapshots := subscription.Next()
+	if len(snapshots) != 2 {
+		t.Fatalf("Expected one snapshot per product, got %+v", snapshots)
+	}
+	if got := snapshots[0]; got.ProductID != "product-1" || got.Quantity != 7 || got.Reserved != 3 || got.Kind != ChangeReserve {
+		t.Errorf("Expected the latest stock of product-1, got %+v", got)
+	}
+	if got := snapshots[1]; got.ProductID != "product-2" || got.Quantity != 5 {
+		t.Errorf("Expected the stock of product-2, got %+v", got)
+	}
+	if pending := subscription.Next(); len(pending) != 0 {
+		t.Errorf("Expected no pending snapshots after Next, got %+v", pending)
+	}
+
+	t.Log("✅ Slow subscriber received only the latest stock of each product")
+}
+
+func TestProductFeed_FollowsSelectedProducts(t *testing.T) {
+	feed := NewProductFeed()
+	subscription := feed.Subscribe("product-1")
+
+	feed.Publish(newProductChange(ChangeRestock, Product{ID: "product-1"}, Product{ID: "product-1", Quantity: 1}))
+	feed.Publish(newProductChange(ChangeRestock, Product{ID: "product-2"}, Product{ID: "product-2", Quantity: 2}))
+	if snapshots := subscription.Next(); len(snapshots) != 1 || snapshots[0].ProductID != "product-1" {
+		t.Errorf("Expected only product-1, got %+v", snapshots)
+	}
+
+	subscription.Follow("product-2")
+	feed.Publish(newProductChange(ChangeRestock, Product{ID: "product-1"}, Product{ID: "product-1", Quantity: 3}))
+	feed.Publish(newProductChange(ChangeRestock, Product{ID: "product-2"}, Product{ID: "product-2", Quantity: 4}))
+	if snapshots := subscription.Next(); len(snapshots) != 1 || snapshots[0].ProductID != "product-2" {
+		t.Errorf("Expected only product-2 after following it, got %+v", snapshots)
+	}
+
+	subscription.Close()
+	subscription.Close()
+	if feed.Subscribers() != 0 {
+		t.Errorf("Expected no subscribers after Close, got %d", feed.Subscribers())
+	}
+	feed.Publish(newProductChange(ChangeRestock, Product{ID: "product-2"}, Product{ID: "product-2", Quantity: 5}))
+	if snapshots := subscription.Next(); len(snapshots) != 0 {
+		t.Errorf("Expected no snapshots after Close, got %+v", snapshots)
+	}
+
+	var nilFeed *ProductFeed
+	nilFeed.Publish(newProductChange(ChangeRestock, Product{ID: "product-1"}, Product{ID: "product-1", Quantity: 1}))
+
+	t.Log("✅ Subscription follows the selected products")
+}
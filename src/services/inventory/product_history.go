@@ -0,0 +1,49 @@
+package inventory
+
+import "time"
+
+const (
+	// Kinds of stock change recorded in a product's history
+	ChangeAdd     = "add"     // The product was added or seeded
+	ChangeReserve = "reserve" // Available stock was moved to reserved
+	ChangeRelease = "release" // Reserved stock was moved back to available
+	ChangeRestock = "restock" // Available stock was increased
+	ChangeUpdate  = "update"  // Available stock was set to a new value
+)
+
+// ProductChange is one entry of a product's stock history, with the stock before and after the change
+type ProductChange struct {
+	ProductID      string    `bson:"productId" json:"productId"`
+	Kind           string    `bson:"kind" json:"kind"`
+	QuantityBefore int       `bson:"quantityBefore" json:"quantityBefore"`
+	QuantityAfter  int       `bson:"quantityAfter" json:"quantityAfter"`
+	QuantityDelta  int       `bson:"quantityDelta" json:"quantityDelta"`
+	ReservedBefore int       `bson:"reservedBefore" json:"reservedBefore"`
+	ReservedAfter  int       `bson:"reservedAfter" json:"reservedAfter"`
+	ReservedDelta  int       `bson:"reservedDelta" json:"reservedDelta"`
+	At             time.Time `bson:"at" json:"at"`
+}
+
+// newProductChange describes the change of a product from before to after
+func newProductChange(kind string, before, after Product) ProductChange {
+	return ProductChange{
+		ProductID:      after.ID,
+		Kind:           kind,
+		QuantityBefore: before.Quantity,
+		QuantityAfter:  after.Quantity,
+		QuantityDelta:  after.Quantity - before.Quantity,
+		ReservedBefore: before.Reserved,
+		ReservedAfter:  after.Reserved,
+		ReservedDelta:  after.Reserved - before.Reserved,
+		At:             time.Now().UTC(),
+	}
+}
+
+// undo returns the product as it was before quantity and reserved were incremented by the given deltas.
+// Updates that $inc return only the document after the change, so its previous state is derived.
+func undo(after Product, quantityDelta, reservedDelta int) Product {
+	before := after
+	before.Quantity -= quantityDelta
+	before.Reserved -= reservedDelta
+	return before
+}
@@ -0,0 +1,48 @@
+package inventory
+
+import "testing"
+
+func TestNewProductChange(t *testing.T) {
+	product := Product{ID: "product-1", Quantity: 10}
+
+	// Reserve 3 then release them, deriving each previous state the way the repository does
+	afterReserve := Product{ID: "product-1", Quantity: 7, Reserved: 3}
+	reserve := newProductChange(ChangeReserve, undo(afterReserve, -3, 3), afterReserve)
+	afterRelease := Product{ID: "product-1", Quantity: 10, Reserved: 0}
+	release := newProductChange(ChangeRelease, undo(afterRelease, 3, -3), afterRelease)
+
+	tests := []struct {
+		name   string
+		change ProductChange
+		want   ProductChange
+	}{
+		{
+			name:   "reserve",
+			change: reserve,
+			want: ProductChange{ProductID: "product-1", Kind: ChangeReserve, QuantityBefore: 10, QuantityAfter: 7,
+				QuantityDelta: -3, ReservedBefore: 0, ReservedAfter: 3, ReservedDelta: 3},
+		},
+		{
+			name:   "release",
+			change: release,
+			want: ProductChange{ProductID: "product-1", Kind: ChangeRelease, QuantityBefore: 7, QuantityAfter: 10,
+				QuantityDelta: 3, ReservedBefore: 3, ReservedAfter: 0, ReservedDelta: -3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.change.At.IsZero() {
+				t.Error("Expected the change to be timestamped")
+			}
+			tt.change.At = tt.want.At
+			if tt.change != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, tt.change)
+			}
+		})
+	}
+
+	if reserve.QuantityBefore != product.Quantity || release.QuantityAfter != product.Quantity {
+		t.Errorf("Expected the history to start and end at quantity %d", product.Quantity)
+	}
+	t.Log("✅ Reserve and release recorded with opposite deltas")
+}
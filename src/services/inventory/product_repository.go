@@ -2,6 +2,10 @@ package inventory
 
 import (
 	"context"
+	"fmt"
+	"go-order-eda/src/infrastructure/log"
+	mongoinfra "go-order-eda/src/infrastructure/mongo"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -9,62 +13,212 @@ import (
 )
 
 type Product struct {
-	ID       string `bson:"id"`
-	Name     string `bson:"name"`
-	Quantity int    `bson:"quantity"`
-	Reserved int    `bson:"reserved"`
+	ID               string `bson:"id" json:"id"`
+	Name             string `bson:"name" json:"name"`
+	Quantity         int    `bson:"quantity" json:"quantity"`
+	Reserved         int    `bson:"reserved" json:"reserved"`
+	ReorderThreshold int    `bson:"reorderThreshold,omitempty" json:"reorderThreshold,omitempty"` // 0 falls back to the global default
+	// Catalog data; products stored before it was introduced have none
+	SKU        string         `bson:"sku,omitempty" json:"sku,omitempty"`
+	Category   string         `bson:"category,omitempty" json:"category,omitempty"`
+	Attributes map[string]any `bson:"attributes,omitempty" json:"attributes,omitempty"` // Any other data, such as a price or color
 }
+
+// ProductAvailability is the stock breakdown of a product.
+// Quantity is the available stock; reserved units are already committed to orders.
+type ProductAvailability struct {
+	ProductID string `json:"productId"`
+	Available int    `json:"available"`
+	Reserved  int    `json:"reserved"`
+	Total     int    `json:"total"`
+}
+
+// Availability computes the stock breakdown of the product
+func (p Product) Availability() ProductAvailability {
+	return ProductAvailability{
+		ProductID: p.ID,
+		Available: p.Quantity,
+		Reserved:  p.Reserved,
+		Total:     p.Quantity + p.Reserved,
+	}
+}
+
 type ProductRepository interface {
-	CheckAndReserveProduct(ctx context.Context, productID string, quantity int) (bool, error)
-	ReleaseReservedProduct(ctx context.Context, productID string, quantity int) error
+	CheckAndReserveProduct(ctx context.Context, productID string, quantity int) (ReservationOutcome, error)
+	ReleaseReservedProduct(ctx context.Context, productID string, quantity int) (*Product, error)
 	SeedProduct(ctx context.Context, product Product) error
 	// New business logic methods
 	GetProductById(ctx context.Context, productID string) (*Product, error)
+	// UpdateProductQuantity sets the available quantity, failing with ErrQuantityBelowReserved or ErrProductNotFound
 	UpdateProductQuantity(ctx context.Context, productID string, quantity int) error
+	// SetProductStock sets the available quantity and zeroes the reserved stock, returning nil when the product does not exist
+	SetProductStock(ctx context.Context, productID string, quantity int) (*Product, error)
+	IncreaseStock(ctx context.Context, productID string, delta int) error
 	GetLowStockProducts(ctx context.Context, threshold int) ([]Product, error)
 	AddProduct(ctx context.Context, product Product) error
-	GetAllProducts(ctx context.Context) ([]Product, error)
+	// GetAllProducts returns the products of a category, or every product when category is empty
+	GetAllProducts(ctx context.Context, category string) ([]Product, error)
+	// GetProductHistory returns the latest limit stock changes of a product, oldest first
+	GetProductHistory(ctx context.Context, productID string, limit int64) ([]ProductChange, error)
+	EnsureIndexes(ctx context.Context) error
 }
 
 type productRepository struct {
 	collection *mongo.Collection
+	history    *mongo.Collection // Stock changes made through the repository, see record
+	feed       *ProductFeed      // Receives the recorded changes, may be nil
+	logger     log.Logger
+	timeout    time.Duration // Upper bound for each database operation
 }
 
-func NewProductRepository(db *mongo.Database) ProductRepository {
+// NewProductRepository returns a repository publishing the stock changes it makes to feed, if not nil
+func NewProductRepository(db *mongo.Database, timeout time.Duration, logger log.Logger, feed *ProductFeed) ProductRepository {
 	return &productRepository{
 		collection: db.Collection("products"),
+		history:    db.Collection("inventory_events"),
+		feed:       feed,
+		logger:     logger,
+		timeout:    timeout,
+	}
+}
+
+// record appends a stock change to the product's history and publishes it to the feed. The change
+// itself has already been applied, so a failure is logged rather than returned: the caller must not
+// retry the change.
+func (r *productRepository) record(ctx context.Context, change ProductChange) {
+	r.feed.Publish(change)
+	if _, err := r.history.InsertOne(ctx, change); err != nil {
+		r.logger.Warn(ctx, fmt.Sprintf("Failed to record %s of product %s in its history: %v", change.Kind, change.ProductID, err))
 	}
 }
 
-func (r *productRepository) CheckAndReserveProduct(ctx context.Context, productID string, quantity int) (bool, error) {
+// ReserveResult tells whether a reservation took the stock, and why not
+type ReserveResult string
+
+const (
+	ReserveOK                ReserveResult = "reserved"
+	ReserveInsufficientStock ReserveResult = "insufficient_stock"
+	ReserveNotFound          ReserveResult = "not_found"
+)
+
+// ReservationOutcome is the result of CheckAndReserveProduct. Product is the product as updated when
+// the stock was reserved, as last read when its stock was insufficient, and nil when it does not exist.
+type ReservationOutcome struct {
+	Result  ReserveResult
+	Product *Product
+}
+
+// reservationSteps are the two queries of a reservation, kept apart so how they interleave with
+// concurrent changes to the product can be tested without a database
+type reservationSteps interface {
+	GetProductById(ctx context.Context, productID string) (*Product, error)
+	// takeStock moves quantity to reserved stock, returning nil when the product is missing or has too little
+	takeStock(ctx context.Context, productID string, quantity int) (*Product, error)
+}
+
+// reserveProduct looks the product up to tell a missing product from insufficient stock, then takes the
+// stock with a conditional update. When the update matches nothing the product changed in between,
+// so it is read again: a product deleted between the two steps is reported as not found.
+func reserveProduct(ctx context.Context, steps reservationSteps, productID string, quantity int) (ReservationOutcome, error) {
+	product, err := steps.GetProductById(ctx, productID)
+	if err != nil {
+		return ReservationOutcome{}, err
+	}
+	if product == nil {
+		return ReservationOutcome{Result: ReserveNotFound}, nil
+	}
+	if product.Quantity < quantity {
+		return ReservationOutcome{Result: ReserveInsufficientStock, Product: product}, nil
+	}
+
+	reserved, err := steps.takeStock(ctx, productID, quantity)
+	if err != nil {
+		return ReservationOutcome{}, err
+	}
+	if reserved != nil {
+		return ReservationOutcome{Result: ReserveOK, Product: reserved}, nil
+	}
+
+	product, err = steps.GetProductById(ctx, productID)
+	if err != nil {
+		return ReservationOutcome{}, err
+	}
+	if product == nil {
+		return ReservationOutcome{Result: ReserveNotFound}, nil
+	}
+	return ReservationOutcome{Result: ReserveInsufficientStock, Product: product}, nil
+}
+
+// CheckAndReserveProduct moves quantity from available to reserved stock when enough is available.
+// The outcome tells a reservation apart from a missing product and from insufficient stock.
+func (r *productRepository) CheckAndReserveProduct(ctx context.Context, productID string, quantity int) (ReservationOutcome, error) {
+	return reserveProduct(ctx, r, productID, quantity)
+}
+
+// takeStock atomically moves quantity from available to reserved stock if enough is available
+func (r *productRepository) takeStock(ctx context.Context, productID string, quantity int) (*Product, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
 	filter := bson.M{"id": productID, "quantity": bson.M{"$gte": quantity}}
 	update := bson.M{"$inc": bson.M{"quantity": -quantity, "reserved": quantity}}
-	res := r.collection.FindOneAndUpdate(ctx, filter, update)
-	if res.Err() != nil {
-		if res.Err() == mongo.ErrNoDocuments {
-			return false, nil
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var product Product
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
 		}
-		return false, res.Err()
+		return nil, err
 	}
-	return true, nil
+	r.record(ctx, newProductChange(ChangeReserve, undo(product, -quantity, quantity), product))
+	return &product, nil
 }
 
-func (r *productRepository) ReleaseReservedProduct(ctx context.Context, productID string, quantity int) error {
+// ReleaseReservedProduct moves quantity from reserved back to available stock.
+// It returns the product as updated, or nil when the product does not exist.
+func (r *productRepository) ReleaseReservedProduct(ctx context.Context, productID string, quantity int) (*Product, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
 	filter := bson.M{"id": productID}
 	update := bson.M{"$inc": bson.M{"quantity": quantity, "reserved": -quantity}}
-	_, err := r.collection.UpdateOne(ctx, filter, update)
-	return err
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var product Product
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	r.record(ctx, newProductChange(ChangeRelease, undo(product, quantity, -quantity), product))
+	return &product, nil
 }
 
 func (r *productRepository) SeedProduct(ctx context.Context, product Product) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
 	filter := bson.M{"id": product.ID}
 	update := bson.M{"$setOnInsert": product}
 	opts := options.Update().SetUpsert(true)
-	_, err := r.collection.UpdateOne(ctx, filter, update, opts)
-	return err
+	res, err := r.collection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return err
+	}
+	if res.UpsertedCount > 0 {
+		r.record(ctx, newProductChange(ChangeAdd, Product{}, product))
+	}
+	return nil
 }
 
 func (r *productRepository) GetProductById(ctx context.Context, productID string) (*Product, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
 	var product Product
 	err := r.collection.FindOne(ctx, bson.M{"id": productID}).Decode(&product)
 	if err != nil {
@@ -76,15 +230,90 @@ func (r *productRepository) GetProductById(ctx context.Context, productID string
 	return &product, nil
 }
 
+// UpdateProductQuantity sets the available quantity of a product unless it is below the product's
+// reserved stock, checked within the same update. It returns ErrQuantityBelowReserved in that case
+// and ErrProductNotFound when the product does not exist.
 func (r *productRepository) UpdateProductQuantity(ctx context.Context, productID string, quantity int) error {
-	filter := bson.M{"id": productID}
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{"id": productID, "reserved": bson.M{"$lte": quantity}}
 	update := bson.M{"$set": bson.M{"quantity": quantity}}
-	_, err := r.collection.UpdateOne(ctx, filter, update)
-	return err
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	var before Product
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		// Tell a missing product apart from one holding more reserved stock than the quantity
+		count, err := r.collection.CountDocuments(ctx, bson.M{"id": productID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrProductNotFound
+		}
+		return ErrQuantityBelowReserved
+	}
+	if err != nil {
+		return err
+	}
+	after := before
+	after.Quantity = quantity
+	r.record(ctx, newProductChange(ChangeUpdate, before, after))
+	return nil
+}
+
+// SetProductStock sets the available quantity of a product and zeroes its reserved stock.
+// It returns the product as updated, or nil when the product does not exist.
+func (r *productRepository) SetProductStock(ctx context.Context, productID string, quantity int) (*Product, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{"id": productID}
+	update := bson.M{"$set": bson.M{"quantity": quantity, "reserved": 0}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	var before Product
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&before)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	after := before
+	after.Quantity = quantity
+	after.Reserved = 0
+	r.record(ctx, newProductChange(ChangeUpdate, before, after))
+	return &after, nil
+}
+
+// IncreaseStock atomically adds delta to the available quantity of a product
+func (r *productRepository) IncreaseStock(ctx context.Context, productID string, delta int) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{"id": productID}
+	update := bson.M{"$inc": bson.M{"quantity": delta}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var product Product
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	r.record(ctx, newProductChange(ChangeRestock, undo(product, delta, 0), product))
+	return nil
 }
 
 // GetLowStockProducts returns products with stock below the threshold
 func (r *productRepository) GetLowStockProducts(ctx context.Context, threshold int) ([]Product, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
 	filter := bson.M{"quantity": bson.M{"$lt": threshold}}
 	cursor, err := r.collection.Find(ctx, filter)
 	if err != nil {
@@ -105,13 +334,26 @@ func (r *productRepository) GetLowStockProducts(ctx context.Context, threshold i
 
 // AddProduct adds a new product to the inventory
 func (r *productRepository) AddProduct(ctx context.Context, product Product) error {
-	_, err := r.collection.InsertOne(ctx, product)
-	return err
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, product); err != nil {
+		return err
+	}
+	r.record(ctx, newProductChange(ChangeAdd, Product{}, product))
+	return nil
 }
 
-// GetAllProducts retrieves all products in the inventory
-func (r *productRepository) GetAllProducts(ctx context.Context) ([]Product, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{})
+// GetAllProducts retrieves the products of a category, or all products in the inventory when category is empty
+func (r *productRepository) GetAllProducts(ctx context.Context, category string) ([]Product, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{}
+	if category != "" {
+		filter["category"] = category
+	}
+	cursor, err := r.collection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -127,3 +369,42 @@ func (r *productRepository) GetAllProducts(ctx context.Context) ([]Product, erro
 	}
 	return products, nil
 }
+
+// GetProductHistory returns the latest limit stock changes of a product, oldest first
+func (r *productRepository) GetProductHistory(ctx context.Context, productID string, limit int64) ([]ProductChange, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	opts := options.Find().SetLimit(limit).SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := r.history.Find(ctx, bson.M{"productId": productID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	changes := []ProductChange{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(changes)-1; i < j; i, j = i+1, j-1 {
+		changes[i], changes[j] = changes[j], changes[i]
+	}
+	return changes, nil
+}
+
+// EnsureIndexes creates the index used to read a product's history
+func (r *productRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.history.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "productId", Value: 1}, {Key: "at", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "category", Value: 1}}, // Products of a category
+	})
+	return err
+}
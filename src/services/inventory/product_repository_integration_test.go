@@ -2,16 +2,26 @@ package inventory
 
 import (
 	"context"
+	"errors"
 	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"go-order-eda/src/infrastructure/log"
+
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// Integration test that requires a real MongoDB connection
+// Integration tests that require a real MongoDB connection
 // To run: go test -tags=integration
-func TestProductRepository_QuantityDecreases_Integration(t *testing.T) {
+func newIntegrationDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
 	// Skip if not running integration tests
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -28,11 +38,16 @@ func TestProductRepository_QuantityDecreases_Integration(t *testing.T) {
 	if err != nil {
 		t.Skipf("Cannot connect to MongoDB: %v", err)
 	}
-	defer client.Disconnect(context.Background())
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
 
 	// Use a test database
-	db := client.Database("test_inventory")
-	repo := NewProductRepository(db)
+	return client.Database("test_inventory")
+}
+
+func TestProductRepository_QuantityDecreases_Integration(t *testing.T) {
+	db := newIntegrationDatabase(t)
+	feed := NewProductFeed()
+	repo := NewProductRepository(db, 5*time.Second, log.NewLogger(), feed)
 	ctx := context.Background()
 
 	t.Run("quantity decreases and reserved increases on successful reservation", func(t *testing.T) {
@@ -66,16 +81,18 @@ func TestProductRepository_QuantityDecreases_Integration(t *testing.T) {
 		initialQuantity := initialProduct.Quantity
 		initialReserved := initialProduct.Reserved
 		reserveAmount := 3
+		subscription := feed.Subscribe(productID)
+		defer subscription.Close()
 
 		// Act - Reserve product
-		success, err := repo.CheckAndReserveProduct(ctx, productID, reserveAmount)
+		outcome, err := repo.CheckAndReserveProduct(ctx, productID, reserveAmount)
 
 		// Assert reservation succeeded
 		if err != nil {
 			t.Fatalf("Reservation failed with error: %v", err)
 		}
-		if !success {
-			t.Fatal("Reservation should have succeeded")
+		if outcome.Result != ReserveOK || outcome.Product == nil {
+			t.Fatalf("Reservation should have succeeded, got %+v", outcome)
 		}
 
 		// Get updated state
@@ -101,6 +118,12 @@ func TestProductRepository_QuantityDecreases_Integration(t *testing.T) {
 				expectedReserved, updatedProduct.Reserved)
 		}
 
+		// Verify the change reached the feed
+		snapshots := subscription.Next()
+		if len(snapshots) != 1 || snapshots[0].Kind != ChangeReserve || snapshots[0].Quantity != expectedQuantity || snapshots[0].Reserved != expectedReserved {
+			t.Errorf("Expected a reserve snapshot of %d available and %d reserved, got %+v", expectedQuantity, expectedReserved, snapshots)
+		}
+
 		t.Logf("✅ Quantity correctly decreased from %d to %d", initialQuantity, updatedProduct.Quantity)
 		t.Logf("✅ Reserved correctly increased from %d to %d", initialReserved, updatedProduct.Reserved)
 	})
@@ -124,14 +147,14 @@ func TestProductRepository_QuantityDecreases_Integration(t *testing.T) {
 		reserveAmount := 5 // More than available
 
 		// Act - Try to reserve more than available
-		success, err := repo.CheckAndReserveProduct(ctx, productID, reserveAmount)
+		outcome, err := repo.CheckAndReserveProduct(ctx, productID, reserveAmount)
 
 		// Assert reservation failed
 		if err != nil {
 			t.Fatalf("Unexpected error during reservation: %v", err)
 		}
-		if success {
-			t.Fatal("Reservation should have failed due to insufficient quantity")
+		if outcome.Result != ReserveInsufficientStock {
+			t.Fatalf("Reservation should have failed due to insufficient quantity, got %+v", outcome)
 		}
 
 		// Verify quantity unchanged
@@ -170,17 +193,12 @@ func TestProductRepository_QuantityDecreases_Integration(t *testing.T) {
 
 		reserveAmount := 4
 
-		// Act 1 - Reserve
-		success, err := repo.CheckAndReserveProduct(ctx, productID, reserveAmount)
-		if err != nil || !success {
-			t.Fatalf("Reservation failed: success=%v, err=%v", success, err)
-		}
-
-		// Verify after reservation
-		afterReserve, err := repo.GetProductById(ctx, productID)
-		if err != nil {
-			t.Fatalf("Failed to get product after reservation: %v", err)
+		// Act 1 - Reserve; the returned document is the state after the update
+		outcome, err := repo.CheckAndReserveProduct(ctx, productID, reserveAmount)
+		if err != nil || outcome.Result != ReserveOK {
+			t.Fatalf("Reservation failed: outcome=%+v, err=%v", outcome, err)
 		}
+		afterReserve := outcome.Product
 
 		expectedQuantityAfterReserve := testProduct.Quantity - reserveAmount
 		expectedReservedAfterReserve := testProduct.Reserved + reserveAmount
@@ -195,15 +213,9 @@ func TestProductRepository_QuantityDecreases_Integration(t *testing.T) {
 		}
 
 		// Act 2 - Release
-		err = repo.ReleaseReservedProduct(ctx, productID, reserveAmount)
-		if err != nil {
-			t.Fatalf("Release failed: %v", err)
-		}
-
-		// Verify after release
-		afterRelease, err := repo.GetProductById(ctx, productID)
-		if err != nil {
-			t.Fatalf("Failed to get product after release: %v", err)
+		afterRelease, err := repo.ReleaseReservedProduct(ctx, productID, reserveAmount)
+		if err != nil || afterRelease == nil {
+			t.Fatalf("Release failed: product=%v, err=%v", afterRelease, err)
 		}
 
 		// Should be back to original state
@@ -222,6 +234,244 @@ func TestProductRepository_QuantityDecreases_Integration(t *testing.T) {
 			testProduct.Reserved, afterReserve.Reserved, afterRelease.Reserved)
 	})
 
+	t.Run("returned document matches the persisted state", func(t *testing.T) {
+		productID := "test-product-4"
+		if err := repo.AddProduct(ctx, Product{ID: productID, Name: "Returned Document Product", Quantity: 9}); err != nil {
+			t.Fatalf("Failed to add test product: %v", err)
+		}
+
+		outcome, err := repo.CheckAndReserveProduct(ctx, productID, 2)
+		if err != nil || outcome.Result != ReserveOK {
+			t.Fatalf("Reservation failed: outcome=%+v, err=%v", outcome, err)
+		}
+		reserved := outcome.Product
+		persisted, err := repo.GetProductById(ctx, productID)
+		if err != nil {
+			t.Fatalf("Failed to get product after reservation: %v", err)
+		}
+		if !reflect.DeepEqual(*reserved, *persisted) {
+			t.Errorf("Reserve returned %+v, persisted %+v", *reserved, *persisted)
+		}
+
+		released, err := repo.ReleaseReservedProduct(ctx, productID, 1)
+		if err != nil || released == nil {
+			t.Fatalf("Release failed: product=%v, err=%v", released, err)
+		}
+		persisted, err = repo.GetProductById(ctx, productID)
+		if err != nil {
+			t.Fatalf("Failed to get product after release: %v", err)
+		}
+		if !reflect.DeepEqual(*released, *persisted) {
+			t.Errorf("Release returned %+v, persisted %+v", *released, *persisted)
+		}
+
+		missing, err := repo.ReleaseReservedProduct(ctx, "missing-product", 1)
+		if err != nil || missing != nil {
+			t.Errorf("Expected nil product for a missing ID, got %v, err=%v", missing, err)
+		}
+
+		t.Logf("✅ Returned documents match the persisted state: %+v", *released)
+	})
+
+	t.Run("reserve and release are recorded in the product history", func(t *testing.T) {
+		productID := "test-product-5"
+		db.Collection("inventory_events").Drop(ctx)
+		if err := repo.AddProduct(ctx, Product{ID: productID, Name: "History Product", Quantity: 10}); err != nil {
+			t.Fatalf("Failed to add test product: %v", err)
+		}
+
+		if outcome, err := repo.CheckAndReserveProduct(ctx, productID, 3); err != nil || outcome.Result != ReserveOK {
+			t.Fatalf("Reservation failed: outcome=%+v, err=%v", outcome, err)
+		}
+		if product, err := repo.ReleaseReservedProduct(ctx, productID, 3); err != nil || product == nil {
+			t.Fatalf("Release failed: product=%v, err=%v", product, err)
+		}
+
+		history, err := repo.GetProductHistory(ctx, productID, 10)
+		if err != nil {
+			t.Fatalf("Failed to get product history: %v", err)
+		}
+		if len(history) != 3 || history[0].Kind != ChangeAdd {
+			t.Fatalf("Expected add, reserve and release entries, got %+v", history)
+		}
+		reserve, release := history[1], history[2]
+		if reserve.Kind != ChangeReserve || reserve.QuantityBefore != 10 || reserve.QuantityAfter != 7 ||
+			reserve.QuantityDelta != -3 || reserve.ReservedDelta != 3 {
+			t.Errorf("Unexpected reserve entry %+v", reserve)
+		}
+		if release.Kind != ChangeRelease || release.QuantityBefore != 7 || release.QuantityAfter != 10 ||
+			release.QuantityDelta != 3 || release.ReservedDelta != -3 {
+			t.Errorf("Unexpected release entry %+v", release)
+		}
+
+		t.Logf("✅ History: %s %+d, %s %+d", reserve.Kind, reserve.QuantityDelta, release.Kind, release.QuantityDelta)
+	})
+
+	t.Run("catalog attributes persisted and filtered by category", func(t *testing.T) {
+		db.Collection("products").Drop(ctx)
+		laptop := Product{
+			ID: "test-product-6", Name: "Laptop", Quantity: 5, SKU: "LAP-001", Category: "computers",
+			Attributes: map[string]any{"color": "silver", "price": map[string]any{"amount": int64(129900), "currency": "EUR"}},
+		}
+		mouse := Product{ID: "test-product-7", Name: "Mouse", Quantity: 20, SKU: "MOU-001", Category: "accessories"}
+		for _, product := range []Product{laptop, mouse} {
+			if err := repo.AddProduct(ctx, product); err != nil {
+				t.Fatalf("Failed to add test product: %v", err)
+			}
+		}
+		// A product stored before catalog data was introduced
+		if _, err := db.Collection("products").InsertOne(ctx, map[string]any{"id": "test-product-8", "name": "Legacy", "quantity": 1, "reserved": 0}); err != nil {
+			t.Fatalf("Failed to insert legacy product: %v", err)
+		}
+
+		stored, err := repo.GetProductById(ctx, laptop.ID)
+		if err != nil || stored == nil {
+			t.Fatalf("Failed to get product: product=%v, err=%v", stored, err)
+		}
+		if !reflect.DeepEqual(*stored, laptop) {
+			t.Errorf("Expected %+v, got %+v", laptop, *stored)
+		}
+
+		computers, err := repo.GetAllProducts(ctx, "computers")
+		if err != nil {
+			t.Fatalf("Failed to get products: %v", err)
+		}
+		if len(computers) != 1 || computers[0].ID != laptop.ID {
+			t.Errorf("Expected only the laptop, got %+v", computers)
+		}
+		all, err := repo.GetAllProducts(ctx, "")
+		if err != nil || len(all) != 3 {
+			t.Fatalf("Expected every product, got %d and %v", len(all), err)
+		}
+		legacy, err := repo.GetProductById(ctx, "test-product-8")
+		if err != nil || legacy == nil || legacy.Category != "" || legacy.Attributes != nil {
+			t.Errorf("Expected the legacy product without catalog data, got %+v and %v", legacy, err)
+		}
+	})
+
 	// Cleanup
 	db.Collection("products").Drop(ctx)
+	db.Collection("inventory_events").Drop(ctx)
+}
+
+func TestReservationRepository_Claim_Integration(t *testing.T) {
+	db := newIntegrationDatabase(t)
+	ctx := context.Background()
+	db.Collection("reservations").Drop(ctx)
+	t.Cleanup(func() { db.Collection("reservations").Drop(ctx) })
+
+	repo := NewReservationRepository(db, 5*time.Second)
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("EnsureIndexes failed: %v", err)
+	}
+	reservation := Reservation{OrderID: "order-claim-1", ProductID: "product-1", Quantity: 2, Status: ReservationActive, ReservedAt: time.Now().UTC()}
+
+	t.Run("concurrent claims of the same order and product", func(t *testing.T) {
+		var wg sync.WaitGroup
+		var claims atomic.Int32
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				claimed, err := repo.Claim(ctx, reservation)
+				if err != nil {
+					t.Errorf("Claim failed: %v", err)
+				}
+				if claimed {
+					claims.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if claims.Load() != 1 {
+			t.Errorf("Expected exactly one claim, got %d", claims.Load())
+		}
+		if count, _ := db.Collection("reservations").CountDocuments(ctx, bson.M{"orderId": "order-claim-1"}); count != 1 {
+			t.Errorf("Expected 1 ledger entry, got %d", count)
+		}
+	})
+
+	t.Run("released reservation can be claimed again", func(t *testing.T) {
+		if ok, err := repo.MarkReleased(ctx, "order-claim-1", "product-1"); err != nil || !ok {
+			t.Fatalf("MarkReleased failed: %v, %v", ok, err)
+		}
+		if claimed, err := repo.Claim(ctx, reservation); err != nil || !claimed {
+			t.Errorf("Expected the released reservation claimed again, got %v, %v", claimed, err)
+		}
+	})
+
+	t.Run("unclaimed reservation leaves no entry", func(t *testing.T) {
+		if err := repo.Unclaim(ctx, "order-claim-1", "product-1"); err != nil {
+			t.Fatalf("Unclaim failed: %v", err)
+		}
+		reservations, err := repo.GetByOrderID(ctx, "order-claim-1")
+		if err != nil || len(reservations) != 0 {
+			t.Errorf("Expected no ledger entry, got %+v, %v", reservations, err)
+		}
+	})
+}
+
+func TestInventoryService_ConcurrentRestocks_Integration(t *testing.T) {
+	db := newIntegrationDatabase(t)
+	repo := NewProductRepository(db, 5*time.Second, log.NewLogger(), NewProductFeed())
+	service := NewInventoryService(log.NewLogger(), repo, NewReservationRepository(db, 5*time.Second), &fakePublisher{}, 10, nil)
+	ctx := context.Background()
+
+	const productID = "test-product-restock"
+	db.Collection("products").DeleteMany(ctx, bson.M{"id": productID})
+	if err := repo.AddProduct(ctx, Product{ID: productID, Name: "Test Product", Quantity: 10}); err != nil {
+		t.Fatalf("Failed to add test product: %v", err)
+	}
+
+	const workers = 50
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := service.RestockProduct(ctx, productID, 2); err != nil {
+				t.Errorf("RestockProduct failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	product, err := repo.GetProductById(ctx, productID)
+	if err != nil {
+		t.Fatalf("Failed to get product: %v", err)
+	}
+	if product.Quantity != 10+workers*2 {
+		t.Errorf("Expected quantity %d, got %d", 10+workers*2, product.Quantity)
+	}
+
+	t.Log("✅ Concurrent restocks all applied by the atomic $inc")
+}
+
+func TestProductRepository_UpdateProductQuantity_Integration(t *testing.T) {
+	db := newIntegrationDatabase(t)
+	repo := NewProductRepository(db, 5*time.Second, log.NewLogger(), NewProductFeed())
+	ctx := context.Background()
+
+	const productID = "test-product-update"
+	db.Collection("products").DeleteMany(ctx, bson.M{"id": productID})
+	if err := repo.AddProduct(ctx, Product{ID: productID, Name: "Test Product", Quantity: 10, Reserved: 5}); err != nil {
+		t.Fatalf("Failed to add test product: %v", err)
+	}
+
+	if err := repo.UpdateProductQuantity(ctx, productID, 4); !errors.Is(err, ErrQuantityBelowReserved) {
+		t.Errorf("Expected ErrQuantityBelowReserved, got %v", err)
+	}
+	if err := repo.UpdateProductQuantity(ctx, "missing-product", 4); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, got %v", err)
+	}
+	if err := repo.UpdateProductQuantity(ctx, productID, 5); err != nil {
+		t.Fatalf("UpdateProductQuantity failed: %v", err)
+	}
+	product, err := repo.GetProductById(ctx, productID)
+	if err != nil || product.Quantity != 5 {
+		t.Errorf("Expected quantity 5, got %+v (%v)", product, err)
+	}
+
+	t.Log("✅ Quantity below the reserved stock rejected within the update")
 }
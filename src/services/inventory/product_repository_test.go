@@ -2,6 +2,7 @@ package inventory
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -49,7 +50,7 @@ func TestCheckAndReserveProduct_Logic(t *testing.T) {
 			ctx := context.Background()
 
 			// This should compile without errors
-			_ = func() (bool, error) {
+			_ = func() (ReservationOutcome, error) {
 				return repo.CheckAndReserveProduct(ctx, tt.productID, tt.requestQuantity)
 			}
 
@@ -85,6 +86,29 @@ func TestProductStruct(t *testing.T) {
 	t.Log("✅ Product struct test passed")
 }
 
+// TestProductJSONKeys verifies the API representation uses the same lowerCamel keys as the events
+func TestProductJSONKeys(t *testing.T) {
+	body, err := json.Marshal(Product{ID: "test-id", Name: "Test Product", Quantity: 10, Reserved: 2})
+	if err != nil {
+		t.Fatalf("Product marshaling failed: %v", err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatalf("Product unmarshaling failed: %v", err)
+	}
+	for _, key := range []string{"id", "name", "quantity", "reserved"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("Expected JSON key %q in %s", key, body)
+		}
+	}
+	if len(fields) != 4 {
+		t.Errorf("Expected exactly 4 JSON keys, got %s", body)
+	}
+
+	t.Log("✅ Product JSON keys verified")
+}
+
 // TestMongoDBOperations verifies the MongoDB operations are correctly structured
 func TestMongoDBOperations(t *testing.T) {
 	t.Run("CheckAndReserveProduct filter structure", func(t *testing.T) {
@@ -216,3 +240,85 @@ func TestReservationLogic(t *testing.T) {
 		})
 	}
 }
+
+// scriptedSteps serves a reservation's queries from a product that between can change after the first lookup
+type scriptedSteps struct {
+	product *Product
+	between func(s *scriptedSteps) // Runs before the conditional update
+}
+
+func (s *scriptedSteps) GetProductById(ctx context.Context, productID string) (*Product, error) {
+	if s.product == nil || s.product.ID != productID {
+		return nil, nil
+	}
+	copied := *s.product
+	return &copied, nil
+}
+
+func (s *scriptedSteps) takeStock(ctx context.Context, productID string, quantity int) (*Product, error) {
+	if s.between != nil {
+		s.between(s)
+	}
+	if s.product == nil || s.product.ID != productID || s.product.Quantity < quantity {
+		return nil, nil
+	}
+	s.product.Quantity -= quantity
+	s.product.Reserved += quantity
+	copied := *s.product
+	return &copied, nil
+}
+
+func TestReserveProduct(t *testing.T) {
+	tests := []struct {
+		name         string
+		between      func(s *scriptedSteps)
+		productID    string
+		quantity     int
+		wantResult   ReserveResult
+		wantQuantity int // Of the returned product, if any
+	}{
+		{name: "reserved", productID: "product-1", quantity: 4, wantResult: ReserveOK, wantQuantity: 6},
+		{name: "insufficient stock", productID: "product-1", quantity: 11, wantResult: ReserveInsufficientStock, wantQuantity: 10},
+		{name: "missing product", productID: "product-2", quantity: 1, wantResult: ReserveNotFound},
+		{
+			name:       "deleted between the lookup and the update",
+			between:    func(s *scriptedSteps) { s.product = nil },
+			productID:  "product-1",
+			quantity:   4,
+			wantResult: ReserveNotFound,
+		},
+		{
+			name:         "stock taken between the lookup and the update",
+			between:      func(s *scriptedSteps) { s.product.Quantity = 2 },
+			productID:    "product-1",
+			quantity:     4,
+			wantResult:   ReserveInsufficientStock,
+			wantQuantity: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := &scriptedSteps{product: &Product{ID: "product-1", Quantity: 10}, between: tt.between}
+
+			outcome, err := reserveProduct(context.Background(), steps, tt.productID, tt.quantity)
+			if err != nil {
+				t.Fatalf("Reservation failed: %v", err)
+			}
+			if outcome.Result != tt.wantResult {
+				t.Errorf("Expected %s, got %s", tt.wantResult, outcome.Result)
+			}
+			if tt.wantResult == ReserveNotFound {
+				if outcome.Product != nil {
+					t.Errorf("Expected no product, got %+v", outcome.Product)
+				}
+				return
+			}
+			if outcome.Product == nil || outcome.Product.Quantity != tt.wantQuantity {
+				t.Errorf("Expected the product at quantity %d, got %+v", tt.wantQuantity, outcome.Product)
+			}
+		})
+	}
+
+	t.Log("✅ Missing products told apart from insufficient stock")
+}
@@ -0,0 +1,54 @@
+package inventory
+
+import "sync"
+
+// ReservationStats are the reservation counters of one product. A high failure ratio means
+// orders keep asking for more than is in stock, so the product needs to be reordered.
+type ReservationStats struct {
+	Attempts     int64   `json:"attempts"`
+	Failures     int64   `json:"failures"`
+	FailureRatio float64 `json:"failureRatio"`
+}
+
+// ReservationMetrics counts reservation attempts and the ones failing for lack of stock, per product
+type ReservationMetrics struct {
+	mu       sync.Mutex
+	attempts map[string]int64
+	failures map[string]int64
+}
+
+func NewReservationMetrics() *ReservationMetrics {
+	return &ReservationMetrics{
+		attempts: make(map[string]int64),
+		failures: make(map[string]int64),
+	}
+}
+
+// observe counts a reservation of the product, and whether it failed for lack of stock.
+// It does nothing on a nil receiver, so the service runs without metrics.
+func (m *ReservationMetrics) observe(productID string, insufficient bool) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts[productID]++
+	if insufficient {
+		m.failures[productID]++
+	}
+}
+
+// Snapshot returns the current counters keyed by product ID
+func (m *ReservationMetrics) Snapshot() map[string]ReservationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make(map[string]ReservationStats, len(m.attempts))
+	for productID, attempts := range m.attempts {
+		stats[productID] = ReservationStats{
+			Attempts:     attempts,
+			Failures:     m.failures[productID],
+			FailureRatio: float64(m.failures[productID]) / float64(attempts),
+		}
+	}
+	return stats
+}
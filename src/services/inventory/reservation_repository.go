@@ -0,0 +1,192 @@
+package inventory
+
+import (
+	"context"
+	mongoinfra "go-order-eda/src/infrastructure/mongo"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Reservation statuses
+	ReservationActive    = "active"    // Stock is held for an order still in flight
+	ReservationReleased  = "released"  // Stock was returned, by a cancellation or the expiry sweeper
+	ReservationCompleted = "completed" // The order settled and keeps its stock
+)
+
+// Reservation is the ledger entry of the stock of one product held for an order. An order holds
+// one entry per product it reserved, so the ledger records exactly what a partially fulfilled order holds.
+type Reservation struct {
+	OrderID    string     `bson:"orderId" json:"orderId"`
+	ProductID  string     `bson:"productId" json:"productId"`
+	Quantity   int        `bson:"quantity" json:"quantity"`
+	Status     string     `bson:"status" json:"status"`
+	ReservedAt time.Time  `bson:"reservedAt" json:"reservedAt"`
+	ReleasedAt *time.Time `bson:"releasedAt,omitempty" json:"releasedAt,omitempty"`
+}
+
+type ReservationRepository interface {
+	Claim(ctx context.Context, reservation Reservation) (bool, error)
+	Unclaim(ctx context.Context, orderID, productID string) error
+	GetByOrderID(ctx context.Context, orderID string) ([]Reservation, error)
+	FindExpired(ctx context.Context, reservedBefore time.Time, limit int64) ([]Reservation, error)
+	MarkReleased(ctx context.Context, orderID, productID string) (bool, error)
+	RevertReleased(ctx context.Context, orderID, productID string) error
+	MarkCompleted(ctx context.Context, orderID string) error
+	MarkProductReleased(ctx context.Context, productID string) (int64, error)
+	// EnsureIndexes creates the unique index on orderId and productId that Claim relies on to detect concurrent claims
+	EnsureIndexes(ctx context.Context) error
+}
+
+type reservationRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration // Upper bound for each database operation
+}
+
+func NewReservationRepository(db *mongo.Database, timeout time.Duration) ReservationRepository {
+	return &reservationRepository{
+		collection: db.Collection("reservations"),
+		timeout:    timeout,
+	}
+}
+
+// Claim stores the reservation of a product for an order before its stock is reserved, replacing an
+// earlier released or completed one for the same order and product. It reports false when the order
+// already holds an active reservation of the product, so of two concurrent deliveries of the same
+// order only one goes on to reserve stock.
+func (r *reservationRepository) Claim(ctx context.Context, reservation Reservation) (bool, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	opts := options.Replace().SetUpsert(true)
+	filter := bson.M{"orderId": reservation.OrderID, "productId": reservation.ProductID, "status": bson.M{"$ne": ReservationActive}}
+	_, err := r.collection.ReplaceOne(ctx, filter, reservation, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		// The insert collided with the active reservation on the unique index
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Unclaim removes the active reservation of a product for an order, for a claim whose stock could not be reserved
+func (r *reservationRepository) Unclaim(ctx context.Context, orderID, productID string) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"orderId": orderID, "productId": productID, "status": ReservationActive})
+	return err
+}
+
+// GetByOrderID returns the reservations recorded for an order, oldest first; none when the order reserved nothing
+func (r *reservationRepository) GetByOrderID(ctx context.Context, orderID string) ([]Reservation, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{bson.E{Key: "reservedAt", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"orderId": orderID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var reservations []Reservation
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// FindExpired returns active reservations made before reservedBefore, oldest first
+func (r *reservationRepository) FindExpired(ctx context.Context, reservedBefore time.Time, limit int64) ([]Reservation, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{"status": ReservationActive, "reservedAt": bson.M{"$lt": reservedBefore}}
+	opts := options.Find().SetLimit(limit).SetSort(bson.D{bson.E{Key: "reservedAt", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []Reservation
+	for cursor.Next(ctx) {
+		var reservation Reservation
+		if err := cursor.Decode(&reservation); err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations, nil
+}
+
+// MarkReleased moves the active reservation of a product for an order to released. It reports false when
+// the reservation was not active, so only one of a cancellation and the sweeper returns the stock.
+func (r *reservationRepository) MarkReleased(ctx context.Context, orderID, productID string) (bool, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	filter := bson.M{"orderId": orderID, "productId": productID, "status": ReservationActive}
+	update := bson.M{"$set": bson.M{"status": ReservationReleased, "releasedAt": now}}
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// RevertReleased moves a reservation MarkReleased claimed back to active, for a release whose stock
+// could not be returned, so a retry or the sweeper releases it again
+func (r *reservationRepository) RevertReleased(ctx context.Context, orderID, productID string) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{"orderId": orderID, "productId": productID, "status": ReservationReleased}
+	update := bson.M{"$set": bson.M{"status": ReservationActive}, "$unset": bson.M{"releasedAt": ""}}
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// MarkCompleted moves the active reservations of an order to completed so the sweeper no longer considers them
+func (r *reservationRepository) MarkCompleted(ctx context.Context, orderID string) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{"orderId": orderID, "status": ReservationActive}
+	update := bson.M{"$set": bson.M{"status": ReservationCompleted}}
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// MarkProductReleased moves every active reservation of a product to released without returning
+// their stock, for a product whose reserved stock was cleared. It returns how many were released.
+func (r *reservationRepository) MarkProductReleased(ctx context.Context, productID string) (int64, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	filter := bson.M{"productId": productID, "status": ReservationActive}
+	update := bson.M{"$set": bson.M{"status": ReservationReleased, "releasedAt": now}}
+	res, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// EnsureIndexes creates the unique index on orderId and productId, which also serves the reads by order
+func (r *reservationRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "orderId", Value: 1}, {Key: "productId", Value: 1}},
+		Options: options.Index().SetUnique(true), // One ledger entry per order and product
+	})
+	return err
+}
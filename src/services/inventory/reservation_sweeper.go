@@ -0,0 +1,127 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/events"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// OrderStore is the part of the order repository the reservation sweeper needs.
+// GetOrderStatus returns ErrOrderNotFound for an unknown order.
+type OrderStore interface {
+	GetOrderStatus(ctx context.Context, id string) (string, error)
+	UpdateOrder(ctx context.Context, id string, update bson.M) error
+}
+
+// ReservationSweeper releases reservations held for longer than the TTL by orders that never
+// reached a terminal state, e.g. because a downstream step was lost, and marks those orders as failed.
+type ReservationSweeper struct {
+	reservations     ReservationRepository
+	inventoryService InventoryService
+	orders           OrderStore
+	logger           log.Logger
+	ttl              time.Duration
+	interval         time.Duration
+	batchSize        int64
+	lastRun          atomic.Int64 // Unix nanoseconds of the last successful sweep
+}
+
+func NewReservationSweeper(reservations ReservationRepository, inventoryService InventoryService, orders OrderStore, logger log.Logger, ttl, interval time.Duration, batchSize int64) *ReservationSweeper {
+	return &ReservationSweeper{
+		reservations:     reservations,
+		inventoryService: inventoryService,
+		orders:           orders,
+		logger:           logger,
+		ttl:              ttl,
+		interval:         interval,
+		batchSize:        batchSize,
+	}
+}
+
+// Run sweeps expired reservations every interval until the context is cancelled
+func (s *ReservationSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.logger.Info(ctx, "Reservation sweeper started")
+	for {
+		if _, err := s.Sweep(ctx); err != nil {
+			s.logger.Exception(ctx, "Reservation sweep failed", err)
+		} else {
+			s.lastRun.Store(time.Now().UTC().UnixNano())
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info(ctx, "Reservation sweeper stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// LastRun returns when the sweeper last completed a sweep, or the zero time if it has not yet
+func (s *ReservationSweeper) LastRun() time.Time {
+	if nanos := s.lastRun.Load(); nanos != 0 {
+		return time.Unix(0, nanos).UTC()
+	}
+	return time.Time{}
+}
+
+// Sweep handles one batch of expired reservations and returns how many were released.
+// Reservations of completed orders are kept and marked completed instead.
+func (s *ReservationSweeper) Sweep(ctx context.Context) (int, error) {
+	expired, err := s.reservations.FindExpired(ctx, time.Now().UTC().Add(-s.ttl), s.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch expired reservations: %w", err)
+	}
+
+	released := 0
+	for _, reservation := range expired {
+		ok, err := s.expire(ctx, reservation)
+		if err != nil {
+			s.logger.Warn(ctx, fmt.Sprintf("Failed to expire reservation for order %s: %v", reservation.OrderID, err))
+			continue
+		}
+		if ok {
+			released++
+		}
+	}
+
+	if released > 0 {
+		s.logger.Info(ctx, fmt.Sprintf("Reservation sweeper released %d of %d expired reservations", released, len(expired)))
+	}
+	return released, nil
+}
+
+// expire settles one expired reservation and reports whether its stock was released
+func (s *ReservationSweeper) expire(ctx context.Context, reservation Reservation) (bool, error) {
+	status, err := s.orders.GetOrderStatus(ctx, reservation.OrderID)
+	if err != nil && !errors.Is(err, ErrOrderNotFound) {
+		return false, err
+	}
+
+	if status == events.OrderStatusCompleted {
+		return false, s.inventoryService.CompleteOrderReservation(ctx, reservation.OrderID)
+	}
+
+	if _, err := s.inventoryService.ReleaseOrderReservation(ctx, reservation.OrderID, reservation.ProductID, reservation.Quantity); err != nil {
+		return false, err
+	}
+
+	// Cancelled and failed orders only needed their stock back; an order that is gone has nothing to update
+	if status != "" && !events.IsTerminalOrderStatus(status) {
+		if err := s.orders.UpdateOrder(ctx, reservation.OrderID, bson.M{"status": events.OrderStatusFailed}); err != nil {
+			return true, fmt.Errorf("stock released but order status not updated: %w", err)
+		}
+		s.logger.Info(ctx, fmt.Sprintf("Reservation for order %s expired after %s, order marked %s",
+			reservation.OrderID, s.ttl, events.OrderStatusFailed))
+	}
+	return true, nil
+}
@@ -0,0 +1,433 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/events"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// fakeReservationRepository is an in-memory reservations ledger, keyed by order and product
+type fakeReservationRepository struct {
+	mu           sync.Mutex
+	reservations map[[2]string]*Reservation
+}
+
+func newFakeReservationRepository() *fakeReservationRepository {
+	return &fakeReservationRepository{reservations: make(map[[2]string]*Reservation)}
+}
+
+// Record stores a reservation as it is, for tests seeding the ledger
+func (r *fakeReservationRepository) Record(ctx context.Context, reservation Reservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reservations[[2]string{reservation.OrderID, reservation.ProductID}] = &reservation
+	return nil
+}
+
+func (r *fakeReservationRepository) Claim(ctx context.Context, reservation Reservation) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]string{reservation.OrderID, reservation.ProductID}
+	if existing, ok := r.reservations[key]; ok && existing.Status == ReservationActive {
+		return false, nil
+	}
+	r.reservations[key] = &reservation
+	return true, nil
+}
+
+func (r *fakeReservationRepository) Unclaim(ctx context.Context, orderID, productID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]string{orderID, productID}
+	if existing, ok := r.reservations[key]; ok && existing.Status == ReservationActive {
+		delete(r.reservations, key)
+	}
+	return nil
+}
+
+func (r *fakeReservationRepository) GetByOrderID(ctx context.Context, orderID string) ([]Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var reservations []Reservation
+	for _, reservation := range r.reservations {
+		if reservation.OrderID == orderID {
+			reservations = append(reservations, *reservation)
+		}
+	}
+	sort.Slice(reservations, func(i, j int) bool { return reservations[i].ProductID < reservations[j].ProductID })
+	return reservations, nil
+}
+
+func (r *fakeReservationRepository) FindExpired(ctx context.Context, reservedBefore time.Time, limit int64) ([]Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var expired []Reservation
+	for _, reservation := range r.reservations {
+		if reservation.Status == ReservationActive && reservation.ReservedAt.Before(reservedBefore) {
+			expired = append(expired, *reservation)
+		}
+	}
+	return expired, nil
+}
+
+func (r *fakeReservationRepository) MarkReleased(ctx context.Context, orderID, productID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservation, ok := r.reservations[[2]string{orderID, productID}]
+	if !ok || reservation.Status != ReservationActive {
+		return false, nil
+	}
+	now := time.Now().UTC()
+	reservation.Status = ReservationReleased
+	reservation.ReleasedAt = &now
+	return true, nil
+}
+
+func (r *fakeReservationRepository) RevertReleased(ctx context.Context, orderID, productID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if reservation, ok := r.reservations[[2]string{orderID, productID}]; ok && reservation.Status == ReservationReleased {
+		reservation.Status = ReservationActive
+		reservation.ReleasedAt = nil
+	}
+	return nil
+}
+
+func (r *fakeReservationRepository) MarkCompleted(ctx context.Context, orderID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, reservation := range r.reservations {
+		if reservation.OrderID == orderID && reservation.Status == ReservationActive {
+			reservation.Status = ReservationCompleted
+		}
+	}
+	return nil
+}
+
+func (r *fakeReservationRepository) MarkProductReleased(ctx context.Context, productID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var released int64
+	now := time.Now().UTC()
+	for _, reservation := range r.reservations {
+		if reservation.ProductID == productID && reservation.Status == ReservationActive {
+			reservation.Status = ReservationReleased
+			reservation.ReleasedAt = &now
+			released++
+		}
+	}
+	return released, nil
+}
+
+func (r *fakeReservationRepository) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+func (r *fakeReservationRepository) status(orderID, productID string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if reservation, ok := r.reservations[[2]string{orderID, productID}]; ok {
+		return reservation.Status
+	}
+	return ""
+}
+
+// fakeOrderStore keeps order statuses in memory
+type fakeOrderStore struct {
+	mu       sync.Mutex
+	statuses map[string]string
+}
+
+func (s *fakeOrderStore) GetOrderStatus(ctx context.Context, id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.statuses[id]
+	if !ok {
+		return "", ErrOrderNotFound
+	}
+	return status, nil
+}
+
+func (s *fakeOrderStore) UpdateOrder(ctx context.Context, id string, update bson.M) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status, ok := update["status"].(string); ok {
+		s.statuses[id] = status
+	}
+	return nil
+}
+
+func (s *fakeOrderStore) status(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statuses[id]
+}
+
+func TestReservationSweeper_Sweep(t *testing.T) {
+	ctx := context.Background()
+	const ttl = 50 * time.Millisecond
+
+	products := newFakeProductRepository(Product{ID: "product-1", Quantity: 20})
+	reservations := newFakeReservationRepository()
+	service := NewInventoryService(log.NewLogger(), products, reservations, &fakePublisher{}, 0, nil)
+	orders := &fakeOrderStore{statuses: map[string]string{
+		"order-stale":     "Confirmed",
+		"order-completed": events.OrderStatusCompleted,
+		"order-fresh":     "Confirmed",
+	}}
+	sweeper := NewReservationSweeper(reservations, service, orders, log.NewLogger(), ttl, time.Minute, 100)
+
+	for _, orderID := range []string{"order-stale", "order-completed"} {
+		if product, err := service.ReserveProductForOrder(ctx, orderID, "product-1", 3); err != nil || product == nil {
+			t.Fatalf("Reservation failed: product=%v, err=%v", product, err)
+		}
+	}
+	time.Sleep(2 * ttl)
+	if product, err := service.ReserveProductForOrder(ctx, "order-fresh", "product-1", 4); err != nil || product == nil {
+		t.Fatalf("Reservation failed: product=%v, err=%v", product, err)
+	}
+
+	released, err := sweeper.Sweep(ctx)
+	if err != nil {
+		t.Fatalf("Sweep failed: %v", err)
+	}
+	if released != 1 {
+		t.Errorf("Expected 1 released reservation, got %d", released)
+	}
+
+	t.Run("stale reservation is released and the order failed", func(t *testing.T) {
+		if status := reservations.status("order-stale", "product-1"); status != ReservationReleased {
+			t.Errorf("Expected reservation %s, got %s", ReservationReleased, status)
+		}
+		if status := orders.status("order-stale"); status != events.OrderStatusFailed {
+			t.Errorf("Expected order %s, got %s", events.OrderStatusFailed, status)
+		}
+	})
+
+	t.Run("completed order keeps its stock", func(t *testing.T) {
+		if status := reservations.status("order-completed", "product-1"); status != ReservationCompleted {
+			t.Errorf("Expected reservation %s, got %s", ReservationCompleted, status)
+		}
+		if status := orders.status("order-completed"); status != events.OrderStatusCompleted {
+			t.Errorf("Expected order to stay %s, got %s", events.OrderStatusCompleted, status)
+		}
+	})
+
+	t.Run("fresh reservation is kept", func(t *testing.T) {
+		if status := reservations.status("order-fresh", "product-1"); status != ReservationActive {
+			t.Errorf("Expected reservation %s, got %s", ReservationActive, status)
+		}
+		if status := orders.status("order-fresh"); status != "Confirmed" {
+			t.Errorf("Expected order to stay Confirmed, got %s", status)
+		}
+	})
+
+	product, _ := products.GetProductById(ctx, "product-1")
+	if product.Quantity != 13 || product.Reserved != 7 {
+		t.Errorf("Expected quantity 13 and reserved 7, got %d and %d", product.Quantity, product.Reserved)
+	}
+
+	t.Log("✅ Stale reservations released, fresh ones kept")
+}
+
+func TestInventoryService_ReleaseOrderReservation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("release after expiry does not return stock twice", func(t *testing.T) {
+		products := newFakeProductRepository(Product{ID: "product-1", Quantity: 10})
+		service := NewInventoryService(log.NewLogger(), products, newFakeReservationRepository(), &fakePublisher{}, 0, nil)
+
+		if product, err := service.ReserveProductForOrder(ctx, "order-1", "product-1", 4); err != nil || product == nil {
+			t.Fatalf("Reservation failed: product=%v, err=%v", product, err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := service.ReleaseOrderReservation(ctx, "order-1", "product-1", 4); err != nil {
+				t.Fatalf("Release failed: %v", err)
+			}
+		}
+
+		product, _ := products.GetProductById(ctx, "product-1")
+		if product.Quantity != 10 || product.Reserved != 0 {
+			t.Errorf("Expected quantity 10 and reserved 0, got %d and %d", product.Quantity, product.Reserved)
+		}
+	})
+
+	t.Run("order without a ledger entry is released directly", func(t *testing.T) {
+		products := newFakeProductRepository(Product{ID: "product-1", Quantity: 6, Reserved: 4})
+		service := NewInventoryService(log.NewLogger(), products, newFakeReservationRepository(), &fakePublisher{}, 0, nil)
+
+		if _, err := service.ReleaseOrderReservation(ctx, "legacy-order", "product-1", 4); err != nil {
+			t.Fatalf("Release failed: %v", err)
+		}
+
+		product, _ := products.GetProductById(ctx, "product-1")
+		if product.Quantity != 10 || product.Reserved != 0 {
+			t.Errorf("Expected quantity 10 and reserved 0, got %d and %d", product.Quantity, product.Reserved)
+		}
+	})
+}
+
+func TestInventoryService_ReleaseOrderReservations(t *testing.T) {
+	ctx := context.Background()
+	products := newFakeProductRepository(
+		Product{ID: "product-1", Quantity: 10},
+		Product{ID: "product-2", Quantity: 10},
+		Product{ID: "product-3", Quantity: 1},
+	)
+	reservations := newFakeReservationRepository()
+	service := NewInventoryService(log.NewLogger(), products, reservations, &fakePublisher{}, 0, nil)
+
+	// The order is partially fulfilled: product-3 is out of stock, so only the first two are reserved
+	for _, item := range []struct {
+		productID string
+		quantity  int
+	}{{"product-1", 2}, {"product-2", 5}} {
+		if product, err := service.ReserveProductForOrder(ctx, "order-1", item.productID, item.quantity); err != nil || product == nil {
+			t.Fatalf("Reservation of %s failed: product=%v, err=%v", item.productID, product, err)
+		}
+	}
+	if product, err := service.ReserveProductForOrder(ctx, "order-1", "product-3", 4); err != nil || product != nil {
+		t.Fatalf("Expected the reservation of product-3 to fail for lack of stock, got product=%v, err=%v", product, err)
+	}
+
+	t.Run("only the reserved products are released", func(t *testing.T) {
+		released, err := service.ReleaseOrderReservations(ctx, "order-1", nil)
+		if err != nil {
+			t.Fatalf("Release failed: %v", err)
+		}
+		if len(released) != 2 || released[0].ProductID != "product-1" || released[1].ProductID != "product-2" {
+			t.Errorf("Expected product-1 and product-2 released, got %+v", released)
+		}
+		for _, productID := range []string{"product-1", "product-2"} {
+			product, _ := products.GetProductById(ctx, productID)
+			if product.Quantity != 10 || product.Reserved != 0 {
+				t.Errorf("Expected %s back at quantity 10 and reserved 0, got %d and %d", productID, product.Quantity, product.Reserved)
+			}
+			if status := reservations.status("order-1", productID); status != ReservationReleased {
+				t.Errorf("Expected reservation of %s %s, got %s", productID, ReservationReleased, status)
+			}
+		}
+		product, _ := products.GetProductById(ctx, "product-3")
+		if product.Quantity != 1 || product.Reserved != 0 {
+			t.Errorf("Expected product-3 untouched, got quantity %d and reserved %d", product.Quantity, product.Reserved)
+		}
+	})
+
+	t.Run("repeated release is a no-op", func(t *testing.T) {
+		released, err := service.ReleaseOrderReservations(ctx, "order-1", nil)
+		if err != nil || len(released) != 0 {
+			t.Errorf("Expected nothing released, got %+v, %v", released, err)
+		}
+		product, _ := products.GetProductById(ctx, "product-2")
+		if product.Quantity != 10 || product.Reserved != 0 {
+			t.Errorf("Expected product-2 not released twice, got quantity %d and reserved %d", product.Quantity, product.Reserved)
+		}
+	})
+
+	t.Run("order without reservations releases nothing", func(t *testing.T) {
+		released, err := service.ReleaseOrderReservations(ctx, "order-unreserved", nil)
+		if err != nil || len(released) != 0 {
+			t.Errorf("Expected nothing released, got %+v, %v", released, err)
+		}
+	})
+
+	t.Run("order reserved before the ledger releases its product once", func(t *testing.T) {
+		products.SeedProduct(ctx, Product{ID: "product-4", Quantity: 5, Reserved: 2})
+		legacy := &Reservation{ProductID: "product-4", Quantity: 2}
+		for i := 0; i < 2; i++ {
+			if _, err := service.ReleaseOrderReservations(ctx, "legacy-order", legacy); err != nil {
+				t.Fatalf("Release failed: %v", err)
+			}
+		}
+		product, _ := products.GetProductById(ctx, "product-4")
+		if product.Quantity != 7 || product.Reserved != 0 {
+			t.Errorf("Expected product-4 released once, got quantity %d and reserved %d", product.Quantity, product.Reserved)
+		}
+		if status := reservations.status("legacy-order", "product-4"); status != ReservationReleased {
+			t.Errorf("Expected the release recorded as %s, got %s", ReservationReleased, status)
+		}
+	})
+
+	t.Run("failed release is retried", func(t *testing.T) {
+		if product, err := service.ReserveProductForOrder(ctx, "order-retry", "product-1", 3); err != nil || product == nil {
+			t.Fatalf("Reservation failed: product=%v, err=%v", product, err)
+		}
+		products.releaseErr = errors.New("mongo unavailable")
+		if _, err := service.ReleaseOrderReservations(ctx, "order-retry", nil); err == nil {
+			t.Fatal("Expected the release to fail")
+		}
+		if status := reservations.status("order-retry", "product-1"); status != ReservationActive {
+			t.Errorf("Expected the reservation active again, got %s", status)
+		}
+
+		products.releaseErr = nil
+		released, err := service.ReleaseOrderReservations(ctx, "order-retry", nil)
+		if err != nil || len(released) != 1 {
+			t.Fatalf("Expected the retry to release the reservation, got %+v, %v", released, err)
+		}
+		product, _ := products.GetProductById(ctx, "product-1")
+		if product.Quantity != 10 || product.Reserved != 0 {
+			t.Errorf("Expected product-1 back at quantity 10 and reserved 0, got %d and %d", product.Quantity, product.Reserved)
+		}
+	})
+
+	t.Log("✅ Cancellation releases exactly the reserved products")
+}
+
+func TestInventoryService_ReserveProductForOrder(t *testing.T) {
+	ctx := context.Background()
+	products := newFakeProductRepository(Product{ID: "product-1", Quantity: 10})
+	reservations := newFakeReservationRepository()
+	service := NewInventoryService(log.NewLogger(), products, reservations, &fakePublisher{}, 0, nil)
+
+	if product, err := service.ReserveProductForOrder(ctx, "order-1", "product-1", 3); err != nil || product == nil {
+		t.Fatalf("Reservation failed: product=%v, err=%v", product, err)
+	}
+	if _, err := service.ReserveProductForOrder(ctx, "order-1", "product-1", 3); !errors.Is(err, ErrReservationExists) {
+		t.Errorf("Expected ErrReservationExists, got %v", err)
+	}
+	if _, err := service.ReleaseOrderReservation(ctx, "order-1", "product-2", 3); !errors.Is(err, ErrReservationMismatch) {
+		t.Errorf("Expected ErrReservationMismatch, got %v", err)
+	}
+
+	product, _ := products.GetProductById(ctx, "product-1")
+	if product.Quantity != 7 || product.Reserved != 3 {
+		t.Errorf("Expected quantity 7 and reserved 3, got %d and %d", product.Quantity, product.Reserved)
+	}
+
+	t.Run("concurrent deliveries reserve once", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := service.ReserveProductForOrder(ctx, "order-2", "product-1", 2); err != nil && !errors.Is(err, ErrReservationExists) {
+					t.Errorf("Reservation failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		product, _ := products.GetProductById(ctx, "product-1")
+		if product.Quantity != 5 || product.Reserved != 5 {
+			t.Errorf("Expected quantity 5 and reserved 5, got %d and %d", product.Quantity, product.Reserved)
+		}
+	})
+
+	t.Run("insufficient stock leaves no claim", func(t *testing.T) {
+		if product, err := service.ReserveProductForOrder(ctx, "order-3", "product-1", 50); err != nil || product != nil {
+			t.Fatalf("Expected the reservation to fail for lack of stock, got product=%v, err=%v", product, err)
+		}
+		if status := reservations.status("order-3", "product-1"); status != "" {
+			t.Errorf("Expected no ledger entry, got %s", status)
+		}
+	})
+}
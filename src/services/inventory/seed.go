@@ -0,0 +1,11 @@
+package inventory
+
+// SeedProducts are the sample products seeded on startup with the seeding feature, at the stock
+// ResetInventory restores them to. Seeding gives each product a new ID, so they are matched by name.
+var SeedProducts = []Product{
+	{Name: "Gaming Laptop", Quantity: 50},
+	{Name: "Wireless Mouse", Quantity: 100},
+	{Name: "Mechanical Keyboard", Quantity: 75},
+	{Name: "4K Monitor", Quantity: 30},
+	{Name: "USB-C Hub", Quantity: 80},
+}
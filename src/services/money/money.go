@@ -0,0 +1,176 @@
+// Package money represents order amounts exactly, as integer minor units of an ISO 4217 currency.
+// Amounts are kept in the minor unit of their currency, e.g. cents, so they add up and
+// round-trip exactly, which binary floating point cannot guarantee.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of requests that do not name one, and of orders and events
+// stored before amounts carried a currency
+const DefaultCurrency = "USD"
+
+var (
+	// ErrUnknownCurrency is returned for a code that is not a supported ISO 4217 currency
+	ErrUnknownCurrency = errors.New("unknown currency")
+	// ErrInvalidAmount is returned for an amount that is not a decimal number in the currency's
+	// precision, or that is not positive where a positive amount is required
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrCurrencyMismatch is returned when amounts in different currencies are combined
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// exponents holds the supported ISO 4217 currencies and the number of digits of their minor unit
+var exponents = map[string]int{
+	"AUD": 2,
+	"BHD": 3,
+	"CAD": 2,
+	"CHF": 2,
+	"CNY": 2,
+	"DKK": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"HKD": 2,
+	"INR": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"NOK": 2,
+	"NZD": 2,
+	"PLN": 2,
+	"SEK": 2,
+	"SGD": 2,
+	"TRY": 2,
+	"USD": 2,
+}
+
+// IsKnownCurrency reports whether code is a supported ISO 4217 currency code
+func IsKnownCurrency(code string) bool {
+	_, ok := exponents[code]
+	return ok
+}
+
+// Exponent returns the number of digits of a currency's minor unit, e.g. 2 for USD cents
+func Exponent(currency string) (int, error) {
+	exp, ok := exponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return exp, nil
+}
+
+// ParseMinor converts a decimal amount such as "19.99" into minor units of the currency.
+// The conversion is exact: amounts with more fractional digits than the currency has are
+// rejected rather than rounded.
+func ParseMinor(amount, currency string) (int64, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return 0, err
+	}
+
+	sign := ""
+	if strings.HasPrefix(amount, "-") {
+		sign, amount = "-", amount[1:]
+	}
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if whole == "" || strings.ContainsAny(whole+fraction, "+-eE") || len(fraction) > exp {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, sign+amount)
+	}
+	fraction += strings.Repeat("0", exp-len(fraction))
+
+	minor, err := strconv.ParseInt(sign+whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, sign+amount)
+	}
+	return minor, nil
+}
+
+// FromMajor converts a floating point amount into minor units of the currency, rounding to the
+// nearest minor unit. It exists for amounts stored as floats before minor units were introduced.
+func FromMajor(amount float64, currency string) (int64, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return 0, err
+	}
+	return int64(math.Round(amount * math.Pow10(exp))), nil
+}
+
+// FormatMinor renders minor units of the currency as a decimal amount, e.g. 1999 USD as "19.99"
+func FormatMinor(minor int64, currency string) (string, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return "", err
+	}
+	digits := strconv.FormatInt(minor, 10)
+	sign := ""
+	if minor < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if exp == 0 {
+		return sign + digits, nil
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:], nil
+}
+
+// Money is an amount in the minor unit of its currency. Embedded in a struct it marshals to
+// "amount" and "currency" fields, in JSON and, tagged inline, in BSON. Integer minor units
+// add and multiply exactly, so totals reconcile to the cent.
+type Money struct {
+	Amount   int64  `json:"amount" bson:"amount"`     // In the minor unit of Currency, e.g. cents
+	Currency string `json:"currency" bson:"currency"` // ISO 4217 code
+}
+
+// New returns minor units of a currency as Money
+func New(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: currency}
+}
+
+// Parse converts a decimal amount such as "19.99" into Money, exactly as ParseMinor does
+func Parse(amount, currency string) (Money, error) {
+	minor, err := ParseMinor(amount, currency)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(minor, currency), nil
+}
+
+// Add returns the sum of two amounts in the same currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return New(m.Amount+other.Amount, m.Currency), nil
+}
+
+// Mul returns the amount multiplied by a quantity, e.g. a unit price by the number of units
+func (m Money) Mul(quantity int64) Money {
+	return New(m.Amount*quantity, m.Currency)
+}
+
+// Validate checks that the amount is positive and the currency is known
+func (m Money) Validate() error {
+	if !IsKnownCurrency(m.Currency) {
+		return fmt.Errorf("%w: %q", ErrUnknownCurrency, m.Currency)
+	}
+	if m.Amount <= 0 {
+		return fmt.Errorf("%w: must be greater than 0", ErrInvalidAmount)
+	}
+	return nil
+}
+
+// String renders the amount with its currency, e.g. "19.99 EUR"
+func (m Money) String() string {
+	amount, err := FormatMinor(m.Amount, m.Currency)
+	if err != nil {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency) // Minor units of an unknown currency
+	}
+	return amount + " " + m.Currency
+}
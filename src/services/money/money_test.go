@@ -0,0 +1,204 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestParseMinor(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     int64
+		wantErr  error
+	}{
+		{amount: "19.99", currency: "USD", want: 1999},
+		{amount: "19.9", currency: "EUR", want: 1990},
+		{amount: "100", currency: "USD", want: 10000},
+		{amount: "0.01", currency: "GBP", want: 1},
+		{amount: "-5.50", currency: "USD", want: -550},
+		{amount: "1500", currency: "JPY", want: 1500},
+		{amount: "1.234", currency: "KWD", want: 1234},
+		{amount: "19.999", currency: "USD", wantErr: ErrInvalidAmount},
+		{amount: "1.5", currency: "JPY", wantErr: ErrInvalidAmount},
+		{amount: "1e3", currency: "USD", wantErr: ErrInvalidAmount},
+		{amount: ".5", currency: "USD", wantErr: ErrInvalidAmount},
+		{amount: "abc", currency: "USD", wantErr: ErrInvalidAmount},
+		{amount: "10", currency: "XYZ", wantErr: ErrUnknownCurrency},
+		{amount: "10", currency: "usd", wantErr: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount+" "+tt.currency, func(t *testing.T) {
+			got, err := ParseMinor(tt.amount, tt.currency)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %d, %v", tt.wantErr, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Expected %d, got %d, %v", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestFormatMinor_RoundTrips(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+	}{
+		{"19.99", "USD"},
+		{"0.05", "EUR"},
+		{"-3.10", "GBP"},
+		{"1500", "JPY"},
+		{"0.001", "BHD"},
+	}
+
+	for _, tt := range tests {
+		minor, err := ParseMinor(tt.amount, tt.currency)
+		if err != nil {
+			t.Fatalf("ParseMinor(%s) failed: %v", tt.amount, err)
+		}
+		formatted, err := FormatMinor(minor, tt.currency)
+		if err != nil || formatted != tt.amount {
+			t.Errorf("Expected %s %s to round-trip, got %q, %v", tt.amount, tt.currency, formatted, err)
+		}
+	}
+
+	t.Log("✅ Decimal amounts round-trip exactly through minor units")
+}
+
+func TestFromMajor(t *testing.T) {
+	// 19.99 is not representable in binary floating point; truncating 19.99*100 would give 1998
+	if minor, err := FromMajor(19.99, "USD"); err != nil || minor != 1999 {
+		t.Errorf("Expected 1999, got %d, %v", minor, err)
+	}
+	if minor, err := FromMajor(0.1+0.2, "EUR"); err != nil || minor != 30 {
+		t.Errorf("Expected 30, got %d, %v", minor, err)
+	}
+	if _, err := FromMajor(1, "XYZ"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("Expected ErrUnknownCurrency, got %v", err)
+	}
+}
+
+func TestIsKnownCurrency(t *testing.T) {
+	for _, code := range []string{"USD", "EUR", "JPY", DefaultCurrency} {
+		if !IsKnownCurrency(code) {
+			t.Errorf("Expected %s to be known", code)
+		}
+	}
+	for _, code := range []string{"", "usd", "US", "XXX"} {
+		if IsKnownCurrency(code) {
+			t.Errorf("Expected %q to be unknown", code)
+		}
+	}
+}
+
+func TestMoney_ExactArithmetic(t *testing.T) {
+	t.Run("0.1 + 0.2 is 0.3", func(t *testing.T) {
+		if floatOf(t, "0.1")+floatOf(t, "0.2") == floatOf(t, "0.3") {
+			t.Fatal("Expected float64 addition to be inexact")
+		}
+		sum, err := mustParse(t, "0.10", "USD").Add(mustParse(t, "0.20", "USD"))
+		if err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if sum != mustParse(t, "0.30", "USD") {
+			t.Errorf("Expected 0.30 USD, got %s", sum)
+		}
+	})
+
+	t.Run("summing many orders reconciles", func(t *testing.T) {
+		var floatTotal float64
+		total := New(0, "EUR")
+		for i := 0; i < 1000; i++ {
+			floatTotal += floatOf(t, "19.99")
+			var err error
+			if total, err = total.Add(mustParse(t, "19.99", "EUR")); err != nil {
+				t.Fatalf("Add failed: %v", err)
+			}
+		}
+		if floatTotal == floatOf(t, "19990") {
+			t.Fatal("Expected the float64 total to drift")
+		}
+		if total.String() != "19990.00 EUR" {
+			t.Errorf("Expected 19990.00 EUR, got %s", total)
+		}
+	})
+
+	t.Run("price times quantity", func(t *testing.T) {
+		if floatOf(t, "1.15")*100 == floatOf(t, "115") {
+			t.Fatal("Expected float64 multiplication to be inexact")
+		}
+		if total := mustParse(t, "1.15", "GBP").Mul(100); total.String() != "115.00 GBP" {
+			t.Errorf("Expected 115.00 GBP, got %s", total)
+		}
+	})
+
+	t.Run("different currencies do not add", func(t *testing.T) {
+		if _, err := New(100, "USD").Add(New(100, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+			t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+		}
+	})
+
+	t.Log("✅ Money arithmetic is exact where float64 is not")
+}
+
+func TestMoney_JSON(t *testing.T) {
+	body, err := json.Marshal(mustParse(t, "19.99", "USD"))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(body) != `{"amount":1999,"currency":"USD"}` {
+		t.Errorf("Unexpected JSON: %s", body)
+	}
+
+	var decoded Money
+	if err := json.Unmarshal(body, &decoded); err != nil || decoded != New(1999, "USD") {
+		t.Errorf("Expected 1999 USD, got %v, %v", decoded, err)
+	}
+}
+
+func TestMoney_Validate(t *testing.T) {
+	tests := []struct {
+		money   Money
+		wantErr error
+	}{
+		{money: New(1999, "USD")},
+		{money: New(0, "USD"), wantErr: ErrInvalidAmount},
+		{money: New(-1, "EUR"), wantErr: ErrInvalidAmount},
+		{money: New(1999, ""), wantErr: ErrUnknownCurrency},
+		{money: New(1999, "XYZ"), wantErr: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		err := tt.money.Validate()
+		if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Errorf("Validate(%v): expected %v, got %v", tt.money, tt.wantErr, err)
+		}
+	}
+}
+
+// mustParse parses a decimal amount or fails the test
+func mustParse(t *testing.T, amount, currency string) Money {
+	t.Helper()
+	m, err := Parse(amount, currency)
+	if err != nil {
+		t.Fatalf("Parse(%s, %s) failed: %v", amount, currency, err)
+	}
+	return m
+}
+
+// floatOf parses a decimal amount the way a float64 amount field would hold it
+func floatOf(t *testing.T, amount string) float64 {
+	t.Helper()
+	f, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		t.Fatalf("ParseFloat(%s) failed: %v", amount, err)
+	}
+	return f
+}
@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"fmt"
+	"go-order-eda/src/config"
+	"go-order-eda/src/services/events"
+	"slices"
+)
+
+const (
+	// Notification message types, reported as such in NotificationSentEvent
+	MessageTypeConfirmation = events.NotificationConfirmation
+	MessageTypeCancellation = events.NotificationCancellation
+)
+
+// ChannelPolicy maps a notification message type to the channels it is delivered through
+type ChannelPolicy map[string][]NotificationChannel
+
+// NewChannelPolicy builds the channel policy from configuration.
+// The SMS channel is left out unless the sms feature is enabled.
+func NewChannelPolicy(cfg *config.Config) ChannelPolicy {
+	policy := ChannelPolicy{
+		MessageTypeConfirmation: toChannels(cfg.ConfirmationChannels),
+		MessageTypeCancellation: toChannels(cfg.CancellationChannels),
+	}
+	if !cfg.Enabled(config.FeatureSMS) {
+		for messageType, channels := range policy {
+			policy[messageType] = slices.DeleteFunc(channels, func(channel NotificationChannel) bool { return channel == ChannelSMS })
+		}
+	}
+	return policy
+}
+
+// ChannelsFor returns the channels configured for a message type
+func (p ChannelPolicy) ChannelsFor(messageType string) []NotificationChannel {
+	return p[messageType]
+}
+
+// Validate ensures every message type has at least one channel and that each channel
+// has a registered sender, so misconfiguration is caught at startup
+func (p ChannelPolicy) Validate(service NotificationService) error {
+	for messageType, channels := range p {
+		if len(channels) == 0 {
+			return fmt.Errorf("no notification channels configured for %s", messageType)
+		}
+		for _, channel := range channels {
+			if !service.SupportsChannel(channel) {
+				return fmt.Errorf("%w: %q configured for %s", ErrUnknownChannel, channel, messageType)
+			}
+		}
+	}
+	return nil
+}
+
+func toChannels(names []string) []NotificationChannel {
+	channels := make([]NotificationChannel, 0, len(names))
+	for _, name := range names {
+		channels = append(channels, NotificationChannel(name))
+	}
+	return channels
+}
@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"errors"
+	"testing"
+
+	"go-order-eda/src/config"
+	"go-order-eda/src/infrastructure/log"
+)
+
+func TestChannelPolicy_Validate(t *testing.T) {
+	service := NewNotificationService(log.NewLogger(), newFakeNotificationRepository())
+
+	t.Run("configured built-in channels are valid", func(t *testing.T) {
+		policy := NewChannelPolicy(&config.Config{
+			ConfirmationChannels: []string{"email"},
+			CancellationChannels: []string{"email", "sms"},
+		})
+		if err := policy.Validate(service); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got := policy.ChannelsFor(MessageTypeConfirmation); len(got) != 1 || got[0] != ChannelEmail {
+			t.Errorf("Expected confirmation channels [email], got %v", got)
+		}
+	})
+
+	t.Run("SMS left out when its feature is disabled", func(t *testing.T) {
+		policy := NewChannelPolicy(&config.Config{
+			ConfirmationChannels: []string{"email"},
+			CancellationChannels: []string{"email", "sms"},
+			Features:             map[string]bool{config.FeatureSyncCreate: true},
+		})
+		if got := policy.ChannelsFor(MessageTypeCancellation); len(got) != 1 || got[0] != ChannelEmail {
+			t.Errorf("Expected cancellation channels [email], got %v", got)
+		}
+	})
+
+	t.Run("unknown channel fails validation", func(t *testing.T) {
+		policy := NewChannelPolicy(&config.Config{
+			ConfirmationChannels: []string{"email"},
+			CancellationChannels: []string{"pager"},
+		})
+		if err := policy.Validate(service); !errors.Is(err, ErrUnknownChannel) {
+			t.Fatalf("Expected ErrUnknownChannel, got %v", err)
+		}
+	})
+
+	t.Run("empty channel list fails validation", func(t *testing.T) {
+		policy := NewChannelPolicy(&config.Config{
+			ConfirmationChannels: []string{"email"},
+		})
+		if err := policy.Validate(service); err == nil {
+			t.Fatal("Expected error for message type without channels")
+		}
+	})
+}
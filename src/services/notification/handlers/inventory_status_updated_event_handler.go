@@ -3,134 +3,144 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"go-order-eda/src/infrastructure"
 	"go-order-eda/src/infrastructure/log"
 	rabbitmq "go-order-eda/src/infrastructure/rabbitmq"
 	"go-order-eda/src/services/events"
 	"go-order-eda/src/services/notification"
+	"slices"
 	"time"
 )
 
 type InventoryStatusUpdatedEventHandler struct {
-	rabbitMQService     *rabbitmq.RabbitMQServiceImpl
+	rabbitMQService     rabbitmq.Publisher
 	notificationService notification.NotificationService
+	channelPolicy       notification.ChannelPolicy
 	logger              log.Logger
 }
 
 func NewInventoryStatusUpdatedEventHandler(
-	rabbit *rabbitmq.RabbitMQServiceImpl,
+	rabbit rabbitmq.Publisher,
 	notificationService notification.NotificationService,
+	channelPolicy notification.ChannelPolicy,
 	logger log.Logger,
 ) *InventoryStatusUpdatedEventHandler {
 	return &InventoryStatusUpdatedEventHandler{
 		rabbitMQService:     rabbit,
 		notificationService: notificationService,
+		channelPolicy:       channelPolicy,
 		logger:              logger,
 	}
 }
 
-// Handle processes the InventoryStatusUpdatedEvent message
-func (h *InventoryStatusUpdatedEventHandler) Handle(ctx context.Context, msgBody []byte) {
+// Handle processes the InventoryStatusUpdatedEvent message. A failed notification is routed to
+// the notification retry queue rather than failing the message. Once an out-of-stock order's
+// OrderCancelled event is published the message always succeeds, as a redelivery would publish
+// it again; a notification that can then be neither sent nor retried is only logged, its failed
+// attempts staying recorded by the notification service.
+func (h *InventoryStatusUpdatedEventHandler) Handle(ctx context.Context, msgBody []byte) error {
 	var event events.InventoryStatusUpdatedEvent
 	if err := json.Unmarshal(msgBody, &event); err != nil {
 		h.logger.Exception(ctx, "Failed to unmarshal InventoryStatusUpdatedEvent", err)
-		h.sendToDLQ(msgBody)
-		return
+		return infrastructure.Permanent(err)
 	}
 
-	// Send notification based on inventory status
-	if event.HasStock {
-		h.logger.Info(ctx, "Sending order confirmation notification for product: "+event.ProductID)
-
-		// Send confirmation notification
-		notificationReq := notification.NotificationRequest{
-			OrderID:     event.OrderID,
-			ProductID:   event.ProductID,
-			Message:     "Your order has been confirmed! Product: " + event.ProductID,
-			Channel:     notification.ChannelEmail, // Default to email
-			Recipient:   "customer@example.com",    // TODO: Get actual customer email from order
-			MessageType: "confirmation",
-		}
-
-		// Send notification via multiple channels
-		err := h.notificationService.SendMultiChannelNotification(ctx, notificationReq,
-			[]notification.NotificationChannel{
-				notification.ChannelEmail,
-				notification.ChannelPush,
-			})
-		if err != nil {
-			h.logger.Exception(ctx, "Failed to send confirmation notification", err)
-		}
-	} else {
+	// Cancel the order first so that a notification failure never affects order or inventory state
+	if !event.HasStock {
 		h.logger.Info(ctx, "No stock available for product: "+event.ProductID+", cancelling order: "+event.OrderID)
 
-		// Send cancellation notification
-		notificationReq := notification.NotificationRequest{
-			OrderID:     event.OrderID,
-			ProductID:   event.ProductID,
-			Message:     "Your order has been cancelled due to insufficient stock. Product: " + event.ProductID,
-			Channel:     notification.ChannelEmail, // Default to email
-			Recipient:   "customer@example.com",    // TODO: Get actual customer email from order
-			MessageType: "cancellation",
-		}
-
-		// Send notification via multiple channels
-		err := h.notificationService.SendMultiChannelNotification(ctx, notificationReq,
-			[]notification.NotificationChannel{
-				notification.ChannelEmail,
-				notification.ChannelSMS, // SMS for urgent cancellations
-			})
-		if err != nil {
-			h.logger.Exception(ctx, "Failed to send cancellation notification", err)
-		}
-
 		// Fire OrderCancelled event when there's no stock
 		orderCancelledEvent := events.OrderCancelledEvent{
 			OrderID:   event.OrderID,
 			Status:    "Cancelled",
 			Version:   1,
-			TimeStamp: time.Now().Local(),
+			TimeStamp: time.Now().UTC(),
 		}
 
-		cancelledEventJSON, err := json.Marshal(orderCancelledEvent)
-		if err != nil {
-			h.logger.Exception(ctx, "Failed to marshal OrderCancelledEvent", err)
-			h.sendToDLQ(msgBody)
-			return
+		err := rabbitmq.PublishEvent(ctx, h.rabbitMQService, events.OrderCancelled, orderCancelledEvent)
+		if rabbitmq.IsUnpublishable(err) {
+			h.logger.Exception(ctx, "Failed to prepare OrderCancelledEvent", err)
+			return infrastructure.Permanent(err)
 		}
-
-		err = h.rabbitMQService.Publish(events.OrderCancelled, cancelledEventJSON)
 		if err != nil {
 			h.logger.Exception(ctx, "Failed to publish OrderCancelledEvent", err)
-			h.sendToDLQ(msgBody)
-			return
+			return infrastructure.Transient(err)
 		}
 
 		h.logger.Info(ctx, "OrderCancelled event published for order: "+event.OrderID)
 	}
 
-	// Publish NotificationSentEvent
-	notificationEvent := events.NotificationSentEvent{
-		OrderID:   event.OrderID, // ✅ Use actual OrderID from event chain
-		Message:   getNotificationMessage(event.HasStock, event.ProductID),
-		Version:   1,
-		TimeStamp: time.Now().Local(),
+	err := h.Notify(ctx, event)
+	if err == nil {
+		return nil
 	}
+	if rabbitmq.IsUnpublishable(err) {
+		// Retrying cannot make the NotificationSent event valid
+		h.logger.Exception(ctx, "NotificationSent event rejected for order: "+event.OrderID, err)
+		err = infrastructure.Permanent(err)
+	} else {
+		h.logger.Exception(ctx, "Notification failed for order: "+event.OrderID+", routing to notification retry", err)
+		err = h.sendToNotificationRetry(ctx, msgBody)
+	}
+	if err != nil && !event.HasStock {
+		h.logger.Warn(ctx, "Order cancelled without notifying the customer: "+event.OrderID)
+		return nil
+	}
+	return err
+}
 
-	notificationJSON, err := json.Marshal(notificationEvent)
-	if err != nil {
-		h.logger.Exception(ctx, "Failed to marshal NotificationSentEvent", err)
-		h.sendToDLQ(msgBody)
-		return
+// Notify sends the confirmation or cancellation notification and publishes NotificationSent
+// only when at least one channel delivered it. Already delivered channels are skipped by the
+// notification service, so Notify is safe to call again for the same event.
+func (h *InventoryStatusUpdatedEventHandler) Notify(ctx context.Context, event events.InventoryStatusUpdatedEvent) error {
+	notificationReq := notification.NotificationRequest{
+		OrderID:     event.OrderID,
+		ProductID:   event.ProductID,
+		Message:     "Your order has been confirmed! Product: " + event.ProductID,
+		Channel:     notification.ChannelEmail, // Default to email
+		Recipient:   "customer@example.com",    // TODO: Get actual customer email from order
+		MessageType: notification.MessageTypeConfirmation,
+	}
+	if event.HasStock {
+		h.logger.Info(ctx, "Sending order confirmation notification for product: "+event.ProductID)
+	} else {
+		h.logger.Info(ctx, "Sending order cancellation notification for product: "+event.ProductID)
+		notificationReq.Message = "Your order has been cancelled due to insufficient stock. Product: " + event.ProductID
+		notificationReq.MessageType = notification.MessageTypeCancellation
 	}
 
-	err = h.rabbitMQService.Publish(events.NotificationSent, notificationJSON)
+	// Send notification via the channels configured for the message type
+	channels := h.channelPolicy.ChannelsFor(notificationReq.MessageType)
+	delivered, err := h.notificationService.SendMultiChannelNotification(ctx, notificationReq, channels)
 	if err != nil {
-		h.logger.Exception(ctx, "Failed to publish NotificationSentEvent", err)
-		h.sendToDLQ(msgBody)
-		return
+		return fmt.Errorf("failed to send %s notification: %w", notificationReq.MessageType, err)
+	}
+
+	// Publish NotificationSentEvent with the outcome of every channel
+	notificationEvent := events.NotificationSentEvent{
+		OrderID:     event.OrderID, // ✅ Use actual OrderID from event chain
+		ProductID:   event.ProductID,
+		Message:     getNotificationMessage(event.HasStock, event.ProductID),
+		MessageType: notificationReq.MessageType,
+		Status:      make(map[string]string, len(channels)),
+		Version:     1,
+		TimeStamp:   time.Now().UTC(),
+	}
+	for _, channel := range channels {
+		notificationEvent.Channels = append(notificationEvent.Channels, string(channel))
+		notificationEvent.Status[string(channel)] = events.NotificationFailed
+		if slices.Contains(delivered, channel) {
+			notificationEvent.Status[string(channel)] = events.NotificationDelivered
+		}
+	}
+
+	if err := rabbitmq.PublishEvent(ctx, h.rabbitMQService, events.NotificationSent, notificationEvent); err != nil {
+		return fmt.Errorf("failed to publish NotificationSentEvent: %w", err)
 	}
 
 	h.logger.Info(ctx, "Notification sent and event published for order: "+event.OrderID+" product: "+event.ProductID)
+	return nil
 }
 
 func getNotificationMessage(hasStock bool, productID string) string {
@@ -140,10 +150,13 @@ func getNotificationMessage(hasStock bool, productID string) string {
 	return "Order cancelled due to insufficient stock for product: " + productID
 }
 
-func (h *InventoryStatusUpdatedEventHandler) sendToDLQ(body []byte) {
-	// Simply send to DLQ queue - another process will handle storing to MongoDB
-	err := h.rabbitMQService.Publish("inventory.status.updated.dlq", body)
+// sendToNotificationRetry routes an event whose notification failed to the notification retry queue,
+// which holds it for the retry delay of events.NotificationRetry first
+func (h *InventoryStatusUpdatedEventHandler) sendToNotificationRetry(ctx context.Context, body []byte) error {
+	err := h.rabbitMQService.Publish(ctx, events.NotificationRetry, body)
 	if err != nil {
-		h.logger.Exception(context.TODO(), "Failed to send event to DLQ", err)
+		h.logger.Exception(ctx, "Failed to send event to notification retry queue", err)
+		return infrastructure.Transient(err)
 	}
+	return nil
 }
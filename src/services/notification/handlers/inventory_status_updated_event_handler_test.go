@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go-order-eda/src/infrastructure"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/rabbitmq"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/notification"
+)
+
+// fakePublisher records published messages instead of sending them to RabbitMQ,
+// failing publishes to the topics in failing
+type fakePublisher struct {
+	mu       sync.Mutex
+	messages map[string][][]byte
+	failing  map[string]error
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, topic string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.failing[topic]; err != nil {
+		return err
+	}
+	if p.messages == nil {
+		p.messages = make(map[string][][]byte)
+	}
+	p.messages[topic] = append(p.messages[topic], body)
+	return nil
+}
+
+func (p *fakePublisher) published(topic string) [][]byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.messages[topic]
+}
+
+// fakeNotificationService records the channels each notification was dispatched to.
+// Channels listed in failing are reported as failed deliveries.
+type fakeNotificationService struct {
+	mu         sync.Mutex
+	dispatched map[string][]notification.NotificationChannel
+	failing    map[notification.NotificationChannel]bool
+}
+
+func (n *fakeNotificationService) SendNotification(ctx context.Context, request notification.NotificationRequest) error {
+	_, err := n.SendMultiChannelNotification(ctx, request, []notification.NotificationChannel{request.Channel})
+	return err
+}
+
+func (n *fakeNotificationService) SendMultiChannelNotification(ctx context.Context, request notification.NotificationRequest, channels []notification.NotificationChannel) ([]notification.NotificationChannel, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.dispatched == nil {
+		n.dispatched = make(map[string][]notification.NotificationChannel)
+	}
+	n.dispatched[request.MessageType] = append(n.dispatched[request.MessageType], channels...)
+
+	var delivered []notification.NotificationChannel
+	for _, channel := range channels {
+		if !n.failing[channel] {
+			delivered = append(delivered, channel)
+		}
+	}
+	if len(delivered) == 0 {
+		return nil, notification.ErrAllChannelsFailed
+	}
+	return delivered, nil
+}
+
+func (n *fakeNotificationService) RegisterChannel(channel notification.NotificationChannel, sender notification.ChannelSender) error {
+	return nil
+}
+
+func (n *fakeNotificationService) SupportsChannel(channel notification.NotificationChannel) bool {
+	return true
+}
+
+func (n *fakeNotificationService) GetNotificationsByOrderID(ctx context.Context, orderID string) ([]notification.NotificationRecord, error) {
+	return nil, nil
+}
+
+func TestInventoryStatusUpdatedEventHandler_ChannelPolicy(t *testing.T) {
+	policy := notification.ChannelPolicy{
+		notification.MessageTypeConfirmation: {notification.ChannelPush},
+		notification.MessageTypeCancellation: {notification.ChannelEmail, "webhook"},
+	}
+
+	tests := []struct {
+		name        string
+		hasStock    bool
+		messageType string
+		expected    []notification.NotificationChannel
+	}{
+		{
+			name:        "confirmation uses configured channels",
+			hasStock:    true,
+			messageType: notification.MessageTypeConfirmation,
+			expected:    []notification.NotificationChannel{notification.ChannelPush},
+		},
+		{
+			name:        "cancellation uses configured channels",
+			hasStock:    false,
+			messageType: notification.MessageTypeCancellation,
+			expected:    []notification.NotificationChannel{notification.ChannelEmail, "webhook"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakePublisher{}
+			notificationService := &fakeNotificationService{}
+			handler := NewInventoryStatusUpdatedEventHandler(publisher, notificationService, policy, log.NewLogger())
+
+			body, _ := json.Marshal(events.InventoryStatusUpdatedEvent{
+				OrderID:   "order-1",
+				ProductID: "product-1",
+				HasStock:  tt.hasStock,
+				Version:   1,
+				TimeStamp: time.Now().UTC(),
+			})
+			handler.Handle(context.Background(), body)
+
+			if got := notificationService.dispatched[tt.messageType]; !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected channels %v, got %v", tt.expected, got)
+			}
+			if len(notificationService.dispatched) != 1 {
+				t.Errorf("Expected a single message type to be dispatched, got %v", notificationService.dispatched)
+			}
+			if n := len(publisher.published(events.NotificationSent)); n != 1 {
+				t.Errorf("Expected 1 NotificationSent event, got %d", n)
+			}
+		})
+	}
+}
+
+func TestInventoryStatusUpdatedEventHandler_NotificationFailures(t *testing.T) {
+	policy := notification.ChannelPolicy{
+		notification.MessageTypeConfirmation: {notification.ChannelEmail, notification.ChannelPush},
+		notification.MessageTypeCancellation: {notification.ChannelEmail, notification.ChannelSMS},
+	}
+
+	newEvent := func(hasStock bool) []byte {
+		body, _ := json.Marshal(events.InventoryStatusUpdatedEvent{
+			OrderID:   "order-1",
+			ProductID: "product-1",
+			HasStock:  hasStock,
+			Version:   1,
+			TimeStamp: time.Now().UTC(),
+		})
+		return body
+	}
+
+	t.Run("all channels fail routes to retry without NotificationSent", func(t *testing.T) {
+		publisher := &fakePublisher{}
+		notificationService := &fakeNotificationService{failing: map[notification.NotificationChannel]bool{
+			notification.ChannelEmail: true,
+			notification.ChannelPush:  true,
+		}}
+		handler := NewInventoryStatusUpdatedEventHandler(publisher, notificationService, policy, log.NewLogger())
+
+		if err := handler.Handle(context.Background(), newEvent(true)); err != nil {
+			t.Errorf("Expected notification failure not to fail the event, got %v", err)
+		}
+
+		if n := len(publisher.published(events.NotificationSent)); n != 0 {
+			t.Errorf("Expected no NotificationSent event, got %d", n)
+		}
+		if n := len(publisher.published(events.NotificationRetry)); n != 1 {
+			t.Errorf("Expected 1 notification retry message, got %d", n)
+		}
+	})
+
+	t.Run("all channels fail still cancels an out-of-stock order", func(t *testing.T) {
+		publisher := &fakePublisher{}
+		notificationService := &fakeNotificationService{failing: map[notification.NotificationChannel]bool{
+			notification.ChannelEmail: true,
+			notification.ChannelSMS:   true,
+		}}
+		handler := NewInventoryStatusUpdatedEventHandler(publisher, notificationService, policy, log.NewLogger())
+
+		handler.Handle(context.Background(), newEvent(false))
+
+		if n := len(publisher.published(events.OrderCancelled)); n != 1 {
+			t.Errorf("Expected 1 OrderCancelled event, got %d", n)
+		}
+		if n := len(publisher.published(events.NotificationSent)); n != 0 {
+			t.Errorf("Expected no NotificationSent event, got %d", n)
+		}
+		if n := len(publisher.published(events.NotificationRetry)); n != 1 {
+			t.Errorf("Expected 1 notification retry message, got %d", n)
+		}
+	})
+
+	t.Run("failed retry routing keeps a published cancellation", func(t *testing.T) {
+		publisher := &fakePublisher{failing: map[string]error{events.NotificationRetry: errors.New("broker unavailable")}}
+		notificationService := &fakeNotificationService{failing: map[notification.NotificationChannel]bool{
+			notification.ChannelEmail: true,
+			notification.ChannelSMS:   true,
+		}}
+		handler := NewInventoryStatusUpdatedEventHandler(publisher, notificationService, policy, log.NewLogger())
+
+		if err := handler.Handle(context.Background(), newEvent(false)); err != nil {
+			t.Errorf("Expected the event acknowledged so OrderCancelled is not published again, got %v", err)
+		}
+		if n := len(publisher.published(events.OrderCancelled)); n != 1 {
+			t.Errorf("Expected 1 OrderCancelled event, got %d", n)
+		}
+	})
+
+	t.Run("failed retry routing of a confirmation is retried", func(t *testing.T) {
+		publisher := &fakePublisher{failing: map[string]error{events.NotificationRetry: errors.New("broker unavailable")}}
+		notificationService := &fakeNotificationService{failing: map[notification.NotificationChannel]bool{
+			notification.ChannelEmail: true,
+			notification.ChannelPush:  true,
+		}}
+		handler := NewInventoryStatusUpdatedEventHandler(publisher, notificationService, policy, log.NewLogger())
+
+		if err := handler.Handle(context.Background(), newEvent(true)); err == nil || !infrastructure.IsRetryable(err) {
+			t.Errorf("Expected a transient error, got %v", err)
+		}
+	})
+
+	t.Run("partial success publishes NotificationSent", func(t *testing.T) {
+		publisher := &fakePublisher{}
+		notificationService := &fakeNotificationService{failing: map[notification.NotificationChannel]bool{
+			notification.ChannelEmail: true,
+		}}
+		handler := NewInventoryStatusUpdatedEventHandler(publisher, notificationService, policy, log.NewLogger())
+
+		handler.Handle(context.Background(), newEvent(true))
+
+		sent := publisher.published(events.NotificationSent)
+		if len(sent) != 1 {
+			t.Fatalf("Expected 1 NotificationSent event, got %d", len(sent))
+		}
+		if n := len(publisher.published(events.NotificationRetry)); n != 0 {
+			t.Errorf("Expected no notification retry message, got %d", n)
+		}
+
+		var event events.NotificationSentEvent
+		if err := json.Unmarshal(sent[0], &event); err != nil {
+			t.Fatalf("Failed to decode NotificationSent: %v", err)
+		}
+		if event.ProductID != "product-1" || !reflect.DeepEqual(event.Channels, []string{"email", "push"}) {
+			t.Errorf("Expected product-1 sent through email and push, got %+v", event)
+		}
+		wantStatus := map[string]string{"email": events.NotificationFailed, "push": events.NotificationDelivered}
+		if !reflect.DeepEqual(event.Status, wantStatus) {
+			t.Errorf("Expected channel outcomes %v, got %v", wantStatus, event.Status)
+		}
+	})
+
+	t.Run("retry handler dead-letters after a second failure", func(t *testing.T) {
+		publisher := &fakePublisher{}
+		notificationService := &fakeNotificationService{failing: map[notification.NotificationChannel]bool{
+			notification.ChannelEmail: true,
+			notification.ChannelPush:  true,
+		}}
+		statusHandler := NewInventoryStatusUpdatedEventHandler(publisher, notificationService, policy, log.NewLogger())
+		retryHandler := NewNotificationRetryEventHandler(statusHandler, log.NewLogger())
+
+		err := retryHandler.Handle(context.Background(), newEvent(true))
+		if err == nil || infrastructure.IsRetryable(err) {
+			t.Errorf("Expected a permanent error so the event is dead-lettered, got %v", err)
+		}
+		if n := len(publisher.published(events.NotificationRetry)); n != 0 {
+			t.Errorf("Expected retry handler not to requeue itself, got %d", n)
+		}
+	})
+}
+
+func TestInventoryStatusUpdatedEventHandler_RejectsInvalidEventsAtTheSource(t *testing.T) {
+	policy := notification.ChannelPolicy{notification.MessageTypeConfirmation: {notification.ChannelEmail}}
+	// Without an order ID neither OrderCancelled nor NotificationSent passes validation
+	body, _ := json.Marshal(events.InventoryStatusUpdatedEvent{ProductID: "product-1", HasStock: true, Version: 1})
+
+	publisher := &fakePublisher{}
+	handler := NewInventoryStatusUpdatedEventHandler(publisher, &fakeNotificationService{}, policy, log.NewLogger())
+
+	err := handler.Handle(context.Background(), body)
+	if err == nil || infrastructure.IsRetryable(err) || !rabbitmq.IsUnpublishable(err) {
+		t.Fatalf("Expected a permanent error for an unpublishable event, got %v", err)
+	}
+	for _, topic := range []string{events.NotificationSent, events.NotificationRetry} {
+		if n := len(publisher.published(topic)); n != 0 {
+			t.Errorf("Expected nothing published to %s, got %d", topic, n)
+		}
+	}
+
+	t.Log("✅ Invalid NotificationSent event dead-lettered instead of published or retried")
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-order-eda/src/infrastructure"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/notification"
+)
+
+type LowStockEventHandler struct {
+	notificationService notification.NotificationService
+	logger              log.Logger
+}
+
+func NewLowStockEventHandler(
+	notificationService notification.NotificationService,
+	logger log.Logger,
+) *LowStockEventHandler {
+	return &LowStockEventHandler{
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+// Handle processes the LowStockEvent message and alerts the inventory team
+func (h *LowStockEventHandler) Handle(ctx context.Context, msgBody []byte) error {
+	var event events.LowStockEvent
+	if err := json.Unmarshal(msgBody, &event); err != nil {
+		h.logger.Exception(ctx, "Failed to unmarshal LowStockEvent", err)
+		return infrastructure.Permanent(err)
+	}
+
+	if err := event.Validate(); err != nil {
+		h.logger.Exception(ctx, "Invalid LowStockEvent", err)
+		return infrastructure.Permanent(err)
+	}
+
+	notificationReq := notification.NotificationRequest{
+		ProductID:   event.ProductID,
+		Message:     fmt.Sprintf("Low stock for product %s: %d remaining (threshold %d)", event.ProductID, event.RemainingQuantity, event.Threshold),
+		Channel:     notification.ChannelEmail,
+		Recipient:   "inventory@example.com", // TODO: Make the inventory team recipient configurable
+		MessageType: "low_stock",
+	}
+
+	if err := h.notificationService.SendNotification(ctx, notificationReq); err != nil {
+		h.logger.Exception(ctx, "Failed to send low stock notification", err)
+		return infrastructure.Transient(err)
+	}
+
+	h.logger.Info(ctx, "Low stock notification sent for product: "+event.ProductID)
+	return nil
+}
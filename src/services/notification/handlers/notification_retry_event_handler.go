@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-order-eda/src/infrastructure"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/events"
+)
+
+// NotificationRetryEventHandler retries notifications for InventoryStatusUpdated events
+// without repeating any order or inventory side effects
+type NotificationRetryEventHandler struct {
+	statusHandler *InventoryStatusUpdatedEventHandler
+	logger        log.Logger
+}
+
+func NewNotificationRetryEventHandler(
+	statusHandler *InventoryStatusUpdatedEventHandler,
+	logger log.Logger,
+) *NotificationRetryEventHandler {
+	return &NotificationRetryEventHandler{
+		statusHandler: statusHandler,
+		logger:        logger,
+	}
+}
+
+// Handle retries the notification once, after the broker held the event for the retry delay of
+// events.NotificationRetry, and dead-letters the event if it fails again
+func (h *NotificationRetryEventHandler) Handle(ctx context.Context, msgBody []byte) error {
+	var event events.InventoryStatusUpdatedEvent
+	if err := json.Unmarshal(msgBody, &event); err != nil {
+		h.logger.Exception(ctx, "Failed to unmarshal notification retry event", err)
+		return infrastructure.Permanent(err)
+	}
+
+	if err := h.statusHandler.Notify(ctx, event); err != nil {
+		h.logger.Exception(ctx, "Notification retry failed for order: "+event.OrderID, err)
+		return infrastructure.Permanent(err)
+	}
+
+	h.logger.Info(ctx, "Notification retry succeeded for order: "+event.OrderID)
+	return nil
+}
@@ -0,0 +1,116 @@
+package notification
+
+import (
+	"context"
+	mongoinfra "go-order-eda/src/infrastructure/mongo"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Notification attempt statuses
+	NotificationStatusSent   = "sent"
+	NotificationStatusFailed = "failed"
+)
+
+// NotificationRecord is the audit record of a single notification attempt
+type NotificationRecord struct {
+	OrderID     string              `bson:"orderId" json:"orderId"`
+	ProductID   string              `bson:"productId,omitempty" json:"productId,omitempty"`
+	Channel     NotificationChannel `bson:"channel" json:"channel"`
+	Recipient   string              `bson:"recipient" json:"recipient"`
+	MessageType string              `bson:"messageType" json:"messageType"`
+	Message     string              `bson:"message" json:"message"`
+	Status      string              `bson:"status" json:"status"`
+	Error       string              `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt   time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+type NotificationRepository interface {
+	RecordAttempt(ctx context.Context, record NotificationRecord) error
+	HasSent(ctx context.Context, orderID string, channel NotificationChannel, messageType string) (bool, error)
+	GetByOrderID(ctx context.Context, orderID string) ([]NotificationRecord, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type notificationRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration // Upper bound for each database operation
+}
+
+func NewNotificationRepository(db *mongo.Database, timeout time.Duration) NotificationRepository {
+	return &notificationRepository{
+		collection: db.Collection("notifications"),
+		timeout:    timeout,
+	}
+}
+
+// EnsureIndexes creates the index HasSent checks every send against, whose orderId prefix also serves GetByOrderID
+func (r *notificationRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "orderId", Value: 1},
+			{Key: "channel", Value: 1},
+			{Key: "messageType", Value: 1},
+			{Key: "status", Value: 1},
+		},
+	})
+	return err
+}
+
+// RecordAttempt stores a notification attempt regardless of its outcome
+func (r *notificationRepository) RecordAttempt(ctx context.Context, record NotificationRecord) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(ctx, record)
+	return err
+}
+
+// HasSent reports whether a notification of the given type was already delivered
+// successfully to the order through the channel
+func (r *notificationRepository) HasSent(ctx context.Context, orderID string, channel NotificationChannel, messageType string) (bool, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{
+		"orderId":     orderID,
+		"channel":     channel,
+		"messageType": messageType,
+		"status":      NotificationStatusSent,
+	}
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetByOrderID returns all notification attempts for an order, oldest first
+func (r *notificationRepository) GetByOrderID(ctx context.Context, orderID string) ([]NotificationRecord, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{bson.E{Key: "createdAt", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"orderId": orderID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	records := []NotificationRecord{}
+	for cursor.Next(ctx) {
+		var record NotificationRecord
+		if err := cursor.Decode(&record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
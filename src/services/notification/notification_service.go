@@ -2,7 +2,11 @@ package notification
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go-order-eda/src/infrastructure/log"
+	"sync"
+	"time"
 )
 
 // NotificationChannel represents different notification delivery methods
@@ -14,6 +18,27 @@ const (
 	ChannelPush  NotificationChannel = "push"
 )
 
+var (
+	// ErrUnknownChannel is returned when a notification is sent through a channel with no registered sender
+	ErrUnknownChannel = errors.New("unknown notification channel")
+	// ErrAllChannelsFailed is returned when a multi-channel notification could not be delivered through any channel
+	ErrAllChannelsFailed = errors.New("notification failed on all channels")
+)
+
+// Valid reports whether the channel is one of the built-in channels.
+// Custom channels are accepted by the service once registered with RegisterChannel.
+func (c NotificationChannel) Valid() bool {
+	switch c {
+	case ChannelEmail, ChannelSMS, ChannelPush:
+		return true
+	default:
+		return false
+	}
+}
+
+// ChannelSender delivers a notification through a single channel
+type ChannelSender func(ctx context.Context, request NotificationRequest) error
+
 // NotificationRequest represents a notification to be sent
 type NotificationRequest struct {
 	OrderID     string              `json:"orderId"`
@@ -27,50 +52,138 @@ type NotificationRequest struct {
 // NotificationService defines the interface for sending notifications
 type NotificationService interface {
 	SendNotification(ctx context.Context, request NotificationRequest) error
-	SendMultiChannelNotification(ctx context.Context, request NotificationRequest, channels []NotificationChannel) error
+	SendMultiChannelNotification(ctx context.Context, request NotificationRequest, channels []NotificationChannel) (delivered []NotificationChannel, err error)
+	RegisterChannel(channel NotificationChannel, sender ChannelSender) error
+	SupportsChannel(channel NotificationChannel) bool
+	GetNotificationsByOrderID(ctx context.Context, orderID string) ([]NotificationRecord, error)
 }
 
 // NotificationServiceImpl implements the NotificationService interface
 type NotificationServiceImpl struct {
-	logger log.Logger
+	logger     log.Logger
+	repository NotificationRepository
 	// In a real implementation, you would have clients for different services:
 	// emailClient EmailClient
 	// smsClient   SMSClient
 	// pushClient  PushClient
+	mu      sync.RWMutex
+	senders map[NotificationChannel]ChannelSender
 }
 
 // NewNotificationService creates a new notification service instance
-func NewNotificationService(logger log.Logger) NotificationService {
-	return &NotificationServiceImpl{
-		logger: logger,
+// with the built-in email, SMS and push channels registered
+func NewNotificationService(logger log.Logger, repository NotificationRepository) NotificationService {
+	n := &NotificationServiceImpl{
+		logger:     logger,
+		repository: repository,
+	}
+	n.senders = map[NotificationChannel]ChannelSender{
+		ChannelEmail: n.sendEmailNotification,
+		ChannelSMS:   n.sendSMSNotification,
+		ChannelPush:  n.sendPushNotification,
 	}
+	return n
+}
+
+// RegisterChannel adds a sender for a new channel (e.g. webhook, Slack) or replaces an existing one
+func (n *NotificationServiceImpl) RegisterChannel(channel NotificationChannel, sender ChannelSender) error {
+	if channel == "" {
+		return errors.New("notification channel name is required")
+	}
+	if sender == nil {
+		return errors.New("notification channel sender is required")
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.senders[channel] = sender
+	return nil
+}
+
+// SupportsChannel reports whether a sender is registered for the channel
+func (n *NotificationServiceImpl) SupportsChannel(channel NotificationChannel) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	_, ok := n.senders[channel]
+	return ok
 }
 
-// SendNotification sends a notification through the specified channel
+// SendNotification sends a notification through the specified channel and records the attempt.
+// Notifications already delivered for the same order, channel and message type are skipped,
+// so a redelivered event does not notify the customer twice.
+// Returns ErrUnknownChannel when no sender is registered for the channel.
 func (n *NotificationServiceImpl) SendNotification(ctx context.Context, request NotificationRequest) error {
-	switch request.Channel {
-	case ChannelEmail:
-		return n.sendEmailNotification(ctx, request)
-	case ChannelSMS:
-		return n.sendSMSNotification(ctx, request)
-	case ChannelPush:
-		return n.sendPushNotification(ctx, request)
-	default:
-		n.logger.Warn(ctx, "Unknown notification channel: "+string(request.Channel))
-		return nil
+	n.mu.RLock()
+	sender, ok := n.senders[request.Channel]
+	n.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownChannel, request.Channel)
+	}
+
+	if request.OrderID != "" {
+		sent, err := n.repository.HasSent(ctx, request.OrderID, request.Channel, request.MessageType)
+		if err != nil {
+			n.logger.Warn(ctx, fmt.Sprintf("Failed to check notification history for order %s: %v", request.OrderID, err))
+		} else if sent {
+			n.logger.Info(ctx, fmt.Sprintf("Skipping %s %s notification already sent for order %s",
+				request.MessageType, request.Channel, request.OrderID))
+			return nil
+		}
+	}
+
+	sendErr := sender(ctx, request)
+	n.recordAttempt(ctx, request, sendErr)
+	return sendErr
+}
+
+// GetNotificationsByOrderID returns the notification audit trail for an order
+func (n *NotificationServiceImpl) GetNotificationsByOrderID(ctx context.Context, orderID string) ([]NotificationRecord, error) {
+	return n.repository.GetByOrderID(ctx, orderID)
+}
+
+// recordAttempt persists the outcome of a notification attempt; failures to record are only logged
+func (n *NotificationServiceImpl) recordAttempt(ctx context.Context, request NotificationRequest, sendErr error) {
+	record := NotificationRecord{
+		OrderID:     request.OrderID,
+		ProductID:   request.ProductID,
+		Channel:     request.Channel,
+		Recipient:   request.Recipient,
+		MessageType: request.MessageType,
+		Message:     request.Message,
+		Status:      NotificationStatusSent,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if sendErr != nil {
+		record.Status = NotificationStatusFailed
+		record.Error = sendErr.Error()
+	}
+
+	if err := n.repository.RecordAttempt(ctx, record); err != nil {
+		n.logger.Warn(ctx, fmt.Sprintf("Failed to record %s notification for order %s: %v", request.Channel, request.OrderID, err))
 	}
 }
 
-// SendMultiChannelNotification sends notifications through multiple channels
-func (n *NotificationServiceImpl) SendMultiChannelNotification(ctx context.Context, request NotificationRequest, channels []NotificationChannel) error {
+// SendMultiChannelNotification sends notifications through multiple channels and returns those
+// that delivered it. It succeeds when at least one channel delivered the notification and
+// returns ErrAllChannelsFailed when none did.
+func (n *NotificationServiceImpl) SendMultiChannelNotification(ctx context.Context, request NotificationRequest, channels []NotificationChannel) ([]NotificationChannel, error) {
+	var errs []error
+	var delivered []NotificationChannel
 	for _, channel := range channels {
 		request.Channel = channel
 		if err := n.SendNotification(ctx, request); err != nil {
 			n.logger.Exception(ctx, "Failed to send notification via "+string(channel), err)
 			// Continue with other channels instead of failing entirely
+			errs = append(errs, err)
+			continue
 		}
+		delivered = append(delivered, channel)
 	}
-	return nil
+
+	if len(delivered) == 0 {
+		return nil, fmt.Errorf("%w: %w", ErrAllChannelsFailed, errors.Join(errs...))
+	}
+	return delivered, nil
 }
 
 // sendEmailNotification sends an email notification
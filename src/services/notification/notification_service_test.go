@@ -0,0 +1,240 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"go-order-eda/src/infrastructure/log"
+)
+
+// fakeNotificationRepository is an in-memory NotificationRepository used by service tests
+type fakeNotificationRepository struct {
+	mu      sync.Mutex
+	records []NotificationRecord
+}
+
+func newFakeNotificationRepository() *fakeNotificationRepository {
+	return &fakeNotificationRepository{}
+}
+
+func (r *fakeNotificationRepository) RecordAttempt(ctx context.Context, record NotificationRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, record)
+	return nil
+}
+
+func (r *fakeNotificationRepository) HasSent(ctx context.Context, orderID string, channel NotificationChannel, messageType string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, record := range r.records {
+		if record.OrderID == orderID && record.Channel == channel &&
+			record.MessageType == messageType && record.Status == NotificationStatusSent {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeNotificationRepository) EnsureIndexes(ctx context.Context) error { return nil }
+
+func (r *fakeNotificationRepository) GetByOrderID(ctx context.Context, orderID string) ([]NotificationRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []NotificationRecord
+	for _, record := range r.records {
+		if record.OrderID == orderID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func TestNotificationChannel_Valid(t *testing.T) {
+	for _, channel := range []NotificationChannel{ChannelEmail, ChannelSMS, ChannelPush} {
+		if !channel.Valid() {
+			t.Errorf("Expected %q to be valid", channel)
+		}
+	}
+	for _, channel := range []NotificationChannel{"", "emial", "webhook"} {
+		if channel.Valid() {
+			t.Errorf("Expected %q to be invalid", channel)
+		}
+	}
+}
+
+func TestNotificationService_SendNotification(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("built-in channel is delivered", func(t *testing.T) {
+		service := NewNotificationService(log.NewLogger(), newFakeNotificationRepository())
+
+		err := service.SendNotification(ctx, NotificationRequest{OrderID: "order-1", Channel: ChannelEmail, Message: "hello"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unknown channel returns an error", func(t *testing.T) {
+		service := NewNotificationService(log.NewLogger(), newFakeNotificationRepository())
+
+		err := service.SendNotification(ctx, NotificationRequest{OrderID: "order-1", Channel: "emial", Message: "hello"})
+		if !errors.Is(err, ErrUnknownChannel) {
+			t.Fatalf("Expected ErrUnknownChannel, got %v", err)
+		}
+	})
+
+	t.Run("registered custom channel is delivered", func(t *testing.T) {
+		service := NewNotificationService(log.NewLogger(), newFakeNotificationRepository())
+
+		const webhook NotificationChannel = "webhook"
+		var delivered []NotificationRequest
+		err := service.RegisterChannel(webhook, func(ctx context.Context, request NotificationRequest) error {
+			delivered = append(delivered, request)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to register channel: %v", err)
+		}
+		if !service.SupportsChannel(webhook) {
+			t.Fatal("Expected webhook channel to be supported after registration")
+		}
+
+		err = service.SendNotification(ctx, NotificationRequest{OrderID: "order-1", Channel: webhook, Message: "hello"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(delivered) != 1 || delivered[0].OrderID != "order-1" {
+			t.Errorf("Expected webhook sender to receive the request, got %+v", delivered)
+		}
+	})
+
+	t.Run("registration requires a name and a sender", func(t *testing.T) {
+		service := NewNotificationService(log.NewLogger(), newFakeNotificationRepository())
+
+		if err := service.RegisterChannel("", func(context.Context, NotificationRequest) error { return nil }); err == nil {
+			t.Error("Expected error for empty channel name")
+		}
+		if err := service.RegisterChannel("slack", nil); err == nil {
+			t.Error("Expected error for nil sender")
+		}
+	})
+}
+
+func TestNotificationService_Audit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("successful and failed attempts are recorded", func(t *testing.T) {
+		repo := newFakeNotificationRepository()
+		service := NewNotificationService(log.NewLogger(), repo)
+		_ = service.RegisterChannel("webhook", func(context.Context, NotificationRequest) error {
+			return errors.New("webhook unavailable")
+		})
+
+		request := NotificationRequest{OrderID: "order-1", Recipient: "customer@example.com", MessageType: "confirmation", Message: "hello"}
+
+		request.Channel = ChannelSMS
+		if err := service.SendNotification(ctx, request); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		request.Channel = "webhook"
+		if err := service.SendNotification(ctx, request); err == nil {
+			t.Fatal("Expected webhook failure to be returned")
+		}
+
+		records, _ := service.GetNotificationsByOrderID(ctx, "order-1")
+		if len(records) != 2 {
+			t.Fatalf("Expected 2 recorded attempts, got %d", len(records))
+		}
+		if records[0].Channel != ChannelSMS || records[0].Status != NotificationStatusSent || records[0].Recipient != "customer@example.com" {
+			t.Errorf("Unexpected SMS record: %+v", records[0])
+		}
+		if records[1].Status != NotificationStatusFailed || records[1].Error != "webhook unavailable" {
+			t.Errorf("Unexpected webhook record: %+v", records[1])
+		}
+		if records[0].CreatedAt.IsZero() {
+			t.Error("Expected record timestamp to be set")
+		}
+	})
+
+	t.Run("already sent notification is skipped on redelivery", func(t *testing.T) {
+		repo := newFakeNotificationRepository()
+		service := NewNotificationService(log.NewLogger(), repo)
+
+		calls := 0
+		_ = service.RegisterChannel(ChannelEmail, func(context.Context, NotificationRequest) error {
+			calls++
+			return nil
+		})
+
+		request := NotificationRequest{OrderID: "order-1", Channel: ChannelEmail, MessageType: "confirmation", Message: "hello"}
+		for i := 0; i < 3; i++ {
+			if err := service.SendNotification(ctx, request); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+
+		if calls != 1 {
+			t.Errorf("Expected email to be sent once, got %d", calls)
+		}
+		if len(repo.records) != 1 {
+			t.Errorf("Expected a single recorded attempt, got %d", len(repo.records))
+		}
+	})
+
+	t.Run("failed notification is retried on redelivery", func(t *testing.T) {
+		repo := newFakeNotificationRepository()
+		service := NewNotificationService(log.NewLogger(), repo)
+
+		calls := 0
+		_ = service.RegisterChannel(ChannelEmail, func(context.Context, NotificationRequest) error {
+			calls++
+			if calls == 1 {
+				return errors.New("smtp timeout")
+			}
+			return nil
+		})
+
+		request := NotificationRequest{OrderID: "order-1", Channel: ChannelEmail, MessageType: "confirmation", Message: "hello"}
+		_ = service.SendNotification(ctx, request)
+		if err := service.SendNotification(ctx, request); err != nil {
+			t.Fatalf("Unexpected error on retry: %v", err)
+		}
+
+		if calls != 2 {
+			t.Errorf("Expected email to be attempted twice, got %d", calls)
+		}
+	})
+}
+
+func TestNotificationService_SendMultiChannelNotification(t *testing.T) {
+	ctx := context.Background()
+	request := NotificationRequest{OrderID: "order-1", MessageType: "confirmation", Message: "hello"}
+	failing := func(context.Context, NotificationRequest) error { return errors.New("unavailable") }
+
+	t.Run("partial success is not an error", func(t *testing.T) {
+		service := NewNotificationService(log.NewLogger(), newFakeNotificationRepository())
+		_ = service.RegisterChannel(ChannelSMS, failing)
+
+		delivered, err := service.SendMultiChannelNotification(ctx, request, []NotificationChannel{ChannelEmail, ChannelSMS})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(delivered) != 1 || delivered[0] != ChannelEmail {
+			t.Errorf("Expected only email delivered, got %v", delivered)
+		}
+	})
+
+	t.Run("all channels failing returns ErrAllChannelsFailed", func(t *testing.T) {
+		service := NewNotificationService(log.NewLogger(), newFakeNotificationRepository())
+		_ = service.RegisterChannel(ChannelEmail, failing)
+		_ = service.RegisterChannel(ChannelSMS, failing)
+
+		_, err := service.SendMultiChannelNotification(ctx, request, []NotificationChannel{ChannelEmail, ChannelSMS})
+		if !errors.Is(err, ErrAllChannelsFailed) {
+			t.Fatalf("Expected ErrAllChannelsFailed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"go-order-eda/src/services/events"
+	"sync"
+	"time"
+)
+
+// statusPollInterval is how often CreateOrderAndWait reads the stored status, which catches
+// orders settled by another instance, whose completion signal does not reach this one
+const statusPollInterval = 500 * time.Millisecond
+
+// IsSettledOrderStatus reports whether a waiting client has its answer: the order was
+// confirmed, or it reached a terminal status
+func IsSettledOrderStatus(status string) bool {
+	return status == events.OrderStatusConfirmed || events.IsTerminalOrderStatus(status)
+}
+
+// Completions signals waiting requests when their order settles. Handlers in this instance
+// call Complete; requests Subscribe before publishing the order so no signal is missed.
+type Completions struct {
+	mu      sync.Mutex
+	waiters map[string][]chan string
+}
+
+func NewCompletions() *Completions {
+	return &Completions{waiters: make(map[string][]chan string)}
+}
+
+// Subscribe returns a channel receiving the order's settled status, and a function
+// that must be called to stop waiting
+func (c *Completions) Subscribe(orderID string) (<-chan string, func()) {
+	ch := make(chan string, 1)
+	c.mu.Lock()
+	c.waiters[orderID] = append(c.waiters[orderID], ch)
+	c.mu.Unlock()
+
+	return ch, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		waiters := c.waiters[orderID]
+		for i, waiter := range waiters {
+			if waiter == ch {
+				waiters = append(waiters[:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(waiters) == 0 {
+			delete(c.waiters, orderID)
+		} else {
+			c.waiters[orderID] = waiters
+		}
+	}
+}
+
+// Complete hands the status to everyone waiting for the order
+func (c *Completions) Complete(orderID, status string) {
+	c.mu.Lock()
+	waiters := c.waiters[orderID]
+	delete(c.waiters, orderID)
+	c.mu.Unlock()
+
+	for _, ch := range waiters {
+		ch <- status // Buffered and sent to once, so this never blocks
+	}
+}
+
+// CreateOrderAndWait creates an order like CreateOrder and then waits up to timeout for it to settle.
+// It returns the settled status, or "" when the timeout elapsed first and the order is still in progress.
+func (s *orderService) CreateOrderAndWait(ctx context.Context, order Order, timeout time.Duration) (string, string, error) {
+	var settled <-chan string
+	if s.completions != nil {
+		ch, unsubscribe := s.completions.Subscribe(order.ID)
+		defer unsubscribe()
+		settled = ch
+	}
+
+	orderID, err := s.CreateOrder(ctx, order)
+	if err != nil {
+		return "", "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(statusPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case status := <-settled:
+			return orderID, status, nil
+		case <-ticker.C:
+			status, err := s.orderRepository.GetOrderStatus(ctx, orderID)
+			if err == nil && IsSettledOrderStatus(status) {
+				return orderID, status, nil
+			}
+			if err != nil && !errors.Is(err, ErrOrderNotFound) && ctx.Err() == nil {
+				s.logger.Warn(ctx, "Failed to poll status of order "+orderID+": "+err.Error())
+			}
+		case <-ctx.Done():
+			return orderID, "", nil
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/retry"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/money"
+)
+
+// signallingPublisher settles every order it publishes, like the event chain of a running service
+type signallingPublisher struct {
+	fakePublisher
+	completions *Completions
+	status      string
+}
+
+func (p *signallingPublisher) Publish(ctx context.Context, topic string, body []byte) error {
+	var event events.OrderRequestedEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return err
+	}
+	go p.completions.Complete(event.ID, p.status)
+	return p.fakePublisher.Publish(ctx, topic, body)
+}
+
+func TestOrderService_CreateOrderAndWait(t *testing.T) {
+	ctx := context.Background()
+	order := Order{ID: "order-1", Money: money.New(10, "USD"), Product: Product{ID: "product-1", Quantity: 1}}
+
+	t.Run("returns the signalled status", func(t *testing.T) {
+		completions := NewCompletions()
+		service := &orderService{
+			logger:          log.NewLogger(),
+			rabbitMQService: &signallingPublisher{completions: completions, status: events.OrderStatusConfirmed},
+			orderRepository: &fakeOrderStore{statuses: map[string]string{}},
+			backoff:         retry.Policy{Wait: skipWait},
+			completions:     completions,
+		}
+
+		orderID, status, err := service.CreateOrderAndWait(ctx, order, time.Second)
+		if err != nil {
+			t.Fatalf("CreateOrderAndWait failed: %v", err)
+		}
+		if orderID != "order-1" || status != events.OrderStatusConfirmed {
+			t.Errorf("Expected order-1 Confirmed, got %s %q", orderID, status)
+		}
+		if len(completions.waiters) != 0 {
+			t.Errorf("Expected the subscription to be removed, got %v", completions.waiters)
+		}
+	})
+
+	t.Run("finds a status settled elsewhere by polling", func(t *testing.T) {
+		service := &orderService{
+			logger:          log.NewLogger(),
+			rabbitMQService: &fakePublisher{},
+			orderRepository: &fakeOrderStore{statuses: map[string]string{"order-1": events.OrderStatusCancelled}},
+			backoff:         retry.Policy{Wait: skipWait},
+		}
+
+		_, status, err := service.CreateOrderAndWait(ctx, order, 2*statusPollInterval)
+		if err != nil {
+			t.Fatalf("CreateOrderAndWait failed: %v", err)
+		}
+		if status != events.OrderStatusCancelled {
+			t.Errorf("Expected the stored status cancelled, got %q", status)
+		}
+	})
+
+	t.Run("returns no status when the timeout elapses", func(t *testing.T) {
+		completions := NewCompletions()
+		service := &orderService{
+			logger:          log.NewLogger(),
+			rabbitMQService: &fakePublisher{},
+			orderRepository: &fakeOrderStore{statuses: map[string]string{"order-1": "Processing"}},
+			backoff:         retry.Policy{Wait: skipWait},
+			completions:     completions,
+		}
+
+		start := time.Now()
+		orderID, status, err := service.CreateOrderAndWait(ctx, order, 50*time.Millisecond)
+		if err != nil {
+			t.Fatalf("CreateOrderAndWait failed: %v", err)
+		}
+		if orderID != "order-1" || status != "" {
+			t.Errorf("Expected order-1 without a status, got %s %q", orderID, status)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected to give up after the timeout, waited %s", elapsed)
+		}
+	})
+
+	t.Log("✅ Synchronous creation returns the settled status or times out")
+}
+
+func TestCompletions(t *testing.T) {
+	completions := NewCompletions()
+	first, stopFirst := completions.Subscribe("order-1")
+	second, stopSecond := completions.Subscribe("order-1")
+	defer stopSecond()
+	stopFirst()
+
+	completions.Complete("order-1", events.OrderStatusCompleted)
+	completions.Complete("order-2", events.OrderStatusCompleted) // Nobody waiting
+
+	select {
+	case status := <-second:
+		if status != events.OrderStatusCompleted {
+			t.Errorf("Expected Completed, got %s", status)
+		}
+	default:
+		t.Error("Expected the remaining subscriber to be signalled")
+	}
+	select {
+	case status := <-first:
+		t.Errorf("Expected no signal after unsubscribing, got %s", status)
+	default:
+	}
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"go-order-eda/src/infrastructure/log"
+	"sync/atomic"
+	"time"
+)
+
+// eventRetentionStore is the part of the order repository the event cleaner needs.
+// It is satisfied by *persistence.OrderRepository.
+type eventRetentionStore interface {
+	DeleteCompletedEvents(ctx context.Context, before time.Time) (int64, error)
+	ArchiveDeadEvents(ctx context.Context, before time.Time, limit int64) (int, error)
+}
+
+// EventCleaner keeps the order_events collection from growing without bound: completed events older
+// than the retention window are deleted, and failed events that old are archived as dead.
+// Pending and replaying events are in flight and are never touched.
+type EventCleaner struct {
+	store     eventRetentionStore
+	logger    log.Logger
+	retention time.Duration
+	interval  time.Duration
+	batchSize int64
+	lastRun   atomic.Int64 // Unix nanoseconds of the last successful cleanup
+}
+
+func NewEventCleaner(store eventRetentionStore, logger log.Logger, retention, interval time.Duration, batchSize int64) *EventCleaner {
+	return &EventCleaner{
+		store:     store,
+		logger:    logger,
+		retention: retention,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run cleans up old events every interval until the context is cancelled
+func (c *EventCleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.logger.Info(ctx, "Order event cleaner started")
+	for {
+		if _, _, err := c.Clean(ctx); err != nil {
+			c.logger.Exception(ctx, "Order event cleanup failed", err)
+		} else {
+			c.lastRun.Store(time.Now().UTC().UnixNano())
+		}
+
+		select {
+		case <-ctx.Done():
+			c.logger.Info(ctx, "Order event cleaner stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// LastRun returns when the cleaner last completed a cleanup, or the zero time if it has not yet
+func (c *EventCleaner) LastRun() time.Time {
+	if nanos := c.lastRun.Load(); nanos != 0 {
+		return time.Unix(0, nanos).UTC()
+	}
+	return time.Time{}
+}
+
+// Clean deletes completed events and archives one batch of dead events created before the
+// retention window, and returns how many of each it handled
+func (c *EventCleaner) Clean(ctx context.Context) (int64, int, error) {
+	before := time.Now().UTC().Add(-c.retention)
+
+	deleted, err := c.store.DeleteCompletedEvents(ctx, before)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to delete completed events: %w", err)
+	}
+	archived, err := c.store.ArchiveDeadEvents(ctx, before, c.batchSize)
+	if err != nil {
+		return deleted, archived, fmt.Errorf("failed to archive dead events: %w", err)
+	}
+
+	if deleted > 0 || archived > 0 {
+		c.logger.Info(ctx, fmt.Sprintf("Order event cleaner deleted %d completed and archived %d dead events older than %s",
+			deleted, archived, c.retention))
+	}
+	return deleted, archived, nil
+}
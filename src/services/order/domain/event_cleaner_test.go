@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/order/domain/persistence"
+)
+
+// fakeEventRetentionStore keeps events in memory and applies the repository's retention rules
+type fakeEventRetentionStore struct {
+	mu       sync.Mutex
+	events   []persistence.OrderEvent
+	archived []persistence.OrderEvent
+}
+
+func (s *fakeEventRetentionStore) DeleteCompletedEvents(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	s.events = slices.DeleteFunc(s.events, func(evt persistence.OrderEvent) bool {
+		if evt.Status == events.EventStatusCompleted && evt.CreatedAt.Before(before) {
+			deleted++
+			return true
+		}
+		return false
+	})
+	return deleted, nil
+}
+
+func (s *fakeEventRetentionStore) ArchiveDeadEvents(ctx context.Context, before time.Time, limit int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	archived := 0
+	s.events = slices.DeleteFunc(s.events, func(evt persistence.OrderEvent) bool {
+		if evt.Status == events.EventStatusFailed && evt.CreatedAt.Before(before) && int64(archived) < limit {
+			evt.Status = events.EventStatusDead
+			s.archived = append(s.archived, evt)
+			archived++
+			return true
+		}
+		return false
+	})
+	return archived, nil
+}
+
+func (s *fakeEventRetentionStore) ids() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for _, evt := range s.events {
+		ids = append(ids, evt.ID)
+	}
+	return ids
+}
+
+func TestEventCleaner_Clean(t *testing.T) {
+	const retention = 24 * time.Hour
+	old := time.Now().UTC().Add(-2 * retention)
+	recent := time.Now().UTC().Add(-time.Hour)
+
+	store := &fakeEventRetentionStore{events: []persistence.OrderEvent{
+		{ID: "old-completed", Status: events.EventStatusCompleted, CreatedAt: old},
+		{ID: "recent-completed", Status: events.EventStatusCompleted, CreatedAt: recent},
+		{ID: "old-failed", Status: events.EventStatusFailed, CreatedAt: old},
+		{ID: "recent-failed", Status: events.EventStatusFailed, CreatedAt: recent},
+		{ID: "old-pending", Status: events.EventStatusPending, CreatedAt: old},
+		{ID: "old-replaying", Status: events.EventStatusReplaying, CreatedAt: old},
+	}}
+	cleaner := NewEventCleaner(store, log.NewLogger(), retention, time.Hour, 100)
+
+	deleted, archived, err := cleaner.Clean(context.Background())
+	if err != nil {
+		t.Fatalf("Clean failed: %v", err)
+	}
+	if deleted != 1 || archived != 1 {
+		t.Errorf("Expected 1 deleted and 1 archived event, got %d and %d", deleted, archived)
+	}
+
+	want := []string{"recent-completed", "recent-failed", "old-pending", "old-replaying"}
+	if remaining := store.ids(); !slices.Equal(remaining, want) {
+		t.Errorf("Expected %v to remain, got %v", want, remaining)
+	}
+	if len(store.archived) != 1 || store.archived[0].ID != "old-failed" || store.archived[0].Status != events.EventStatusDead {
+		t.Errorf("Expected old-failed archived as dead, got %+v", store.archived)
+	}
+
+	t.Log("✅ Old completed events removed, recent and in-flight ones kept")
+}
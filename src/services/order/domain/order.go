@@ -1,10 +1,13 @@
 package domain
 
-import "time"
+import (
+	"go-order-eda/src/services/money"
+	"time"
+)
 
 type Order struct {
-	ID     string
-	Amount float64
+	ID string
+	money.Money
 	Status string
 	Product
 	CreatedAt time.Time
@@ -16,15 +19,15 @@ type Product struct {
 	Quantity int
 }
 
-func NewOrder(id string, amount float64) *Order {
+func NewOrder(id string, amount money.Money) *Order {
 	return &Order{
 		ID:     id,
-		Amount: amount,
+		Money:  amount,
 		Status: "Pending",
 		Product: Product{
 			ID:   "1",
 			Name: "Sample Product",
 		},
-		CreatedAt: time.Now().Local(),
+		CreatedAt: time.Now().UTC(),
 	}
 }
@@ -2,43 +2,133 @@ package domain
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"go-order-eda/src/infrastructure/log"
 	"go-order-eda/src/infrastructure/rabbitmq"
+	"go-order-eda/src/infrastructure/retry"
 	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/inventory"
 	"go-order-eda/src/services/order/domain/persistence"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var (
+	// ErrOrderNotFound is returned when no order exists for the given ID
+	ErrOrderNotFound = persistence.ErrOrderNotFound
+	// ErrOrderTerminal is returned when an order has already reached a final status
+	ErrOrderTerminal = errors.New("order is already in a terminal status")
+	// ErrOrderNotTerminal is returned when an order that is still in progress is archived
+	ErrOrderNotTerminal = errors.New("order is not in a terminal status")
+	// ErrInsufficientStock is returned when an order asks for more than the product's available stock
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrInvalidReplayFilter is returned when a replay is asked for an invalid time range, status or event type
+	ErrInvalidReplayFilter = errors.New("invalid replay filter")
+)
+
+// StockChecker reads the current stock of a product. It is satisfied by inventory.InventoryService.
+type StockChecker interface {
+	GetProductStock(ctx context.Context, productID string) (*inventory.Product, error)
+}
+
 type OrderService interface {
 	CreateOrder(ctx context.Context, order Order) (string, error)
+	CreateOrderAndWait(ctx context.Context, order Order, timeout time.Duration) (orderID string, status string, err error)
 	CancelOrder(ctx context.Context, orderID string) error
-	ReplayFailedEvents(ctx context.Context) error
+	GetOrderStatus(ctx context.Context, orderID string) (string, error)
+	ArchiveOrder(ctx context.Context, orderID string) error
+	ReplayFailedEvents(ctx context.Context, filter ReplayFilter, dryRun bool) (ReplaySummary, error)
+}
+
+// ReplayFilter selects the unreplayed events a replay republishes, e.g. those of one incident window.
+// Zero values do not filter.
+type ReplayFilter struct {
+	From      time.Time // Earliest time the event was stored, inclusive
+	To        time.Time // Latest time the event was stored, exclusive
+	Statuses  []string  // Some of pending and failed
+	EventType string    // An event type of events.Registry
+}
+
+// Validate reports a filter whose range ends before it starts, or that names a status that is
+// never replayed or an unknown event type
+func (f ReplayFilter) Validate() error {
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return fmt.Errorf("%w: from %s is not before to %s", ErrInvalidReplayFilter, f.From.Format(time.RFC3339), f.To.Format(time.RFC3339))
+	}
+	for _, status := range f.Statuses {
+		if status != events.EventStatusPending && status != events.EventStatusFailed {
+			return fmt.Errorf("%w: status %q is not replayed, use %s or %s", ErrInvalidReplayFilter, status, events.EventStatusPending, events.EventStatusFailed)
+		}
+	}
+	if f.EventType != "" {
+		if _, ok := events.LookupEventType(f.EventType); !ok {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidReplayFilter, f.EventType)
+		}
+	}
+	return nil
+}
+
+// ReplaySummary reports the events a replay read, counted by the event type they are replayed as, and how their replay went.
+// A dry run only reads them, so Succeeded and Failed stay zero. Events of a type that cannot be routed are
+// only counted as Skipped.
+type ReplaySummary struct {
+	DryRun      bool             `json:"dryRun"`
+	Events      int64            `json:"events"`
+	ByEventType map[string]int64 `json:"byEventType"`
+	Succeeded   int64            `json:"succeeded"`
+	Failed      int64            `json:"failed"`
+	Skipped     int64            `json:"skipped"`
+}
+
+// orderStore is the part of the order repository the service reads and writes.
+// It is satisfied by *persistence.OrderRepository.
+type orderStore interface {
+	GetOrderStatus(ctx context.Context, id string) (string, error)
+	ArchiveOrder(ctx context.Context, id string) error
+	GetUnreplayedEvents(ctx context.Context, filter persistence.EventFilter, after *persistence.OrderEvent, limit int64) ([]persistence.OrderEvent, error)
+	MarkEventAsReplaying(ctx context.Context, eventID string) error
+	MarkEventAsCompleted(ctx context.Context, eventID string) error
+	MarkEventAsFailed(ctx context.Context, eventID string) error
 }
 
 type orderService struct {
 	logger          log.Logger
-	rabbitMQService rabbitmq.RabbitMQServiceImpl
-	orderRepository *persistence.OrderRepository
+	rabbitMQService rabbitmq.Publisher
+	orderRepository orderStore
+	backoff         retry.Policy    // Delays between publish retries
+	completions     *Completions    // Signals CreateOrderAndWait; without it only the stored status is polled
+	stock           StockChecker    // Rejects orders exceeding the available stock up front; nil disables the check
+	replayWorkers   int             // Orders whose events ReplayFailedEvents replays at once
+	publishMetrics  *PublishMetrics // Publishes that ran out of retries; nil disables them
 }
 
 func NewOrderService(
 	logger log.Logger,
-	rabbitMQService rabbitmq.RabbitMQServiceImpl,
+	rabbitMQService rabbitmq.Publisher,
 	orderRepository *persistence.OrderRepository,
+	completions *Completions,
+	stock StockChecker,
+	replayWorkers int,
+	publishMetrics *PublishMetrics,
 ) *orderService {
 	return &orderService{
 		logger:          logger,
 		rabbitMQService: rabbitMQService,
 		orderRepository: orderRepository,
+		backoff:         retry.Default,
+		completions:     completions,
+		stock:           stock,
+		replayWorkers:   replayWorkers,
+		publishMetrics:  publishMetrics,
 	}
 }
 
 // CreateOrder initiates the order creation process by publishing an OrderRequested event.
 // This follows the event sourcing pattern where the actual order creation happens in handlers.
-// Returns the order ID and any error that occurred during event publishing.
+// Returns the order ID and any error that occurred during event publishing; a publish retry
+// waiting when ctx is cancelled is abandoned and the context error returned.
 func (s *orderService) CreateOrder(ctx context.Context, order Order) (string, error) {
 	if order.ID == "" {
 		return "", errors.New("order ID is required")
@@ -51,48 +141,34 @@ func (s *orderService) CreateOrder(ctx context.Context, order Order) (string, er
 	if order.Product.Quantity <= 0 {
 		return "", errors.New("product quantity must be greater than 0")
 	}
-	if order.Amount <= 0 {
-		return "", errors.New("order amount must be greater than 0")
+	if err := order.Money.Validate(); err != nil {
+		return "", fmt.Errorf("invalid order amount: %w", err)
+	}
+
+	if err := s.checkStock(ctx, order); err != nil {
+		return "", err
 	}
 
 	// Create OrderRequested event
 	orderRequestedEvent := events.OrderRequestedEvent{
 		ID:        order.ID,
 		Product:   events.Product{ID: order.Product.ID, Name: order.Product.Name, Quantity: order.Product.Quantity},
-		Amount:    order.Amount,
+		Money:     order.Money,
 		Status:    events.OrderStatusRequested,
 		Version:   1,
-		TimeStamp: time.Now().Local(),
+		TimeStamp: time.Now().UTC(),
 	}
 
-	// Validate the event before publishing
-	if err := orderRequestedEvent.Validate(); err != nil {
+	const maxRetries = 2
+	err := rabbitmq.PublishEvent(ctx, s.eventBus(maxRetries, "order "+order.ID), events.OrderRequested, orderRequestedEvent)
+	switch {
+	case errors.Is(err, rabbitmq.ErrInvalidEvent):
 		s.logger.Exception(ctx, "Order requested event validation failed", err)
 		return "", fmt.Errorf("invalid order request: %w", err)
-	}
-
-	eventJSON, err := json.Marshal(orderRequestedEvent)
-	if err != nil {
+	case errors.Is(err, rabbitmq.ErrMarshalEvent):
 		s.logger.Exception(ctx, "failed to marshal order requested event", err)
 		return "", fmt.Errorf("failed to process order request: %w", err)
-	}
-
-	// Publish with retry logic
-	const maxRetries = 2
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		err = s.rabbitMQService.Publish(events.OrderRequested, eventJSON)
-		if err == nil {
-			break
-		}
-		s.logger.Warn(ctx, fmt.Sprintf("Publish OrderRequested failed for order %s, attempt %d/%d: %v",
-			order.ID, attempt, maxRetries, err))
-
-		if attempt < maxRetries {
-			time.Sleep(time.Duration(attempt) * time.Second)
-		}
-	}
-
-	if err != nil {
+	case err != nil:
 		s.logger.Exception(ctx, fmt.Sprintf("failed to publish order requested event for order %s after %d retries",
 			order.ID, maxRetries), err)
 		return "", fmt.Errorf("failed to publish order request: %w", err)
@@ -102,46 +178,60 @@ func (s *orderService) CreateOrder(ctx context.Context, order Order) (string, er
 	return order.ID, nil
 }
 
+// checkStock rejects an order that clearly cannot be fulfilled from the product's current stock.
+// The check is advisory: stock can still run out before the OrderCreated handler makes the
+// authoritative reservation, and an order is accepted when the stock cannot be read.
+func (s *orderService) checkStock(ctx context.Context, order Order) error {
+	if s.stock == nil {
+		return nil
+	}
+	product, err := s.stock.GetProductStock(ctx, order.Product.ID)
+	if err != nil {
+		s.logger.Warn(ctx, fmt.Sprintf("Stock pre-check for order %s skipped: %v", order.ID, err))
+		return nil
+	}
+	if product == nil {
+		return fmt.Errorf("%w: product %s does not exist", ErrInsufficientStock, order.Product.ID)
+	}
+	if order.Product.Quantity > product.Quantity {
+		return fmt.Errorf("%w: %d of product %s requested, %d available",
+			ErrInsufficientStock, order.Product.Quantity, order.Product.ID, product.Quantity)
+	}
+	return nil
+}
+
 // CancelOrder initiates the order cancellation process by publishing an OrderCancelled event.
 // This follows the event-driven pattern where the cancellation is processed asynchronously.
+// Returns ErrOrderNotFound for unknown orders and ErrOrderTerminal for orders that already finished.
+// Like CreateOrder, it stops retrying the publish as soon as ctx is cancelled.
 func (s *orderService) CancelOrder(ctx context.Context, orderID string) error {
 	if orderID == "" {
 		return errors.New("order ID is required for cancellation")
 	}
+	status, err := s.orderRepository.GetOrderStatus(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if events.IsTerminalOrderStatus(status) {
+		return fmt.Errorf("%w: order %s is %s", ErrOrderTerminal, orderID, status)
+	}
 	cancellationEvent := events.OrderCancelledEvent{
 		OrderID:   orderID,
 		Status:    events.OrderStatusCancelled,
 		Version:   1,
-		TimeStamp: time.Now().Local(),
+		TimeStamp: time.Now().UTC(),
 	}
 
-	// Validate the event before publishing
-	if err := cancellationEvent.Validate(); err != nil {
+	const maxRetries = 2
+	err = rabbitmq.PublishEvent(ctx, s.eventBus(maxRetries, "order "+orderID), events.OrderCancelled, cancellationEvent)
+	switch {
+	case errors.Is(err, rabbitmq.ErrInvalidEvent):
 		s.logger.Exception(ctx, "Order cancelled event validation failed", err)
 		return fmt.Errorf("invalid cancellation request: %w", err)
-	}
-	eventJSON, err := json.Marshal(cancellationEvent)
-	if err != nil {
+	case errors.Is(err, rabbitmq.ErrMarshalEvent):
 		s.logger.Exception(ctx, fmt.Sprintf("failed to marshal cancellation event for order %s", orderID), err)
 		return fmt.Errorf("failed to process cancellation: %w", err)
-	}
-
-	// Publish with retry logic
-	const maxRetries = 2
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		err = s.rabbitMQService.Publish(events.OrderCancelled, eventJSON)
-		if err == nil {
-			break
-		}
-		s.logger.Warn(ctx, fmt.Sprintf("Publish OrderCancelled failed for order %s, attempt %d/%d: %v",
-			orderID, attempt, maxRetries, err))
-
-		if attempt < maxRetries {
-			time.Sleep(time.Duration(attempt) * time.Second)
-		}
-	}
-
-	if err != nil {
+	case err != nil:
 		s.logger.Exception(ctx, fmt.Sprintf("failed to publish order cancelled event for order %s after %d retries",
 			orderID, maxRetries), err)
 		return fmt.Errorf("failed to publish cancellation event: %w", err)
@@ -151,70 +241,242 @@ func (s *orderService) CancelOrder(ctx context.Context, orderID string) error {
 	return nil
 }
 
+// ArchiveOrder archives an order that has reached a terminal status, removing it from the order reads.
+// Returns ErrOrderNotFound for unknown or already archived orders and ErrOrderNotTerminal for orders still in progress.
+func (s *orderService) ArchiveOrder(ctx context.Context, orderID string) error {
+	if orderID == "" {
+		return errors.New("order ID is required for archival")
+	}
+	status, err := s.orderRepository.GetOrderStatus(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if !events.IsTerminalOrderStatus(status) {
+		return fmt.Errorf("%w: order %s is %s", ErrOrderNotTerminal, orderID, status)
+	}
+	if err := s.orderRepository.ArchiveOrder(ctx, orderID); err != nil {
+		return err
+	}
+	s.logger.Info(ctx, "Order archived: "+orderID)
+	return nil
+}
+
+// eventBus publishes through the broker, retrying a failed publish up to attempts times and
+// logging every failure with the subject it concerns. A publish failing on every attempt is
+// counted in publishMetrics and logged as an alert.
+func (s *orderService) eventBus(attempts int, subject string) rabbitmq.EventBus {
+	return rabbitmq.EventBus{
+		Publisher: s.rabbitMQService,
+		Backoff:   s.backoff,
+		Attempts:  attempts,
+		OnFailure: func(ctx context.Context, topic string, attempt int, err error) {
+			s.logger.Warn(ctx, fmt.Sprintf("Publish %s failed for %s, attempt %d/%d: %v", topic, subject, attempt, attempts, err))
+		},
+		OnExhausted: func(ctx context.Context, topic string, err error) {
+			s.publishMetrics.observeExhausted(topic)
+			s.logger.Alert(ctx, "Publish retries exhausted", err, map[string]any{
+				"eventType": topic,
+				"subject":   subject,
+				"attempts":  attempts,
+			})
+		},
+	}
+}
+
+// GetOrderStatus returns the current status of an order, or ErrOrderNotFound when it does not exist
+func (s *orderService) GetOrderStatus(ctx context.Context, orderID string) (string, error) {
+	if orderID == "" {
+		return "", errors.New("order ID is required")
+	}
+	return s.orderRepository.GetOrderStatus(ctx, orderID)
+}
+
 // ReplayFailedEvents processes failed events from the order_events collection
 // and attempts to republish them with retry logic and proper status tracking.
-func (s *orderService) ReplayFailedEvents(ctx context.Context) error {
-	const batchSize = 100
-	const maxRetries = 3
+// The backlog is read in batches, oldest first, until it is drained or maxReplayEvents were read;
+// events that fail again are not read twice in the same call.
+// Events are replayed by a pool of replayWorkers workers, each taking all events of one order at a time,
+// so events of the same order are republished in the order they were stored.
+// Only the events matching the filter are replayed, each published as the event type it was stored with.
+// With dryRun the same events are read and summarized, but nothing is published and no status changes.
+func (s *orderService) ReplayFailedEvents(ctx context.Context, filter ReplayFilter, dryRun bool) (ReplaySummary, error) {
+	const (
+		batchSize       = 100
+		maxReplayEvents = 10000 // Bounds one call; anything beyond is left for the next replay
+	)
 
-	// Fetch unreplayed events in batches for better memory management
-	events, err := s.orderRepository.GetUnreplayedEvents(ctx, batchSize)
-	if err != nil {
-		s.logger.Exception(ctx, "failed to fetch unreplayed events", err)
-		return fmt.Errorf("failed to fetch unreplayed events: %w", err)
+	summary := ReplaySummary{DryRun: dryRun, ByEventType: make(map[string]int64)}
+	if err := filter.Validate(); err != nil {
+		return summary, err
 	}
+	stored := persistence.EventFilter{From: filter.From, To: filter.To, Statuses: filter.Statuses, EventType: filter.EventType}
 
-	if len(events) == 0 {
+	var after *persistence.OrderEvent
+	for summary.Events < maxReplayEvents {
+		limit := min(batchSize, maxReplayEvents-summary.Events)
+		fetched, err := s.orderRepository.GetUnreplayedEvents(ctx, stored, after, limit)
+		if err != nil {
+			s.logger.Exception(ctx, "failed to fetch unreplayed events", err)
+			return summary, fmt.Errorf("failed to fetch unreplayed events: %w", err)
+		}
+		if len(fetched) == 0 {
+			break
+		}
+		after = &fetched[len(fetched)-1]
+		var batch []persistence.OrderEvent
+		for _, evt := range fetched {
+			eventType := replayEventType(evt)
+			if filter.EventType != "" && eventType != filter.EventType {
+				continue
+			}
+			if _, ok := events.LookupEventType(eventType); !ok {
+				// E.g. a dead-lettered message whose type was not recorded; it stays stored for inspection
+				s.logger.Warn(ctx, fmt.Sprintf("Skipping event %s of order %s: event type %q cannot be routed", evt.ID, evt.OrderID, eventType))
+				summary.Skipped++
+				continue
+			}
+			batch = append(batch, evt)
+			summary.ByEventType[eventType]++
+		}
+		summary.Events += int64(len(batch))
+
+		if !dryRun && len(batch) > 0 {
+			batchSucceeded, batchFailed := s.replayBatch(ctx, batch)
+			summary.Succeeded += batchSucceeded
+			summary.Failed += batchFailed
+		}
+		if ctx.Err() != nil || int64(len(fetched)) < limit {
+			break
+		}
+	}
+
+	if summary.Events == 0 {
 		s.logger.Info(ctx, "No events to replay")
-		return nil
+		return summary, nil
 	}
 
-	s.logger.Info(ctx, fmt.Sprintf("Starting replay of %d failed events", len(events)))
+	if err := ctx.Err(); err != nil {
+		s.logger.Warn(ctx, fmt.Sprintf("Replay interrupted: %d successful, %d failed, %d left for the next replay",
+			summary.Succeeded, summary.Failed, summary.Events-summary.Succeeded-summary.Failed))
+		return summary, fmt.Errorf("replay interrupted: %w", err)
+	}
+	if dryRun {
+		s.logger.Info(ctx, fmt.Sprintf("Replay dry run: %d events would be replayed", summary.Events))
+	} else {
+		s.logger.Info(ctx, fmt.Sprintf("Replay completed: %d successful, %d failed", summary.Succeeded, summary.Failed))
+	}
+	if summary.Events == maxReplayEvents {
+		s.logger.Warn(ctx, fmt.Sprintf("Replay stopped after %d events, the rest is left for the next replay", maxReplayEvents))
+	}
 
-	successCount := 0
-	failureCount := 0
+	if summary.Failed > 0 {
+		return summary, fmt.Errorf("replay completed with %d failures out of %d events", summary.Failed, summary.Events)
+	}
 
-	for _, evt := range events {
-		// Mark event as being replayed for audit trail
-		if err := s.orderRepository.MarkEventAsReplaying(ctx, evt.ID); err != nil {
-			s.logger.Warn(ctx, fmt.Sprintf("Failed to mark event %s as replaying: %v", evt.ID, err))
-		}
+	return summary, nil
+}
 
-		// Attempt to republish with retry logic
-		var pubErr error
-		for attempt := 1; attempt <= maxRetries; attempt++ {
-			// TODO: Should determine correct routing key based on event type instead of hardcoding
-			pubErr = s.rabbitMQService.Publish("order.created", evt.EventData)
-			if pubErr == nil {
-				break
+// replayEventType returns the type a stored event is replayed as: the type it was stored with.
+// Events stored before the type was recorded fall back to the type of their envelope, if any,
+// and otherwise to OrderCreated, the event they were always republished as.
+func replayEventType(evt persistence.OrderEvent) string {
+	if evt.EventType != "" {
+		return evt.EventType
+	}
+	if envelope, ok := events.DecodeEnvelope(evt.EventData); ok {
+		return envelope.EventType
+	}
+	return events.OrderCreated
+}
+
+// replayBatch replays one batch of events with the worker pool and returns how many were
+// completed and how many failed. Events left when ctx is cancelled are counted as neither.
+func (s *orderService) replayBatch(ctx context.Context, batch []persistence.OrderEvent) (int64, int64) {
+	partitions := partitionByOrder(batch)
+	workers := min(max(s.replayWorkers, 1), len(partitions))
+	s.logger.Info(ctx, fmt.Sprintf("Starting replay of %d failed events for %d orders with %d workers", len(batch), len(partitions), workers))
+
+	var successCount, failureCount atomic.Int64
+	queue := make(chan []persistence.OrderEvent)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partition := range queue {
+				for _, evt := range partition {
+					// Once cancelled, the remaining events keep their status for the next replay
+					if ctx.Err() != nil {
+						break
+					}
+					completed, err := s.replayEvent(ctx, evt)
+					if err != nil {
+						failureCount.Add(1)
+					} else if completed {
+						successCount.Add(1)
+					}
+				}
 			}
-			s.logger.Warn(ctx, fmt.Sprintf("Replay publish failed for event %s, attempt %d/%d: %v",
-				evt.ID, attempt, maxRetries, pubErr))
+		}()
+	}
+	for _, partition := range partitions {
+		queue <- partition
+	}
+	close(queue)
+	wg.Wait()
+
+	return successCount.Load(), failureCount.Load()
+}
 
-			// Exponential backoff: 1s, 2s, 3s
-			time.Sleep(time.Duration(attempt) * time.Second)
+// partitionByOrder groups events by order ID, keeping the order of the events within each group
+// and ordering the groups by their first event
+func partitionByOrder(events []persistence.OrderEvent) [][]persistence.OrderEvent {
+	index := make(map[string]int)
+	var partitions [][]persistence.OrderEvent
+	for _, evt := range events {
+		i, ok := index[evt.OrderID]
+		if !ok {
+			i = len(partitions)
+			index[evt.OrderID] = i
+			partitions = append(partitions, nil)
 		}
-		if pubErr == nil {
-			if err := s.orderRepository.MarkEventAsCompleted(ctx, evt.ID); err != nil {
-				s.logger.Warn(ctx, fmt.Sprintf("Failed to mark event %s as completed: %v", evt.ID, err))
-			} else {
-				s.logger.Info(ctx, fmt.Sprintf("Event %s successfully replayed and marked as completed", evt.ID))
-				successCount++
-			}
+		partitions[i] = append(partitions[i], evt)
+	}
+	return partitions
+}
+
+// replayEvent republishes one event with retries and records the outcome on it.
+// It returns the publish error once retries are exhausted, and otherwise reports whether
+// the replayed event was also marked as completed.
+func (s *orderService) replayEvent(ctx context.Context, evt persistence.OrderEvent) (bool, error) {
+	const maxRetries = 3
+
+	// Mark event as being replayed for audit trail
+	if err := s.orderRepository.MarkEventAsReplaying(ctx, evt.ID); err != nil {
+		s.logger.Warn(ctx, fmt.Sprintf("Failed to mark event %s as replaying: %v", evt.ID, err))
+	}
+
+	// Attempt to republish with retry logic; the stored event is already marshaled
+	pubErr := s.eventBus(maxRetries, "replayed event "+evt.ID).Publish(ctx, replayEventType(evt), evt.EventData)
+	if pubErr != nil {
+		if ctx.Err() != nil {
+			// Interrupted during the backoff: put the event back for the next replay,
+			// with a context that outlives the cancellation
+			s.logger.Warn(ctx, fmt.Sprintf("Replay of event %s interrupted: %v", evt.ID, pubErr))
+			ctx = context.WithoutCancel(ctx)
 		} else {
 			s.logger.Exception(ctx, fmt.Sprintf("Replay failed for event %s after %d retries", evt.ID, maxRetries), pubErr)
-			if err := s.orderRepository.MarkEventAsFailed(ctx, evt.ID); err != nil {
-				s.logger.Warn(ctx, fmt.Sprintf("Failed to mark event %s as failed: %v", evt.ID, err))
-			}
-			failureCount++
 		}
+		if err := s.orderRepository.MarkEventAsFailed(ctx, evt.ID); err != nil {
+			s.logger.Warn(ctx, fmt.Sprintf("Failed to mark event %s as failed: %v", evt.ID, err))
+		}
+		return false, pubErr
 	}
 
-	s.logger.Info(ctx, fmt.Sprintf("Replay completed: %d successful, %d failed", successCount, failureCount))
-
-	if failureCount > 0 {
-		return fmt.Errorf("replay completed with %d failures out of %d events", failureCount, len(events))
+	if err := s.orderRepository.MarkEventAsCompleted(ctx, evt.ID); err != nil {
+		s.logger.Warn(ctx, fmt.Sprintf("Failed to mark event %s as completed: %v", evt.ID, err))
+		return false, nil
 	}
-
-	return nil
+	s.logger.Info(ctx, fmt.Sprintf("Event %s successfully replayed and marked as completed", evt.ID))
+	return true, nil
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/money"
 )
 
 // TestOrderService_NewEventSourcingFlow tests the new event sourcing pattern
@@ -13,7 +14,7 @@ func TestOrderService_NewEventSourcingFlow(t *testing.T) {
 		// Test the new flow logic
 		order := Order{
 			ID:     "test-order-123",
-			Amount: 99.99,
+			Money:  money.New(9999, "USD"),
 			Status: "Requested",
 			Product: Product{
 				ID:       "product-1",
@@ -26,10 +27,10 @@ func TestOrderService_NewEventSourcingFlow(t *testing.T) {
 		expectedEvent := events.OrderRequestedEvent{
 			ID:        order.ID,
 			Product:   events.Product{ID: order.Product.ID, Name: order.Product.Name, Quantity: order.Product.Quantity},
-			Amount:    order.Amount,
+			Money:     order.Money,
 			Status:    "Requested",
 			Version:   1,
-			TimeStamp: time.Now().Local(),
+			TimeStamp: time.Now().UTC(),
 		}
 
 		// Test event validation
@@ -88,7 +89,7 @@ func TestOrderService_NewEventSourcingFlow(t *testing.T) {
 				event: events.OrderRequestedEvent{
 					ID:      "valid-order",
 					Product: events.Product{ID: "product-1", Name: "Product", Quantity: 1},
-					Amount:  10.0,
+					Money:   money.New(1000, "USD"),
 					Status:  "Requested",
 					Version: 1,
 				},
@@ -99,40 +100,40 @@ func TestOrderService_NewEventSourcingFlow(t *testing.T) {
 				event: events.OrderRequestedEvent{
 					ID:      "",
 					Product: events.Product{ID: "product-1", Name: "Product", Quantity: 1},
-					Amount:  10.0,
+					Money:   money.New(1000, "USD"),
 				},
 				expectError:   true,
-				errorContains: "missing required fields",
+				errorContains: "validation failed",
 			},
 			{
 				name: "missing product ID",
 				event: events.OrderRequestedEvent{
 					ID:      "order-1",
 					Product: events.Product{ID: "", Name: "Product", Quantity: 1},
-					Amount:  10.0,
+					Money:   money.New(1000, "USD"),
 				},
 				expectError:   true,
-				errorContains: "missing required fields",
+				errorContains: "validation failed",
 			},
 			{
 				name: "zero quantity",
 				event: events.OrderRequestedEvent{
 					ID:      "order-1",
 					Product: events.Product{ID: "product-1", Name: "Product", Quantity: 0},
-					Amount:  10.0,
+					Money:   money.New(1000, "USD"),
 				},
 				expectError:   true,
-				errorContains: "missing required fields",
+				errorContains: "validation failed",
 			},
 			{
 				name: "negative quantity",
 				event: events.OrderRequestedEvent{
 					ID:      "order-1",
 					Product: events.Product{ID: "product-1", Name: "Product", Quantity: -1},
-					Amount:  10.0,
+					Money:   money.New(1000, "USD"),
 				},
 				expectError:   true,
-				errorContains: "missing required fields",
+				errorContains: "validation failed",
 			},
 		}
 
@@ -0,0 +1,973 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/rabbitmq/rabbitmqtest"
+	"go-order-eda/src/infrastructure/retry"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/inventory"
+	"go-order-eda/src/services/money"
+	"go-order-eda/src/services/order/domain/persistence"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakePublisher records published messages per topic
+type fakePublisher struct {
+	messages map[string][][]byte
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, topic string, body []byte) error {
+	if p.messages == nil {
+		p.messages = make(map[string][][]byte)
+	}
+	p.messages[topic] = append(p.messages[topic], body)
+	return nil
+}
+
+// skipWait replaces the backoff between publish retries so tests do not sleep
+func skipWait(ctx context.Context, d time.Duration) error { return nil }
+
+// fakeOrderStore keeps order statuses in memory
+type fakeOrderStore struct {
+	statuses map[string]string
+}
+
+func (f *fakeOrderStore) GetOrderStatus(ctx context.Context, id string) (string, error) {
+	status, ok := f.statuses[id]
+	if !ok {
+		return "", persistence.ErrOrderNotFound
+	}
+	return status, nil
+}
+
+func (f *fakeOrderStore) ArchiveOrder(ctx context.Context, id string) error {
+	if _, ok := f.statuses[id]; !ok {
+		return persistence.ErrOrderNotFound
+	}
+	delete(f.statuses, id) // Archived orders are no longer read
+	return nil
+}
+
+func (f *fakeOrderStore) GetUnreplayedEvents(ctx context.Context, filter persistence.EventFilter, after *persistence.OrderEvent, limit int64) ([]persistence.OrderEvent, error) {
+	return nil, nil
+}
+
+func (f *fakeOrderStore) MarkEventAsReplaying(ctx context.Context, eventID string) error { return nil }
+func (f *fakeOrderStore) MarkEventAsCompleted(ctx context.Context, eventID string) error { return nil }
+func (f *fakeOrderStore) MarkEventAsFailed(ctx context.Context, eventID string) error    { return nil }
+
+func TestOrderService_GetOrderStatus(t *testing.T) {
+	service := &orderService{
+		logger:          log.NewLogger(),
+		orderRepository: &fakeOrderStore{statuses: map[string]string{"order-1": "Confirmed"}},
+	}
+
+	t.Run("returns the status of an existing order", func(t *testing.T) {
+		status, err := service.GetOrderStatus(context.Background(), "order-1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if status != "Confirmed" {
+			t.Errorf("Expected status Confirmed, got %s", status)
+		}
+	})
+
+	t.Run("returns ErrOrderNotFound for an unknown order", func(t *testing.T) {
+		_, err := service.GetOrderStatus(context.Background(), "missing")
+		if !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("Expected ErrOrderNotFound, got %v", err)
+		}
+	})
+
+	t.Run("rejects an empty order ID", func(t *testing.T) {
+		if _, err := service.GetOrderStatus(context.Background(), ""); err == nil {
+			t.Error("Expected an error for an empty order ID")
+		}
+	})
+}
+
+func TestOrderService_CancelOrder(t *testing.T) {
+	newService := func() (*orderService, *fakePublisher) {
+		publisher := &fakePublisher{}
+		return &orderService{
+			logger:          log.NewLogger(),
+			rabbitMQService: publisher,
+			orderRepository: &fakeOrderStore{statuses: map[string]string{
+				"order-confirmed": "Confirmed",
+				"order-cancelled": events.OrderStatusCancelled,
+				"order-completed": events.OrderStatusCompleted,
+			}},
+		}, publisher
+	}
+
+	t.Run("publishes OrderCancelled for an active order", func(t *testing.T) {
+		service, publisher := newService()
+		if err := service.CancelOrder(context.Background(), "order-confirmed"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(publisher.messages[events.OrderCancelled]) != 1 {
+			t.Errorf("Expected one OrderCancelled event, got %d", len(publisher.messages[events.OrderCancelled]))
+		}
+	})
+
+	t.Run("emits the timestamp in UTC", func(t *testing.T) {
+		service, publisher := newService()
+		if err := service.CancelOrder(context.Background(), "order-confirmed"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		body := publisher.messages[events.OrderCancelled][0]
+		var raw struct {
+			TimeStamp string `json:"timestamp"`
+		}
+		if err := json.Unmarshal(body, &raw); err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, raw.TimeStamp)
+		if err != nil {
+			t.Fatalf("Expected an RFC3339 timestamp, got %q: %v", raw.TimeStamp, err)
+		}
+		if !strings.HasSuffix(raw.TimeStamp, "Z") {
+			t.Errorf("Expected a UTC timestamp ending in Z, got %q", raw.TimeStamp)
+		}
+		if _, offset := parsed.Zone(); offset != 0 {
+			t.Errorf("Expected zero UTC offset, got %d", offset)
+		}
+	})
+
+	t.Run("returns ErrOrderNotFound for an unknown order", func(t *testing.T) {
+		service, publisher := newService()
+		if err := service.CancelOrder(context.Background(), "missing"); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("Expected ErrOrderNotFound, got %v", err)
+		}
+		if len(publisher.messages) != 0 {
+			t.Errorf("Expected nothing published, got %v", publisher.messages)
+		}
+	})
+
+	for _, orderID := range []string{"order-cancelled", "order-completed"} {
+		t.Run("returns ErrOrderTerminal for "+orderID, func(t *testing.T) {
+			service, publisher := newService()
+			if err := service.CancelOrder(context.Background(), orderID); !errors.Is(err, ErrOrderTerminal) {
+				t.Errorf("Expected ErrOrderTerminal, got %v", err)
+			}
+			if len(publisher.messages) != 0 {
+				t.Errorf("Expected nothing published, got %v", publisher.messages)
+			}
+		})
+	}
+}
+
+func TestOrderService_ArchiveOrder(t *testing.T) {
+	newService := func() (*orderService, *fakeOrderStore) {
+		store := &fakeOrderStore{statuses: map[string]string{
+			"order-completed":  events.OrderStatusCompleted,
+			"order-cancelled":  events.OrderStatusCancelled,
+			"order-processing": "Processing",
+		}}
+		return &orderService{logger: log.NewLogger(), orderRepository: store}, store
+	}
+
+	for _, orderID := range []string{"order-completed", "order-cancelled"} {
+		t.Run("archives "+orderID, func(t *testing.T) {
+			service, store := newService()
+			if err := service.ArchiveOrder(context.Background(), orderID); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if _, err := store.GetOrderStatus(context.Background(), orderID); !errors.Is(err, ErrOrderNotFound) {
+				t.Errorf("Expected the archived order to be gone, got %v", err)
+			}
+		})
+	}
+
+	t.Run("rejects an order in progress", func(t *testing.T) {
+		service, store := newService()
+		if err := service.ArchiveOrder(context.Background(), "order-processing"); !errors.Is(err, ErrOrderNotTerminal) {
+			t.Errorf("Expected ErrOrderNotTerminal, got %v", err)
+		}
+		if status, err := store.GetOrderStatus(context.Background(), "order-processing"); err != nil || status != "Processing" {
+			t.Errorf("Expected the order to stay, got %q, %v", status, err)
+		}
+	})
+
+	t.Run("returns ErrOrderNotFound for an unknown order", func(t *testing.T) {
+		service, _ := newService()
+		if err := service.ArchiveOrder(context.Background(), "missing"); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("Expected ErrOrderNotFound, got %v", err)
+		}
+	})
+}
+
+func TestOrderService_CreateOrder(t *testing.T) {
+	broker := rabbitmqtest.NewBroker()
+	service := &orderService{
+		logger:          log.NewLogger(),
+		rabbitMQService: broker,
+		orderRepository: &fakeOrderStore{},
+	}
+
+	order := Order{ID: "order-1", Product: Product{ID: "product-1", Name: "Widget", Quantity: 2}, Money: money.New(1998, "EUR")}
+	id, err := service.CreateOrder(context.Background(), order)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if id != "order-1" {
+		t.Errorf("Expected order ID order-1, got %s", id)
+	}
+
+	if messages := broker.Messages(); len(messages) != 1 || messages[0].Topic != events.OrderRequested {
+		t.Fatalf("Expected one message on %s, got %+v", events.OrderRequested, messages)
+	}
+	var published events.OrderRequestedEvent
+	if err := json.Unmarshal(broker.Published(events.OrderRequested)[0], &published); err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	if published.ID != "order-1" || published.Amount != 1998 || published.Currency != "EUR" || published.Status != events.OrderStatusRequested || published.Version != 1 {
+		t.Errorf("Unexpected event: %+v", published)
+	}
+	if published.Product != (events.Product{ID: "product-1", Name: "Widget", Quantity: 2}) {
+		t.Errorf("Unexpected product: %+v", published.Product)
+	}
+
+	t.Run("invalid order publishes nothing", func(t *testing.T) {
+		broker := rabbitmqtest.NewBroker()
+		service.rabbitMQService = broker
+		if _, err := service.CreateOrder(context.Background(), Order{ID: "order-2", Product: Product{ID: "product-1"}, Money: money.New(10, "USD")}); err == nil {
+			t.Error("Expected an error for a zero quantity")
+		}
+		if messages := broker.Messages(); len(messages) != 0 {
+			t.Errorf("Expected nothing published, got %+v", messages)
+		}
+	})
+
+	t.Run("unknown currency publishes nothing", func(t *testing.T) {
+		broker := rabbitmqtest.NewBroker()
+		service.rabbitMQService = broker
+		if _, err := service.CreateOrder(context.Background(), Order{ID: "order-3", Product: Product{ID: "product-1", Quantity: 1}, Money: money.New(10, "XYZ")}); err == nil {
+			t.Error("Expected an error for an unknown currency")
+		}
+		if messages := broker.Messages(); len(messages) != 0 {
+			t.Errorf("Expected nothing published, got %+v", messages)
+		}
+	})
+
+	t.Log("✅ CreateOrder published the OrderRequested payload")
+}
+
+func TestOrderService_PublishRetries(t *testing.T) {
+	errBroker := errors.New("broker unavailable")
+	order := Order{ID: "order-1", Product: Product{ID: "product-1", Quantity: 1}, Money: money.New(5, "USD")}
+
+	newService := func(failures int) (*orderService, *rabbitmqtest.Broker, *[]time.Duration) {
+		broker := rabbitmqtest.NewBroker()
+		broker.FailPublishes(failures, errBroker)
+		var sleeps []time.Duration
+		return &orderService{
+			logger:          log.NewLogger(),
+			rabbitMQService: broker,
+			orderRepository: &fakeOrderStore{statuses: map[string]string{"order-1": "Confirmed"}},
+			backoff: retry.Policy{Base: time.Second, Wait: func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			}},
+		}, broker, &sleeps
+	}
+
+	tests := []struct {
+		name         string
+		failures     int
+		wantErr      bool
+		wantAttempts int
+		wantSleeps   []time.Duration
+	}{
+		{name: "succeeds on the first try", failures: 0, wantAttempts: 1},
+		{name: "succeeds after one retry", failures: 1, wantAttempts: 2, wantSleeps: []time.Duration{time.Second}},
+		{name: "returns the wrapped publish error when retries are exhausted", failures: 2, wantErr: true, wantAttempts: 2, wantSleeps: []time.Duration{time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, broker, sleeps := newService(tt.failures)
+
+			_, err := service.CreateOrder(context.Background(), order)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Expected error=%v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr && !errors.Is(err, errBroker) {
+				t.Errorf("Expected the publish error to be wrapped, got %v", err)
+			}
+			if broker.Attempts() != tt.wantAttempts {
+				t.Errorf("Expected %d publish attempts, got %d", tt.wantAttempts, broker.Attempts())
+			}
+			if published := len(broker.Published(events.OrderRequested)); published != tt.wantAttempts-tt.failures {
+				t.Errorf("Expected %d published messages, got %d", tt.wantAttempts-tt.failures, published)
+			}
+			if !slices.Equal(*sleeps, tt.wantSleeps) {
+				t.Errorf("Expected backoff %v, got %v", tt.wantSleeps, *sleeps)
+			}
+		})
+	}
+
+	t.Run("CancelOrder retries too", func(t *testing.T) {
+		service, broker, sleeps := newService(1)
+		if err := service.CancelOrder(context.Background(), "order-1"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if broker.Attempts() != 2 || len(broker.Published(events.OrderCancelled)) != 1 {
+			t.Errorf("Expected 2 attempts and 1 published message, got %d and %d",
+				broker.Attempts(), len(broker.Published(events.OrderCancelled)))
+		}
+		if len(*sleeps) != 1 {
+			t.Errorf("Expected one backoff, got %v", *sleeps)
+		}
+	})
+
+	t.Log("✅ Publish retry loop behaves")
+}
+
+// failingPublisher fails every publish, like a broker refusing messages
+type failingPublisher struct {
+	err error
+}
+
+func (p failingPublisher) Publish(ctx context.Context, topic string, body []byte) error {
+	return p.err
+}
+
+func TestOrderService_PublishRetriesExhausted(t *testing.T) {
+	errBroker := errors.New("broker unavailable")
+	metrics := NewPublishMetrics()
+	service := &orderService{
+		logger:          log.NewLogger(),
+		rabbitMQService: failingPublisher{err: errBroker},
+		orderRepository: &fakeOrderStore{statuses: map[string]string{"order-1": "Confirmed"}},
+		backoff:         retry.Policy{Wait: skipWait},
+		publishMetrics:  metrics,
+	}
+	order := Order{ID: "order-1", Product: Product{ID: "product-1", Quantity: 1}, Money: money.New(5, "USD")}
+
+	for range 2 {
+		if _, err := service.CreateOrder(context.Background(), order); !errors.Is(err, errBroker) {
+			t.Fatalf("Expected the publish error, got %v", err)
+		}
+	}
+	if err := service.CancelOrder(context.Background(), "order-1"); !errors.Is(err, errBroker) {
+		t.Fatalf("Expected the publish error, got %v", err)
+	}
+
+	want := map[string]int64{events.OrderRequested: 2, events.OrderCancelled: 1}
+	if got := metrics.Snapshot(); !maps.Equal(got, want) {
+		t.Errorf("Expected exhausted publishes %v, got %v", want, got)
+	}
+
+	t.Run("cancellation is not exhaustion", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		metrics := NewPublishMetrics()
+		service := &orderService{
+			logger:          log.NewLogger(),
+			rabbitMQService: &cancellingPublisher{cancel: cancel},
+			backoff:         retry.Policy{Base: time.Minute},
+			publishMetrics:  metrics,
+		}
+		if _, err := service.CreateOrder(ctx, order); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+		if got := metrics.Snapshot(); len(got) != 0 {
+			t.Errorf("Expected nothing counted, got %v", got)
+		}
+	})
+
+	t.Log("✅ Exhausted publish retries counted per event type")
+}
+
+// fakeStockChecker serves product stock from memory
+type fakeStockChecker struct {
+	products map[string]inventory.Product
+	err      error
+}
+
+func (f *fakeStockChecker) GetProductStock(ctx context.Context, productID string) (*inventory.Product, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	product, ok := f.products[productID]
+	if !ok {
+		return nil, nil
+	}
+	return &product, nil
+}
+
+func TestOrderService_StockPrecheck(t *testing.T) {
+	stock := &fakeStockChecker{products: map[string]inventory.Product{
+		"product-1": {ID: "product-1", Quantity: 5, Reserved: 10},
+	}}
+
+	tests := []struct {
+		name        string
+		stock       StockChecker
+		productID   string
+		quantity    int
+		wantErr     error
+		wantPublish bool
+	}{
+		{name: "in-stock order is accepted", stock: stock, productID: "product-1", quantity: 5, wantPublish: true},
+		{name: "over-order is rejected up front", stock: stock, productID: "product-1", quantity: 6, wantErr: ErrInsufficientStock},
+		{name: "unknown product is rejected up front", stock: stock, productID: "product-2", quantity: 1, wantErr: ErrInsufficientStock},
+		{name: "unreadable stock does not block the order", stock: &fakeStockChecker{err: errors.New("mongo timeout")}, productID: "product-1", quantity: 6, wantPublish: true},
+		{name: "without a checker every order is accepted", productID: "product-1", quantity: 100, wantPublish: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakePublisher{}
+			service := &orderService{
+				logger:          log.NewLogger(),
+				rabbitMQService: publisher,
+				backoff:         retry.Policy{Wait: skipWait},
+				stock:           tt.stock,
+			}
+
+			_, err := service.CreateOrder(context.Background(), Order{
+				ID:      "order-1",
+				Money:   money.New(10, "USD"),
+				Product: Product{ID: tt.productID, Quantity: tt.quantity},
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if published := len(publisher.messages[events.OrderRequested]) == 1; published != tt.wantPublish {
+				t.Errorf("Expected published=%v, got %v", tt.wantPublish, published)
+			}
+		})
+	}
+
+	t.Log("✅ Orders exceeding the available stock rejected before publishing")
+}
+
+// replayStore serves stored events for replay and records the status each one ends in
+type replayStore struct {
+	fakeOrderStore
+	events   []persistence.OrderEvent
+	mu       sync.Mutex
+	statuses map[string]string
+	fetches  []int64 // Limit of each GetUnreplayedEvents call
+}
+
+// GetUnreplayedEvents pages through the stored events in order, skipping completed ones and
+// those outside the filter
+func (s *replayStore) GetUnreplayedEvents(ctx context.Context, filter persistence.EventFilter, after *persistence.OrderEvent, limit int64) ([]persistence.OrderEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetches = append(s.fetches, limit)
+
+	start := 0
+	if after != nil {
+		for i, evt := range s.events {
+			if evt.ID == after.ID {
+				start = i + 1
+			}
+		}
+	}
+	var batch []persistence.OrderEvent
+	for _, evt := range s.events[start:] {
+		if int64(len(batch)) == limit {
+			break
+		}
+		status := s.statuses[evt.ID]
+		if status == "" {
+			status = evt.Status
+		}
+		if status == events.EventStatusCompleted ||
+			(len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, status)) ||
+			(!filter.From.IsZero() && evt.CreatedAt.Before(filter.From)) ||
+			(!filter.To.IsZero() && !evt.CreatedAt.Before(filter.To)) ||
+			(filter.EventType != "" && evt.EventType != "" && evt.EventType != filter.EventType) {
+			continue
+		}
+		batch = append(batch, evt)
+	}
+	return batch, nil
+}
+
+func (s *replayStore) mark(eventID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[eventID] = status
+	return nil
+}
+
+func (s *replayStore) MarkEventAsReplaying(ctx context.Context, eventID string) error {
+	return s.mark(eventID, events.EventStatusReplaying)
+}
+
+func (s *replayStore) MarkEventAsCompleted(ctx context.Context, eventID string) error {
+	return s.mark(eventID, events.EventStatusCompleted)
+}
+
+func (s *replayStore) MarkEventAsFailed(ctx context.Context, eventID string) error {
+	return s.mark(eventID, events.EventStatusFailed)
+}
+
+// replayPublisher records replayed bodies in publish order and the topic each was published to,
+// failing those marked as poison, and tracks how many publishes ran at once
+type replayPublisher struct {
+	mu        sync.Mutex
+	published []string
+	topics    map[string]string // Topic by body
+	active    int
+	maxActive int
+}
+
+func (p *replayPublisher) Publish(ctx context.Context, topic string, body []byte) error {
+	p.mu.Lock()
+	p.active++
+	p.maxActive = max(p.maxActive, p.active)
+	p.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active--
+	if strings.HasPrefix(string(body), "poison") {
+		return errors.New("broker rejected the message")
+	}
+	p.published = append(p.published, string(body))
+	if p.topics == nil {
+		p.topics = make(map[string]string)
+	}
+	p.topics[string(body)] = topic
+	return nil
+}
+
+func TestOrderService_ReplayFailedEvents(t *testing.T) {
+	const orders, eventsPerOrder = 8, 5
+
+	var stored []persistence.OrderEvent
+	for seq := 0; seq < eventsPerOrder; seq++ {
+		for order := 0; order < orders; order++ {
+			body := fmt.Sprintf("order-%d/%d", order, seq)
+			if order == 0 && seq == eventsPerOrder-1 {
+				body = "poison/" + body
+			}
+			stored = append(stored, persistence.OrderEvent{
+				ID:        fmt.Sprintf("event-%d-%d", order, seq),
+				OrderID:   fmt.Sprintf("order-%d", order),
+				EventData: []byte(body),
+			})
+		}
+	}
+
+	store := &replayStore{events: stored, statuses: make(map[string]string)}
+	publisher := &replayPublisher{}
+	service := &orderService{
+		logger:          log.NewLogger(),
+		rabbitMQService: publisher,
+		orderRepository: store,
+		backoff:         retry.Policy{Wait: skipWait},
+		replayWorkers:   4,
+	}
+
+	summary, err := service.ReplayFailedEvents(context.Background(), ReplayFilter{}, false)
+	if err == nil || !strings.Contains(err.Error(), "1 failures out of 40 events") {
+		t.Fatalf("Expected one failure out of 40 events, got %v", err)
+	}
+	if summary.Events != 40 || summary.Succeeded != 39 || summary.Failed != 1 {
+		t.Errorf("Expected 39 of 40 events replayed, got %+v", summary)
+	}
+
+	t.Run("every event ends completed or failed", func(t *testing.T) {
+		completed, failed := 0, 0
+		for _, evt := range stored {
+			switch store.statuses[evt.ID] {
+			case events.EventStatusCompleted:
+				completed++
+			case events.EventStatusFailed:
+				failed++
+			default:
+				t.Errorf("Event %s ended %q", evt.ID, store.statuses[evt.ID])
+			}
+		}
+		if completed != orders*eventsPerOrder-1 || failed != 1 {
+			t.Errorf("Expected %d completed and 1 failed, got %d and %d", orders*eventsPerOrder-1, completed, failed)
+		}
+	})
+
+	t.Run("events of the same order keep their order", func(t *testing.T) {
+		next := make(map[string]int)
+		for _, body := range publisher.published {
+			var order string
+			var seq int
+			if _, err := fmt.Sscanf(strings.Replace(body, "/", " ", 1), "%s %d", &order, &seq); err != nil {
+				t.Fatalf("Unexpected body %q", body)
+			}
+			if seq != next[order] {
+				t.Errorf("Expected %s event %d next, got %d", order, next[order], seq)
+			}
+			next[order] = seq + 1
+		}
+	})
+
+	t.Run("orders are replayed in parallel within the bound", func(t *testing.T) {
+		if publisher.maxActive < 2 || publisher.maxActive > 4 {
+			t.Errorf("Expected between 2 and 4 concurrent publishes, got %d", publisher.maxActive)
+		}
+	})
+
+	t.Log("✅ Replay ran in parallel, kept per-order order and counted every outcome")
+}
+
+func TestOrderService_ReplayFailedEventsDrainsBacklog(t *testing.T) {
+	const total = 250
+
+	var stored []persistence.OrderEvent
+	for i := 0; i < total; i++ {
+		body := fmt.Sprintf("order-%d/0", i)
+		if i == 10 {
+			body = "poison/" + body // Stays failed, so it must not be fetched again
+		}
+		stored = append(stored, persistence.OrderEvent{
+			ID:        fmt.Sprintf("event-%d", i),
+			OrderID:   fmt.Sprintf("order-%d", i),
+			EventData: []byte(body),
+		})
+	}
+
+	store := &replayStore{events: stored, statuses: make(map[string]string)}
+	publisher := &replayPublisher{}
+	service := &orderService{
+		logger:          log.NewLogger(),
+		rabbitMQService: publisher,
+		orderRepository: store,
+		backoff:         retry.Policy{Wait: skipWait},
+		replayWorkers:   8,
+	}
+
+	_, err := service.ReplayFailedEvents(context.Background(), ReplayFilter{}, false)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("1 failures out of %d events", total)) {
+		t.Fatalf("Expected one failure out of %d events, got %v", total, err)
+	}
+
+	attempted := 0
+	for _, evt := range stored {
+		if status := store.statuses[evt.ID]; status == events.EventStatusCompleted || status == events.EventStatusFailed {
+			attempted++
+		}
+	}
+	if attempted != total {
+		t.Errorf("Expected all %d events attempted, got %d", total, attempted)
+	}
+	if len(publisher.published) != total-1 {
+		t.Errorf("Expected %d events republished, got %d", total-1, len(publisher.published))
+	}
+	if fmt.Sprint(store.fetches) != "[100 100 100]" {
+		t.Errorf("Expected three batches of up to 100 events, got fetches %v", store.fetches)
+	}
+
+	t.Log("✅ Replay drained the backlog across batches")
+}
+
+func TestOrderService_ReplayFailedEventsDryRun(t *testing.T) {
+	const total = 150
+
+	// Every third event is an order cancellation, every fifth a bare payload stored before event types were recorded
+	var stored []persistence.OrderEvent
+	for i := 0; i < total; i++ {
+		eventType := events.OrderCreated
+		switch {
+		case i%3 == 0:
+			eventType = events.OrderCancelled
+		case i%5 == 0:
+			eventType = ""
+		}
+		stored = append(stored, persistence.OrderEvent{
+			ID:        fmt.Sprintf("event-%d", i),
+			OrderID:   fmt.Sprintf("order-%d", i),
+			EventType: eventType,
+			EventData: []byte(fmt.Sprintf(`{"orderId":"order-%d"}`, i)),
+		})
+	}
+
+	store := &replayStore{events: stored, statuses: make(map[string]string)}
+	publisher := &replayPublisher{}
+	service := &orderService{
+		logger:          log.NewLogger(),
+		rabbitMQService: publisher,
+		orderRepository: store,
+		backoff:         retry.Policy{Wait: skipWait},
+		replayWorkers:   4,
+	}
+
+	summary, err := service.ReplayFailedEvents(context.Background(), ReplayFilter{}, true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+
+	t.Run("events counted by type", func(t *testing.T) {
+		if !summary.DryRun || summary.Events != total || summary.Succeeded != 0 || summary.Failed != 0 {
+			t.Errorf("Expected a dry run over %d events, got %+v", total, summary)
+		}
+		want := map[string]int64{events.OrderCancelled: total / 3, events.OrderCreated: total - total/3}
+		if !maps.Equal(summary.ByEventType, want) {
+			t.Errorf("Expected %v, got %v", want, summary.ByEventType)
+		}
+	})
+
+	t.Run("nothing published and no status changed", func(t *testing.T) {
+		if len(publisher.published) != 0 {
+			t.Errorf("Expected nothing published, got %d events", len(publisher.published))
+		}
+		if len(store.statuses) != 0 {
+			t.Errorf("Expected every status unchanged, got %v", store.statuses)
+		}
+	})
+
+	t.Log("✅ Dry run reported the backlog without replaying it")
+}
+
+func TestOrderService_ReplayFailedEventsFilter(t *testing.T) {
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Event i is stored i minutes after base; odd ones failed, and every third is an order cancellation
+	var stored []persistence.OrderEvent
+	for i := 0; i < 12; i++ {
+		eventType := events.OrderCreated
+		if i%3 == 0 {
+			eventType = events.OrderCancelled
+		}
+		status := events.EventStatusPending
+		if i%2 == 1 {
+			status = events.EventStatusFailed
+		}
+		stored = append(stored, persistence.OrderEvent{
+			ID:        fmt.Sprintf("event-%d", i),
+			OrderID:   fmt.Sprintf("order-%d", i),
+			EventType: eventType,
+			EventData: []byte(fmt.Sprintf(`{"id":"order-%d"}`, i)),
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+			Status:    status,
+		})
+	}
+	newService := func() (*orderService, *replayStore, *replayPublisher) {
+		store := &replayStore{events: stored, statuses: make(map[string]string)}
+		publisher := &replayPublisher{}
+		return &orderService{
+			logger:          log.NewLogger(),
+			rabbitMQService: publisher,
+			orderRepository: store,
+			backoff:         retry.Policy{Wait: skipWait},
+			replayWorkers:   4,
+		}, store, publisher
+	}
+
+	t.Run("only events in the window with the status and type are replayed", func(t *testing.T) {
+		service, store, publisher := newService()
+		filter := ReplayFilter{
+			From:      base.Add(2 * time.Minute),
+			To:        base.Add(8 * time.Minute),
+			Statuses:  []string{events.EventStatusFailed},
+			EventType: events.OrderCreated,
+		}
+
+		summary, err := service.ReplayFailedEvents(context.Background(), filter, false)
+		if err != nil {
+			t.Fatalf("Replay failed: %v", err)
+		}
+		if summary.Events != 2 || summary.Succeeded != 2 || summary.ByEventType[events.OrderCreated] != 2 {
+			t.Errorf("Expected events 5 and 7 replayed, got %+v", summary)
+		}
+		published := slices.Clone(publisher.published)
+		slices.Sort(published)
+		if want := []string{`{"id":"order-5"}`, `{"id":"order-7"}`}; !slices.Equal(published, want) {
+			t.Errorf("Expected %v published, got %v", want, published)
+		}
+		for id := range store.statuses {
+			if id != "event-5" && id != "event-7" {
+				t.Errorf("Expected %s left alone, got %s", id, store.statuses[id])
+			}
+		}
+	})
+
+	t.Run("events republished as the type they were stored with", func(t *testing.T) {
+		service, _, publisher := newService()
+
+		summary, err := service.ReplayFailedEvents(context.Background(), ReplayFilter{EventType: events.OrderCancelled}, false)
+		if err != nil {
+			t.Fatalf("Replay failed: %v", err)
+		}
+		if summary.Events != 4 || summary.ByEventType[events.OrderCancelled] != 4 {
+			t.Errorf("Expected the 4 order cancellations replayed, got %+v", summary)
+		}
+		for _, body := range publisher.published {
+			if topic := publisher.topics[body]; topic != events.OrderCancelled {
+				t.Errorf("Expected %s published to %s, got %s", body, events.OrderCancelled, topic)
+			}
+		}
+	})
+
+	t.Run("invalid filters", func(t *testing.T) {
+		for name, filter := range map[string]ReplayFilter{
+			"range ending before it starts": {From: base.Add(time.Hour), To: base},
+			"empty range":                   {From: base, To: base},
+			"completed status":              {Statuses: []string{events.EventStatusCompleted}},
+			"unknown event type":            {EventType: "order.teleported"},
+		} {
+			service, store, _ := newService()
+			if _, err := service.ReplayFailedEvents(context.Background(), filter, false); !errors.Is(err, ErrInvalidReplayFilter) {
+				t.Errorf("%s: expected ErrInvalidReplayFilter, got %v", name, err)
+			}
+			if len(store.fetches) != 0 {
+				t.Errorf("%s: expected no events read, got %d fetches", name, len(store.fetches))
+			}
+		}
+	})
+
+	t.Log("✅ Replay limited to the filtered events")
+}
+
+func TestOrderService_ReplayFailedEventsSkipsUnroutableTypes(t *testing.T) {
+	stored := []persistence.OrderEvent{
+		{ID: "event-1", OrderID: "order-1", EventType: events.OrderCancelled, EventData: []byte(`{"orderId":"order-1"}`)},
+		{ID: "event-2", OrderID: "order-2", EventType: "dlq.catch_all", EventData: []byte(`{"orderId":"order-2"}`)},
+		{ID: "event-3", OrderID: "order-3", EventType: "legacy.key", EventData: []byte(`{"orderId":"order-3"}`)},
+		{ID: "event-4", OrderID: "order-4", EventData: []byte(`{"id":"order-4"}`)}, // Stored before types were recorded
+	}
+	store := &replayStore{events: stored, statuses: make(map[string]string)}
+	publisher := &replayPublisher{}
+	service := &orderService{
+		logger:          log.NewLogger(),
+		rabbitMQService: publisher,
+		orderRepository: store,
+		backoff:         retry.Policy{Wait: skipWait},
+		replayWorkers:   2,
+	}
+
+	summary, err := service.ReplayFailedEvents(context.Background(), ReplayFilter{}, false)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if summary.Events != 2 || summary.Succeeded != 2 || summary.Skipped != 2 {
+		t.Errorf("Expected 2 events replayed and 2 skipped, got %+v", summary)
+	}
+	want := map[string]string{`{"orderId":"order-1"}`: events.OrderCancelled, `{"id":"order-4"}`: events.OrderCreated}
+	if !maps.Equal(publisher.topics, want) {
+		t.Errorf("Expected %v published, got %v", want, publisher.topics)
+	}
+	for _, id := range []string{"event-2", "event-3"} {
+		if status, ok := store.statuses[id]; ok {
+			t.Errorf("Expected %s left stored unchanged, got %s", id, status)
+		}
+	}
+
+	t.Log("✅ Events of unroutable types skipped, the rest republished to their routing key")
+}
+
+// cancellingPublisher fails every publish and cancels the caller's context on the first one,
+// like a shutdown arriving while the broker is unavailable
+type cancellingPublisher struct {
+	cancel   context.CancelFunc
+	attempts atomic.Int32
+}
+
+func (p *cancellingPublisher) Publish(ctx context.Context, topic string, body []byte) error {
+	p.attempts.Add(1)
+	p.cancel()
+	return errors.New("broker unavailable")
+}
+
+func TestOrderService_RetriesAbortOnCancellation(t *testing.T) {
+	order := Order{ID: "order-1", Product: Product{ID: "product-1", Quantity: 1}, Money: money.New(5, "USD")}
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context, service *orderService) error
+	}{
+		{name: "CreateOrder", call: func(ctx context.Context, service *orderService) error {
+			_, err := service.CreateOrder(ctx, order)
+			return err
+		}},
+		{name: "CancelOrder", call: func(ctx context.Context, service *orderService) error {
+			return service.CancelOrder(ctx, "order-1")
+		}},
+		{name: "ReplayFailedEvents", call: func(ctx context.Context, service *orderService) error {
+			_, err := service.ReplayFailedEvents(ctx, ReplayFilter{}, false)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			publisher := &cancellingPublisher{cancel: cancel}
+			store := &replayStore{
+				fakeOrderStore: fakeOrderStore{statuses: map[string]string{"order-1": "Confirmed"}},
+				events: []persistence.OrderEvent{
+					{ID: "event-1", OrderID: "order-1", EventData: []byte(`{}`)},
+					{ID: "event-2", OrderID: "order-1", EventData: []byte(`{}`)},
+				},
+				statuses: make(map[string]string),
+			}
+			service := &orderService{
+				logger:          log.NewLogger(),
+				rabbitMQService: publisher,
+				orderRepository: store,
+				// A real timer: without cancellation the backoff would outlast the test
+				backoff:       retry.Policy{Base: time.Minute},
+				replayWorkers: 1,
+			}
+
+			start := time.Now()
+			err := tt.call(ctx, service)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Expected an immediate return, took %s", elapsed)
+			}
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+			if attempts := publisher.attempts.Load(); attempts != 1 {
+				t.Errorf("Expected the remaining attempts to be skipped, got %d publishes", attempts)
+			}
+		})
+	}
+
+	t.Run("interrupted replay leaves events for the next replay", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		store := &replayStore{
+			events: []persistence.OrderEvent{
+				{ID: "event-1", OrderID: "order-1", EventData: []byte(`{}`)},
+				{ID: "event-2", OrderID: "order-1", EventData: []byte(`{}`)},
+			},
+			statuses: make(map[string]string),
+		}
+		service := &orderService{
+			logger:          log.NewLogger(),
+			rabbitMQService: &cancellingPublisher{cancel: cancel},
+			orderRepository: store,
+			backoff:         retry.Policy{Base: time.Minute},
+			replayWorkers:   1,
+		}
+
+		service.ReplayFailedEvents(ctx, ReplayFilter{}, false)
+		if status := store.statuses["event-1"]; status != events.EventStatusFailed {
+			t.Errorf("Expected the interrupted event to be failed again, got %q", status)
+		}
+		if status, touched := store.statuses["event-2"]; touched {
+			t.Errorf("Expected the next event to be left alone, got %q", status)
+		}
+	})
+
+	t.Log("✅ Cancellation during the backoff aborted the remaining attempts")
+}
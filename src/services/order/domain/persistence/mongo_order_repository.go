@@ -2,102 +2,432 @@ package persistence
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"go-order-eda/src/config"
+	mongoinfra "go-order-eda/src/infrastructure/mongo"
+	"go-order-eda/src/infrastructure/outbox"
 	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/money"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrOrderNotFound is returned when no order exists for the given ID
+var ErrOrderNotFound = errors.New("order not found")
+
 type OrderRepository struct {
 	collection *mongo.Collection
+	timeout    time.Duration // Upper bound for each database operation
 }
 
 // OrderDocument is the storage model for MongoDB
 type OrderDocument struct {
-	ID        string          `bson:"id"`
-	Amount    float64         `bson:"amount"`
-	Status    string          `bson:"status"`
-	Product   ProductDocument `bson:"product"`
-	CreatedAt time.Time       `bson:"created_at"`
+	ID        string          `bson:"id" json:"id"`
+	Status    string          `bson:"status" json:"status"`
+	Product   ProductDocument `bson:"product" json:"product"`
+	CreatedAt time.Time       `bson:"created_at" json:"createdAt"`
+	// ArchivedAt is set once the order is archived; archived orders are no longer read by ID
+	ArchivedAt *time.Time `bson:"archived_at,omitempty" json:"archivedAt,omitempty"`
+
+	money.Money `bson:",inline"` // Stored as the amount and currency fields
 }
 type ProductDocument struct {
-	ID       string `bson:"id"`
-	Name     string `bson:"name"`
-	Quantity int    `bson:"quantity"`
+	ID       string `bson:"id" json:"id"`
+	Name     string `bson:"name" json:"name"`
+	Quantity int    `bson:"quantity" json:"quantity"`
 }
 
 func NewOrderRepository(cfg *config.Config, client *mongo.Client) *OrderRepository {
 	return &OrderRepository{
 		collection: client.Database(cfg.MongoDBDatabaseName).Collection("orders"),
+		timeout:    cfg.MongoOperationTimeout,
+	}
+}
+
+// errOrderExists aborts the writes of CreateOrderWithOutbox when the order already exists
+var errOrderExists = errors.New("order already exists")
+
+// CreateOrder inserts the order unless one with the same ID exists, and reports whether it was
+// created. An existing order is left as it is, so a redelivered or replayed request neither
+// duplicates it nor resets the status it has progressed to since.
+func (r *OrderRepository) CreateOrder(ctx context.Context, order *OrderDocument) (string, bool, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	doc := newOrderDocument(order)
+	err := r.insertOrderIfAbsent(ctx, doc)
+	if errors.Is(err, errOrderExists) {
+		return doc.ID, false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return doc.ID, true, nil
+}
+
+// insertOrderIfAbsent inserts the order, or returns errOrderExists when one with the same ID exists.
+// A concurrent insert of the same order is caught by the unique index on id.
+func (r *OrderRepository) insertOrderIfAbsent(ctx context.Context, doc OrderDocument) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"id": doc.ID},
+		bson.M{"$setOnInsert": doc},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return errOrderExists
+	}
+	if err != nil {
+		return err
+	}
+	if result.UpsertedCount == 0 {
+		return errOrderExists
+	}
+	return nil
+}
+
+// CreateOrderWithOutbox creates the order and writes the outbox message in a single
+// transaction, so either both writes commit or neither does. On a standalone mongod, where
+// transactions are not available, the writes are applied sequentially and the order is
+// removed again if writing the outbox message fails. It reports whether the order was created:
+// an order that already exists, e.g. for a redelivered request, is left as it is and the
+// outbox message is not written, so its event is not published twice.
+func (r *OrderRepository) CreateOrderWithOutbox(ctx context.Context, order *OrderDocument, message outbox.Message) (string, bool, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	if !json.Valid(message.Payload) {
+		return "", false, errors.New("invalid JSON event data")
+	}
+
+	doc := newOrderDocument(order)
+	outboxColl := r.collection.Database().Collection(outbox.CollectionName)
+	write := func(ctx context.Context) error {
+		if err := r.insertOrderIfAbsent(ctx, doc); err != nil {
+			return err
+		}
+		_, err := outboxColl.InsertOne(ctx, message)
+		return err
+	}
+
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return "", false, err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, write(sc)
+	})
+	switch {
+	case err == nil:
+		return doc.ID, true, nil
+	case errors.Is(err, errOrderExists):
+		return doc.ID, false, nil
+	case !isTransactionNotSupported(err):
+		return "", false, err
+	}
+
+	// Standalone fallback: sequential writes with compensation
+	if err := r.insertOrderIfAbsent(ctx, doc); err != nil {
+		if errors.Is(err, errOrderExists) {
+			return doc.ID, false, nil
+		}
+		return "", false, err
 	}
+	if _, err := outboxColl.InsertOne(ctx, message); err != nil {
+		if _, delErr := r.collection.DeleteOne(ctx, bson.M{"id": doc.ID}); delErr != nil {
+			return "", false, errors.Join(err, delErr)
+		}
+		return "", false, err
+	}
+	return doc.ID, true, nil
+}
+
+// isTransactionNotSupported reports whether the server rejected a transaction because it is
+// not part of a replica set or sharded cluster
+func isTransactionNotSupported(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == 20 { // IllegalOperation
+		return true
+	}
+	return strings.Contains(err.Error(), "Transaction numbers are only allowed")
 }
 
-func (r *OrderRepository) CreateOrder(ctx context.Context, order *OrderDocument) (string, error) {
-	doc := OrderDocument{
+func newOrderDocument(order *OrderDocument) OrderDocument {
+	return OrderDocument{
 		ID:     order.ID, // Fix: Use the provided ID
-		Amount: order.Amount,
+		Money:  order.Money,
 		Status: order.Status,
 		Product: ProductDocument{
 			ID:       order.Product.ID,
 			Name:     order.Product.Name,
 			Quantity: order.Product.Quantity,
 		},
-		CreatedAt: time.Now().Local(), // Use local time
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func (r *OrderRepository) GetOrderByID(ctx context.Context, id string) (*OrderDocument, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	var doc OrderDocument
+	err := r.collection.FindOne(ctx, activeOrderFilter(id)).Decode(&doc)
+	if err != nil {
+		return nil, err
 	}
+	return &doc, nil
+}
 
-	_, err := r.collection.InsertOne(ctx, doc)
+// activeOrderFilter matches the order unless it is archived
+func activeOrderFilter(id string) bson.M {
+	return bson.M{"id": id, "archived_at": bson.M{"$exists": false}}
+}
+
+// GetOrderStatus returns only the status of an order, projecting away the rest of the document.
+// Archived orders are reported as ErrOrderNotFound.
+func (r *OrderRepository) GetOrderStatus(ctx context.Context, id string) (string, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	var doc struct {
+		Status string `bson:"status"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"status": 1})
+	err := r.collection.FindOne(ctx, activeOrderFilter(id), opts).Decode(&doc)
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", ErrOrderNotFound
+		}
 		return "", err
 	}
-	return doc.ID, nil
+	return doc.Status, nil
 }
 
-func (r *OrderRepository) GetOrderByID(ctx context.Context, id string) (*OrderDocument, error) {
-	var doc OrderDocument
-	err := r.collection.FindOne(ctx, bson.M{"id": id}).Decode(&doc)
+// CountByStatus returns the number of orders in each status, leaving out archived orders.
+// Statuses without orders are absent from the result.
+func (r *OrderRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"archived_at": bson.M{"$exists": false}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
-	return &doc, nil
+	var groups []struct {
+		Status string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(groups))
+	for _, group := range groups {
+		counts[group.Status] = group.Count
+	}
+	return counts, nil
+}
+
+// terminalOrderStatuses are the statuses an order no longer leaves
+var terminalOrderStatuses = []string{events.OrderStatusCancelled, events.OrderStatusCompleted, events.OrderStatusFailed}
+
+// GetStuckOrders returns up to limit orders created before the given time that have not reached
+// a terminal status, oldest first. Archived orders are left out.
+func (r *OrderRepository) GetStuckOrders(ctx context.Context, createdBefore time.Time, limit int64) ([]OrderDocument, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{
+		"created_at":  bson.M{"$lt": createdBefore},
+		"status":      bson.M{"$nin": terminalOrderStatuses},
+		"archived_at": bson.M{"$exists": false},
+	}
+	opts := options.Find().SetSort(bson.D{bson.E{Key: "created_at", Value: 1}}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var orders []OrderDocument
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
 }
 
 func (r *OrderRepository) UpdateOrder(ctx context.Context, id string, update bson.M) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
 	_, err := r.collection.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": update})
 	return err
 }
 
-func (r *OrderRepository) CancelOrder(ctx context.Context, id string) error {
-	_, err := r.collection.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": bson.M{"status": "cancelled"}})
-	return err
+// CancelActiveOrder sets the order status to Cancelled unless the order is already in a terminal
+// status, so a cancellation racing a completion does not overwrite it. It reports whether the
+// order was cancelled; an unknown or already terminal order is not.
+func (r *OrderRepository) CancelActiveOrder(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{"id": id, "status": bson.M{"$nin": terminalOrderStatuses}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"status": events.OrderStatusCancelled}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
 }
-func (r *OrderRepository) StoreEventForReplay(ctx context.Context, orderID string, eventData []byte) error {
+
+// UpdateOrderNotification sets the notification fields of an order together with sentAt, unless
+// the order already records a notification sent at or after sentAt. A redelivered or out-of-order
+// NotificationSent event therefore leaves a newer notification in place. An update that sets the
+// status only applies to an order not already in a terminal status, so a confirmation racing a
+// cancellation does not complete a cancelled order. It reports whether the order was updated;
+// an unknown order is not.
+func (r *OrderRepository) UpdateOrderNotification(ctx context.Context, id string, sentAt time.Time, update bson.M) (bool, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	const sentAtField = "notificationSentAt"
+	filter := bson.M{"id": id, "$or": bson.A{
+		bson.M{sentAtField: bson.M{"$exists": false}},
+		bson.M{sentAtField: bson.M{"$lt": sentAt}},
+	}}
+	if _, ok := update["status"]; ok {
+		filter["status"] = bson.M{"$nin": terminalOrderStatuses}
+	}
+	set := bson.M{sentAtField: sentAt}
+	for field, value := range update {
+		set[field] = value
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// ArchiveOrder soft-deletes the order by setting its archived_at time, after which it is no
+// longer returned by GetOrderByID or GetOrderStatus. The document stays in the collection, so
+// its events can still be inspected. Callers archive only orders in a terminal status.
+// It returns ErrOrderNotFound for unknown or already archived orders.
+func (r *OrderRepository) ArchiveOrder(ctx context.Context, id string) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx, activeOrderFilter(id), bson.M{"$set": bson.M{"archived_at": time.Now().UTC()}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrOrderNotFound
+	}
+	return nil
+}
+
+// StoreEventForReplay stores a failed event of the given event type for replay. The same event
+// failing repeatedly updates a single document, identified by a hash of its order ID and content,
+// and increments its attempt count instead of inserting a duplicate.
+func (r *OrderRepository) StoreEventForReplay(ctx context.Context, orderID, eventType string, eventData []byte) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
 	// Validate that eventData is valid JSON
 	if !json.Valid(eventData) {
 		return errors.New("invalid JSON event data")
 	}
 
-	// Create OrderEvent document with proper structure
-	eventDoc := OrderEvent{
-		ID:        primitive.NewObjectID().Hex(), // Generate unique ID
-		OrderID:   orderID,
-		EventData: eventData, // Store as raw JSON bytes
-		CreatedAt: time.Now().Local(),
-		Replayed:  false,                    // Initially not replayed
-		Status:    events.EventStatusFailed, // Mark as failed for DLQ events
+	filter := bson.M{eventFieldContentHash: eventContentHash(orderID, eventData)}
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			eventFieldID:        primitive.NewObjectID().Hex(), // Generate unique ID
+			eventFieldOrderID:   orderID,
+			eventFieldEventData: eventData, // Store as raw JSON bytes
+			eventFieldCreatedAt: time.Now().UTC(),
+		},
+		"$set": bson.M{
+			eventFieldReplayed: false,                    // Failed again, so it needs another replay
+			eventFieldStatus:   events.EventStatusFailed, // Mark as failed for DLQ events
+		},
+		"$inc": bson.M{eventFieldAttempts: 1},
+	}
+	if eventType != "" {
+		update["$set"].(bson.M)[eventFieldEventType] = eventType
 	}
 
-	coll := r.collection.Database().Collection("order_events")
-	_, err := coll.InsertOne(ctx, eventDoc)
+	coll := r.eventCollection()
+	_, err := coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// EnsureIndexes creates the indexes the repository relies on
+func (r *OrderRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true), // One order per ID, however often it is requested
+		},
+		{
+			Keys: bson.D{bson.E{Key: "created_at", Value: 1}}, // Stuck order detection
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	coll := r.eventCollection()
+	_, err = coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: eventFieldContentHash, Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true), // Pending events have no hash
+		},
+		{
+			Keys: bson.D{bson.E{Key: eventFieldStatus, Value: 1}, bson.E{Key: eventFieldCreatedAt, Value: 1}}, // Retention cleanup
+		},
+		{
+			Keys: bson.D{bson.E{Key: eventFieldOrderID, Value: 1}, bson.E{Key: eventFieldCreatedAt, Value: 1}}, // Events of an order
+		},
+	})
 	return err
 }
 
+// MigrateLegacyAmounts converts the float amounts of orders stored before amounts were kept in
+// minor units, and returns how many orders were converted
+func (r *OrderRepository) MigrateLegacyAmounts(ctx context.Context) (int64, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	return mongoinfra.MigrateLegacyAmounts(ctx, r.collection)
+}
+
+// eventContentHash identifies an event by its order ID and raw content
+func eventContentHash(orderID string, eventData []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(orderID))
+	hash.Write([]byte{0})
+	hash.Write(eventData)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
 // StoreEventAsPending stores an event with pending status for tracking
 func (r *OrderRepository) StoreEventAsPending(ctx context.Context, orderID string, eventData []byte) (string, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
 	// Validate that eventData is valid JSON
 	if !json.Valid(eventData) {
 		return "", errors.New("invalid JSON event data")
@@ -108,12 +438,12 @@ func (r *OrderRepository) StoreEventAsPending(ctx context.Context, orderID strin
 		ID:        primitive.NewObjectID().Hex(), // Generate unique ID
 		OrderID:   orderID,
 		EventData: eventData, // Store as raw JSON bytes
-		CreatedAt: time.Now().Local(),
+		CreatedAt: time.Now().UTC(),
 		Replayed:  false,                     // Not yet processed
 		Status:    events.EventStatusPending, // Mark as pending for new events
 	}
 
-	coll := r.collection.Database().Collection("order_events")
+	coll := r.eventCollection()
 	_, err := coll.InsertOne(ctx, eventDoc)
 	if err != nil {
 		return "", err
@@ -123,9 +453,7 @@ func (r *OrderRepository) StoreEventAsPending(ctx context.Context, orderID strin
 
 // UpdateEventData updates the event data with the tracking ID
 func (r *OrderRepository) UpdateEventData(ctx context.Context, eventID string, eventData []byte) error {
-	coll := r.collection.Database().Collection("order_events")
-	_, err := coll.UpdateOne(ctx, bson.M{"_id": eventID}, bson.M{"$set": bson.M{
-		"eventData": eventData,
+	return r.updateEvent(ctx, eventID, bson.M{"$set": bson.M{
+		eventFieldEventData: eventData,
 	}})
-	return err
 }
@@ -0,0 +1,214 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-order-eda/src/config"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/money"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestOrderDocumentJSONKeys verifies the API representation uses the same lowerCamel keys as the events
+func TestOrderDocumentJSONKeys(t *testing.T) {
+	doc := OrderDocument{
+		ID:        "order-1",
+		Money:     money.New(9999, "USD"),
+		Status:    "Confirmed",
+		Product:   ProductDocument{ID: "product-1", Name: "Test Product", Quantity: 2},
+		CreatedAt: time.Now().UTC(),
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("OrderDocument marshaling failed: %v", err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatalf("OrderDocument unmarshaling failed: %v", err)
+	}
+	for _, key := range []string{"id", "amount", "currency", "status", "product", "createdAt"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("Expected JSON key %q in %s", key, body)
+		}
+	}
+
+	product, ok := fields["product"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected product to be an object, got %v", fields["product"])
+	}
+	for _, key := range []string{"id", "name", "quantity"} {
+		if _, ok := product[key]; !ok {
+			t.Errorf("Expected product JSON key %q in %s", key, body)
+		}
+	}
+
+	t.Log("✅ OrderDocument JSON keys verified")
+}
+
+// TestEventContentHash verifies replay deduplication keys on order ID and event content
+func TestEventContentHash(t *testing.T) {
+	event := []byte(`{"id":"order-1","status":"Processing"}`)
+
+	if eventContentHash("order-1", event) != eventContentHash("order-1", event) {
+		t.Error("Expected identical events to hash identically")
+	}
+	if eventContentHash("order-1", event) == eventContentHash("order-2", event) {
+		t.Error("Expected different orders to hash differently")
+	}
+	if eventContentHash("order-1", event) == eventContentHash("order-1", []byte(`{"id":"order-1","status":"Cancelled"}`)) {
+		t.Error("Expected different event content to hash differently")
+	}
+	if eventContentHash("order-1", []byte("2")) == eventContentHash("order-12", []byte("")) {
+		t.Error("Expected order ID and content to be separated in the hash")
+	}
+
+	t.Log("✅ Event content hash verified")
+}
+
+// TestEventIDFilter verifies hex IDs match both string and ObjectID _id values
+func TestEventIDFilter(t *testing.T) {
+	oid := primitive.NewObjectID()
+
+	filter := eventIDFilter(oid.Hex())
+	in, ok := filter["_id"].(bson.M)["$in"].(bson.A)
+	if !ok || len(in) != 2 || in[0] != oid.Hex() || in[1] != oid {
+		t.Errorf("Expected $in filter on string and ObjectID, got %v", filter)
+	}
+
+	filter = eventIDFilter("not-a-hex-id")
+	if filter["_id"] != "not-a-hex-id" {
+		t.Errorf("Expected plain string filter, got %v", filter)
+	}
+
+	t.Log("✅ Event ID filter verified")
+}
+
+// TestRetentionFilters verifies cleanup only matches terminal events created before the cutoff
+func TestRetentionFilters(t *testing.T) {
+	before := time.Now().UTC()
+
+	tests := []struct {
+		name       string
+		filter     bson.M
+		wantStatus string
+	}{
+		{name: "completed events are deleted", filter: completedEventsFilter(before), wantStatus: events.EventStatusCompleted},
+		{name: "failed events are archived", filter: deadEventsFilter(before), wantStatus: events.EventStatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.filter[eventFieldStatus] != tt.wantStatus {
+				t.Errorf("Expected status %q only, got %v", tt.wantStatus, tt.filter[eventFieldStatus])
+			}
+			createdAt, ok := tt.filter[eventFieldCreatedAt].(bson.M)
+			if !ok || createdAt["$lt"] != before {
+				t.Errorf("Expected createdAt before the cutoff, got %v", tt.filter[eventFieldCreatedAt])
+			}
+		})
+	}
+
+	t.Log("✅ Retention filters never match pending or replaying events")
+}
+
+// TestOrderEventSchema verifies the stored document keys match the field names used by filters and updates
+func TestOrderEventSchema(t *testing.T) {
+	now := time.Now().UTC()
+	evt := OrderEvent{
+		ID:          "event-1",
+		OrderID:     "order-1",
+		EventData:   []byte(`{"id":"order-1"}`),
+		CreatedAt:   now,
+		ReplayedAt:  &now,
+		Status:      "failed",
+		ContentHash: "hash",
+		Attempts:    1,
+		ArchivedAt:  &now,
+	}
+
+	raw, err := bson.Marshal(evt)
+	if err != nil {
+		t.Fatalf("OrderEvent marshaling failed: %v", err)
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		t.Fatalf("OrderEvent unmarshaling failed: %v", err)
+	}
+
+	expected := []string{
+		eventFieldID, eventFieldOrderID, eventFieldEventData, eventFieldCreatedAt, eventFieldReplayed,
+		eventFieldReplayedAt, eventFieldStatus, eventFieldContentHash, eventFieldAttempts, eventFieldArchivedAt,
+	}
+	for _, key := range expected {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("Expected bson key %q in stored event, got %v", key, fields)
+		}
+	}
+	if len(fields) != len(expected) {
+		t.Errorf("Expected exactly %d bson keys, got %v", len(expected), fields)
+	}
+
+	t.Log("✅ Order event schema verified")
+}
+
+// newUnreachableRepository returns a repository whose server never answers, so every
+// operation runs until its context ends
+func newUnreachableRepository(t *testing.T, timeout time.Duration) *OrderRepository {
+	t.Helper()
+
+	client, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI("mongodb://127.0.0.1:1").
+		SetServerSelectionTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create MongoDB client: %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+
+	cfg := &config.Config{MongoDBDatabaseName: "test_orders", MongoOperationTimeout: timeout}
+	return NewOrderRepository(cfg, client)
+}
+
+// TestOrderRepository_OperationTimeout verifies repository calls are bounded by the caller's
+// context and by the configured per-operation timeout
+func TestOrderRepository_OperationTimeout(t *testing.T) {
+	t.Run("cancelled context returns promptly", func(t *testing.T) {
+		repo := newUnreachableRepository(t, time.Minute)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		start := time.Now()
+		_, err := repo.GetOrderByID(ctx, "order-1")
+		if err == nil {
+			t.Fatal("Expected an error for a cancelled context")
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected prompt return, took %s", elapsed)
+		}
+	})
+
+	t.Run("operation timeout bounds a slow call", func(t *testing.T) {
+		repo := newUnreachableRepository(t, 50*time.Millisecond)
+
+		start := time.Now()
+		err := repo.MarkEventAsFailed(context.Background(), "event-1")
+		if err == nil {
+			t.Fatal("Expected an error once the operation timeout elapsed")
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Expected the operation timeout to apply, took %s", elapsed)
+		}
+	})
+
+	t.Log("✅ Repository operations are bounded by context and timeout")
+}
@@ -2,33 +2,110 @@ package persistence
 
 import (
 	"context"
+	"errors"
+	mongoinfra "go-order-eda/src/infrastructure/mongo"
 	"go-order-eda/src/services/events"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// order_events document schema. Every filter, update and index on the collection uses these
+// names, and they match the bson tags on OrderEvent.
+const (
+	orderEventsCollection        = "order_events"
+	orderEventsArchiveCollection = "order_events_archive" // Dead events moved out of order_events
+
+	eventFieldID          = "_id"
+	eventFieldOrderID     = "orderId"
+	eventFieldEventType   = "eventType"
+	eventFieldEventData   = "eventData"
+	eventFieldCreatedAt   = "createdAt"
+	eventFieldReplayed    = "replayed"
+	eventFieldReplayedAt  = "replayedAt"
+	eventFieldStatus      = "status"
+	eventFieldContentHash = "contentHash"
+	eventFieldAttempts    = "attempts"
+	eventFieldArchivedAt  = "archivedAt"
+)
+
+// ErrEventNotFound is returned when an update targets an event ID that matches no document
+var ErrEventNotFound = errors.New("order event not found")
+
 type OrderEvent struct {
-	ID         string     `bson:"_id,omitempty"`
-	OrderID    string     `bson:"orderId"`
-	EventData  []byte     `bson:"eventData"`
-	CreatedAt  time.Time  `bson:"createdAt"`
-	Replayed   bool       `bson:"replayed"`
-	ReplayedAt *time.Time `bson:"replayedAt,omitempty"`
-	Status     string     `bson:"status"`
-}
-
-// GetUnreplayedEvents fetches events that have not been replayed yet
-// Events are returned in FIFO order (oldest first) based on createdAt timestamp
-func (r *OrderRepository) GetUnreplayedEvents(ctx context.Context, limit int64) ([]OrderEvent, error) {
-	coll := r.collection.Database().Collection("order_events")
-	filter := bson.M{
-		"replayed": bson.M{"$ne": true},
-		"status":   bson.M{"$in": []string{events.EventStatusPending, events.EventStatusFailed}},
-	}
-	opts := options.Find().SetLimit(limit).SetSort(bson.D{bson.E{Key: "createdAt", Value: 1}}) // 1 = ascending (FIFO)
-	cursor, err := coll.Find(ctx, filter, opts)
+	ID          string     `bson:"_id,omitempty"`
+	OrderID     string     `bson:"orderId"`
+	EventType   string     `bson:"eventType,omitempty"` // Event type the event is replayed as; empty for events stored before it was recorded
+	EventData   []byte     `bson:"eventData"`
+	CreatedAt   time.Time  `bson:"createdAt"`
+	Replayed    bool       `bson:"replayed"`
+	ReplayedAt  *time.Time `bson:"replayedAt,omitempty"`
+	Status      string     `bson:"status"`
+	ContentHash string     `bson:"contentHash,omitempty"` // Set for DLQ events to deduplicate repeated failures
+	Attempts    int        `bson:"attempts"`
+	ArchivedAt  *time.Time `bson:"archivedAt,omitempty"` // Set on events moved to the archive
+}
+
+// eventCollection returns the order_events collection
+func (r *OrderRepository) eventCollection() *mongo.Collection {
+	return r.collection.Database().Collection(orderEventsCollection)
+}
+
+// EventFilter narrows the unreplayed events to those stored within a time window, with given
+// statuses and of a given event type. Zero values do not filter.
+type EventFilter struct {
+	From      time.Time // Earliest createdAt, inclusive
+	To        time.Time // Latest createdAt, exclusive
+	Statuses  []string  // Some of pending and failed
+	EventType string    // Also matches events stored without a type, whose type is only known from their data
+}
+
+// query returns the filter matching the unreplayed events the EventFilter selects
+func (f EventFilter) query() bson.M {
+	query := unreplayedFilter()
+	if len(f.Statuses) > 0 {
+		query[eventFieldStatus] = bson.M{"$in": f.Statuses}
+	}
+	createdAt := bson.M{}
+	if !f.From.IsZero() {
+		createdAt["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		createdAt["$lt"] = f.To
+	}
+	if len(createdAt) > 0 {
+		query[eventFieldCreatedAt] = createdAt
+	}
+	if f.EventType != "" {
+		query[eventFieldEventType] = bson.M{"$in": bson.A{f.EventType, nil}}
+	}
+	return query
+}
+
+// GetUnreplayedEvents fetches events that have not been replayed yet and match the filter
+// Events are returned in FIFO order (oldest first) based on createdAt timestamp, ties broken by ID.
+// With after set, only events that come after it in that order are returned, so the backlog can be
+// read page by page even though events that fail again remain unreplayed.
+func (r *OrderRepository) GetUnreplayedEvents(ctx context.Context, filter EventFilter, after *OrderEvent, limit int64) ([]OrderEvent, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	coll := r.eventCollection()
+	query := filter.query()
+	if after != nil {
+		query["$or"] = bson.A{
+			bson.M{eventFieldCreatedAt: bson.M{"$gt": after.CreatedAt}},
+			bson.M{eventFieldCreatedAt: after.CreatedAt, eventFieldID: bson.M{"$gt": after.ID}},
+		}
+	}
+	opts := options.Find().SetLimit(limit).SetSort(bson.D{
+		bson.E{Key: eventFieldCreatedAt, Value: 1}, // 1 = ascending (FIFO)
+		bson.E{Key: eventFieldID, Value: 1},
+	})
+	cursor, err := coll.Find(ctx, query, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -44,6 +121,80 @@ func (r *OrderRepository) GetUnreplayedEvents(ctx context.Context, limit int64)
 	return events, nil
 }
 
+// GetEventsByOrderID returns every event stored for an order, oldest first, ties broken by ID
+func (r *OrderRepository) GetEventsByOrderID(ctx context.Context, orderID string) ([]OrderEvent, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{
+		bson.E{Key: eventFieldCreatedAt, Value: 1},
+		bson.E{Key: eventFieldID, Value: 1},
+	})
+	cursor, err := r.eventCollection().Find(ctx, bson.M{eventFieldOrderID: orderID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	events := []OrderEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// CountUnreplayedEvents returns the size of the replay backlog, i.e. the events GetUnreplayedEvents would return without a limit
+func (r *OrderRepository) CountUnreplayedEvents(ctx context.Context) (int64, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	return r.eventCollection().CountDocuments(ctx, unreplayedFilter())
+}
+
+// unreplayedFilter matches pending and failed events that have not been replayed yet
+func unreplayedFilter() bson.M {
+	return bson.M{
+		eventFieldReplayed: bson.M{"$ne": true},
+		eventFieldStatus:   bson.M{"$in": []string{events.EventStatusPending, events.EventStatusFailed}},
+	}
+}
+
+// eventIDFilter matches an event by ID. Events are stored with the hex string as _id, but
+// documents written with a native ObjectID _id are matched as well so their status can still change.
+func eventIDFilter(eventID string) bson.M {
+	if oid, err := primitive.ObjectIDFromHex(eventID); err == nil {
+		return bson.M{eventFieldID: bson.M{"$in": bson.A{eventID, oid}}}
+	}
+	return bson.M{eventFieldID: eventID}
+}
+
+// updateEvent applies an update to a single event and reports ErrEventNotFound when nothing matched
+func (r *OrderRepository) updateEvent(ctx context.Context, eventID string, update bson.M) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	coll := r.eventCollection()
+	res, err := coll.UpdateOne(ctx, eventIDFilter(eventID), update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrEventNotFound
+	}
+	return nil
+}
+
+// GetEventByID returns a stored event, or mongo.ErrNoDocuments when it does not exist
+func (r *OrderRepository) GetEventByID(ctx context.Context, eventID string) (*OrderEvent, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	coll := r.eventCollection()
+	var evt OrderEvent
+	if err := coll.FindOne(ctx, eventIDFilter(eventID)).Decode(&evt); err != nil {
+		return nil, err
+	}
+	return &evt, nil
+}
+
 // MarkEventReplayed marks an event as successfully replayed
 // Use this method when replaying events from the order_events collection
 func (r *OrderRepository) MarkEventReplayed(ctx context.Context, eventID string) error {
@@ -52,32 +203,98 @@ func (r *OrderRepository) MarkEventReplayed(ctx context.Context, eventID string)
 
 // MarkEventAsReplaying marks an event as currently being replayed
 func (r *OrderRepository) MarkEventAsReplaying(ctx context.Context, eventID string) error {
-	coll := r.collection.Database().Collection("order_events")
-	_, err := coll.UpdateOne(ctx, bson.M{"_id": eventID}, bson.M{"$set": bson.M{
-		"status": events.EventStatusReplaying,
+	return r.updateEvent(ctx, eventID, bson.M{"$set": bson.M{
+		eventFieldStatus: events.EventStatusReplaying,
 	}})
-	return err
 }
 
 // MarkEventAsCompleted marks an event as successfully completed
 // Use this when an event has been successfully processed (either first time or after replay)
 func (r *OrderRepository) MarkEventAsCompleted(ctx context.Context, eventID string) error {
-	coll := r.collection.Database().Collection("order_events")
-	now := time.Now().Local()
-	_, err := coll.UpdateOne(ctx, bson.M{"_id": eventID}, bson.M{"$set": bson.M{
-		"status":     events.EventStatusCompleted,
-		"replayed":   true,
-		"replayedAt": now,
+	now := time.Now().UTC()
+	return r.updateEvent(ctx, eventID, bson.M{"$set": bson.M{
+		eventFieldStatus:     events.EventStatusCompleted,
+		eventFieldReplayed:   true,
+		eventFieldReplayedAt: now,
 	}})
-	return err
 }
 
 // MarkEventAsFailed marks an event as failed for future replay
 // Use this when event processing fails and should be retried later
 func (r *OrderRepository) MarkEventAsFailed(ctx context.Context, eventID string) error {
-	coll := r.collection.Database().Collection("order_events")
-	_, err := coll.UpdateOne(ctx, bson.M{"_id": eventID}, bson.M{"$set": bson.M{
-		"status": events.EventStatusFailed,
+	return r.updateEvent(ctx, eventID, bson.M{"$set": bson.M{
+		eventFieldStatus: events.EventStatusFailed,
 	}})
-	return err
+}
+
+// completedEventsFilter matches completed events created before the cutoff
+func completedEventsFilter(before time.Time) bson.M {
+	return bson.M{
+		eventFieldStatus:    events.EventStatusCompleted,
+		eventFieldCreatedAt: bson.M{"$lt": before},
+	}
+}
+
+// deadEventsFilter matches failed events created before the cutoff, which were not replayed successfully
+// within the retention window. Pending and replaying events are in flight and never match.
+func deadEventsFilter(before time.Time) bson.M {
+	return bson.M{
+		eventFieldStatus:    events.EventStatusFailed,
+		eventFieldCreatedAt: bson.M{"$lt": before},
+	}
+}
+
+// DeleteCompletedEvents removes completed events created before the cutoff and returns how many were removed
+func (r *OrderRepository) DeleteCompletedEvents(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	res, err := r.eventCollection().DeleteMany(ctx, completedEventsFilter(before))
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// ArchiveDeadEvents moves up to limit failed events created before the cutoff to the archive collection,
+// marked dead, and returns how many were moved. An event that starts being replayed while it is archived
+// stays in order_events and its archive copy is removed again.
+func (r *OrderRepository) ArchiveDeadEvents(ctx context.Context, before time.Time, limit int64) (int, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	opts := options.Find().SetLimit(limit).SetSort(bson.D{bson.E{Key: eventFieldCreatedAt, Value: 1}})
+	cursor, err := r.eventCollection().Find(ctx, deadEventsFilter(before), opts)
+	if err != nil {
+		return 0, err
+	}
+	var dead []OrderEvent
+	if err := cursor.All(ctx, &dead); err != nil {
+		return 0, err
+	}
+
+	archive := r.collection.Database().Collection(orderEventsArchiveCollection)
+	archived := 0
+	for _, evt := range dead {
+		now := time.Now().UTC()
+		evt.Status = events.EventStatusDead
+		evt.ArchivedAt = &now
+		if _, err := archive.ReplaceOne(ctx, bson.M{eventFieldID: evt.ID}, evt, options.Replace().SetUpsert(true)); err != nil {
+			return archived, err
+		}
+
+		res, err := r.eventCollection().DeleteOne(ctx, bson.M{eventFieldID: evt.ID, eventFieldStatus: events.EventStatusFailed})
+		if err != nil {
+			return archived, err
+		}
+		if res.DeletedCount == 0 {
+			// Picked up by a replay in the meantime; it is no longer dead
+			if _, err := archive.DeleteOne(ctx, bson.M{eventFieldID: evt.ID}); err != nil {
+				return archived, err
+			}
+			continue
+		}
+		archived++
+	}
+	return archived, nil
 }
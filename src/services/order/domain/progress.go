@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"go-order-eda/src/services/events"
+	"sync"
+	"time"
+)
+
+// progressBuffer is how many updates a subscriber may fall behind before further updates are dropped.
+// An order goes through a handful of statuses, so a subscriber only misses updates if it stops reading.
+const progressBuffer = 16
+
+// StatusUpdate is one status transition of an order
+type StatusUpdate struct {
+	OrderID string    `json:"orderId"`
+	Status  string    `json:"status"`
+	Event   string    `json:"event,omitempty"` // Event type that caused the transition
+	At      time.Time `json:"at"`
+}
+
+// Progress streams order status transitions to subscribers in this instance, such as clients
+// following an order live. Handlers call Publish as the order's events are consumed.
+// Like Completions it is in-process: transitions handled by another instance are not seen.
+type Progress struct {
+	mu          sync.Mutex
+	subscribers map[string][]chan StatusUpdate
+}
+
+func NewProgress() *Progress {
+	return &Progress{subscribers: make(map[string][]chan StatusUpdate)}
+}
+
+// Subscribe returns a channel receiving the order's status transitions, and a function that
+// must be called to stop receiving them. The channel is closed after a terminal status.
+func (p *Progress) Subscribe(orderID string) (<-chan StatusUpdate, func()) {
+	ch := make(chan StatusUpdate, progressBuffer)
+	p.mu.Lock()
+	p.subscribers[orderID] = append(p.subscribers[orderID], ch)
+	p.mu.Unlock()
+
+	return ch, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		subscribers := p.subscribers[orderID]
+		for i, subscriber := range subscribers {
+			if subscriber == ch {
+				subscribers = append(subscribers[:i], subscribers[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+		if len(subscribers) == 0 {
+			delete(p.subscribers, orderID)
+		} else {
+			p.subscribers[orderID] = subscribers
+		}
+	}
+}
+
+// Publish hands a transition to everyone following the order. A subscriber whose buffer is full
+// misses it rather than blocking the handler. After a terminal status the order's subscriptions
+// are closed, as no further transitions follow.
+func (p *Progress) Publish(update StatusUpdate) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	subscribers := p.subscribers[update.OrderID]
+	for _, ch := range subscribers {
+		select {
+		case ch <- update:
+		default:
+		}
+	}
+	if events.IsTerminalOrderStatus(update.Status) {
+		for _, ch := range subscribers {
+			close(ch)
+		}
+		delete(p.subscribers, update.OrderID)
+	}
+}
+
+// Subscribers returns how many subscribers follow the order
+func (p *Progress) Subscribers(orderID string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.subscribers[orderID])
+}
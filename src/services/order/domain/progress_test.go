@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"go-order-eda/src/services/events"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	t.Run("subscribers receive transitions until a terminal status", func(t *testing.T) {
+		progress := NewProgress()
+		updates, unsubscribe := progress.Subscribe("order-1")
+		defer unsubscribe()
+		other, unsubscribeOther := progress.Subscribe("order-2")
+		defer unsubscribeOther()
+
+		for _, status := range []string{events.OrderStatusCreated, events.OrderStatusConfirmed, events.OrderStatusCompleted} {
+			progress.Publish(StatusUpdate{OrderID: "order-1", Status: status})
+		}
+
+		var received []string
+		for update := range updates {
+			received = append(received, update.Status)
+		}
+		if len(received) != 3 || received[2] != events.OrderStatusCompleted {
+			t.Errorf("Expected Created, Confirmed, Completed, got %v", received)
+		}
+		if n := progress.Subscribers("order-1"); n != 0 {
+			t.Errorf("Expected the subscription removed after the terminal status, got %d", n)
+		}
+		if len(other) != 0 {
+			t.Errorf("Expected no updates for another order, got %d", len(other))
+		}
+	})
+
+	t.Run("unsubscribe closes the subscription", func(t *testing.T) {
+		progress := NewProgress()
+		updates, unsubscribe := progress.Subscribe("order-1")
+		unsubscribe()
+		unsubscribe() // Safe to call again, e.g. after a terminal status
+
+		if _, ok := <-updates; ok {
+			t.Error("Expected the channel to be closed")
+		}
+		if n := progress.Subscribers("order-1"); n != 0 {
+			t.Errorf("Expected no subscribers, got %d", n)
+		}
+		progress.Publish(StatusUpdate{OrderID: "order-1", Status: events.OrderStatusCancelled})
+	})
+
+	t.Run("a subscriber that stops reading does not block publishing", func(t *testing.T) {
+		progress := NewProgress()
+		_, unsubscribe := progress.Subscribe("order-1")
+		defer unsubscribe()
+
+		for i := 0; i < progressBuffer*2; i++ {
+			progress.Publish(StatusUpdate{OrderID: "order-1", Status: events.OrderStatusConfirmed})
+		}
+	})
+
+	t.Log("✅ Progress delivered transitions and cleaned up subscriptions")
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "sync"
+
+// PublishMetrics counts, per event type, the publishes that failed on every retry. Any count
+// means the broker refused events the service could not hand over, so it is worth alerting on.
+type PublishMetrics struct {
+	mu        sync.Mutex
+	exhausted map[string]int64
+}
+
+func NewPublishMetrics() *PublishMetrics {
+	return &PublishMetrics{exhausted: make(map[string]int64)}
+}
+
+// observeExhausted counts a publish of the event type that ran out of retries.
+// It does nothing on a nil receiver, so the service runs without metrics.
+func (m *PublishMetrics) observeExhausted(eventType string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exhausted[eventType]++
+}
+
+// Snapshot returns the exhausted publishes keyed by event type
+func (m *PublishMetrics) Snapshot() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make(map[string]int64, len(m.exhausted))
+	for eventType, count := range m.exhausted {
+		snapshot[eventType] = count
+	}
+	return snapshot
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/order/domain/persistence"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// stuckOrderStore is the part of the order repository the stuck order detector needs.
+// It is satisfied by *persistence.OrderRepository.
+type stuckOrderStore interface {
+	GetStuckOrders(ctx context.Context, createdBefore time.Time, limit int64) ([]persistence.OrderDocument, error)
+}
+
+// StuckOrder is an order that did not reach a terminal status in time
+type StuckOrder struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// StuckOrders is the outcome of the latest check for stuck orders
+type StuckOrders struct {
+	Count     int          `json:"count"`            // Stuck orders found, at most the batch size
+	Orders    []StuckOrder `json:"orders,omitempty"` // Oldest first
+	CheckedAt time.Time    `json:"checkedAt"`
+}
+
+// StuckOrderDetector warns about orders still in a non-terminal status such as Processing long
+// after they were created, e.g. because an event of their chain was lost, so operators notice
+// before customers do. The latest result is reported by the status endpoint.
+type StuckOrderDetector struct {
+	store     stuckOrderStore
+	logger    log.Logger
+	maxAge    time.Duration
+	interval  time.Duration
+	batchSize int64
+	now       func() time.Time // Clock; tests replace it
+	mu        sync.Mutex
+	latest    StuckOrders
+	lastRun   atomic.Int64 // Unix nanoseconds of the last successful check
+}
+
+func NewStuckOrderDetector(store stuckOrderStore, logger log.Logger, maxAge, interval time.Duration, batchSize int64) *StuckOrderDetector {
+	return &StuckOrderDetector{
+		store:     store,
+		logger:    logger,
+		maxAge:    maxAge,
+		interval:  interval,
+		batchSize: batchSize,
+		now:       time.Now,
+	}
+}
+
+// Run checks for stuck orders every interval until the context is cancelled
+func (d *StuckOrderDetector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	d.logger.Info(ctx, "Stuck order detector started")
+	for {
+		if _, err := d.Check(ctx); err != nil {
+			d.logger.Exception(ctx, "Stuck order check failed", err)
+		} else {
+			d.lastRun.Store(time.Now().UTC().UnixNano())
+		}
+
+		select {
+		case <-ctx.Done():
+			d.logger.Info(ctx, "Stuck order detector stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check finds the orders created more than the maximum age ago that have not reached a terminal
+// status, logs a warning for each and keeps the result for Snapshot
+func (d *StuckOrderDetector) Check(ctx context.Context) (StuckOrders, error) {
+	now := d.now().UTC()
+	orders, err := d.store.GetStuckOrders(ctx, now.Add(-d.maxAge), d.batchSize)
+	if err != nil {
+		return StuckOrders{}, fmt.Errorf("failed to query stuck orders: %w", err)
+	}
+
+	result := StuckOrders{Count: len(orders), CheckedAt: now}
+	for _, order := range orders {
+		result.Orders = append(result.Orders, StuckOrder{ID: order.ID, Status: order.Status, CreatedAt: order.CreatedAt})
+		d.logger.WarnWithExtra(ctx, fmt.Sprintf("Order %s stuck in status %s", order.ID, order.Status), map[string]any{
+			"orderId": order.ID,
+			"status":  order.Status,
+			"age":     now.Sub(order.CreatedAt).Round(time.Second).String(),
+		})
+	}
+
+	d.mu.Lock()
+	d.latest = result
+	d.mu.Unlock()
+	return result, nil
+}
+
+// Snapshot returns the result of the latest check
+func (d *StuckOrderDetector) Snapshot() StuckOrders {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.latest
+}
+
+// Probe reports the latest check to the status endpoint, failing while orders are stuck
+func (d *StuckOrderDetector) Probe(ctx context.Context) (any, error) {
+	stuck := d.Snapshot()
+	if stuck.Count > 0 {
+		return stuck, fmt.Errorf("%d orders have not completed within %s", stuck.Count, d.maxAge)
+	}
+	return stuck, nil
+}
+
+// LastRun returns when the detector last completed a check, or the zero time if it has not yet
+func (d *StuckOrderDetector) LastRun() time.Time {
+	if nanos := d.lastRun.Load(); nanos != 0 {
+		return time.Unix(0, nanos).UTC()
+	}
+	return time.Time{}
+}
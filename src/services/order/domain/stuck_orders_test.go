@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/order/domain/persistence"
+)
+
+// fakeStuckOrderStore keeps orders in memory and applies the repository's stuck order rules
+type fakeStuckOrderStore struct {
+	orders []persistence.OrderDocument
+	err    error
+}
+
+func (s *fakeStuckOrderStore) GetStuckOrders(ctx context.Context, createdBefore time.Time, limit int64) ([]persistence.OrderDocument, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var stuck []persistence.OrderDocument
+	for _, order := range s.orders {
+		if order.CreatedAt.Before(createdBefore) && !events.IsTerminalOrderStatus(order.Status) && order.ArchivedAt == nil {
+			stuck = append(stuck, order)
+		}
+	}
+	slices.SortFunc(stuck, func(a, b persistence.OrderDocument) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return stuck[:min(int64(len(stuck)), limit)], nil
+}
+
+func TestStuckOrderDetector_Check(t *testing.T) {
+	const maxAge = 30 * time.Minute
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	archived := now.Add(-time.Hour)
+
+	store := &fakeStuckOrderStore{orders: []persistence.OrderDocument{
+		{ID: "old-processing", Status: "Processing", CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "old-confirmed", Status: events.OrderStatusConfirmed, CreatedAt: now.Add(-31 * time.Minute)},
+		{ID: "recent-processing", Status: "Processing", CreatedAt: now.Add(-29 * time.Minute)},
+		{ID: "old-completed", Status: events.OrderStatusCompleted, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "old-cancelled", Status: events.OrderStatusCancelled, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "old-failed", Status: events.OrderStatusFailed, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "old-archived", Status: "Processing", CreatedAt: now.Add(-2 * time.Hour), ArchivedAt: &archived},
+	}}
+	detector := NewStuckOrderDetector(store, log.NewLogger(), maxAge, time.Minute, 100)
+	detector.now = func() time.Time { return now }
+
+	t.Run("only old non-terminal orders flagged", func(t *testing.T) {
+		stuck, err := detector.Check(context.Background())
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		var ids []string
+		for _, order := range stuck.Orders {
+			ids = append(ids, order.ID)
+		}
+		if want := []string{"old-processing", "old-confirmed"}; stuck.Count != 2 || !slices.Equal(ids, want) {
+			t.Errorf("Expected %v flagged, got %d: %v", want, stuck.Count, ids)
+		}
+		if !stuck.CheckedAt.Equal(now) {
+			t.Errorf("Expected the check at %s, got %s", now, stuck.CheckedAt)
+		}
+		if _, err := detector.Probe(context.Background()); err == nil {
+			t.Error("Expected the probe to fail while orders are stuck")
+		}
+	})
+
+	t.Run("nothing flagged before orders are old enough", func(t *testing.T) {
+		detector.now = func() time.Time { return now.Add(-90 * time.Minute) }
+		defer func() { detector.now = func() time.Time { return now } }()
+		stuck, err := detector.Check(context.Background())
+		if err != nil || stuck.Count != 0 {
+			t.Fatalf("Expected no stuck orders, got %+v and %v", stuck, err)
+		}
+		if _, err := detector.Probe(context.Background()); err != nil {
+			t.Errorf("Expected the probe to pass, got %v", err)
+		}
+	})
+
+	t.Run("store failure keeps the previous result", func(t *testing.T) {
+		failing := NewStuckOrderDetector(&fakeStuckOrderStore{err: errors.New("mongo unavailable")}, log.NewLogger(), maxAge, time.Minute, 100)
+		if _, err := failing.Check(context.Background()); err == nil {
+			t.Error("Expected the store failure returned")
+		}
+		if stuck := failing.Snapshot(); stuck.Count != 0 || !stuck.CheckedAt.IsZero() {
+			t.Errorf("Expected no result, got %+v", stuck)
+		}
+	})
+
+	t.Log("✅ Orders stuck past the maximum age flagged")
+}
@@ -3,13 +3,22 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"go-order-eda/src/infrastructure"
 	"go-order-eda/src/infrastructure/log"
 	"go-order-eda/src/services/events"
 	"go-order-eda/src/services/order/domain/persistence"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
 )
 
+// orderUpdater sets the notification fields of a stored order. It is satisfied by *persistence.OrderRepository.
+type orderUpdater interface {
+	UpdateOrderNotification(ctx context.Context, id string, sentAt time.Time, update bson.M) (bool, error)
+}
+
 type NotificationSentEventHandler struct {
-	orderRepository *persistence.OrderRepository
+	orderRepository orderUpdater
 	logger          log.Logger
 }
 
@@ -23,25 +32,40 @@ func NewNotificationSentEventHandler(
 	}
 }
 
-// Handle processes the NotificationSentEvent message
-func (h *NotificationSentEventHandler) Handle(ctx context.Context, msgBody []byte) {
+// Handle processes the NotificationSentEvent message. The order keeps the notification with the latest
+// timestamp, so a duplicate or an older event delivered late does not overwrite it.
+func (h *NotificationSentEventHandler) Handle(ctx context.Context, msgBody []byte) error {
 	var event events.NotificationSentEvent
 	if err := json.Unmarshal(msgBody, &event); err != nil {
 		h.logger.Exception(ctx, "Failed to unmarshal NotificationSentEvent", err)
-		return
+		return infrastructure.Permanent(err)
 	}
 
-	// Update order with notification status
-	update := map[string]interface{}{
+	// Update order with notification status; the confirmation is the last step, so it completes the order.
+	// A cancellation notification follows the OrderCancelled event, which sets the status itself.
+	update := bson.M{
 		"notificationStatus":  "sent",
 		"notificationMessage": event.Message,
 	}
+	if event.Confirms() {
+		update["status"] = events.OrderStatusCompleted
+	}
+	// Events published before the channels were reported carry neither
+	if len(event.Channels) > 0 {
+		update["notificationChannels"] = event.Channels
+		update["notificationChannelStatus"] = event.Status
+	}
 
-	err := h.orderRepository.UpdateOrder(ctx, event.OrderID, update)
+	updated, err := h.orderRepository.UpdateOrderNotification(ctx, event.OrderID, event.TimeStamp, update)
 	if err != nil {
 		h.logger.Exception(ctx, "Failed to update order with notification status", err)
-		return
+		return infrastructure.Transient(err)
+	}
+	if !updated {
+		h.logger.Info(ctx, "Notification skipped, the order is unknown, already terminal or records this or a newer notification: "+event.OrderID)
+		return nil
 	}
 
 	h.logger.Info(ctx, "Order updated with notification status for order: "+event.OrderID)
+	return nil
 }
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/events"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// fakeOrderUpdater applies notification updates to orders kept in memory, like the repository
+// skipping those not newer than the notification an order records
+type fakeOrderUpdater struct {
+	orders map[string]bson.M
+}
+
+func (f *fakeOrderUpdater) UpdateOrderNotification(ctx context.Context, id string, sentAt time.Time, update bson.M) (bool, error) {
+	if f.orders[id] == nil {
+		f.orders[id] = bson.M{}
+	}
+	if recorded, ok := f.orders[id]["notificationSentAt"].(time.Time); ok && !recorded.Before(sentAt) {
+		return false, nil
+	}
+	f.orders[id]["notificationSentAt"] = sentAt
+	for field, value := range update {
+		f.orders[id][field] = value
+	}
+	return true, nil
+}
+
+func TestNotificationSentEventHandler_PersistsChannels(t *testing.T) {
+	handle := func(t *testing.T, event events.NotificationSentEvent) bson.M {
+		t.Helper()
+		orders := &fakeOrderUpdater{orders: make(map[string]bson.M)}
+		handler := &NotificationSentEventHandler{orderRepository: orders, logger: log.NewLogger()}
+		body, _ := json.Marshal(event)
+		if err := handler.Handle(context.Background(), body); err != nil {
+			t.Fatalf("Handle failed: %v", err)
+		}
+		return orders.orders[event.OrderID]
+	}
+
+	t.Run("channels and their outcome", func(t *testing.T) {
+		order := handle(t, events.NotificationSentEvent{
+			OrderID:   "order-1",
+			ProductID: "product-1",
+			Message:   "Order confirmed for product: product-1",
+			Channels:  []string{"email", "push"},
+			Status:    map[string]string{"email": events.NotificationFailed, "push": events.NotificationDelivered},
+			Version:   1,
+			TimeStamp: time.Now().UTC(),
+		})
+
+		if order["status"] != events.OrderStatusCompleted || order["notificationMessage"] != "Order confirmed for product: product-1" {
+			t.Errorf("Expected the order completed with the notification message, got %v", order)
+		}
+		if got := order["notificationChannels"]; !reflect.DeepEqual(got, []string{"email", "push"}) {
+			t.Errorf("Expected channels [email push], got %v", got)
+		}
+		want := map[string]string{"email": events.NotificationFailed, "push": events.NotificationDelivered}
+		if got := order["notificationChannelStatus"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected channel outcomes %v, got %v", want, got)
+		}
+	})
+
+	t.Run("event without channels", func(t *testing.T) {
+		order := handle(t, events.NotificationSentEvent{OrderID: "order-2", Message: "Order confirmed", Version: 1})
+
+		if order["status"] != events.OrderStatusCompleted {
+			t.Errorf("Expected the order completed, got %v", order)
+		}
+		if _, ok := order["notificationChannels"]; ok {
+			t.Errorf("Expected no channels recorded, got %v", order)
+		}
+	})
+
+	t.Run("cancellation notification", func(t *testing.T) {
+		order := handle(t, events.NotificationSentEvent{
+			OrderID:     "order-3",
+			Message:     "Order cancelled due to insufficient stock for product: product-1",
+			MessageType: events.NotificationCancellation,
+			Version:     1,
+		})
+
+		if _, ok := order["status"]; ok {
+			t.Errorf("Expected the status left to the cancellation, got %v", order["status"])
+		}
+		if order["notificationMessage"] != "Order cancelled due to insufficient stock for product: product-1" {
+			t.Errorf("Expected the notification recorded, got %v", order)
+		}
+	})
+
+	t.Run("legacy cancellation without a message type", func(t *testing.T) {
+		order := handle(t, events.NotificationSentEvent{OrderID: "order-4", Message: "Order cancelled due to insufficient stock for product: product-1", Version: 1})
+
+		if _, ok := order["status"]; ok {
+			t.Errorf("Expected the status left to the cancellation, got %v", order["status"])
+		}
+	})
+
+	t.Log("✅ Order records the notification channels and their outcome")
+}
+
+func TestNotificationSentEventHandler_KeepsLatestNotification(t *testing.T) {
+	sentAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	earlier := events.NotificationSentEvent{
+		OrderID:   "order-1",
+		Message:   "Order confirmed",
+		Channels:  []string{"email"},
+		Status:    map[string]string{"email": events.NotificationFailed},
+		Version:   1,
+		TimeStamp: sentAt,
+	}
+	later := events.NotificationSentEvent{
+		OrderID:   "order-1",
+		Message:   "Order confirmed",
+		Channels:  []string{"email"},
+		Status:    map[string]string{"email": events.NotificationDelivered},
+		Version:   1,
+		TimeStamp: sentAt.Add(time.Minute),
+	}
+	// deliver handles the events in turn and returns the order's notification outcome per channel
+	deliver := func(t *testing.T, deliveries ...events.NotificationSentEvent) any {
+		t.Helper()
+		orders := &fakeOrderUpdater{orders: make(map[string]bson.M)}
+		handler := &NotificationSentEventHandler{orderRepository: orders, logger: log.NewLogger()}
+		for _, event := range deliveries {
+			body, _ := json.Marshal(event)
+			if err := handler.Handle(context.Background(), body); err != nil {
+				t.Fatalf("Handle failed: %v", err)
+			}
+		}
+		return orders.orders["order-1"]["notificationChannelStatus"]
+	}
+	delivered := map[string]string{"email": events.NotificationDelivered}
+
+	t.Run("in order", func(t *testing.T) {
+		if got := deliver(t, earlier, later); !reflect.DeepEqual(got, delivered) {
+			t.Errorf("Expected %v, got %v", delivered, got)
+		}
+	})
+
+	t.Run("older event delivered late", func(t *testing.T) {
+		if got := deliver(t, later, earlier); !reflect.DeepEqual(got, delivered) {
+			t.Errorf("Expected the newer outcome %v kept, got %v", delivered, got)
+		}
+	})
+
+	t.Run("duplicate after a newer event", func(t *testing.T) {
+		if got := deliver(t, earlier, later, later, earlier); !reflect.DeepEqual(got, delivered) {
+			t.Errorf("Expected the newer outcome %v kept, got %v", delivered, got)
+		}
+	})
+
+	t.Log("✅ Duplicate and late notifications do not regress the order")
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/order/domain"
+)
+
+// OrderCompletionEventHandler signals requests waiting in CreateOrderAndWait when an event
+// settles their order. It runs next to the event's own handler and never fails the message:
+// a missed signal only means the waiting request finds the status by polling.
+type OrderCompletionEventHandler struct {
+	eventType   string
+	completions *domain.Completions
+}
+
+// CompletionEventTypes lists the events that settle an order
+var CompletionEventTypes = []string{
+	events.InventoryStatusUpdated,
+	events.NotificationSent,
+	events.OrderCancelled,
+}
+
+func NewOrderCompletionEventHandler(eventType string, completions *domain.Completions) *OrderCompletionEventHandler {
+	return &OrderCompletionEventHandler{
+		eventType:   eventType,
+		completions: completions,
+	}
+}
+
+// Handle completes the order the event settles
+func (h *OrderCompletionEventHandler) Handle(ctx context.Context, msgBody []byte) error {
+	orderID, status := orderStatusAfter(h.eventType, msgBody)
+	if orderID != "" && domain.IsSettledOrderStatus(status) {
+		h.completions.Complete(orderID, status)
+	}
+	return nil
+}
+
+// orderStatusAfter returns the order an event is about and the status the event moves it to,
+// or "" if it does not change the order's status
+func orderStatusAfter(eventType string, msgBody []byte) (string, string) {
+	switch eventType {
+	case events.OrderCreated:
+		var event events.OrderCreatedEvent
+		if json.Unmarshal(msgBody, &event) == nil {
+			return event.ID, events.OrderStatusCreated
+		}
+	case events.InventoryStatusUpdated:
+		// Published after the order is stored as confirmed; without stock a cancellation follows
+		var event events.InventoryStatusUpdatedEvent
+		if json.Unmarshal(msgBody, &event) == nil && event.HasStock {
+			return event.OrderID, events.OrderStatusConfirmed
+		}
+	case events.NotificationSent:
+		// Only a confirmation completes the order; a cancellation notification follows OrderCancelled
+		var event events.NotificationSentEvent
+		if json.Unmarshal(msgBody, &event) == nil && event.Confirms() {
+			return event.OrderID, events.OrderStatusCompleted
+		}
+	case events.OrderCancelled:
+		var event events.OrderCancelledEvent
+		if json.Unmarshal(msgBody, &event) == nil {
+			return event.OrderID, events.OrderStatusCancelled
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/order/domain"
+	"time"
+)
+
+// OrderProgressEventHandler publishes an order's status transitions to clients following it live.
+// It runs next to the event's own handler and never fails the message: a missed transition
+// only means a client sees the next one, or the status it reads on reconnecting.
+type OrderProgressEventHandler struct {
+	eventType string
+	progress  *domain.Progress
+}
+
+// ProgressEventTypes lists the events that move an order to a new status
+var ProgressEventTypes = []string{
+	events.OrderCreated,
+	events.InventoryStatusUpdated,
+	events.NotificationSent,
+	events.OrderCancelled,
+}
+
+func NewOrderProgressEventHandler(eventType string, progress *domain.Progress) *OrderProgressEventHandler {
+	return &OrderProgressEventHandler{
+		eventType: eventType,
+		progress:  progress,
+	}
+}
+
+// Handle publishes the status the event moves its order to
+func (h *OrderProgressEventHandler) Handle(ctx context.Context, msgBody []byte) error {
+	orderID, status := orderStatusAfter(h.eventType, msgBody)
+	if orderID != "" && status != "" {
+		h.progress.Publish(domain.StatusUpdate{OrderID: orderID, Status: status, Event: h.eventType, At: time.Now().UTC()})
+	}
+	return nil
+}
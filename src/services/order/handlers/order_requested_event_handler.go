@@ -3,53 +3,72 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"go-order-eda/src/infrastructure"
 	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/outbox"
 	"go-order-eda/src/infrastructure/rabbitmq"
 	"go-order-eda/src/services/events"
 	"go-order-eda/src/services/order/domain/persistence"
 	"time"
 )
 
+// requestedOrderStore creates the requested order. It is satisfied by *persistence.OrderRepository.
+type requestedOrderStore interface {
+	GetOrderStatus(ctx context.Context, id string) (string, error)
+	CreateOrderWithOutbox(ctx context.Context, order *persistence.OrderDocument, message outbox.Message) (string, bool, error)
+}
+
 type OrderRequestedEventHandler struct {
 	logger          log.Logger
-	rabbitMQService *rabbitmq.RabbitMQServiceImpl
-	orderRepository *persistence.OrderRepository
+	orderRepository requestedOrderStore
 }
 
 func NewOrderRequestedEventHandler(
 	logger log.Logger,
-	rabbitMQService *rabbitmq.RabbitMQServiceImpl,
 	orderRepository *persistence.OrderRepository,
 ) *OrderRequestedEventHandler {
 	return &OrderRequestedEventHandler{
 		logger:          logger,
-		rabbitMQService: rabbitMQService,
 		orderRepository: orderRepository,
 	}
 }
 
-func (h *OrderRequestedEventHandler) Handle(ctx context.Context, eventData []byte) {
+// Handle creates the order for an OrderRequested event. A redelivered request for an order
+// that was already created is acknowledged without creating it twice or queueing its
+// OrderCreated event again, even when both deliveries are handled at the same time.
+func (h *OrderRequestedEventHandler) Handle(ctx context.Context, eventData []byte) error {
 	h.logger.Info(ctx, "Processing OrderRequested event")
 
 	var orderRequestedEvent events.OrderRequestedEvent
 	if err := json.Unmarshal(eventData, &orderRequestedEvent); err != nil {
 		h.logger.Exception(ctx, "Failed to unmarshal OrderRequested event", err)
-		return
+		return infrastructure.Permanent(err)
 	}
 
 	h.logger.Info(ctx, "Unmarshaled OrderRequested event for order: "+orderRequestedEvent.ID)
 
 	if err := orderRequestedEvent.Validate(); err != nil {
 		h.logger.Exception(ctx, "Invalid OrderRequested event", err)
-		return
+		return infrastructure.Permanent(err)
 	}
 
 	h.logger.Info(ctx, "OrderRequested event validation passed for order: "+orderRequestedEvent.ID)
 
-	// Step 1: Create the order in the database
+	_, err := h.orderRepository.GetOrderStatus(ctx, orderRequestedEvent.ID)
+	if err == nil {
+		h.logger.Info(ctx, "Order already created for request: "+orderRequestedEvent.ID)
+		return nil
+	}
+	if !errors.Is(err, persistence.ErrOrderNotFound) {
+		h.logger.Exception(ctx, "Failed to check for an existing order", err)
+		return infrastructure.Transient(err)
+	}
+
+	// Step 1: Build the order document
 	orderDoc := persistence.OrderDocument{
 		ID:     orderRequestedEvent.ID,
-		Amount: orderRequestedEvent.Amount,
+		Money:  orderRequestedEvent.Money,
 		Status: "Processing", // Initial status when processing request
 		Product: persistence.ProductDocument{
 			ID:       orderRequestedEvent.Product.ID,
@@ -58,53 +77,38 @@ func (h *OrderRequestedEventHandler) Handle(ctx context.Context, eventData []byt
 		},
 	}
 
-	h.logger.Info(ctx, "Attempting to create order in database for: "+orderRequestedEvent.ID)
-
-	orderID, err := h.orderRepository.CreateOrder(ctx, &orderDoc)
-	if err != nil {
-		h.logger.Exception(ctx, "Failed to create order from request", err)
-		return
-	}
-
-	h.logger.Info(ctx, "Order created successfully from request: "+orderID)
-
-	// Step 2: Publish OrderCreated event
+	// Step 2: Prepare the OrderCreated event so it can be written together with the order
 	orderCreatedEvent := events.OrderCreatedEvent{
-		ID:        orderID,
+		ID:        orderRequestedEvent.ID,
 		Product:   orderRequestedEvent.Product,
-		Amount:    orderRequestedEvent.Amount,
+		Money:     orderRequestedEvent.Money,
 		Status:    "Processing",
 		Version:   1,
-		TimeStamp: time.Now().Local(),
+		TimeStamp: time.Now().UTC(),
 	}
 
-	if err := h.publishOrderCreatedEvent(ctx, orderCreatedEvent); err != nil {
-		h.logger.Exception(ctx, "Failed to publish OrderCreated event", err)
-		// Store for replay if publishing fails
-		eventJSON, _ := json.Marshal(orderCreatedEvent)
-		_ = h.orderRepository.StoreEventForReplay(ctx, orderID, eventJSON)
-		return
+	// Validated here rather than by its consumer, so a malformed event never enters the outbox
+	eventJSON, err := rabbitmq.MarshalEvent(events.OrderCreated, orderCreatedEvent)
+	if err != nil {
+		h.logger.Exception(ctx, "Failed to prepare OrderCreated event", err)
+		return infrastructure.Permanent(err)
 	}
 
-	h.logger.Info(ctx, "OrderCreated event published successfully for order: "+orderID)
-}
+	h.logger.Info(ctx, "Attempting to create order in database for: "+orderRequestedEvent.ID)
 
-func (h *OrderRequestedEventHandler) publishOrderCreatedEvent(ctx context.Context, event events.OrderCreatedEvent) error {
-	eventJSON, err := json.Marshal(event)
+	// Create the order and its OrderCreated outbox message atomically; the outbox relay
+	// publishes the event, so a crash at any point cannot drop it
+	message := outbox.NewMessage(ctx, orderRequestedEvent.ID, events.OrderCreated, eventJSON)
+	orderID, created, err := h.orderRepository.CreateOrderWithOutbox(ctx, &orderDoc, message)
 	if err != nil {
-		return err
+		h.logger.Exception(ctx, "Failed to create order from request", err)
+		return infrastructure.Transient(err)
 	}
-
-	// Retry logic for event publishing
-	maxRetries := 3
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		err = h.rabbitMQService.Publish(events.OrderCreated, eventJSON)
-		if err == nil {
-			return nil
-		}
-		h.logger.Warn(ctx, "Publish OrderCreated failed, attempt "+string(rune(attempt)))
-		time.Sleep(time.Duration(attempt) * time.Second)
+	if !created {
+		h.logger.Info(ctx, "Order already created for request: "+orderID)
+		return nil
 	}
 
-	return err
+	h.logger.Info(ctx, "Order created and OrderCreated event queued in outbox for order: "+orderID)
+	return nil
 }
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/infrastructure/outbox"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/money"
+	"go-order-eda/src/services/order/domain/persistence"
+	"sync"
+	"testing"
+)
+
+// fakeRequestedOrderStore keeps orders and outbox messages in memory. Status lookups never find
+// an order, like two deliveries of a request checking before either has created it.
+type fakeRequestedOrderStore struct {
+	mu       sync.Mutex
+	orders   map[string]persistence.OrderDocument
+	messages []outbox.Message
+}
+
+func (s *fakeRequestedOrderStore) GetOrderStatus(ctx context.Context, id string) (string, error) {
+	return "", persistence.ErrOrderNotFound
+}
+
+func (s *fakeRequestedOrderStore) CreateOrderWithOutbox(ctx context.Context, order *persistence.OrderDocument, message outbox.Message) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orders[order.ID]; ok {
+		return order.ID, false, nil
+	}
+	s.orders[order.ID] = *order
+	s.messages = append(s.messages, message)
+	return order.ID, true, nil
+}
+
+func TestOrderRequestedEventHandler_RedeliveredRequest(t *testing.T) {
+	store := &fakeRequestedOrderStore{orders: make(map[string]persistence.OrderDocument)}
+	handler := &OrderRequestedEventHandler{logger: log.NewLogger(), orderRepository: store}
+
+	body, _ := json.Marshal(events.OrderRequestedEvent{
+		ID:      "order-1",
+		Product: events.Product{ID: "product-1", Name: "Laptop", Quantity: 1},
+		Money:   money.New(1000, "USD"),
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := handler.Handle(context.Background(), body); err != nil {
+				t.Errorf("Handle failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(store.orders) != 1 {
+		t.Errorf("Expected 1 order, got %d", len(store.orders))
+	}
+	if len(store.messages) != 1 {
+		t.Errorf("Expected 1 OrderCreated outbox message, got %d", len(store.messages))
+	}
+
+	t.Log("✅ A redelivered request neither duplicates the order nor its OrderCreated event")
+}
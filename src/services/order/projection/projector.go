@@ -0,0 +1,158 @@
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-order-eda/src/infrastructure"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/events"
+	"time"
+)
+
+// maxSaveAttempts bounds how often an event is re-applied when concurrent events update the same timeline
+const maxSaveAttempts = 5
+
+// Projector applies order events to their order's timeline
+type Projector struct {
+	repository Repository
+	logger     log.Logger
+}
+
+func NewProjector(repository Repository, logger log.Logger) *Projector {
+	return &Projector{repository: repository, logger: logger}
+}
+
+// EventTypes lists the events the projection subscribes to
+var EventTypes = []string{
+	events.OrderRequested,
+	events.OrderCreated,
+	events.InventoryStatusUpdated,
+	events.NotificationSent,
+	events.OrderCancelled,
+}
+
+// Handler returns the event handler projecting one of EventTypes
+func (p *Projector) Handler(eventType string) infrastructure.EventHandler {
+	return &eventHandler{projector: p, eventType: eventType}
+}
+
+type eventHandler struct {
+	projector *Projector
+	eventType string
+}
+
+func (h *eventHandler) Handle(ctx context.Context, msgBody []byte) error {
+	orderID, change, err := decode(h.eventType, msgBody)
+	if err != nil {
+		h.projector.logger.Exception(ctx, "Failed to decode event for the order projection: "+h.eventType, err)
+		return infrastructure.Permanent(err)
+	}
+	if err := h.projector.Apply(ctx, orderID, change); err != nil {
+		h.projector.logger.Exception(ctx, "Failed to update order projection for order: "+orderID, err)
+		return infrastructure.Transient(err)
+	}
+	return nil
+}
+
+// Apply merges a change into the order's timeline, creating the timeline on the first event.
+// A save that conflicts with a concurrent update is retried on a fresh read.
+func (p *Projector) Apply(ctx context.Context, orderID string, change Change) error {
+	for attempt := 1; ; attempt++ {
+		timeline, err := p.repository.Get(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if timeline == nil {
+			timeline = &OrderTimeline{OrderID: orderID}
+		}
+		if !timeline.apply(change) {
+			return nil // Already projected, e.g. a redelivered event
+		}
+		timeline.UpdatedAt = time.Now().UTC()
+
+		err = p.repository.Save(ctx, timeline)
+		if err == nil || !errors.Is(err, ErrConflict) || attempt == maxSaveAttempts {
+			return err
+		}
+	}
+}
+
+// decode turns an event body into the change it makes to its order's timeline.
+// Events without a timestamp are stamped with the time they are projected.
+func decode(eventType string, body []byte) (string, Change, error) {
+	var (
+		orderID string
+		change  = Change{Event: eventType}
+	)
+	switch eventType {
+	case events.OrderRequested:
+		var event events.OrderRequestedEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return "", change, err
+		}
+		orderID, change.At = event.ID, event.TimeStamp
+		change.Status = events.OrderStatusRequested
+		change.Amount, change.Currency = event.Amount, event.Currency
+		change.ProductID, change.Quantity = event.Product.ID, event.Product.Quantity
+	case events.OrderCreated:
+		var event events.OrderCreatedEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return "", change, err
+		}
+		orderID, change.At = event.ID, event.TimeStamp
+		change.Status = event.Status
+		if change.Status == "" {
+			change.Status = events.OrderStatusCreated
+		}
+		change.Amount, change.Currency = event.Amount, event.Currency
+		change.ProductID, change.Quantity = event.Product.ID, event.Product.Quantity
+	case events.InventoryStatusUpdated:
+		var event events.InventoryStatusUpdatedEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return "", change, err
+		}
+		orderID, change.At = event.OrderID, event.TimeStamp
+		if event.HasStock {
+			change.Status = events.OrderStatusConfirmed // Set by the OrderCreated handler once the stock is reserved
+		}
+		change.Inventory = &InventoryOutcome{ProductID: event.ProductID, HasStock: event.HasStock}
+	case events.NotificationSent:
+		var event events.NotificationSentEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return "", change, err
+		}
+		// The confirmation is the last step, so it completes the order; there is no separate completion event.
+		// A cancellation notification leaves the status to the OrderCancelled event.
+		orderID, change.At = event.OrderID, event.TimeStamp
+		if event.Confirms() {
+			change.Status = events.OrderStatusCompleted
+		}
+		change.Notification = &NotificationOutcome{Message: event.Message}
+	case events.OrderCancelled:
+		var event events.OrderCancelledEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return "", change, err
+		}
+		orderID, change.At = event.OrderID, event.TimeStamp
+		change.Status = events.OrderStatusCancelled
+	default:
+		return "", change, fmt.Errorf("event type %s is not projected", eventType)
+	}
+
+	if orderID == "" {
+		return "", change, errors.New("event has no order ID")
+	}
+	if change.At.IsZero() {
+		change.At = time.Now().UTC()
+	}
+	change.At = change.At.UTC().Truncate(time.Millisecond) // MongoDB stores milliseconds; keeps redeliveries comparable
+	if change.Inventory != nil {
+		change.Inventory.At = change.At
+	}
+	if change.Notification != nil {
+		change.Notification.At = change.At
+	}
+	return orderID, change, nil
+}
@@ -0,0 +1,238 @@
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-order-eda/src/infrastructure"
+	"go-order-eda/src/infrastructure/log"
+	"go-order-eda/src/services/events"
+	"go-order-eda/src/services/money"
+)
+
+// fakeRepository keeps timelines in memory with the same optimistic versioning as the MongoDB repository.
+// conflicts makes that many upcoming saves fail with ErrConflict.
+type fakeRepository struct {
+	mu        sync.Mutex
+	timelines map[string]OrderTimeline
+	conflicts int
+	saves     int
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{timelines: make(map[string]OrderTimeline)}
+}
+
+func (r *fakeRepository) Get(ctx context.Context, orderID string) (*OrderTimeline, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	timeline, ok := r.timelines[orderID]
+	if !ok {
+		return nil, nil
+	}
+	timeline.History = append([]StatusChange(nil), timeline.History...)
+	return &timeline, nil
+}
+
+func (r *fakeRepository) Save(ctx context.Context, timeline *OrderTimeline) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conflicts > 0 {
+		r.conflicts--
+		return ErrConflict
+	}
+	if stored := r.timelines[timeline.OrderID]; stored.Version != timeline.Version {
+		return ErrConflict
+	}
+	r.saves++
+	timeline.Version++
+	saved := *timeline
+	saved.History = append([]StatusChange(nil), timeline.History...)
+	r.timelines[timeline.OrderID] = saved
+	return nil
+}
+
+func (r *fakeRepository) MigrateLegacyAmounts(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeRepository) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+	body, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	return body
+}
+
+func TestProjector_EventSequence(t *testing.T) {
+	ctx := context.Background()
+	repository := newFakeRepository()
+	projector := NewProjector(repository, log.NewLogger())
+	start := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	product := events.Product{ID: "product-1", Quantity: 2}
+
+	steps := []struct {
+		eventType  string
+		event      any
+		wantStatus string
+		check      func(t *testing.T, timeline *OrderTimeline)
+	}{
+		{
+			eventType:  events.OrderRequested,
+			event:      events.OrderRequestedEvent{ID: "order-1", Product: product, Money: money.New(4000, "EUR"), TimeStamp: start},
+			wantStatus: events.OrderStatusRequested,
+			check: func(t *testing.T, timeline *OrderTimeline) {
+				if timeline.Amount != 4000 || timeline.Currency != "EUR" || timeline.ProductID != "product-1" || timeline.Quantity != 2 {
+					t.Errorf("Expected amount 4000 EUR and 2 of product-1, got %+v", timeline)
+				}
+			},
+		},
+		{
+			eventType:  events.OrderCreated,
+			event:      events.OrderCreatedEvent{ID: "order-1", Product: product, Money: money.New(4000, "EUR"), Status: "Processing", TimeStamp: start.Add(time.Second)},
+			wantStatus: "Processing",
+		},
+		{
+			eventType:  events.InventoryStatusUpdated,
+			event:      events.InventoryStatusUpdatedEvent{OrderID: "order-1", ProductID: "product-1", HasStock: true, TimeStamp: start.Add(2 * time.Second)},
+			wantStatus: "Confirmed",
+			check: func(t *testing.T, timeline *OrderTimeline) {
+				if timeline.Inventory == nil || !timeline.Inventory.HasStock || !timeline.Inventory.At.Equal(start.Add(2*time.Second)) {
+					t.Errorf("Expected an in-stock inventory outcome, got %+v", timeline.Inventory)
+				}
+			},
+		},
+		{
+			eventType:  events.NotificationSent,
+			event:      events.NotificationSentEvent{OrderID: "order-1", Message: "Order confirmed", TimeStamp: start.Add(3 * time.Second)},
+			wantStatus: events.OrderStatusCompleted,
+			check: func(t *testing.T, timeline *OrderTimeline) {
+				if timeline.Notification == nil || timeline.Notification.Message != "Order confirmed" {
+					t.Errorf("Expected the notification outcome, got %+v", timeline.Notification)
+				}
+			},
+		},
+	}
+
+	for i, step := range steps {
+		t.Run(step.eventType, func(t *testing.T) {
+			if err := projector.Handler(step.eventType).Handle(ctx, mustMarshal(t, step.event)); err != nil {
+				t.Fatalf("Handle failed: %v", err)
+			}
+			timeline, _ := repository.Get(ctx, "order-1")
+			if timeline == nil {
+				t.Fatal("Expected a timeline")
+			}
+			if timeline.Status != step.wantStatus {
+				t.Errorf("Expected status %s, got %s", step.wantStatus, timeline.Status)
+			}
+			if len(timeline.History) != i+1 {
+				t.Fatalf("Expected %d history entries, got %d", i+1, len(timeline.History))
+			}
+			if last := timeline.History[i]; last.Event != step.eventType || last.Status != step.wantStatus {
+				t.Errorf("Expected last entry %s/%s, got %+v", step.eventType, step.wantStatus, last)
+			}
+			if step.check != nil {
+				step.check(t, timeline)
+			}
+		})
+	}
+
+	t.Run("redelivered event is applied once", func(t *testing.T) {
+		saves := repository.saves
+		body := mustMarshal(t, steps[2].event)
+		if err := projector.Handler(events.InventoryStatusUpdated).Handle(ctx, body); err != nil {
+			t.Fatalf("Handle failed: %v", err)
+		}
+		timeline, _ := repository.Get(ctx, "order-1")
+		if len(timeline.History) != len(steps) || repository.saves != saves {
+			t.Errorf("Expected %d entries and no save, got %d entries and %d saves", len(steps), len(timeline.History), repository.saves-saves)
+		}
+	})
+
+	t.Log("✅ Timeline follows the order through each event")
+}
+
+func TestProjector_OutOfOrderAndFailures(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	t.Run("late event keeps the latest status", func(t *testing.T) {
+		repository := newFakeRepository()
+		projector := NewProjector(repository, log.NewLogger())
+
+		cancelled := events.OrderCancelledEvent{OrderID: "order-1", Status: events.OrderStatusCancelled, TimeStamp: start.Add(time.Minute)}
+		requested := events.OrderRequestedEvent{ID: "order-1", Product: events.Product{ID: "product-1", Quantity: 1}, TimeStamp: start}
+		if err := projector.Handler(events.OrderCancelled).Handle(ctx, mustMarshal(t, cancelled)); err != nil {
+			t.Fatalf("Handle failed: %v", err)
+		}
+		if err := projector.Handler(events.OrderRequested).Handle(ctx, mustMarshal(t, requested)); err != nil {
+			t.Fatalf("Handle failed: %v", err)
+		}
+
+		timeline, _ := repository.Get(ctx, "order-1")
+		if timeline.Status != events.OrderStatusCancelled {
+			t.Errorf("Expected status %s, got %s", events.OrderStatusCancelled, timeline.Status)
+		}
+		if len(timeline.History) != 2 || timeline.History[0].Status != events.OrderStatusRequested {
+			t.Errorf("Expected history sorted by time, got %+v", timeline.History)
+		}
+	})
+
+	t.Run("out of stock is recorded without a status change", func(t *testing.T) {
+		repository := newFakeRepository()
+		projector := NewProjector(repository, log.NewLogger())
+
+		event := events.InventoryStatusUpdatedEvent{OrderID: "order-1", ProductID: "product-1", HasStock: false, TimeStamp: start}
+		if err := projector.Handler(events.InventoryStatusUpdated).Handle(ctx, mustMarshal(t, event)); err != nil {
+			t.Fatalf("Handle failed: %v", err)
+		}
+		timeline, _ := repository.Get(ctx, "order-1")
+		if timeline.Inventory == nil || timeline.Inventory.HasStock || len(timeline.History) != 0 {
+			t.Errorf("Expected an out-of-stock outcome and no history, got %+v", timeline)
+		}
+	})
+
+	t.Run("conflicting saves are retried", func(t *testing.T) {
+		repository := newFakeRepository()
+		repository.conflicts = maxSaveAttempts - 1
+		projector := NewProjector(repository, log.NewLogger())
+
+		if err := projector.Apply(ctx, "order-1", Change{Event: events.OrderCancelled, Status: events.OrderStatusCancelled, At: start}); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+		if timeline, _ := repository.Get(ctx, "order-1"); timeline == nil || timeline.Status != events.OrderStatusCancelled {
+			t.Errorf("Expected the change to be saved, got %+v", timeline)
+		}
+	})
+
+	t.Run("persistent conflicts are transient", func(t *testing.T) {
+		repository := newFakeRepository()
+		repository.conflicts = maxSaveAttempts
+		projector := NewProjector(repository, log.NewLogger())
+
+		event := events.OrderCancelledEvent{OrderID: "order-1", Status: events.OrderStatusCancelled, TimeStamp: start}
+		err := projector.Handler(events.OrderCancelled).Handle(ctx, mustMarshal(t, event))
+		if !errors.Is(err, ErrConflict) || !infrastructure.IsRetryable(err) {
+			t.Errorf("Expected a retryable ErrConflict, got %v", err)
+		}
+	})
+
+	t.Run("malformed event is permanent", func(t *testing.T) {
+		projector := NewProjector(newFakeRepository(), log.NewLogger())
+
+		err := projector.Handler(events.OrderCreated).Handle(ctx, []byte(`{"id":""}`))
+		if err == nil || infrastructure.IsRetryable(err) {
+			t.Errorf("Expected a permanent error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	mongoinfra "go-order-eda/src/infrastructure/mongo"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrConflict is returned by Save when the timeline changed since it was read
+var ErrConflict = errors.New("order timeline was modified concurrently")
+
+type Repository interface {
+	// Get returns the timeline of an order, or nil if no event has been projected for it yet
+	Get(ctx context.Context, orderID string) (*OrderTimeline, error)
+	// Save stores a timeline read with Get, or a new one with version 0, and bumps its version.
+	// It returns ErrConflict if another update was saved in between.
+	Save(ctx context.Context, timeline *OrderTimeline) error
+	// EnsureIndexes creates the unique index on orderId that Save relies on to detect concurrent inserts
+	EnsureIndexes(ctx context.Context) error
+	// MigrateLegacyAmounts converts float amounts of timelines projected before amounts were kept
+	// in minor units, see mongoinfra.MigrateLegacyAmounts
+	MigrateLegacyAmounts(ctx context.Context) (int64, error)
+}
+
+type repository struct {
+	collection *mongo.Collection
+	timeout    time.Duration // Upper bound for each database operation
+}
+
+func NewRepository(db *mongo.Database, timeout time.Duration) Repository {
+	return &repository{
+		collection: db.Collection("order_projections"),
+		timeout:    timeout,
+	}
+}
+
+func (r *repository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "orderId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *repository) MigrateLegacyAmounts(ctx context.Context) (int64, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	return mongoinfra.MigrateLegacyAmounts(ctx, r.collection)
+}
+
+func (r *repository) Get(ctx context.Context, orderID string) (*OrderTimeline, error) {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	var timeline OrderTimeline
+	err := r.collection.FindOne(ctx, bson.M{"orderId": orderID}).Decode(&timeline)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // Nothing projected for the order yet
+		}
+		return nil, err
+	}
+	return &timeline, nil
+}
+
+func (r *repository) Save(ctx context.Context, timeline *OrderTimeline) error {
+	ctx, cancel := mongoinfra.OperationContext(ctx, r.timeout)
+	defer cancel()
+
+	version := timeline.Version
+	timeline.Version++
+	if version == 0 {
+		if _, err := r.collection.InsertOne(ctx, timeline); err != nil {
+			timeline.Version = version
+			if mongo.IsDuplicateKeyError(err) {
+				return ErrConflict
+			}
+			return err
+		}
+		return nil
+	}
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"orderId": timeline.OrderID, "version": version}, timeline)
+	if err != nil {
+		timeline.Version = version
+		return err
+	}
+	if result.MatchedCount == 0 {
+		timeline.Version = version
+		return ErrConflict
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Package projection maintains a denormalized read model of each order's lifecycle,
+// built from the events the order goes through.
+package projection
+
+import (
+	"sort"
+	"time"
+)
+
+// StatusChange is one entry of an order's status history
+type StatusChange struct {
+	Status string    `bson:"status" json:"status"`
+	Event  string    `bson:"event" json:"event"` // Event type that caused the change
+	At     time.Time `bson:"at" json:"at"`
+}
+
+// InventoryOutcome is the result of the stock check for an order
+type InventoryOutcome struct {
+	ProductID string    `bson:"productId" json:"productId"`
+	HasStock  bool      `bson:"hasStock" json:"hasStock"`
+	At        time.Time `bson:"at" json:"at"`
+}
+
+// NotificationOutcome records the notification sent for an order
+type NotificationOutcome struct {
+	Message string    `bson:"message" json:"message"`
+	At      time.Time `bson:"at" json:"at"`
+}
+
+// OrderTimeline is the projection of one order, stored in the order_projections collection
+type OrderTimeline struct {
+	OrderID      string               `bson:"orderId" json:"orderId"`
+	Status       string               `bson:"status" json:"status"` // Status of the latest history entry
+	Amount       int64                `bson:"amount,omitempty" json:"amount,omitempty"`
+	Currency     string               `bson:"currency,omitempty" json:"currency,omitempty"`
+	ProductID    string               `bson:"productId,omitempty" json:"productId,omitempty"`
+	Quantity     int                  `bson:"quantity,omitempty" json:"quantity,omitempty"`
+	History      []StatusChange       `bson:"history" json:"history"`
+	Inventory    *InventoryOutcome    `bson:"inventory,omitempty" json:"inventory,omitempty"`
+	Notification *NotificationOutcome `bson:"notification,omitempty" json:"notification,omitempty"`
+	UpdatedAt    time.Time            `bson:"updatedAt" json:"updatedAt"`
+	Version      int64                `bson:"version" json:"-"` // Guards concurrent updates, see Repository.Save
+}
+
+// Change is what one event contributes to a timeline
+type Change struct {
+	Event        string // Event type
+	At           time.Time
+	Status       string // New status, or "" when the event does not change it
+	Amount       int64
+	Currency     string
+	ProductID    string
+	Quantity     int
+	Inventory    *InventoryOutcome
+	Notification *NotificationOutcome
+}
+
+// apply merges a change into the timeline and reports whether it changed anything.
+// A change already in the history is skipped, so a redelivered event is applied once.
+// Events may arrive out of order across queues, so the history is kept sorted by time
+// and the status is that of the latest entry.
+func (t *OrderTimeline) apply(change Change) bool {
+	if change.Status != "" {
+		for _, entry := range t.History {
+			if entry.Event == change.Event && entry.Status == change.Status && entry.At.Equal(change.At) {
+				return false
+			}
+		}
+		t.History = append(t.History, StatusChange{Status: change.Status, Event: change.Event, At: change.At})
+		sort.SliceStable(t.History, func(i, j int) bool { return t.History[i].At.Before(t.History[j].At) })
+		t.Status = t.History[len(t.History)-1].Status
+	}
+
+	changed := change.Status != ""
+	if change.Amount != 0 && t.Amount != change.Amount {
+		t.Amount, changed = change.Amount, true
+	}
+	if change.Currency != "" && t.Currency != change.Currency {
+		t.Currency, changed = change.Currency, true
+	}
+	if change.ProductID != "" && t.ProductID != change.ProductID {
+		t.ProductID, changed = change.ProductID, true
+	}
+	if change.Quantity != 0 && t.Quantity != change.Quantity {
+		t.Quantity, changed = change.Quantity, true
+	}
+	if change.Inventory != nil && (t.Inventory == nil || *t.Inventory != *change.Inventory) {
+		t.Inventory, changed = change.Inventory, true
+	}
+	if change.Notification != nil && (t.Notification == nil || *t.Notification != *change.Notification) {
+		t.Notification, changed = change.Notification, true
+	}
+	return changed
+}